@@ -0,0 +1,49 @@
+package bpjs
+
+import "context"
+
+// ─── BPJS Bridging Client ────────────────────────────────────────────────────
+// Interface dipakai handler supaya implementasi PCare bisa diganti dengan
+// server tiruan (lihat mock.go) saat pengembangan.
+
+type Client interface {
+	// CekPeserta mengambil status kepesertaan berdasarkan nomor kartu
+	CekPeserta(ctx context.Context, noKartu string) (*Peserta, error)
+	// KirimKunjungan mengirim data kunjungan beserta diagnosa
+	KirimKunjungan(ctx context.Context, k Kunjungan) (*HasilKunjungan, error)
+}
+
+type Peserta struct {
+	NoKartu      string `json:"no_kartu"`
+	Nama         string `json:"nama"`
+	Aktif        bool   `json:"aktif"`
+	KetAktif     string `json:"ket_aktif"`
+	KodeProvider string `json:"kode_provider"` // faskes tingkat pertama peserta
+	NamaProvider string `json:"nama_provider"`
+	JenisPeserta string `json:"jenis_peserta"`
+}
+
+type Kunjungan struct {
+	NoKartu    string
+	TglDaftar  string // YYYY-MM-DD
+	KodePoli   string
+	Keluhan    string
+	KodeDiag1  string // ICD-10
+	KodeDokter string
+	KodeSadar  string // default "01" (compos mentis)
+	KodePulang string // default "3" (berobat jalan)
+}
+
+type HasilKunjungan struct {
+	NoKunjungan string `json:"no_kunjungan"`
+}
+
+// Error dari BPJS (metaData.code bukan 2xx)
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
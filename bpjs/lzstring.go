@@ -0,0 +1,229 @@
+package bpjs
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// ─── LZ-String ───────────────────────────────────────────────────────────────
+// Port decompressFromEncodedURIComponent dari pustaka lz-string (JavaScript)
+// yang dipakai BPJS untuk mengompres respons. Bekerja pada unit UTF-16 agar
+// hasilnya sama dengan versi JavaScript.
+
+const keyStrURISafe = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+-$"
+
+var errLZString = fmt.Errorf("data lz-string tidak valid")
+
+func decompressFromEncodedURIComponent(input string) (string, error) {
+	if input == "" {
+		return "", nil
+	}
+	input = strings.ReplaceAll(input, " ", "+")
+
+	values := make([]int, len(input))
+	for i := 0; i < len(input); i++ {
+		v := strings.IndexByte(keyStrURISafe, input[i])
+		if v < 0 {
+			return "", errLZString
+		}
+		values[i] = v
+	}
+
+	out, err := lzDecompress(len(values), 32, func(i int) int {
+		if i >= len(values) {
+			return 0
+		}
+		return values[i]
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(utf16.Decode(out)), nil
+}
+
+func lzDecompress(length, resetValue int, next func(int) int) ([]uint16, error) {
+	dictionary := [][]uint16{{0}, {1}, {2}}
+	enlargeIn := 4
+	numBits := 3
+
+	dataVal := next(0)
+	dataPosition := resetValue
+	dataIndex := 1
+
+	readBits := func(n int) int {
+		bits := 0
+		power := 1
+		maxPower := 1 << n
+		for power != maxPower {
+			resb := dataVal & dataPosition
+			dataPosition >>= 1
+			if dataPosition == 0 {
+				dataPosition = resetValue
+				dataVal = next(dataIndex)
+				dataIndex++
+			}
+			if resb > 0 {
+				bits |= power
+			}
+			power <<= 1
+		}
+		return bits
+	}
+
+	var c []uint16
+	switch readBits(2) {
+	case 0:
+		c = []uint16{uint16(readBits(8))}
+	case 1:
+		c = []uint16{uint16(readBits(16))}
+	default:
+		return nil, nil
+	}
+	dictionary = append(dictionary, c)
+	w := c
+	result := append([]uint16{}, c...)
+
+	for {
+		if dataIndex > length {
+			return nil, errLZString
+		}
+
+		code := readBits(numBits)
+		switch code {
+		case 0:
+			dictionary = append(dictionary, []uint16{uint16(readBits(8))})
+			code = len(dictionary) - 1
+			enlargeIn--
+		case 1:
+			dictionary = append(dictionary, []uint16{uint16(readBits(16))})
+			code = len(dictionary) - 1
+			enlargeIn--
+		case 2:
+			return result, nil
+		}
+
+		if enlargeIn == 0 {
+			enlargeIn = 1 << numBits
+			numBits++
+		}
+
+		var entry []uint16
+		if code < len(dictionary) {
+			entry = dictionary[code]
+		} else if code == len(dictionary) {
+			entry = append(append([]uint16{}, w...), w[0])
+		} else {
+			return nil, errLZString
+		}
+		result = append(result, entry...)
+
+		dictionary = append(dictionary, append(append([]uint16{}, w...), entry[0]))
+		enlargeIn--
+
+		if enlargeIn == 0 {
+			enlargeIn = 1 << numBits
+			numBits++
+		}
+		w = entry
+	}
+}
+
+// compressToEncodedURIComponent adalah kebalikan fungsi di atas, dipakai
+// server tiruan untuk meniru respons terkompres PCare
+func compressToEncodedURIComponent(input string) string {
+	if input == "" {
+		return ""
+	}
+
+	var out strings.Builder
+	dataVal, dataPosition := 0, 0
+	tulisBit := func(b int) {
+		dataVal = dataVal<<1 | b
+		if dataPosition == 5 {
+			out.WriteByte(keyStrURISafe[dataVal])
+			dataPosition, dataVal = 0, 0
+		} else {
+			dataPosition++
+		}
+	}
+	tulis := func(value, n int) {
+		for i := 0; i < n; i++ {
+			tulisBit(value & 1)
+			value >>= 1
+		}
+	}
+
+	// Kunci kamus: unit UTF-16 sebagai string
+	kunci := func(u []uint16) string {
+		b := make([]byte, 0, len(u)*2)
+		for _, c := range u {
+			b = append(b, byte(c>>8), byte(c))
+		}
+		return string(b)
+	}
+
+	kamus := map[string]int{}
+	belumDitulis := map[string]bool{}
+	enlargeIn, dictSize, numBits := 2, 3, 2
+
+	kurangiEnlarge := func() {
+		enlargeIn--
+		if enlargeIn == 0 {
+			enlargeIn = 1 << numBits
+			numBits++
+		}
+	}
+	tulisW := func(w []uint16) {
+		kw := kunci(w)
+		if belumDitulis[kw] {
+			if w[0] < 256 {
+				tulis(0, numBits)
+				tulis(int(w[0]), 8)
+			} else {
+				tulis(1, numBits)
+				tulis(int(w[0]), 16)
+			}
+			kurangiEnlarge()
+			delete(belumDitulis, kw)
+		} else {
+			tulis(kamus[kw], numBits)
+		}
+		kurangiEnlarge()
+	}
+
+	var w []uint16
+	for _, c := range utf16.Encode([]rune(input)) {
+		kc := kunci([]uint16{c})
+		if _, ada := kamus[kc]; !ada {
+			kamus[kc] = dictSize
+			dictSize++
+			belumDitulis[kc] = true
+		}
+
+		wc := append(append([]uint16{}, w...), c)
+		if _, ada := kamus[kunci(wc)]; ada {
+			w = wc
+			continue
+		}
+		tulisW(w)
+		kamus[kunci(wc)] = dictSize
+		dictSize++
+		w = []uint16{c}
+	}
+	if len(w) > 0 {
+		tulisW(w)
+	}
+
+	// Penanda akhir stream lalu isi sisa bit karakter terakhir
+	tulis(2, numBits)
+	for {
+		dataVal <<= 1
+		if dataPosition == 5 {
+			out.WriteByte(keyStrURISafe[dataVal])
+			break
+		}
+		dataPosition++
+	}
+	return out.String()
+}
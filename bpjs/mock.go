@@ -0,0 +1,180 @@
+package bpjs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ─── Server Tiruan PCare ─────────────────────────────────────────────────────
+// Meniru endpoint /peserta/{noKartu} dan /kunjungan dengan data di memori.
+// Header tanda tangan tetap diperiksa supaya konfigurasi client ikut teruji.
+// Respons dikirim tanpa enkripsi, kecuali Enkripsi diaktifkan untuk meniru
+// PCare versi terbaru (AES-256-CBC + LZ-String).
+//
+// Nomor kartu bawaan:
+//   0001234567890 aktif
+//   0009876543210 tidak aktif (menunggak iuran)
+// Diagnosa "X00" selalu ditolak, untuk mencoba alur gagal kirim.
+
+type MockServer struct {
+	ConsID    string
+	SecretKey string
+	Enkripsi  bool
+
+	mu        sync.Mutex
+	peserta   map[string]Peserta
+	kunjungan []Kunjungan
+}
+
+func NewMockServer(consID, secretKey string) *MockServer {
+	return &MockServer{
+		ConsID:    consID,
+		SecretKey: secretKey,
+		peserta: map[string]Peserta{
+			"0001234567890": {NoKartu: "0001234567890", Nama: "PESERTA AKTIF", Aktif: true, KetAktif: "AKTIF",
+				KodeProvider: "0114U163", NamaProvider: "PUSKESMAS SUKASARI", JenisPeserta: "PBI (APBN)"},
+			"0009876543210": {NoKartu: "0009876543210", Nama: "PESERTA NONAKTIF", Aktif: false, KetAktif: "TIDAK AKTIF: MENUNGGAK IURAN",
+				KodeProvider: "0114U163", NamaProvider: "PUSKESMAS SUKASARI", JenisPeserta: "PEKERJA MANDIRI"},
+		},
+	}
+}
+
+// Kunjungan mengembalikan salinan kunjungan yang sudah diterima
+func (m *MockServer) Kunjungan() []Kunjungan {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Kunjungan(nil), m.kunjungan...)
+}
+
+func (m *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !m.cekSignature(r) {
+		writeMock(w, 401, "Signature tidak valid", nil)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/peserta/"):
+		m.handlePeserta(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/kunjungan"):
+		m.handleKunjungan(w, r)
+	default:
+		writeMock(w, 404, "Endpoint tidak ditemukan", nil)
+	}
+}
+
+func (m *MockServer) cekSignature(r *http.Request) bool {
+	consID := r.Header.Get("X-cons-id")
+	timestamp := r.Header.Get("X-timestamp")
+	if consID != m.ConsID || timestamp == "" {
+		return false
+	}
+	return r.Header.Get("X-signature") == Signature(consID, m.SecretKey, timestamp)
+}
+
+func (m *MockServer) handlePeserta(w http.ResponseWriter, r *http.Request) {
+	noKartu := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	m.mu.Lock()
+	p, ok := m.peserta[noKartu]
+	m.mu.Unlock()
+	if !ok {
+		writeMock(w, 404, "Peserta tidak ditemukan", nil)
+		return
+	}
+
+	writeMock(w, 200, "OK", m.isiRespons(r, map[string]interface{}{
+		"noKartu":       p.NoKartu,
+		"nama":          p.Nama,
+		"aktif":         p.Aktif,
+		"ketAktif":      p.KetAktif,
+		"kdProviderPst": map[string]string{"kdProvider": p.KodeProvider, "nmProvider": p.NamaProvider},
+		"jnsPeserta":    map[string]string{"nama": p.JenisPeserta},
+	}))
+}
+
+func (m *MockServer) handleKunjungan(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		NoKartu   string `json:"noKartu"`
+		TglDaftar string `json:"tglDaftar"`
+		KdPoli    string `json:"kdPoli"`
+		Keluhan   string `json:"keluhan"`
+		KdDiag1   string `json:"kdDiag1"`
+		KdDokter  string `json:"kdDokter"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeMock(w, 400, "Format data kunjungan tidak valid", nil)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.peserta[body.NoKartu]
+	switch {
+	case !ok:
+		writeMock(w, 412, "Peserta tidak ditemukan", nil)
+		return
+	case !p.Aktif:
+		writeMock(w, 412, "Peserta tidak aktif", nil)
+		return
+	case body.KdPoli == "":
+		writeMock(w, 412, "Kode poli wajib diisi", nil)
+		return
+	case body.KdDiag1 == "" || body.KdDiag1 == "X00":
+		writeMock(w, 412, "Kode diagnosa tidak valid", nil)
+		return
+	}
+
+	m.kunjungan = append(m.kunjungan, Kunjungan{
+		NoKartu:    body.NoKartu,
+		TglDaftar:  body.TglDaftar,
+		KodePoli:   body.KdPoli,
+		Keluhan:    body.Keluhan,
+		KodeDiag1:  body.KdDiag1,
+		KodeDokter: body.KdDokter,
+	})
+	noKunjungan := fmt.Sprintf("MOCK%010d", len(m.kunjungan))
+
+	writeMock(w, 201, "CREATED", m.isiRespons(r, map[string]string{"field": "noKunjungan", "message": noKunjungan}))
+}
+
+// isiRespons mengompres dan mengenkripsi isi respons seperti PCare jika
+// Enkripsi aktif; key diturunkan dari timestamp request
+func (m *MockServer) isiRespons(r *http.Request, response interface{}) interface{} {
+	if !m.Enkripsi {
+		return response
+	}
+	b, _ := json.Marshal(response)
+	plain := []byte(compressToEncodedURIComponent(string(b)))
+
+	pad := aes.BlockSize - len(plain)%aes.BlockSize
+	plain = append(plain, bytes.Repeat([]byte{byte(pad)}, pad)...)
+
+	key := sha256.Sum256([]byte(m.ConsID + m.SecretKey + r.Header.Get("X-timestamp")))
+	block, _ := aes.NewCipher(key[:])
+	out := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, key[:aes.BlockSize]).CryptBlocks(out, plain)
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+func writeMock(w http.ResponseWriter, code int, message string, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	status := code
+	if status == 412 {
+		// PCare mengembalikan HTTP 200 dengan metaData.code berisi error
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"response": response,
+		"metaData": map[string]interface{}{"message": message, "code": code},
+	})
+}
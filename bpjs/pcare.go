@@ -0,0 +1,222 @@
+package bpjs
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ─── PCare REST Client ───────────────────────────────────────────────────────
+// Setiap request ditandatangani dengan header X-cons-id, X-timestamp,
+// X-signature (HMAC-SHA256 "consID&timestamp" dengan secret key) dan
+// X-authorization. Respons versi terbaru terenkripsi AES-256-CBC lalu
+// dikompres LZ-String; respons polos (server tiruan) juga diterima.
+
+type PCareClient struct {
+	BaseURL    string
+	ConsID     string
+	SecretKey  string
+	UserKey    string
+	Username   string
+	Password   string
+	KdAplikasi string
+	HTTP       *http.Client
+	now        func() time.Time
+}
+
+func NewPCareClient(baseURL, consID, secretKey, userKey, username, password, kdAplikasi string) *PCareClient {
+	if kdAplikasi == "" {
+		kdAplikasi = "095"
+	}
+	return &PCareClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		ConsID:     consID,
+		SecretKey:  secretKey,
+		UserKey:    userKey,
+		Username:   username,
+		Password:   password,
+		KdAplikasi: kdAplikasi,
+		HTTP:       &http.Client{Timeout: 15 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// Signature menghitung X-signature untuk consID dan timestamp tertentu
+func Signature(consID, secretKey, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(consID + "&" + timestamp))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+type envelope struct {
+	Response json.RawMessage `json:"response"`
+	MetaData struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"metaData"`
+}
+
+func (c *PCareClient) do(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(c.now().Unix(), 10)
+	auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password + ":" + c.KdAplikasi))
+	req.Header.Set("X-cons-id", c.ConsID)
+	req.Header.Set("X-timestamp", timestamp)
+	req.Header.Set("X-signature", Signature(c.ConsID, c.SecretKey, timestamp))
+	req.Header.Set("X-authorization", "Basic "+auth)
+	req.Header.Set("user_key", c.UserKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("gagal menghubungi BPJS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("gagal membaca respons BPJS: %w", err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return fmt.Errorf("respons BPJS tidak valid (HTTP %d)", resp.StatusCode)
+	}
+	if env.MetaData.Code < 200 || env.MetaData.Code > 299 {
+		return &Error{Code: env.MetaData.Code, Message: env.MetaData.Message}
+	}
+	if out == nil || len(env.Response) == 0 || string(env.Response) == "null" {
+		return nil
+	}
+
+	payload := []byte(env.Response)
+	var encrypted string
+	if json.Unmarshal(env.Response, &encrypted) == nil {
+		plain, err := decryptResponse(encrypted, c.ConsID+c.SecretKey+timestamp)
+		if err != nil {
+			return fmt.Errorf("gagal mendekripsi respons BPJS: %w", err)
+		}
+		payload = []byte(plain)
+	}
+
+	return json.Unmarshal(payload, out)
+}
+
+// decryptResponse: AES-256-CBC dengan key sha256(consID+secret+timestamp) dan
+// IV 16 byte pertama key, lalu dekompresi LZ-String
+func decryptResponse(encrypted, keyMaterial string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	key := sha256.Sum256([]byte(keyMaterial))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("panjang data terenkripsi tidak valid")
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, key[:aes.BlockSize]).CryptBlocks(plain, data)
+
+	pad := int(plain[len(plain)-1])
+	if pad < 1 || pad > aes.BlockSize || pad > len(plain) {
+		return "", fmt.Errorf("padding tidak valid")
+	}
+	return decompressFromEncodedURIComponent(string(plain[:len(plain)-pad]))
+}
+
+// ─── Endpoint ────────────────────────────────────────────────────────────────
+
+func (c *PCareClient) CekPeserta(ctx context.Context, noKartu string) (*Peserta, error) {
+	var r struct {
+		NoKartu       string `json:"noKartu"`
+		Nama          string `json:"nama"`
+		Aktif         bool   `json:"aktif"`
+		KetAktif      string `json:"ketAktif"`
+		KdProviderPst struct {
+			KdProvider string `json:"kdProvider"`
+			NmProvider string `json:"nmProvider"`
+		} `json:"kdProviderPst"`
+		JnsPeserta struct {
+			Nama string `json:"nama"`
+		} `json:"jnsPeserta"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/peserta/"+noKartu, nil, &r); err != nil {
+		return nil, err
+	}
+	return &Peserta{
+		NoKartu:      r.NoKartu,
+		Nama:         r.Nama,
+		Aktif:        r.Aktif,
+		KetAktif:     r.KetAktif,
+		KodeProvider: r.KdProviderPst.KdProvider,
+		NamaProvider: r.KdProviderPst.NmProvider,
+		JenisPeserta: r.JnsPeserta.Nama,
+	}, nil
+}
+
+func (c *PCareClient) KirimKunjungan(ctx context.Context, k Kunjungan) (*HasilKunjungan, error) {
+	tgl, err := time.Parse("2006-01-02", k.TglDaftar)
+	if err != nil {
+		return nil, fmt.Errorf("tanggal kunjungan tidak valid: %w", err)
+	}
+	if k.KodeSadar == "" {
+		k.KodeSadar = "01"
+	}
+	if k.KodePulang == "" {
+		k.KodePulang = "3"
+	}
+
+	body := map[string]interface{}{
+		"noKunjungan":    nil,
+		"noKartu":        k.NoKartu,
+		"tglDaftar":      tgl.Format("02-01-2006"),
+		"kdPoli":         k.KodePoli,
+		"keluhan":        k.Keluhan,
+		"kdSadar":        k.KodeSadar,
+		"kdDiag1":        k.KodeDiag1,
+		"kdDiag2":        nil,
+		"kdDiag3":        nil,
+		"kdDokter":       k.KodeDokter,
+		"kdStatusPulang": k.KodePulang,
+		"tglPulang":      tgl.Format("02-01-2006"),
+	}
+
+	var r struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/kunjungan", body, &r); err != nil {
+		return nil, err
+	}
+	return &HasilKunjungan{NoKunjungan: r.Message}, nil
+}
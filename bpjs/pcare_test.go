@@ -0,0 +1,150 @@
+package bpjs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	tesConsID    = "12345"
+	tesSecretKey = "rahasia"
+)
+
+// klienTiruan menjalankan MockServer dan mengembalikan client PCare dengan
+// jam tetap; header request terakhir disimpan di *http.Header
+func klienTiruan(t *testing.T, enkripsi bool) (*PCareClient, *MockServer, *http.Header) {
+	t.Helper()
+	mock := NewMockServer(tesConsID, tesSecretKey)
+	mock.Enkripsi = enkripsi
+
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		mock.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewPCareClient(srv.URL, tesConsID, tesSecretKey, "userkey", "pcareuser", "pcarepass", "")
+	c.now = func() time.Time { return time.Unix(1760000000, 0) }
+	return c, mock, &header
+}
+
+func TestHeaderSignature(t *testing.T) {
+	c, _, header := klienTiruan(t, false)
+	if _, err := c.CekPeserta(context.Background(), "0001234567890"); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(tesSecretKey))
+	mac.Write([]byte(tesConsID + "&1760000000"))
+	want := map[string]string{
+		"X-Cons-Id":       tesConsID,
+		"X-Timestamp":     "1760000000",
+		"X-Signature":     base64.StdEncoding.EncodeToString(mac.Sum(nil)),
+		"X-Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("pcareuser:pcarepass:095")),
+		"User_key":        "userkey",
+	}
+	for k, v := range want {
+		if got := header.Get(k); got != v {
+			t.Errorf("header %s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestSignatureSalahDitolak(t *testing.T) {
+	c, _, _ := klienTiruan(t, false)
+	c.SecretKey = "bukan-rahasia"
+
+	_, err := c.CekPeserta(context.Background(), "0001234567890")
+	var be *Error
+	if !errors.As(err, &be) || be.Code != 401 {
+		t.Fatalf("err = %v, want *Error kode 401", err)
+	}
+}
+
+func TestCekPeserta(t *testing.T) {
+	for _, enkripsi := range []bool{false, true} {
+		c, _, _ := klienTiruan(t, enkripsi)
+
+		p, err := c.CekPeserta(context.Background(), "0001234567890")
+		if err != nil {
+			t.Fatalf("enkripsi=%v: %v", enkripsi, err)
+		}
+		if !p.Aktif || p.Nama != "PESERTA AKTIF" || p.KodeProvider != "0114U163" ||
+			p.NamaProvider != "PUSKESMAS SUKASARI" || p.JenisPeserta != "PBI (APBN)" {
+			t.Errorf("enkripsi=%v: peserta aktif = %+v", enkripsi, p)
+		}
+
+		p, err = c.CekPeserta(context.Background(), "0009876543210")
+		if err != nil {
+			t.Fatalf("enkripsi=%v: %v", enkripsi, err)
+		}
+		if p.Aktif || p.KetAktif != "TIDAK AKTIF: MENUNGGAK IURAN" {
+			t.Errorf("enkripsi=%v: peserta nonaktif = %+v", enkripsi, p)
+		}
+
+		_, err = c.CekPeserta(context.Background(), "0000000000000")
+		var be *Error
+		if !errors.As(err, &be) || be.Code != 404 {
+			t.Errorf("enkripsi=%v: peserta tidak dikenal err = %v, want kode 404", enkripsi, err)
+		}
+	}
+}
+
+func TestKirimKunjungan(t *testing.T) {
+	c, mock, _ := klienTiruan(t, true)
+
+	hasil, err := c.KirimKunjungan(context.Background(), Kunjungan{
+		NoKartu: "0001234567890", TglDaftar: "2026-10-19", KodePoli: "001", KodeDiag1: "J06.9", KodeDokter: "D01",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasil.NoKunjungan != "MOCK0000000001" {
+		t.Errorf("no kunjungan = %q", hasil.NoKunjungan)
+	}
+	diterima := mock.Kunjungan()
+	if len(diterima) != 1 || diterima[0].TglDaftar != "19-10-2026" || diterima[0].KodeDiag1 != "J06.9" {
+		t.Errorf("kunjungan diterima = %+v", diterima)
+	}
+
+	// PCare mengembalikan HTTP 200 dengan metaData.code 412
+	_, err = c.KirimKunjungan(context.Background(), Kunjungan{
+		NoKartu: "0001234567890", TglDaftar: "2026-10-19", KodePoli: "001", KodeDiag1: "X00",
+	})
+	var be *Error
+	if !errors.As(err, &be) || be.Code != 412 {
+		t.Errorf("diagnosa X00 err = %v, want kode 412", err)
+	}
+}
+
+func TestLZStringBolakBalik(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"a",
+		"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		`{"noKartu":"0001234567890","nama":"PESERTA AKTIF","aktif":true}`,
+		"Puskesmas – Sukasari ✓ 日本語 😀",
+	} {
+		enc := compressToEncodedURIComponent(s)
+		got, err := decompressFromEncodedURIComponent(enc)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+			continue
+		}
+		if got != s {
+			t.Errorf("bolak-balik %q = %q (encoded %q)", s, got, enc)
+		}
+	}
+
+	if _, err := decompressFromEncodedURIComponent("!!!"); err == nil {
+		t.Error("karakter di luar alfabet seharusnya ditolak")
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"sikupas/backend/bpjs"
+
+	"github.com/joho/godotenv"
+)
+
+// Server tiruan PCare untuk pengembangan lokal.
+// Jalankan: go run ./cmd/bpjs-mock lalu set
+// BPJS_BASE_URL=http://localhost:8090 pada backend dengan BPJS_CONS_ID dan
+// BPJS_SECRET_KEY yang sama. BPJS_MOCK_ENKRIPSI=true mengenkripsi respons
+// seperti PCare versi terbaru.
+func main() {
+	godotenv.Load()
+
+	port := os.Getenv("BPJS_MOCK_PORT")
+	if port == "" {
+		port = "8090"
+	}
+
+	mock := bpjs.NewMockServer(os.Getenv("BPJS_CONS_ID"), os.Getenv("BPJS_SECRET_KEY"))
+	mock.Enkripsi = os.Getenv("BPJS_MOCK_ENKRIPSI") == "true"
+
+	fmt.Printf("🧪 Server tiruan BPJS PCare berjalan di: http://localhost:%s\n", port)
+	if err := http.ListenAndServe(":"+port, mock); err != nil {
+		log.Fatalf("❌ Gagal memulai server tiruan BPJS: %v", err)
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 
 	"sikupas/backend/config"
+	"sikupas/backend/handler"
+	"sikupas/backend/middleware"
 	"sikupas/backend/route"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,6 +22,11 @@ func main() {
 		log.Println("⚠️  File .env tidak ditemukan, menggunakan environment variabel sistem")
 	}
 
+	// ─── Kunci JWT ─────────────────────────────────────────────────────
+	if err := middleware.MuatKunciJWT(); err != nil {
+		log.Fatalf("❌ Kunci JWT tidak valid: %v", err)
+	}
+
 	// ─── Koneksi Database ──────────────────────────────────────────────
 	config.ConnectDB()
 	defer config.CloseDB()
@@ -26,11 +34,31 @@ func main() {
 	// ─── Jalankan Migrasi ──────────────────────────────────────────────
 	config.RunMigrations()
 
+	// ─── Worker Latar Belakang ─────────────────────────────────────────
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go handler.JalankanWorkerSatusehat(ctx)
+	go handler.JalankanWorkerBooking(ctx)
+	go handler.JalankanWorkerTutupAntrian(ctx)
+
 	// ─── Inisialisasi Fiber ────────────────────────────────────────────
+	// Di balik reverse proxy, IP klien dibaca dari header proxy supaya rate
+	// limit dan kunci login per IP tidak berlaku untuk satu puskesmas
+	// sekaligus. Header hanya dipercaya dari alamat di TRUSTED_PROXIES.
+	proxyHeader := ""
+	trustedProxies := config.GetTrustedProxies()
+	if len(trustedProxies) > 0 {
+		proxyHeader = config.GetProxyHeader()
+	}
+
 	app := fiber.New(fiber.Config{
-		AppName:   "SIKUPAS - Sistem Informasi Kunjungan Pasien",
-		BodyLimit: 10 * 1024 * 1024, // 10 MB
-		Views:     nil,
+		AppName:                 "SIKUPAS - Sistem Informasi Kunjungan Pasien",
+		BodyLimit:               10 * 1024 * 1024, // 10 MB
+		Views:                   nil,
+		ProxyHeader:             proxyHeader,
+		EnableTrustedProxyCheck: len(trustedProxies) > 0,
+		TrustedProxies:          trustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	// Logger middleware
@@ -68,10 +96,7 @@ func main() {
 		port = "8080"
 	}
 
-	env := os.Getenv("APP_ENV")
-	if env == "" {
-		env = "development"
-	}
+	env := config.GetAppEnv()
 
 	fmt.Println("╔══════════════════════════════════════════════════╗")
 	fmt.Println("║   SIKUPAS - Sistem Informasi Kunjungan Pasien   ║")
@@ -79,6 +104,7 @@ func main() {
 	fmt.Println("╚══════════════════════════════════════════════════╝")
 	fmt.Printf("🚀 Server berjalan di: http://localhost:%s\n", port)
 	fmt.Printf("📌 Environment: %s\n", env)
+	fmt.Printf("🔑 JWT: %s\n", middleware.InfoKunciJWT())
 	fmt.Printf("📌 API Base: http://localhost:%s/api\n", port)
 	fmt.Println("─────────────────────────────────────────────────────")
 
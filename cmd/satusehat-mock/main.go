@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"sikupas/backend/satusehat"
+
+	"github.com/joho/godotenv"
+)
+
+// Server tiruan SATUSEHAT untuk pengembangan lokal.
+// Jalankan: go run ./cmd/satusehat-mock lalu set pada backend
+// SATUSEHAT_AUTH_URL=http://localhost:8091/oauth2/v1 dan
+// SATUSEHAT_BASE_URL=http://localhost:8091/fhir-r4/v1 dengan
+// SATUSEHAT_CLIENT_ID dan SATUSEHAT_CLIENT_SECRET yang sama.
+func main() {
+	godotenv.Load()
+
+	port := os.Getenv("SATUSEHAT_MOCK_PORT")
+	if port == "" {
+		port = "8091"
+	}
+
+	mock := satusehat.NewMockServer(os.Getenv("SATUSEHAT_CLIENT_ID"), os.Getenv("SATUSEHAT_CLIENT_SECRET"))
+
+	fmt.Printf("🧪 Server tiruan SATUSEHAT berjalan di: http://localhost:%s\n", port)
+	if err := http.ListenAndServe(":"+port, mock); err != nil {
+		log.Fatalf("❌ Gagal memulai server tiruan SATUSEHAT: %v", err)
+	}
+}
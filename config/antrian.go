@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetUsiaLansia batas usia (tahun) pasien yang otomatis mendapat prioritas
+// lansia saat kategori tidak diisi (default 60)
+func GetUsiaLansia() int {
+	n, err := strconv.Atoi(os.Getenv("ANTRIAN_USIA_LANSIA"))
+	if err != nil || n < 1 {
+		return 60
+	}
+	return n
+}
+
+// GetMaksPrioritasBeruntun jumlah panggilan prioritas berturut-turut sebelum
+// satu pasien reguler wajib dipanggil (default 3, 0 = tanpa batas). Kategori
+// darurat tidak dibatasi dan tidak ikut dihitung.
+func GetMaksPrioritasBeruntun() int {
+	n, err := strconv.Atoi(os.Getenv("ANTRIAN_MAKS_PRIORITAS_BERUNTUN"))
+	if err != nil || n < 0 {
+		return 3
+	}
+	return n
+}
+
+// GetUrutanPrioritas urutan kategori prioritas dari yang paling didahulukan,
+// misal "darurat,disabilitas,ibu_hamil,lansia". Kategori yang tidak disebut
+// dipanggil seperti pasien reguler.
+func GetUrutanPrioritas() []string {
+	s := os.Getenv("ANTRIAN_URUTAN_PRIORITAS")
+	if strings.TrimSpace(s) == "" {
+		s = "darurat,disabilitas,ibu_hamil,lansia"
+	}
+	var hasil []string
+	for _, k := range strings.Split(s, ",") {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			hasil = append(hasil, k)
+		}
+	}
+	return hasil
+}
+
+// GetAntrianToleransiTutup jeda setelah jam_tutup poli sebelum antrian yang
+// belum dilayani ditutup otomatis (default 60 menit)
+func GetAntrianToleransiTutup() time.Duration {
+	n, err := strconv.Atoi(os.Getenv("ANTRIAN_TOLERANSI_TUTUP_MENIT"))
+	if err != nil || n < 0 {
+		return 60 * time.Minute
+	}
+	return time.Duration(n) * time.Minute
+}
+
+// GetDisplayMaksStream batas layar display yang boleh tersambung lewat SSE
+// sekaligus (default 50)
+func GetDisplayMaksStream() int {
+	n, err := strconv.Atoi(os.Getenv("DISPLAY_MAKS_STREAM"))
+	if err != nil || n < 1 {
+		return 50
+	}
+	return n
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetBookingMaksHari batas hari ke depan yang boleh dipesan online (default 14)
+func GetBookingMaksHari() int {
+	n, err := strconv.Atoi(os.Getenv("BOOKING_MAKS_HARI"))
+	if err != nil || n < 1 {
+		return 14
+	}
+	return n
+}
+
+// GetBookingToleransi batas terlambat setelah slot berakhir sebelum booking
+// yang belum check-in dianggap kedaluwarsa (default 30 menit)
+func GetBookingToleransi() time.Duration {
+	n, err := strconv.Atoi(os.Getenv("BOOKING_TOLERANSI_MENIT"))
+	if err != nil || n < 0 {
+		return 30 * time.Minute
+	}
+	return time.Duration(n) * time.Minute
+}
+
+// GetBookingMaksTidakHadir jumlah ketidakhadiran (booking tidak check-in atau
+// tidak datang saat dipanggil) dalam periode sebelum pasien tidak boleh lagi
+// booking online (default 3, 0 = tanpa batas)
+func GetBookingMaksTidakHadir() int {
+	n, err := strconv.Atoi(os.Getenv("BOOKING_MAKS_TIDAK_HADIR"))
+	if err != nil || n < 0 {
+		return 3
+	}
+	return n
+}
+
+// GetBookingPeriodeTidakHadir rentang hari ke belakang yang dihitung untuk
+// batas ketidakhadiran (default 90)
+func GetBookingPeriodeTidakHadir() int {
+	n, err := strconv.Atoi(os.Getenv("BOOKING_PERIODE_TIDAK_HADIR_HARI"))
+	if err != nil || n < 1 {
+		return 90
+	}
+	return n
+}
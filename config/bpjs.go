@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"sync"
+
+	"sikupas/backend/bpjs"
+)
+
+var (
+	bpjsOnce   sync.Once
+	bpjsClient bpjs.Client
+)
+
+// GetBPJSClient mengembalikan client bridging BPJS, atau nil bila
+// BPJS_BASE_URL tidak diisi (bridging nonaktif). Untuk pengembangan arahkan
+// BPJS_BASE_URL ke server tiruan: go run ./cmd/bpjs-mock
+func GetBPJSClient() bpjs.Client {
+	bpjsOnce.Do(func() {
+		baseURL := os.Getenv("BPJS_BASE_URL")
+		if baseURL == "" {
+			return
+		}
+		bpjsClient = bpjs.NewPCareClient(
+			baseURL,
+			os.Getenv("BPJS_CONS_ID"),
+			os.Getenv("BPJS_SECRET_KEY"),
+			os.Getenv("BPJS_USER_KEY"),
+			os.Getenv("BPJS_USERNAME"),
+			os.Getenv("BPJS_PASSWORD"),
+			os.Getenv("BPJS_KD_APLIKASI"),
+		)
+	})
+	return bpjsClient
+}
+
+// BPJSCekEligibilitasDefault: cek status peserta saat pemeriksaan BPJS dibuat
+// bila request tidak menentukan sendiri (BPJS_CEK_ELIGIBILITAS=true)
+func BPJSCekEligibilitasDefault() bool {
+	return os.Getenv("BPJS_CEK_ELIGIBILITAS") == "true"
+}
+
+// BPJSKirimKunjunganDefault: kirim kunjungan ke BPJS saat pemeriksaan dibuat
+// bila request tidak menentukan sendiri (BPJS_KIRIM_KUNJUNGAN=true)
+func BPJSKirimKunjunganDefault() bool {
+	return os.Getenv("BPJS_KIRIM_KUNJUNGAN") == "true"
+}
@@ -69,3 +69,11 @@ func IsUniqueViolation(err error) bool {
 	}
 	return false
 }
+
+// IsForeignKeyViolation cek apakah error karena data masih direferensikan
+func IsForeignKeyViolation(err error) bool {
+	if pgErr, ok := err.(*pgconn.PgError); ok {
+		return pgErr.Code == "23503"
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// GetAppEnv mengembalikan APP_ENV. Kosong dianggap production supaya deploy
+// yang lupa mengatur environment tidak berjalan dengan setelan development.
+func GetAppEnv() string {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		env = "production"
+	}
+	return env
+}
+
+// IsDevelopment true hanya jika APP_ENV=development diatur eksplisit; hanya
+// di sini secret JWT bawaan boleh dipakai
+func IsDevelopment() bool {
+	return os.Getenv("APP_ENV") == "development"
+}
+
+// GetJWTAlg algoritma penandatanganan token baru: HS256 (default), RS256
+// atau EdDSA
+func GetJWTAlg() string {
+	switch alg := strings.ToUpper(os.Getenv("JWT_ALG")); alg {
+	case "RS256":
+		return "RS256"
+	case "EDDSA":
+		return "EdDSA"
+	default:
+		return "HS256"
+	}
+}
+
+// GetJWTKid kid kunci aktif yang dipakai menandatangani token baru (default
+// "utama"). Ganti bersama kuncinya setiap rotasi.
+func GetJWTKid() string {
+	kid := os.Getenv("JWT_KID")
+	if kid == "" {
+		kid = "utama"
+	}
+	return kid
+}
+
+// GetJWTSecret secret HS256 aktif; kosong berarti belum diatur
+func GetJWTSecret() string {
+	return os.Getenv("JWT_SECRET")
+}
+
+// GetJWTPrivateKeyFile path PEM private key aktif untuk RS256/EdDSA
+func GetJWTPrivateKeyFile() string {
+	return os.Getenv("JWT_PRIVATE_KEY_FILE")
+}
+
+// GetJWTSecretLama secret HS256 yang sudah dirotasi tetapi token lamanya
+// masih diterima, format "kid:secret,kid:secret". Hapus setelah token
+// terakhir yang ditandatanganinya kedaluwarsa (24 jam).
+func GetJWTSecretLama() map[string]string {
+	return parsePasanganKid(os.Getenv("JWT_SECRET_LAMA"))
+}
+
+// GetJWTPublicKeyLama public key RS256/EdDSA yang sudah dirotasi, format
+// "kid:/path/public.pem,..."; tetap diterima dan ikut diterbitkan di JWKS
+func GetJWTPublicKeyLama() map[string]string {
+	return parsePasanganKid(os.Getenv("JWT_PUBLIC_KEY_LAMA"))
+}
+
+func parsePasanganKid(s string) map[string]string {
+	hasil := map[string]string{}
+	for _, bagian := range strings.Split(s, ",") {
+		kid, nilai, ok := strings.Cut(strings.TrimSpace(bagian), ":")
+		if !ok || strings.TrimSpace(kid) == "" || nilai == "" {
+			continue
+		}
+		hasil[strings.TrimSpace(kid)] = nilai
+	}
+	return hasil
+}
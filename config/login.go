@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetLoginMaksGagal jumlah login gagal berturut-turut untuk satu username
+// sebelum akun dikunci sementara (default 5)
+func GetLoginMaksGagal() int {
+	n, err := strconv.Atoi(os.Getenv("LOGIN_MAKS_GAGAL"))
+	if err != nil || n < 1 {
+		return 5
+	}
+	return n
+}
+
+// GetLoginMaksGagalIP jumlah login gagal dari satu IP sebelum IP tersebut
+// dikunci sementara; lebih longgar karena satu IP bisa dipakai banyak
+// komputer di balik NAT puskesmas (default 20)
+func GetLoginMaksGagalIP() int {
+	n, err := strconv.Atoi(os.Getenv("LOGIN_MAKS_GAGAL_IP"))
+	if err != nil || n < 1 {
+		return 20
+	}
+	return n
+}
+
+// GetLoginLamaKunci lama penguncian setelah batas gagal tercapai, sekaligus
+// rentang waktu penghitungan gagal berturut-turut (default 15 menit)
+func GetLoginLamaKunci() time.Duration {
+	n, err := strconv.Atoi(os.Getenv("LOGIN_LAMA_KUNCI_MENIT"))
+	if err != nil || n < 1 {
+		return 15 * time.Minute
+	}
+	return time.Duration(n) * time.Minute
+}
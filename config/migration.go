@@ -14,7 +14,7 @@ func RunMigrations() {
 			nama        VARCHAR(100) NOT NULL,
 			username    VARCHAR(50)  NOT NULL UNIQUE,
 			password    VARCHAR(255) NOT NULL,
-			role        VARCHAR(20)  NOT NULL DEFAULT 'admin' CHECK (role IN ('admin', 'kepala_puskesmas')),
+			role        VARCHAR(20)  NOT NULL DEFAULT 'admin' CHECK (role IN ('admin', 'kepala_puskesmas', 'kasir')),
 			created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
 			updated_at  TIMESTAMP    NOT NULL DEFAULT NOW()
 		);`,
@@ -39,8 +39,7 @@ func RunMigrations() {
 			tanggal_kunjungan DATE        NOT NULL DEFAULT CURRENT_DATE,
 			status            VARCHAR(20) NOT NULL DEFAULT 'belum_dikelola' CHECK (status IN ('belum_dikelola', 'sudah_dikelola')),
 			created_at        TIMESTAMP   NOT NULL DEFAULT NOW(),
-			updated_at        TIMESTAMP   NOT NULL DEFAULT NOW(),
-			UNIQUE(nomor_antrian, tanggal_kunjungan)
+			updated_at        TIMESTAMP   NOT NULL DEFAULT NOW()
 		);`,
 
 		// ===================== Tabel Poli (Master) =====================
@@ -51,13 +50,16 @@ func RunMigrations() {
 		);`,
 
 		// ===================== Seed Data Poli =====================
-		`INSERT INTO poli (nama_poli, nama_dokter) VALUES
+		// Hanya diisi saat tabel masih kosong; poli berikutnya dikelola lewat API
+		`INSERT INTO poli (nama_poli, nama_dokter)
+		SELECT v.nama_poli, v.nama_dokter FROM (VALUES
 			('Poli Umum', 'Dr. Ahmad Suryadi'),
 			('Poli Anak', 'Dr. Siti Nurhaliza'),
 			('Poli Kandungan', 'Dr. Dewi Lestari'),
 			('Poli Gigi', 'Drg. Budi Santoso'),
 			('Poli Mata', 'Dr. Rini Wahyudi')
-		ON CONFLICT DO NOTHING;`,
+		) AS v(nama_poli, nama_dokter)
+		WHERE NOT EXISTS (SELECT 1 FROM poli);`,
 
 		// ===================== Tabel Pemeriksaan =====================
 		`CREATE TABLE IF NOT EXISTS pemeriksaan (
@@ -72,11 +74,607 @@ func RunMigrations() {
 			updated_at           TIMESTAMP   NOT NULL DEFAULT NOW()
 		);`,
 
+		// ===================== Pengelolaan Poli =====================
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS aktif          BOOLEAN    NOT NULL DEFAULT TRUE;`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS urutan         INTEGER    NOT NULL DEFAULT 0;`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS jam_buka       TIME       NOT NULL DEFAULT '08:00';`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS jam_tutup      TIME       NOT NULL DEFAULT '14:00';`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS kuota_harian   INTEGER    NOT NULL DEFAULT 50;`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS prefix_antrian VARCHAR(3);`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS created_at     TIMESTAMP  NOT NULL DEFAULT NOW();`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS updated_at     TIMESTAMP  NOT NULL DEFAULT NOW();`,
+		`UPDATE poli SET prefix_antrian = CHR(64 + id_poli) WHERE prefix_antrian IS NULL AND id_poli BETWEEN 1 AND 26;`,
+		`UPDATE poli SET urutan = id_poli WHERE urutan = 0;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_poli_prefix ON poli(prefix_antrian);`,
+
+		// Antrian per poli: nomor urut dihitung per poli per hari
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS id_poli INTEGER REFERENCES poli(id_poli);`,
+		`ALTER TABLE antrian DROP CONSTRAINT IF EXISTS antrian_nomor_antrian_tanggal_kunjungan_key;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_antrian_nomor_poli
+			ON antrian(tanggal_kunjungan, COALESCE(id_poli, 0), nomor_antrian);`,
+
 		// ===================== Index untuk performa =====================
 		`CREATE INDEX IF NOT EXISTS idx_antrian_tanggal ON antrian(tanggal_kunjungan);`,
 		`CREATE INDEX IF NOT EXISTS idx_antrian_nik ON antrian(nik);`,
 		`CREATE INDEX IF NOT EXISTS idx_pemeriksaan_tanggal ON pemeriksaan(tanggal_pemeriksaan);`,
 		`CREATE INDEX IF NOT EXISTS idx_pemeriksaan_nik ON pemeriksaan(nik_pasien);`,
+
+		// ===================== Tabel Rujukan =====================
+		`CREATE TABLE IF NOT EXISTS rujukan (
+			id_rujukan       SERIAL       PRIMARY KEY,
+			nomor_rujukan    VARCHAR(30)  NOT NULL UNIQUE,
+			id_pemeriksaan   INTEGER      NOT NULL REFERENCES pemeriksaan(id_pemeriksaan) ON DELETE CASCADE,
+			jenis_rujukan    VARCHAR(10)  NOT NULL CHECK (jenis_rujukan IN ('internal', 'eksternal')),
+			faskes_tujuan    VARCHAR(150) NOT NULL,
+			spesialis        VARCHAR(100) NOT NULL,
+			diagnosa         TEXT         NOT NULL,
+			alasan           TEXT         NOT NULL,
+			tanggal_rujukan  DATE         NOT NULL DEFAULT CURRENT_DATE,
+			created_at       TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at       TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_rujukan_tanggal ON rujukan(tanggal_rujukan);`,
+		`CREATE INDEX IF NOT EXISTS idx_rujukan_pemeriksaan ON rujukan(id_pemeriksaan);`,
+
+		// ===================== Tabel Dokter =====================
+		`CREATE TABLE IF NOT EXISTS dokter (
+			id_dokter     SERIAL       PRIMARY KEY,
+			id_user       INTEGER      UNIQUE REFERENCES users(id) ON DELETE SET NULL,
+			nama_dokter   VARCHAR(100) NOT NULL,
+			nomor_sip     VARCHAR(50)  NOT NULL UNIQUE,
+			spesialisasi  VARCHAR(100) NOT NULL DEFAULT '',
+			aktif         BOOLEAN      NOT NULL DEFAULT TRUE,
+			created_at    TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+
+		// ===================== Jadwal Praktik Mingguan =====================
+		`CREATE TABLE IF NOT EXISTS jadwal_dokter (
+			id_jadwal    SERIAL   PRIMARY KEY,
+			id_dokter    INTEGER  NOT NULL REFERENCES dokter(id_dokter) ON DELETE CASCADE,
+			id_poli      INTEGER  NOT NULL REFERENCES poli(id_poli),
+			hari         SMALLINT NOT NULL CHECK (hari BETWEEN 1 AND 7),
+			jam_mulai    TIME     NOT NULL,
+			jam_selesai  TIME     NOT NULL,
+			CHECK (jam_selesai > jam_mulai)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_jadwal_dokter_poli_hari ON jadwal_dokter(id_poli, hari);`,
+
+		// ===================== Cuti / Izin Dokter =====================
+		`CREATE TABLE IF NOT EXISTS cuti_dokter (
+			id_cuti          SERIAL       PRIMARY KEY,
+			id_dokter        INTEGER      NOT NULL REFERENCES dokter(id_dokter) ON DELETE CASCADE,
+			tanggal_mulai    DATE         NOT NULL,
+			tanggal_selesai  DATE         NOT NULL,
+			keterangan       VARCHAR(255) NOT NULL DEFAULT '',
+			CHECK (tanggal_selesai >= tanggal_mulai)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_cuti_dokter ON cuti_dokter(id_dokter, tanggal_mulai, tanggal_selesai);`,
+
+		// Dokter yang benar-benar memeriksa (bisa dokter pengganti)
+		`ALTER TABLE pemeriksaan ADD COLUMN IF NOT EXISTS id_dokter INTEGER REFERENCES dokter(id_dokter);`,
+
+		// ===================== Role Kasir =====================
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;`,
+
+		// ===================== Tabel Tarif (Master) =====================
+		`CREATE TABLE IF NOT EXISTS tarif (
+			id_tarif      SERIAL        PRIMARY KEY,
+			kode_tarif    VARCHAR(20)   NOT NULL UNIQUE,
+			nama_layanan  VARCHAR(100)  NOT NULL,
+			kategori      VARCHAR(20)   NOT NULL CHECK (kategori IN ('konsultasi', 'tindakan', 'obat', 'lab')),
+			harga         NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (harga >= 0),
+			aktif         BOOLEAN       NOT NULL DEFAULT TRUE,
+			created_at    TIMESTAMP     NOT NULL DEFAULT NOW(),
+			updated_at    TIMESTAMP     NOT NULL DEFAULT NOW()
+		);`,
+
+		// ===================== Tabel Tagihan =====================
+		`CREATE TABLE IF NOT EXISTS tagihan (
+			id_tagihan      SERIAL        PRIMARY KEY,
+			nomor_tagihan   VARCHAR(30)   NOT NULL UNIQUE,
+			id_pemeriksaan  INTEGER       NOT NULL UNIQUE REFERENCES pemeriksaan(id_pemeriksaan) ON DELETE CASCADE,
+			status          VARCHAR(15)   NOT NULL DEFAULT 'belum_lunas' CHECK (status IN ('belum_lunas', 'lunas', 'batal')),
+			total           NUMERIC(12,2) NOT NULL DEFAULT 0,
+			ditanggung      NUMERIC(12,2) NOT NULL DEFAULT 0,
+			total_bayar     NUMERIC(12,2) NOT NULL DEFAULT 0,
+			created_at      TIMESTAMP     NOT NULL DEFAULT NOW(),
+			updated_at      TIMESTAMP     NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS tagihan_item (
+			id_item       SERIAL        PRIMARY KEY,
+			id_tagihan    INTEGER       NOT NULL REFERENCES tagihan(id_tagihan) ON DELETE CASCADE,
+			id_tarif      INTEGER       NOT NULL REFERENCES tarif(id_tarif),
+			nama_item     VARCHAR(100)  NOT NULL,
+			kategori      VARCHAR(20)   NOT NULL,
+			harga_satuan  NUMERIC(12,2) NOT NULL,
+			jumlah        INTEGER       NOT NULL CHECK (jumlah > 0),
+			subtotal      NUMERIC(12,2) NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS pembayaran (
+			id_pembayaran   SERIAL        PRIMARY KEY,
+			id_tagihan      INTEGER       NOT NULL UNIQUE REFERENCES tagihan(id_tagihan) ON DELETE CASCADE,
+			metode          VARCHAR(10)   NOT NULL CHECK (metode IN ('tunai', 'qris')),
+			jumlah_dibayar  NUMERIC(12,2) NOT NULL,
+			kembalian       NUMERIC(12,2) NOT NULL DEFAULT 0,
+			referensi_qris  VARCHAR(64),
+			id_kasir        INTEGER       NOT NULL REFERENCES users(id),
+			dibayar_at      TIMESTAMP     NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_tagihan_item_tagihan ON tagihan_item(id_tagihan);`,
+		`CREATE INDEX IF NOT EXISTS idx_pembayaran_tanggal ON pembayaran(dibayar_at);`,
+
+		// ===================== Riwayat Harga Tarif =====================
+		`CREATE TABLE IF NOT EXISTS tarif_harga (
+			id_harga       SERIAL        PRIMARY KEY,
+			id_tarif       INTEGER       NOT NULL REFERENCES tarif(id_tarif) ON DELETE CASCADE,
+			harga          NUMERIC(12,2) NOT NULL CHECK (harga >= 0),
+			berlaku_mulai  DATE          NOT NULL,
+			created_at     TIMESTAMP     NOT NULL DEFAULT NOW(),
+			UNIQUE (id_tarif, berlaku_mulai)
+		);`,
+		// Harga awal tarif lama dianggap berlaku sejak tarif dibuat
+		`INSERT INTO tarif_harga (id_tarif, harga, berlaku_mulai)
+		 SELECT t.id_tarif, t.harga, t.created_at::date FROM tarif t
+		 WHERE NOT EXISTS (SELECT 1 FROM tarif_harga th WHERE th.id_tarif = t.id_tarif);`,
+
+		// ===================== Aturan Harga (Umum / BPJS) =====================
+		`CREATE TABLE IF NOT EXISTS aturan_harga (
+			id_aturan          SERIAL        PRIMARY KEY,
+			nama_aturan        VARCHAR(100)  NOT NULL,
+			jenis              VARCHAR(15)   NOT NULL CHECK (jenis IN ('tanggungan', 'diskon')),
+			metode_pembayaran  VARCHAR(10),
+			kategori           VARCHAR(20),
+			id_tarif           INTEGER       REFERENCES tarif(id_tarif) ON DELETE CASCADE,
+			umur_min           INTEGER,
+			umur_max           INTEGER,
+			persen             NUMERIC(5,2)  NOT NULL CHECK (persen > 0 AND persen <= 100),
+			berlaku_mulai      DATE          NOT NULL DEFAULT CURRENT_DATE,
+			berlaku_sampai     DATE,
+			prioritas          INTEGER       NOT NULL DEFAULT 0,
+			aktif              BOOLEAN       NOT NULL DEFAULT TRUE,
+			keterangan         VARCHAR(255)  NOT NULL DEFAULT '',
+			created_at         TIMESTAMP     NOT NULL DEFAULT NOW(),
+			updated_at         TIMESTAMP     NOT NULL DEFAULT NOW()
+		);`,
+		// Perilaku lama: pasien BPJS ditanggung penuh
+		`INSERT INTO aturan_harga (nama_aturan, jenis, metode_pembayaran, persen, berlaku_mulai, keterangan)
+		 SELECT 'BPJS ditanggung penuh', 'tanggungan', 'BPJS', 100, DATE '2000-01-01', 'Aturan bawaan'
+		 WHERE NOT EXISTS (SELECT 1 FROM aturan_harga);`,
+		`ALTER TABLE tagihan ADD COLUMN IF NOT EXISTS diskon NUMERIC(12,2) NOT NULL DEFAULT 0;`,
+		`ALTER TABLE tagihan_item ADD COLUMN IF NOT EXISTS ditanggung NUMERIC(12,2) NOT NULL DEFAULT 0;`,
+		`ALTER TABLE tagihan_item ADD COLUMN IF NOT EXISTS diskon NUMERIC(12,2) NOT NULL DEFAULT 0;`,
+
+		// ===================== Tabel Penjamin (Master) =====================
+		`CREATE TABLE IF NOT EXISTS penjamin (
+			kode_penjamin  VARCHAR(20)  PRIMARY KEY,
+			nama_penjamin  VARCHAR(100) NOT NULL,
+			jenis          VARCHAR(15)  NOT NULL CHECK (jenis IN ('umum', 'bpjs', 'jamkesda', 'asuransi', 'perusahaan')),
+			wajib_nomor    BOOLEAN      NOT NULL DEFAULT FALSE,
+			panjang_nomor  INTEGER      CHECK (panjang_nomor BETWEEN 1 AND 30),
+			aktif          BOOLEAN      NOT NULL DEFAULT TRUE,
+			created_at     TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at     TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`INSERT INTO penjamin (kode_penjamin, nama_penjamin, jenis, wajib_nomor, panjang_nomor) VALUES
+			('Umum', 'Umum (Bayar Sendiri)', 'umum', FALSE, NULL),
+			('BPJS', 'BPJS Kesehatan', 'bpjs', TRUE, 13),
+			('Jamkesda', 'Jamkesda', 'jamkesda', TRUE, NULL)
+		 ON CONFLICT (kode_penjamin) DO NOTHING;`,
+		`ALTER TABLE pemeriksaan DROP CONSTRAINT IF EXISTS pemeriksaan_metode_pembayaran_check;`,
+		`ALTER TABLE pemeriksaan ALTER COLUMN metode_pembayaran TYPE VARCHAR(20);`,
+		`ALTER TABLE pemeriksaan ADD COLUMN IF NOT EXISTS nomor_penjamin VARCHAR(30);`,
+		`ALTER TABLE pemeriksaan DROP CONSTRAINT IF EXISTS pemeriksaan_penjamin_fkey;`,
+		`ALTER TABLE pemeriksaan ADD CONSTRAINT pemeriksaan_penjamin_fkey
+			FOREIGN KEY (metode_pembayaran) REFERENCES penjamin(kode_penjamin);`,
+		`ALTER TABLE aturan_harga ALTER COLUMN metode_pembayaran TYPE VARCHAR(20);`,
+		`ALTER TABLE aturan_harga DROP CONSTRAINT IF EXISTS aturan_harga_penjamin_fkey;`,
+		`ALTER TABLE aturan_harga ADD CONSTRAINT aturan_harga_penjamin_fkey
+			FOREIGN KEY (metode_pembayaran) REFERENCES penjamin(kode_penjamin);`,
+		`CREATE INDEX IF NOT EXISTS idx_pemeriksaan_penjamin ON pemeriksaan(metode_pembayaran);`,
+
+		// ===================== Bridging BPJS =====================
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS kode_poli_bpjs VARCHAR(5);`,
+		`ALTER TABLE dokter ADD COLUMN IF NOT EXISTS kode_dokter_bpjs VARCHAR(20);`,
+		`ALTER TABLE pemeriksaan ADD COLUMN IF NOT EXISTS kode_diagnosa VARCHAR(10);`,
+		`CREATE TABLE IF NOT EXISTS bpjs_sinkron (
+			id_pemeriksaan    INTEGER      PRIMARY KEY REFERENCES pemeriksaan(id_pemeriksaan) ON DELETE CASCADE,
+			peserta_aktif     BOOLEAN,
+			ket_peserta       TEXT         NOT NULL DEFAULT '',
+			status            VARCHAR(10)  NOT NULL DEFAULT 'menunggu' CHECK (status IN ('menunggu', 'terkirim', 'gagal')),
+			no_kunjungan      VARCHAR(30),
+			pesan_error       TEXT         NOT NULL DEFAULT '',
+			percobaan         INTEGER      NOT NULL DEFAULT 0,
+			terakhir_dikirim  TIMESTAMP,
+			created_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at        TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_bpjs_sinkron_status ON bpjs_sinkron(status);`,
+		// Database lama membuat ket_peserta sebagai VARCHAR(100); pesan
+		// eligibilitas BPJS bisa lebih panjang
+		`ALTER TABLE bpjs_sinkron ALTER COLUMN ket_peserta TYPE TEXT;`,
+
+		// ===================== Tanda Vital =====================
+		`CREATE TABLE IF NOT EXISTS tanda_vital (
+			id_pemeriksaan  INTEGER      PRIMARY KEY REFERENCES pemeriksaan(id_pemeriksaan) ON DELETE CASCADE,
+			sistole         INTEGER      CHECK (sistole BETWEEN 40 AND 300),
+			diastole        INTEGER      CHECK (diastole BETWEEN 20 AND 200),
+			nadi            INTEGER      CHECK (nadi BETWEEN 20 AND 250),
+			pernapasan      INTEGER      CHECK (pernapasan BETWEEN 5 AND 80),
+			suhu            NUMERIC(4,1) CHECK (suhu BETWEEN 30 AND 45),
+			berat_badan     NUMERIC(5,1) CHECK (berat_badan > 0),
+			tinggi_badan    NUMERIC(5,1) CHECK (tinggi_badan > 0),
+			created_at      TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at      TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+
+		// ===================== Sinkronisasi SATUSEHAT =====================
+		// id_lokal: NIK (Patient), id_pemeriksaan (Encounter/Condition),
+		// id_pemeriksaan:jenis (Observation, misal 12:sistole)
+		`CREATE TABLE IF NOT EXISTS satusehat_sinkron (
+			id_sinkron        SERIAL       PRIMARY KEY,
+			jenis_resource    VARCHAR(15)  NOT NULL CHECK (jenis_resource IN ('Patient', 'Encounter', 'Condition', 'Observation')),
+			id_lokal          VARCHAR(40)  NOT NULL,
+			id_satusehat      VARCHAR(64),
+			status            VARCHAR(10)  NOT NULL DEFAULT 'menunggu' CHECK (status IN ('menunggu', 'terkirim', 'gagal')),
+			pesan_error       TEXT         NOT NULL DEFAULT '',
+			percobaan         INTEGER      NOT NULL DEFAULT 0,
+			coba_lagi_at      TIMESTAMP,
+			terakhir_dikirim  TIMESTAMP,
+			created_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at        TIMESTAMP    NOT NULL DEFAULT NOW(),
+			UNIQUE (jenis_resource, id_lokal)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_satusehat_sinkron_status ON satusehat_sinkron(status, coba_lagi_at);`,
+
+		// ===================== Booking Online =====================
+		// Kapasitas slot = jumlah dokter bertugas pada slot x kuota_slot
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS durasi_slot INTEGER NOT NULL DEFAULT 30 CHECK (durasi_slot BETWEEN 5 AND 240);`,
+		`ALTER TABLE poli ADD COLUMN IF NOT EXISTS kuota_slot  INTEGER NOT NULL DEFAULT 3 CHECK (kuota_slot BETWEEN 1 AND 50);`,
+		`CREATE TABLE IF NOT EXISTS booking (
+			id_booking    SERIAL       PRIMARY KEY,
+			kode_booking  VARCHAR(10)  NOT NULL UNIQUE,
+			nik           VARCHAR(20)  NOT NULL REFERENCES pasien(nik) ON DELETE CASCADE,
+			id_poli       INTEGER      NOT NULL REFERENCES poli(id_poli),
+			tanggal       DATE         NOT NULL,
+			jam_mulai     TIME         NOT NULL,
+			jam_selesai   TIME         NOT NULL,
+			status        VARCHAR(15)  NOT NULL DEFAULT 'aktif' CHECK (status IN ('aktif', 'checkin', 'kedaluwarsa', 'batal')),
+			id_antrian    INTEGER      REFERENCES antrian(id_antrian) ON DELETE SET NULL,
+			ip_pemesan    VARCHAR(45)  NOT NULL DEFAULT '',
+			checkin_at    TIMESTAMP,
+			created_at    TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_nik_tanggal
+			ON booking(nik, tanggal) WHERE status IN ('aktif', 'checkin');`,
+		`CREATE INDEX IF NOT EXISTS idx_booking_slot ON booking(id_poli, tanggal, jam_mulai);`,
+		`CREATE INDEX IF NOT EXISTS idx_booking_status ON booking(status, tanggal);`,
+
+		// ===================== Perangkat Kiosk =====================
+		// API key hanya disimpan sebagai hash SHA-256; prefix dipakai untuk
+		// mencari baris dan ditampilkan di daftar perangkat
+		`CREATE TABLE IF NOT EXISTS kiosk (
+			id_kiosk      SERIAL       PRIMARY KEY,
+			nama_kiosk    VARCHAR(100) NOT NULL,
+			lokasi        VARCHAR(100) NOT NULL DEFAULT '',
+			key_prefix    VARCHAR(12)  NOT NULL UNIQUE,
+			key_hash      VARCHAR(64)  NOT NULL,
+			izin          VARCHAR(30)  NOT NULL DEFAULT 'antrian:buat' CHECK (izin IN ('antrian:buat')),
+			aktif         BOOLEAN      NOT NULL DEFAULT TRUE,
+			terakhir_aktif TIMESTAMP,
+			created_at    TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+
+		// ===================== Audit Log =====================
+		`CREATE TABLE IF NOT EXISTS audit_log (
+			id_audit      BIGSERIAL    PRIMARY KEY,
+			jenis_aktor   VARCHAR(10)  NOT NULL CHECK (jenis_aktor IN ('user', 'kiosk')),
+			id_aktor      INTEGER      NOT NULL,
+			nama_aktor    VARCHAR(100) NOT NULL DEFAULT '',
+			aksi          VARCHAR(50)  NOT NULL,
+			objek         VARCHAR(30)  NOT NULL DEFAULT '',
+			id_objek      VARCHAR(40)  NOT NULL DEFAULT '',
+			keterangan    TEXT         NOT NULL DEFAULT '',
+			ip            VARCHAR(45)  NOT NULL DEFAULT '',
+			created_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_aktor ON audit_log(jenis_aktor, id_aktor, created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_log_waktu ON audit_log(created_at);`,
+
+		// ===================== Waktu Layanan Antrian =====================
+		// Selisih dipanggil_at -> selesai_at menjadi dasar estimasi waktu tunggu
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS dipanggil_at TIMESTAMP;`,
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS selesai_at   TIMESTAMP;`,
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS id_dokter    INTEGER REFERENCES dokter(id_dokter);`,
+		`CREATE INDEX IF NOT EXISTS idx_antrian_layanan ON antrian(id_poli, tanggal_kunjungan) WHERE selesai_at IS NOT NULL;`,
+
+		// ===================== Prioritas Antrian =====================
+		// Lansia, ibu hamil, disabilitas dan darurat didahulukan saat panggil berikutnya
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS prioritas VARCHAR(20) NOT NULL DEFAULT 'reguler'
+			CHECK (prioritas IN ('reguler', 'lansia', 'ibu_hamil', 'disabilitas', 'darurat'));`,
+
+		// ===================== Kapasitas Antrian =====================
+		// Kuota per hari dalam minggu; hari tanpa baris memakai poli.kuota_harian
+		`CREATE TABLE IF NOT EXISTS kapasitas_poli (
+			id_poli  INTEGER  NOT NULL REFERENCES poli(id_poli) ON DELETE CASCADE,
+			hari     SMALLINT NOT NULL CHECK (hari BETWEEN 1 AND 7),
+			kuota    INTEGER  NOT NULL CHECK (kuota BETWEEN 0 AND 999),
+			PRIMARY KEY (id_poli, hari)
+		);`,
+
+		// Kuota khusus satu tanggal (setengah hari, kampanye vaksinasi, dll)
+		`CREATE TABLE IF NOT EXISTS kapasitas_tanggal (
+			id_kapasitas  SERIAL       PRIMARY KEY,
+			id_poli       INTEGER      NOT NULL REFERENCES poli(id_poli) ON DELETE CASCADE,
+			tanggal       DATE         NOT NULL,
+			kuota         INTEGER      NOT NULL CHECK (kuota BETWEEN 0 AND 999),
+			keterangan    VARCHAR(255) NOT NULL DEFAULT '',
+			created_at    TIMESTAMP    NOT NULL DEFAULT NOW(),
+			UNIQUE (id_poli, tanggal)
+		);`,
+
+		// Hari libur: seluruh puskesmas tutup, antrian dan booking ditolak
+		`CREATE TABLE IF NOT EXISTS hari_libur (
+			id_libur    SERIAL       PRIMARY KEY,
+			tanggal     DATE         NOT NULL UNIQUE,
+			nama        VARCHAR(100) NOT NULL,
+			created_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+
+		// ===================== Penutupan Antrian Harian =====================
+		// tidak_hadir = sudah dipanggil tapi tidak datang, kedaluwarsa = belum
+		// sempat dipanggil saat poli tutup
+		`ALTER TABLE antrian DROP CONSTRAINT IF EXISTS antrian_status_check;`,
+		`ALTER TABLE antrian ADD CONSTRAINT antrian_status_check
+			CHECK (status IN ('belum_dikelola', 'sudah_dikelola', 'tidak_hadir', 'kedaluwarsa'));`,
+		`CREATE INDEX IF NOT EXISTS idx_antrian_nik_status ON antrian(nik, status, tanggal_kunjungan);`,
+
+		`CREATE TABLE IF NOT EXISTS ringkasan_antrian (
+			id_ringkasan        SERIAL       PRIMARY KEY,
+			tanggal             DATE         NOT NULL,
+			id_poli             INTEGER      REFERENCES poli(id_poli),
+			total               INTEGER      NOT NULL DEFAULT 0,
+			dilayani            INTEGER      NOT NULL DEFAULT 0,
+			tidak_hadir         INTEGER      NOT NULL DEFAULT 0,
+			kedaluwarsa         INTEGER      NOT NULL DEFAULT 0,
+			prioritas           INTEGER      NOT NULL DEFAULT 0,
+			rata_tunggu_menit   NUMERIC(6,1),
+			rata_layanan_menit  NUMERIC(6,1),
+			ditutup_at          TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_ringkasan_antrian_poli_tanggal
+			ON ringkasan_antrian(tanggal, (COALESCE(id_poli, 0)));`,
+
+		// ===================== Pengumuman Panggilan =====================
+		// dipanggil_at tetap waktu panggil pertama; panggil ulang hanya
+		// memperbarui terakhir_dipanggil_at agar layar mengumumkan lagi
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS terakhir_dipanggil_at TIMESTAMP;`,
+
+		// ===================== Loket Pendaftaran =====================
+		// Petugas memilih loket saat login; satu sesi terbuka per petugas dan
+		// per loket. Antrian mencatat loket dan petugas yang memanggil.
+		`CREATE TABLE IF NOT EXISTS loket (
+			id_loket    SERIAL      PRIMARY KEY,
+			nama_loket  VARCHAR(50) NOT NULL UNIQUE,
+			aktif       BOOLEAN     NOT NULL DEFAULT TRUE,
+			created_at  TIMESTAMP   NOT NULL DEFAULT NOW(),
+			updated_at  TIMESTAMP   NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS sesi_loket (
+			id_sesi     SERIAL    PRIMARY KEY,
+			id_loket    INTEGER   NOT NULL REFERENCES loket(id_loket),
+			id_user     INTEGER   NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			mulai_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+			selesai_at  TIMESTAMP
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_sesi_loket_user_terbuka ON sesi_loket(id_user) WHERE selesai_at IS NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_sesi_loket_loket_terbuka ON sesi_loket(id_loket) WHERE selesai_at IS NULL;`,
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS id_loket       INTEGER REFERENCES loket(id_loket);`,
+		`ALTER TABLE antrian ADD COLUMN IF NOT EXISTS dipanggil_oleh INTEGER REFERENCES users(id) ON DELETE SET NULL;`,
+		`CREATE INDEX IF NOT EXISTS idx_antrian_loket ON antrian(id_loket, tanggal_kunjungan);`,
+
+		// ===================== Kunjungan Bertahap =====================
+		// Satu kunjungan melewati beberapa tahap (pendaftaran -> poli -> farmasi),
+		// masing-masing dengan antrian dan waktunya sendiri. Tahap per_poli
+		// memakai tabel antrian poli yang sudah ada.
+		`CREATE TABLE IF NOT EXISTS tahap_layanan (
+			id_tahap        SERIAL      PRIMARY KEY,
+			kode_tahap      VARCHAR(30) NOT NULL UNIQUE,
+			nama_tahap      VARCHAR(50) NOT NULL,
+			urutan          INTEGER     NOT NULL,
+			per_poli        BOOLEAN     NOT NULL DEFAULT FALSE,
+			prefix_antrian  VARCHAR(5)  NOT NULL DEFAULT '',
+			aktif           BOOLEAN     NOT NULL DEFAULT TRUE,
+			created_at      TIMESTAMP   NOT NULL DEFAULT NOW(),
+			updated_at      TIMESTAMP   NOT NULL DEFAULT NOW()
+		);`,
+		`INSERT INTO tahap_layanan (kode_tahap, nama_tahap, urutan, per_poli, prefix_antrian) VALUES
+			('pendaftaran', 'Pendaftaran', 1, FALSE, 'R'),
+			('poli', 'Poli', 2, TRUE, ''),
+			('farmasi', 'Farmasi', 3, FALSE, 'F')
+		 ON CONFLICT (kode_tahap) DO NOTHING;`,
+
+		`CREATE TABLE IF NOT EXISTS kunjungan (
+			id_kunjungan  SERIAL      PRIMARY KEY,
+			nik           VARCHAR(20) NOT NULL REFERENCES pasien(nik) ON DELETE CASCADE,
+			id_poli       INTEGER     NOT NULL REFERENCES poli(id_poli),
+			tanggal       DATE        NOT NULL DEFAULT CURRENT_DATE,
+			prioritas     VARCHAR(20) NOT NULL DEFAULT 'reguler'
+				CHECK (prioritas IN ('reguler', 'lansia', 'ibu_hamil', 'disabilitas', 'darurat')),
+			status        VARCHAR(10) NOT NULL DEFAULT 'aktif' CHECK (status IN ('aktif', 'selesai', 'batal')),
+			id_tahap      INTEGER     REFERENCES tahap_layanan(id_tahap),
+			created_at    TIMESTAMP   NOT NULL DEFAULT NOW(),
+			selesai_at    TIMESTAMP,
+			updated_at    TIMESTAMP   NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_kunjungan_nik_aktif ON kunjungan(nik, tanggal) WHERE status = 'aktif';`,
+		`CREATE INDEX IF NOT EXISTS idx_kunjungan_tanggal ON kunjungan(tanggal, status);`,
+
+		`CREATE TABLE IF NOT EXISTS kunjungan_tahap (
+			id_kunjungan_tahap  SERIAL      PRIMARY KEY,
+			id_kunjungan        INTEGER     NOT NULL REFERENCES kunjungan(id_kunjungan) ON DELETE CASCADE,
+			id_tahap            INTEGER     NOT NULL REFERENCES tahap_layanan(id_tahap),
+			tanggal             DATE        NOT NULL,
+			nomor_antrian       INTEGER     NOT NULL,
+			id_antrian          INTEGER     REFERENCES antrian(id_antrian) ON DELETE SET NULL,
+			status              VARCHAR(10) NOT NULL DEFAULT 'menunggu'
+				CHECK (status IN ('menunggu', 'dipanggil', 'selesai', 'batal')),
+			id_loket            INTEGER     REFERENCES loket(id_loket),
+			masuk_at            TIMESTAMP   NOT NULL DEFAULT NOW(),
+			dipanggil_at        TIMESTAMP,
+			selesai_at          TIMESTAMP,
+			UNIQUE (id_kunjungan, id_tahap)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_kunjungan_tahap_antrian ON kunjungan_tahap(id_tahap, tanggal, status);`,
+
+		// ===================== Asal Pemeriksaan =====================
+		// Pemeriksaan merujuk tiket antrian dan kunjungan asalnya; satu tiket
+		// hanya untuk satu pemeriksaan
+		`ALTER TABLE pemeriksaan ADD COLUMN IF NOT EXISTS id_antrian   INTEGER REFERENCES antrian(id_antrian) ON DELETE SET NULL;`,
+		`ALTER TABLE pemeriksaan ADD COLUMN IF NOT EXISTS id_kunjungan INTEGER REFERENCES kunjungan(id_kunjungan) ON DELETE SET NULL;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_pemeriksaan_antrian ON pemeriksaan(id_antrian) WHERE id_antrian IS NOT NULL;`,
+
+		// ===================== Role & Izin =====================
+		// Role tidak lagi dibatasi CHECK; users.role merujuk tabel role dan
+		// akses endpoint ditentukan izin pada role_izin
+		`CREATE TABLE IF NOT EXISTS role (
+			kode_role   VARCHAR(20)  PRIMARY KEY,
+			nama_role   VARCHAR(50)  NOT NULL,
+			keterangan  VARCHAR(255) NOT NULL DEFAULT '',
+			sistem      BOOLEAN      NOT NULL DEFAULT FALSE,
+			created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+			updated_at  TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE TABLE IF NOT EXISTS role_izin (
+			kode_role  VARCHAR(20) NOT NULL REFERENCES role(kode_role) ON DELETE CASCADE,
+			izin       VARCHAR(50) NOT NULL,
+			PRIMARY KEY (kode_role, izin)
+		);`,
+		// Izin bawaan hanya diisi saat role pertama kali dibuat supaya
+		// perubahan dari admin tidak tertimpa saat restart
+		`WITH seed (kode_role, nama_role, sistem, izin) AS (VALUES
+			('admin', 'Administrator', TRUE, ARRAY[]::text[]),
+			('kepala_puskesmas', 'Kepala Puskesmas', TRUE, ARRAY['laporan.read', 'laporan.export']),
+			('kasir', 'Kasir', TRUE, ARRAY['tarif.read', 'kasir.read', 'kasir.write']),
+			('petugas_pendaftaran', 'Petugas Pendaftaran', FALSE, ARRAY['pasien.read', 'pasien.write',
+				'antrian.read', 'antrian.write', 'kunjungan.read', 'kunjungan.write', 'booking.read',
+				'booking.write', 'master.read', 'integrasi.read']),
+			('dokter', 'Dokter', FALSE, ARRAY['pasien.read', 'antrian.read', 'antrian.write',
+				'kunjungan.read', 'kunjungan.write', 'pemeriksaan.read', 'pemeriksaan.write',
+				'rujukan.read', 'rujukan.write', 'laporan.export', 'master.read']),
+			('perawat', 'Perawat', FALSE, ARRAY['pasien.read', 'antrian.read', 'antrian.write',
+				'kunjungan.read', 'kunjungan.write', 'pemeriksaan.read', 'pemeriksaan.write', 'master.read']),
+			('apoteker', 'Apoteker', FALSE, ARRAY['pasien.read', 'kunjungan.read', 'kunjungan.write',
+				'pemeriksaan.read', 'master.read'])
+		),
+		baru AS (
+			INSERT INTO role (kode_role, nama_role, sistem)
+			SELECT kode_role, nama_role, sistem FROM seed
+			ON CONFLICT (kode_role) DO NOTHING
+			RETURNING kode_role
+		)
+		INSERT INTO role_izin (kode_role, izin)
+		SELECT s.kode_role, unnest(s.izin) FROM seed s JOIN baru b ON s.kode_role = b.kode_role;`,
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;`,
+		`ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_fkey;`,
+		`ALTER TABLE users ADD CONSTRAINT users_role_fkey FOREIGN KEY (role) REFERENCES role(kode_role);`,
+
+		// ===================== Cakupan Poli & Akses Darurat =====================
+		// Role batasi_poli hanya melihat data poli tugas user. Nilai awal diisi
+		// sekali saat kolom baru ditambahkan, sesudahnya diatur admin.
+		`ALTER TABLE role ADD COLUMN IF NOT EXISTS batasi_poli BOOLEAN;`,
+		`UPDATE role SET batasi_poli = kode_role IN ('dokter', 'perawat') WHERE batasi_poli IS NULL;`,
+		`ALTER TABLE role ALTER COLUMN batasi_poli SET DEFAULT FALSE;`,
+		`ALTER TABLE role ALTER COLUMN batasi_poli SET NOT NULL;`,
+		`CREATE TABLE IF NOT EXISTS user_poli (
+			id_user  INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			id_poli  INTEGER NOT NULL REFERENCES poli(id_poli) ON DELETE CASCADE,
+			PRIMARY KEY (id_user, id_poli)
+		);`,
+		`CREATE TABLE IF NOT EXISTS akses_darurat (
+			id_akses     SERIAL     PRIMARY KEY,
+			id_user      INTEGER    NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			alasan       TEXT       NOT NULL,
+			mulai_at     TIMESTAMP  NOT NULL DEFAULT NOW(),
+			berakhir_at  TIMESTAMP  NOT NULL,
+			diakhiri_at  TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_akses_darurat_user ON akses_darurat(id_user, berakhir_at);`,
+
+		// ===================== Login Percobaan & Kunci =====================
+		`CREATE TABLE IF NOT EXISTS login_percobaan (
+			id_percobaan  BIGSERIAL    PRIMARY KEY,
+			username      VARCHAR(50)  NOT NULL,
+			ip            VARCHAR(64)  NOT NULL,
+			user_agent    VARCHAR(255) NOT NULL DEFAULT '',
+			berhasil      BOOLEAN      NOT NULL,
+			keterangan    VARCHAR(30)  NOT NULL DEFAULT '',
+			created_at    TIMESTAMP    NOT NULL DEFAULT NOW()
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_percobaan_username ON login_percobaan(username, created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_login_percobaan_ip ON login_percobaan(ip, created_at);`,
+		// Penghitung gagal berturut-turut per username dan per IP
+		`CREATE TABLE IF NOT EXISTS login_kunci (
+			jenis              VARCHAR(10)  NOT NULL CHECK (jenis IN ('username', 'ip')),
+			nilai              VARCHAR(64)  NOT NULL,
+			gagal              INTEGER      NOT NULL DEFAULT 0,
+			terakhir_gagal_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+			tunda_sampai       TIMESTAMP,
+			terkunci_sampai    TIMESTAMP,
+			PRIMARY KEY (jenis, nilai)
+		);`,
+
+		// ===================== 2FA (TOTP) =====================
+		// totp_secret terisi saat pendaftaran dimulai; baru berlaku setelah
+		// kode pertama diverifikasi (totp_aktif). totp_step_terakhir mencegah
+		// kode yang sama dipakai dua kali.
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret        VARCHAR(64);`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_aktif         BOOLEAN NOT NULL DEFAULT FALSE;`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_step_terakhir BIGINT  NOT NULL DEFAULT 0;`,
+		`CREATE TABLE IF NOT EXISTS totp_pemulihan (
+			id_pemulihan  SERIAL      PRIMARY KEY,
+			id_user       INTEGER     NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			kode_hash     VARCHAR(64) NOT NULL,
+			dipakai_at    TIMESTAMP,
+			UNIQUE (id_user, kode_hash)
+		);`,
+		`ALTER TABLE role ADD COLUMN IF NOT EXISTS wajib_2fa BOOLEAN NOT NULL DEFAULT FALSE;`,
+
+		// ===================== Izin Ekspor Laporan =====================
+		// laporan.export menjaga unduhan laporan dan PDF rujukan. Diberikan
+		// sekali ke role yang sudah bisa membaca laporan atau rujukan; setelah
+		// ada role yang memegangnya, pengaturan diserahkan ke admin.
+		`INSERT INTO role_izin (kode_role, izin)
+		 SELECT DISTINCT kode_role, 'laporan.export' FROM role_izin
+		 WHERE izin IN ('laporan.read', 'rujukan.read')
+			AND NOT EXISTS (SELECT 1 FROM role_izin WHERE izin = 'laporan.export')
+		 ON CONFLICT DO NOTHING;`,
+
+		// ===================== Role Bidan & Analis Lab =====================
+		// Sama seperti seed Role & Izin: izin hanya diisi saat role baru dibuat
+		`WITH seed (kode_role, nama_role, batasi_poli, izin) AS (VALUES
+			('bidan', 'Bidan', TRUE, ARRAY['pasien.read', 'antrian.read', 'antrian.write',
+				'kunjungan.read', 'kunjungan.write', 'pemeriksaan.read', 'pemeriksaan.write',
+				'rujukan.read', 'rujukan.write', 'laporan.export', 'master.read']),
+			('analis_lab', 'Analis Laboratorium', FALSE, ARRAY['pasien.read', 'kunjungan.read',
+				'kunjungan.write', 'pemeriksaan.read', 'master.read'])
+		),
+		baru AS (
+			INSERT INTO role (kode_role, nama_role, batasi_poli)
+			SELECT kode_role, nama_role, batasi_poli FROM seed
+			ON CONFLICT (kode_role) DO NOTHING
+			RETURNING kode_role
+		)
+		INSERT INTO role_izin (kode_role, izin)
+		SELECT s.kode_role, unnest(s.izin) FROM seed s JOIN baru b ON s.kode_role = b.kode_role;`,
+
+		// ===================== Tagihan Batal =====================
+		// Satu pemeriksaan hanya boleh punya satu tagihan aktif; tagihan yang
+		// dibatalkan tidak menghalangi tagihan baru
+		`ALTER TABLE tagihan DROP CONSTRAINT IF EXISTS tagihan_id_pemeriksaan_key;`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_tagihan_pemeriksaan_aktif ON tagihan(id_pemeriksaan) WHERE status <> 'batal';`,
 	}
 
 	for i, sql := range migrations {
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// GetTrustedProxies daftar IP / CIDR reverse proxy dari TRUSTED_PROXIES,
+// dipisah koma. Kosong berarti server diakses langsung dan c.IP() adalah
+// alamat koneksi.
+func GetTrustedProxies() []string {
+	var hasil []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			hasil = append(hasil, p)
+		}
+	}
+	return hasil
+}
+
+// GetProxyHeader header berisi IP klien asli yang diisi reverse proxy
+// (default X-Real-IP). Hindari X-Forwarded-For kecuali proxy menimpanya,
+// karena nilai pertamanya bisa dikirim sendiri oleh klien.
+func GetProxyHeader() string {
+	h := strings.TrimSpace(os.Getenv("PROXY_HEADER"))
+	if h == "" {
+		h = "X-Real-IP"
+	}
+	return h
+}
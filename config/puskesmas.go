@@ -0,0 +1,26 @@
+package config
+
+import "os"
+
+// GetPuskesmasNama mengembalikan nama puskesmas untuk kop surat dan tiket
+func GetPuskesmasNama() string {
+	nama := os.Getenv("PUSKESMAS_NAMA")
+	if nama == "" {
+		nama = "Puskesmas Sukasari"
+	}
+	return nama
+}
+
+// GetPuskesmasAlamat mengembalikan alamat puskesmas (boleh kosong)
+func GetPuskesmasAlamat() string {
+	return os.Getenv("PUSKESMAS_ALAMAT")
+}
+
+// GetPrinterKertas mengembalikan lebar kertas printer thermal default dalam
+// mm (58 atau 80) untuk tiket antrian dan kwitansi
+func GetPrinterKertas() int {
+	if os.Getenv("PRINTER_KERTAS") == "58" {
+		return 58
+	}
+	return 80
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"sikupas/backend/satusehat"
+)
+
+var (
+	satusehatOnce   sync.Once
+	satusehatClient *satusehat.Client
+)
+
+// GetSatusehatClient mengembalikan client SATUSEHAT, atau nil bila
+// SATUSEHAT_BASE_URL tidak diisi (sinkronisasi nonaktif). Untuk pengembangan
+// arahkan ke server tiruan: go run ./cmd/satusehat-mock
+func GetSatusehatClient() *satusehat.Client {
+	satusehatOnce.Do(func() {
+		baseURL := os.Getenv("SATUSEHAT_BASE_URL")
+		if baseURL == "" {
+			return
+		}
+		satusehatClient = satusehat.NewClient(
+			os.Getenv("SATUSEHAT_AUTH_URL"),
+			baseURL,
+			os.Getenv("SATUSEHAT_CLIENT_ID"),
+			os.Getenv("SATUSEHAT_CLIENT_SECRET"),
+			os.Getenv("SATUSEHAT_ORG_ID"),
+		)
+	})
+	return satusehatClient
+}
+
+// GetSatusehatInterval jeda antar putaran worker sinkronisasi (default 1 menit)
+func GetSatusehatInterval() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("SATUSEHAT_INTERVAL"))
+	if err != nil || d < 10*time.Second {
+		return time.Minute
+	}
+	return d
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const pesanDiluarPoli = "Data ini di luar poli tugas Anda, gunakan akses darurat jika diperlukan"
+
+// cakupanPoli adalah poli yang datanya boleh dilihat user pada request ini
+type cakupanPoli struct {
+	semua  bool
+	idPoli []int
+}
+
+func (k cakupanPoli) boleh(idPoli int) bool {
+	if k.semua {
+		return true
+	}
+	for _, id := range k.idPoli {
+		if id == idPoli {
+			return true
+		}
+	}
+	return false
+}
+
+// cakupanPoliUser menentukan poli yang boleh dilihat user login. Role tanpa
+// batasi_poli melihat semua poli. Selama akses darurat aktif semua poli
+// terbuka, dan setiap pemanggilan dicatat di audit log beserta alasannya.
+func cakupanPoliUser(c *fiber.Ctx, objek, idObjek string) (cakupanPoli, error) {
+	userID, _ := c.Locals("user_id").(int)
+
+	var batasi bool
+	var k cakupanPoli
+	var alasan *string
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT r.batasi_poli,
+			COALESCE(ARRAY(SELECT id_poli FROM user_poli WHERE id_user = u.id ORDER BY id_poli), '{}'),
+			(SELECT alasan FROM akses_darurat WHERE id_user = u.id AND berakhir_at > NOW()
+			 ORDER BY mulai_at DESC LIMIT 1)
+		 FROM users u JOIN role r ON u.role = r.kode_role
+		 WHERE u.id = $1`, userID,
+	).Scan(&batasi, &k.idPoli, &alasan)
+	if err != nil {
+		return k, err
+	}
+
+	if !batasi {
+		k.semua = true
+		return k, nil
+	}
+	if alasan != nil {
+		k.semua = true
+		catatAudit(c, "akses_darurat.akses", objek, idObjek, c.Method()+" "+c.OriginalURL()+": "+*alasan)
+	}
+	return k, nil
+}
+
+// cakupanPemeriksaan memastikan pemeriksaan ada dan poli-nya dalam cakupan
+// user, untuk data turunan pemeriksaan seperti tanda vital dan rujukan.
+// Mengembalikan kode HTTP dan pesan error, atau 0 jika boleh.
+func cakupanPemeriksaan(c *fiber.Ctx, idPemeriksaan int) (int, string) {
+	var idPoli int
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id_poli FROM pemeriksaan WHERE id_pemeriksaan = $1`, idPemeriksaan,
+	).Scan(&idPoli)
+	if err != nil {
+		return 404, "Pemeriksaan tidak ditemukan"
+	}
+
+	cakupan, err := cakupanPoliUser(c, "pemeriksaan", strconv.Itoa(idPemeriksaan))
+	if err != nil {
+		return 500, "Gagal memeriksa cakupan poli"
+	}
+	if !cakupan.boleh(idPoli) {
+		return 403, pesanDiluarPoli
+	}
+	return 0, ""
+}
+
+// isiCakupanUser mengisi poli tugas dan akses darurat yang aktif untuk /me
+func isiCakupanUser(u *model.UserResponse) {
+	config.DB.QueryRow(context.Background(),
+		`SELECT ARRAY(SELECT id_poli FROM user_poli WHERE id_user = $1 ORDER BY id_poli),
+			(SELECT to_char(MAX(berakhir_at), 'YYYY-MM-DD HH24:MI:SS') FROM akses_darurat
+			 WHERE id_user = $1 AND berakhir_at > NOW())`, u.ID,
+	).Scan(&u.IDPoli, &u.AksesDaruratSampai)
+}
+
+const aksesDaruratSelectSQL = `SELECT ad.id_akses, ad.id_user, u.username, ad.alasan,
+	to_char(ad.mulai_at, 'YYYY-MM-DD HH24:MI:SS'), to_char(ad.berakhir_at, 'YYYY-MM-DD HH24:MI:SS'),
+	to_char(ad.diakhiri_at, 'YYYY-MM-DD HH24:MI:SS'), ad.berakhir_at > NOW(),
+	(SELECT COUNT(*) FROM audit_log al
+	 WHERE al.jenis_aktor = 'user' AND al.id_aktor = ad.id_user AND al.aksi = 'akses_darurat.akses'
+	   AND al.created_at >= ad.mulai_at AND al.created_at <= ad.berakhir_at)
+	FROM akses_darurat ad
+	JOIN users u ON ad.id_user = u.id `
+
+func scanAksesDarurat(row pgx.Row) (model.AksesDarurat, error) {
+	var a model.AksesDarurat
+	err := row.Scan(&a.IDAkses, &a.IDUser, &a.Username, &a.Alasan, &a.MulaiAt, &a.BerakhirAt,
+		&a.DiakhiriAt, &a.Aktif, &a.JumlahAkses)
+	return a, err
+}
+
+// ─── POST /me/akses-darurat ──────────────────────────────────────────────────
+
+// MulaiAksesDarurat membuka semua poli untuk user login selama durasi yang
+// diminta. Alasan wajib diisi dan ikut tercatat pada setiap akses.
+func MulaiAksesDarurat(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var req model.AksesDaruratRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.Alasan = strings.TrimSpace(req.Alasan)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+	if req.DurasiMenit == 0 {
+		req.DurasiMenit = model.DurasiAksesDaruratDefault
+	}
+
+	var aktif bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM akses_darurat WHERE id_user = $1 AND berakhir_at > NOW())`, userID,
+	).Scan(&aktif)
+	if aktif {
+		return model.ErrorResponse(c, 409, "Akses darurat masih aktif, akhiri terlebih dahulu untuk memulai yang baru")
+	}
+
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO akses_darurat (id_user, alasan, berakhir_at)
+		 VALUES ($1, $2, NOW() + make_interval(mins => $3)) RETURNING id_akses`,
+		userID, req.Alasan, req.DurasiMenit,
+	).Scan(&id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai akses darurat")
+	}
+
+	catatAudit(c, "akses_darurat.mulai", "akses_darurat", strconv.Itoa(id),
+		strconv.Itoa(req.DurasiMenit)+" menit: "+req.Alasan)
+
+	a, _ := scanAksesDarurat(config.DB.QueryRow(context.Background(),
+		aksesDaruratSelectSQL+`WHERE ad.id_akses = $1`, id))
+	return model.SuccessResponse(c, 201, "Akses darurat aktif, semua akses data dicatat", a)
+}
+
+// ─── DELETE /me/akses-darurat ────────────────────────────────────────────────
+
+func AkhiriAksesDarurat(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`UPDATE akses_darurat SET berakhir_at = NOW(), diakhiri_at = NOW()
+		 WHERE id_user = $1 AND berakhir_at > NOW() RETURNING id_akses`, userID,
+	).Scan(&id)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tidak ada akses darurat yang aktif")
+	}
+
+	catatAudit(c, "akses_darurat.akhiri", "akses_darurat", strconv.Itoa(id), "")
+
+	return model.SuccessResponse(c, 200, "Akses darurat diakhiri", nil)
+}
+
+// ─── GET /akses-darurat ──────────────────────────────────────────────────────
+
+// GetAllAksesDarurat mendaftar riwayat akses darurat untuk ditinjau; rincian
+// setiap akses ada di audit log dengan aksi akses_darurat.akses
+func GetAllAksesDarurat(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	idUser, _ := strconv.Atoi(c.Query("id_user", "0"))
+	aktif := c.Query("aktif", "") == "true"
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if idUser > 0 {
+		baseWhere += ` AND ad.id_user = $` + strconv.Itoa(argIdx)
+		args = append(args, idUser)
+		argIdx++
+	}
+	if aktif {
+		baseWhere += ` AND ad.berakhir_at > NOW()`
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM akses_darurat ad `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := aksesDaruratSelectSQL + baseWhere + `
+		ORDER BY ad.mulai_at DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data akses darurat")
+	}
+	defer queryRows.Close()
+
+	rows := []model.AksesDarurat{}
+	for queryRows.Next() {
+		a, _ := scanAksesDarurat(queryRows)
+		rows = append(rows, a)
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
@@ -2,15 +2,46 @@ package handler
 
 import (
 	"context"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"sikupas/backend/config"
 	"sikupas/backend/model"
 )
 
+// antrianSelectSQL dibangun saat dipakai karena jumlah antrian di depan
+// bergantung pada urutan prioritas yang bisa diubah lewat env
+func antrianSelectSQL() string {
+	return `SELECT a.id_antrian, a.nik, p.nama_pasien, a.id_poli, COALESCE(po.nama_poli, ''),
+	a.nomor_antrian, COALESCE(po.prefix_antrian, ''), a.tanggal_kunjungan, a.status, a.prioritas,
+	a.id_dokter, a.id_loket, l.nama_loket, to_char(a.dipanggil_at, 'YYYY-MM-DD HH24:MI:SS'), to_char(a.selesai_at, 'YYYY-MM-DD HH24:MI:SS'),
+	(SELECT COUNT(*) FROM antrian x
+	 WHERE x.tanggal_kunjungan = a.tanggal_kunjungan AND x.id_poli IS NOT DISTINCT FROM a.id_poli
+	   AND x.status = 'belum_dikelola' AND x.dipanggil_at IS NULL
+	   AND ` + didepanSQL("x.prioritas", "x.nomor_antrian", "a.prioritas", "a.nomor_antrian", peringkatPrioritas(false)) + `)
+	FROM antrian a
+	JOIN pasien p ON a.nik = p.nik
+	LEFT JOIN poli po ON a.id_poli = po.id_poli
+	LEFT JOIN loket l ON a.id_loket = l.id_loket
+	`
+}
+
+func scanAntrian(row pgx.Row) (model.AntrianResponse, error) {
+	var a model.AntrianResponse
+	var tg interface{}
+	var prefix string
+	err := row.Scan(&a.IDantrian, &a.NIK, &a.NamaPasien, &a.IDPoli, &a.NamaPoli,
+		&a.NomorAntrian, &prefix, &tg, &a.Status, &a.Prioritas,
+		&a.IDDokter, &a.IDLoket, &a.NamaLoket, &a.DipanggilAt, &a.SelesaiAt, &a.JumlahMenunggu)
+	a.KodeAntrian = model.FormatKodeAntrian(prefix, a.NomorAntrian)
+	a.TanggalKunjungan = formatDate(tg)
+	return a, err
+}
+
 // ─── GET /antrian ────────────────────────────────────────────────────────────
 
 func GetAllAntrian(c *fiber.Ctx) error {
@@ -18,6 +49,8 @@ func GetAllAntrian(c *fiber.Ctx) error {
 	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
 	search := strings.TrimSpace(c.Query("search", ""))
 	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
+	prioritas := strings.TrimSpace(c.Query("prioritas", ""))
 
 	if page < 1 {
 		page = 1
@@ -30,57 +63,58 @@ func GetAllAntrian(c *fiber.Ctx) error {
 	}
 	offset := (page - 1) * perPage
 
-	var totalData int
-	var rows []model.AntrianResponse
+	cakupan, err := cakupanPoliUser(c, "antrian", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
 
+	baseWhere := `WHERE a.tanggal_kunjungan = $1`
+	args := []interface{}{tanggal}
+	argIdx := 2
+
+	if !cakupan.semua {
+		baseWhere += ` AND a.id_poli = ANY($` + strconv.Itoa(argIdx) + `)`
+		args = append(args, cakupan.idPoli)
+		argIdx++
+	}
+	if idPoli > 0 {
+		baseWhere += ` AND a.id_poli = $` + strconv.Itoa(argIdx)
+		args = append(args, idPoli)
+		argIdx++
+	}
+	if prioritas != "" {
+		baseWhere += ` AND a.prioritas = $` + strconv.Itoa(argIdx)
+		args = append(args, prioritas)
+		argIdx++
+	}
 	if search != "" {
-		config.DB.QueryRow(context.Background(),
-			`SELECT COUNT(*) FROM antrian a JOIN pasien p ON a.nik = p.nik
-			 WHERE a.tanggal_kunjungan = $1 AND (a.nik LIKE '%'||$2||'%' OR p.nama_pasien ILIKE '%'||$2||'%')`,
-			tanggal, search,
-		).Scan(&totalData)
-
-		queryRows, err := config.DB.Query(context.Background(),
-			`SELECT a.id_antrian, a.nik, p.nama_pasien, a.nomor_antrian, a.tanggal_kunjungan, a.status
-			 FROM antrian a JOIN pasien p ON a.nik = p.nik
-			 WHERE a.tanggal_kunjungan = $1 AND (a.nik LIKE '%'||$2||'%' OR p.nama_pasien ILIKE '%'||$2||'%')
-			 ORDER BY a.nomor_antrian ASC LIMIT $3 OFFSET $4`,
-			tanggal, search, perPage, offset)
-		if err != nil {
-			return model.ErrorResponse(c, 500, "Gagal mengambil data antrian")
-		}
-		defer queryRows.Close()
-
-		for queryRows.Next() {
-			var a model.AntrianResponse
-			var tg interface{}
-			queryRows.Scan(&a.IDantrian, &a.NIK, &a.NamaPasien, &a.NomorAntrian, &tg, &a.Status)
-			a.TanggalKunjungan = formatDate(tg)
-			rows = append(rows, a)
-		}
-	} else {
-		config.DB.QueryRow(context.Background(),
-			`SELECT COUNT(*) FROM antrian WHERE tanggal_kunjungan = $1`, tanggal,
-		).Scan(&totalData)
-
-		queryRows, err := config.DB.Query(context.Background(),
-			`SELECT a.id_antrian, a.nik, p.nama_pasien, a.nomor_antrian, a.tanggal_kunjungan, a.status
-			 FROM antrian a JOIN pasien p ON a.nik = p.nik
-			 WHERE a.tanggal_kunjungan = $1
-			 ORDER BY a.nomor_antrian ASC LIMIT $2 OFFSET $3`,
-			tanggal, perPage, offset)
-		if err != nil {
-			return model.ErrorResponse(c, 500, "Gagal mengambil data antrian")
-		}
-		defer queryRows.Close()
-
-		for queryRows.Next() {
-			var a model.AntrianResponse
-			var tg interface{}
-			queryRows.Scan(&a.IDantrian, &a.NIK, &a.NamaPasien, &a.NomorAntrian, &tg, &a.Status)
-			a.TanggalKunjungan = formatDate(tg)
-			rows = append(rows, a)
-		}
+		baseWhere += ` AND (a.nik LIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR p.nama_pasien ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM antrian a JOIN pasien p ON a.nik = p.nik `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := antrianSelectSQL() + baseWhere + `
+		ORDER BY po.urutan NULLS FIRST, a.nomor_antrian ASC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data antrian")
+	}
+	defer queryRows.Close()
+
+	var rows []model.AntrianResponse
+	laju := map[int]float64{}
+	for queryRows.Next() {
+		a, _ := scanAntrian(queryRows)
+		isiEstimasiAntrian(&a, laju)
+		rows = append(rows, a)
 	}
 
 	if rows == nil {
@@ -98,18 +132,22 @@ func GetAntrianByID(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 400, "ID tidak valid")
 	}
 
-	var a model.AntrianResponse
-	var tg interface{}
-	err = config.DB.QueryRow(context.Background(),
-		`SELECT a.id_antrian, a.nik, p.nama_pasien, a.nomor_antrian, a.tanggal_kunjungan, a.status
-		 FROM antrian a JOIN pasien p ON a.nik = p.nik WHERE a.id_antrian = $1`, id,
-	).Scan(&a.IDantrian, &a.NIK, &a.NamaPasien, &a.NomorAntrian, &tg, &a.Status)
-
+	a, err := scanAntrian(config.DB.QueryRow(context.Background(),
+		antrianSelectSQL()+`WHERE a.id_antrian = $1`, id))
 	if err != nil {
 		return model.ErrorResponse(c, 404, "Antrian tidak ditemukan")
 	}
 
-	a.TanggalKunjungan = formatDate(tg)
+	// Antrian lama tanpa poli hanya terlihat oleh role yang tidak dibatasi
+	cakupan, err := cakupanPoliUser(c, "antrian", strconv.Itoa(id))
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.semua && (a.IDPoli == nil || !cakupan.boleh(*a.IDPoli)) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+	isiEstimasiAntrian(&a, map[int]float64{})
+
 	return model.SuccessResponse(c, 200, "Berhasil", a)
 }
 
@@ -123,71 +161,31 @@ func CreateAntrian(c *fiber.Ctx) error {
 
 	req.NIK = strings.TrimSpace(req.NIK)
 	req.TanggalKunjungan = strings.TrimSpace(req.TanggalKunjungan)
+	req.Prioritas = strings.TrimSpace(req.Prioritas)
 
 	if errs := req.Validate(); len(errs) > 0 {
 		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
 	}
 
-	// Cek pasien ada
-	var pasienExists bool
-	config.DB.QueryRow(context.Background(),
-		`SELECT EXISTS(SELECT 1 FROM pasien WHERE nik = $1)`, req.NIK,
-	).Scan(&pasienExists)
-
-	if !pasienExists {
-		return model.ErrorResponse(c, 404, "Pasien dengan NIK tersebut tidak ditemukan")
-	}
-
-	// Cek sudah ada antrian hari yang sama
-	var dupExists bool
+	// Pasien yang sudah booking online diarahkan ke check-in supaya slotnya terpakai
+	var kodeBooking string
 	config.DB.QueryRow(context.Background(),
-		`SELECT EXISTS(SELECT 1 FROM antrian WHERE nik = $1 AND tanggal_kunjungan = $2)`,
+		`SELECT kode_booking FROM booking WHERE nik = $1 AND tanggal = $2 AND status = 'aktif'`,
 		req.NIK, req.TanggalKunjungan,
-	).Scan(&dupExists)
-
-	if dupExists {
-		return model.ErrorResponse(c, 409, "Pasien sudah memiliki antrian pada hari ini")
-	}
-
-	// Cek max antrian (50 per hari)
-	var totalHari int
-	config.DB.QueryRow(context.Background(),
-		`SELECT COUNT(*) FROM antrian WHERE tanggal_kunjungan = $1`, req.TanggalKunjungan,
-	).Scan(&totalHari)
-
-	if totalHari >= 50 {
-		return model.ErrorResponse(c, 409, "Antrian hari ini sudah penuh (max 50)")
+	).Scan(&kodeBooking)
+	if kodeBooking != "" {
+		return model.ErrorResponse(c, 409, "Pasien memiliki booking "+kodeBooking+" pada tanggal ini, lakukan check-in")
 	}
 
-	// Nomor antrian berikutnya
-	nomorAntrian := totalHari + 1
-
-	var idAntrian int
-	err := config.DB.QueryRow(context.Background(),
-		`INSERT INTO antrian (nik, nomor_antrian, tanggal_kunjungan, status)
-		 VALUES ($1, $2, $3, 'belum_dikelola')
-		 RETURNING id_antrian`,
-		req.NIK, nomorAntrian, req.TanggalKunjungan,
-	).Scan(&idAntrian)
-
-	if err != nil {
-		return model.ErrorResponse(c, 500, "Gagal membuat antrian: "+err.Error())
+	idAntrian, code, msg := buatAntrian(req.NIK, req.IDPoli, req.TanggalKunjungan, req.Prioritas, 0)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
 	}
 
-	// Ambil nama pasien
-	var namaPasien string
-	config.DB.QueryRow(context.Background(),
-		`SELECT nama_pasien FROM pasien WHERE nik = $1`, req.NIK,
-	).Scan(&namaPasien)
-
-	return model.SuccessResponse(c, 201, "Antrian berhasil dibuat", model.AntrianResponse{
-		IDantrian:        idAntrian,
-		NIK:              req.NIK,
-		NamaPasien:       namaPasien,
-		NomorAntrian:     nomorAntrian,
-		TanggalKunjungan: req.TanggalKunjungan,
-		Status:           "belum_dikelola",
-	})
+	a, _ := scanAntrian(config.DB.QueryRow(context.Background(),
+		antrianSelectSQL()+`WHERE a.id_antrian = $1`, idAntrian))
+	isiEstimasiAntrian(&a, map[int]float64{})
+	return model.SuccessResponse(c, 201, "Antrian berhasil dibuat", a)
 }
 
 // ─── PUT /antrian/:id ────────────────────────────────────────────────────────
@@ -208,8 +206,13 @@ func UpdateAntrianStatus(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
 	}
 
+	// selesai_at dicatat sekali saat antrian selesai dilayani; dikosongkan
+	// lagi jika status dikembalikan
 	result, err := config.DB.Exec(context.Background(),
-		`UPDATE antrian SET status = $1, updated_at = NOW() WHERE id_antrian = $2`,
+		`UPDATE antrian SET status = $1,
+			selesai_at = CASE WHEN $1 = 'sudah_dikelola' THEN COALESCE(selesai_at, NOW()) END,
+			updated_at = NOW()
+		 WHERE id_antrian = $2`,
 		req.Status, id)
 
 	if err != nil {
@@ -222,6 +225,51 @@ func UpdateAntrianStatus(c *fiber.Ctx) error {
 	return model.SuccessResponse(c, 200, "Status antrian berhasil diupdate", nil)
 }
 
+// ─── PUT /antrian/:id/prioritas ──────────────────────────────────────────────
+
+// UpdatePrioritasAntrian mengubah kategori prioritas antrian yang belum
+// dipanggil, misal pasien ternyata ibu hamil atau datang dalam kondisi darurat
+func UpdatePrioritasAntrian(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.UpdatePrioritasAntrianRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.Prioritas = strings.TrimSpace(req.Prioritas)
+	req.Keterangan = strings.TrimSpace(req.Keterangan)
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	a, err := scanAntrian(config.DB.QueryRow(context.Background(),
+		antrianSelectSQL()+`WHERE a.id_antrian = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Antrian tidak ditemukan")
+	}
+	if a.Status != "belum_dikelola" || a.DipanggilAt != nil {
+		return model.ErrorResponse(c, 409, "Prioritas hanya bisa diubah sebelum antrian dipanggil")
+	}
+
+	config.DB.Exec(context.Background(),
+		`UPDATE antrian SET prioritas = $1, updated_at = NOW() WHERE id_antrian = $2`, req.Prioritas, id)
+
+	keterangan := a.KodeAntrian + ": " + a.Prioritas + " -> " + req.Prioritas
+	if req.Keterangan != "" {
+		keterangan += " (" + req.Keterangan + ")"
+	}
+	catatAudit(c, "antrian.ubah_prioritas", "antrian", strconv.Itoa(id), keterangan)
+
+	a, _ = scanAntrian(config.DB.QueryRow(context.Background(),
+		antrianSelectSQL()+`WHERE a.id_antrian = $1`, id))
+	isiEstimasiAntrian(&a, map[int]float64{})
+	return model.SuccessResponse(c, 200, "Prioritas antrian berhasil diubah", a)
+}
+
 // ─── DELETE /antrian/:id ─────────────────────────────────────────────────────
 
 func DeleteAntrian(c *fiber.Ctx) error {
@@ -247,34 +295,66 @@ func DeleteAntrian(c *fiber.Ctx) error {
 
 func GetDashboardSummary(c *fiber.Ctx) error {
 	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
 	if tanggal == "" {
 		tanggal = time.Now().Format("2006-01-02")
 	}
 
-	var total, sudah, belum int
+	// Tanpa id_poli, role yang dibatasi hanya menghitung poli tugasnya
+	cakupan, err := cakupanPoliUser(c, "antrian", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if idPoli > 0 && !cakupan.boleh(idPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
 
-	config.DB.QueryRow(context.Background(),
-		`SELECT COUNT(*) FROM antrian WHERE tanggal_kunjungan = $1`, tanggal,
-	).Scan(&total)
+	var total, sudah, belum, tidakHadir, kedaluwarsa int
 
 	config.DB.QueryRow(context.Background(),
-		`SELECT COUNT(*) FROM antrian WHERE tanggal_kunjungan = $1 AND status = 'sudah_dikelola'`, tanggal,
-	).Scan(&sudah)
-
-	belum = total - sudah
+		`SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'sudah_dikelola'),
+			COUNT(*) FILTER (WHERE status = 'belum_dikelola'),
+			COUNT(*) FILTER (WHERE status = 'tidak_hadir'),
+			COUNT(*) FILTER (WHERE status = 'kedaluwarsa')
+		 FROM antrian WHERE tanggal_kunjungan = $1 AND ($2 = 0 OR id_poli = $2)
+		   AND ($3 OR id_poli = ANY($4))`, tanggal, idPoli, cakupan.semua, cakupan.idPoli,
+	).Scan(&total, &sudah, &belum, &tidakHadir, &kedaluwarsa)
+
+	jumlahPrioritas := map[string]int{}
+	for _, k := range model.KategoriPrioritas {
+		jumlahPrioritas[k] = 0
+	}
+	prioritasRows, err := config.DB.Query(context.Background(),
+		`SELECT prioritas, COUNT(*) FROM antrian WHERE tanggal_kunjungan = $1 AND ($2 = 0 OR id_poli = $2)
+		   AND ($3 OR id_poli = ANY($4))
+		 GROUP BY prioritas`, tanggal, idPoli, cakupan.semua, cakupan.idPoli)
+	if err == nil {
+		for prioritasRows.Next() {
+			var k string
+			var n int
+			prioritasRows.Scan(&k, &n)
+			jumlahPrioritas[k] = n
+		}
+		prioritasRows.Close()
+	}
 
 	// Cari nomor antrian pertama yang belum dikelola
 	nomorSekarang := 1
 	config.DB.QueryRow(context.Background(),
 		`SELECT COALESCE(MIN(nomor_antrian), 1) FROM antrian
-		 WHERE tanggal_kunjungan = $1 AND status = 'belum_dikelola'`, tanggal,
+		 WHERE tanggal_kunjungan = $1 AND ($2 = 0 OR id_poli = $2) AND ($3 OR id_poli = ANY($4))
+		   AND status = 'belum_dikelola'`, tanggal, idPoli, cakupan.semua, cakupan.idPoli,
 	).Scan(&nomorSekarang)
 
 	return model.SuccessResponse(c, 200, "Berhasil", model.DashboardSummary{
 		TotalAntrian:         total,
 		TotalSudahDikelola:   sudah,
 		TotalBelumDikelola:   belum,
+		TotalTidakHadir:      tidakHadir,
+		TotalKedaluwarsa:     kedaluwarsa,
 		NomorAntrianSekarang: nomorSekarang,
+		JumlahPrioritas:      jumlahPrioritas,
 	})
 }
 
@@ -282,34 +362,533 @@ func GetDashboardSummary(c *fiber.Ctx) error {
 
 func GetAntrianBoxes(c *fiber.Ctx) error {
 	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
 	if tanggal == "" {
 		tanggal = time.Now().Format("2006-01-02")
 	}
 
-	boxes := make([]model.AntrianBoxItem, 50)
-	for i := 0; i < 50; i++ {
+	cakupan, err := cakupanPoliUser(c, "antrian", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.boleh(idPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
+	// Tanpa id_poli: antrian lama tanpa poli
+	var poli *int
+	if idPoli > 0 {
+		poli = &idPoli
+	}
+	kapasitas, tutup, err := kapasitasHarian(poli, tanggal)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	// Kapasitas yang diturunkan setelah antrian terisi tidak menyembunyikan
+	// nomor yang sudah terlanjur dibuat
+	var nomorTerakhir int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COALESCE(MAX(nomor_antrian), 0) FROM antrian
+		 WHERE tanggal_kunjungan = $1 AND id_poli IS NOT DISTINCT FROM $2`, tanggal, poli,
+	).Scan(&nomorTerakhir)
+	if nomorTerakhir > kapasitas {
+		kapasitas = nomorTerakhir
+	}
+
+	boxes := make([]model.AntrianBoxItem, kapasitas)
+	for i := 0; i < kapasitas; i++ {
 		boxes[i] = model.AntrianBoxItem{
 			NomorAntrian: i + 1,
 			Status:       "kosong",
 		}
 	}
 
-	// Ambil semua antrian hari ini
+	// Ambil semua antrian hari ini dalam urutan panggil (prioritas lalu nomor)
+	// agar jumlah menunggu di depan bisa dihitung sambil jalan
 	queryRows, err := config.DB.Query(context.Background(),
-		`SELECT nomor_antrian, status FROM antrian WHERE tanggal_kunjungan = $1`, tanggal)
+		`SELECT nomor_antrian, status, prioritas, dipanggil_at IS NOT NULL FROM antrian
+		 WHERE tanggal_kunjungan = $1 AND id_poli IS NOT DISTINCT FROM NULLIF($2, 0)
+		 ORDER BY `+rankPrioritasSQL("prioritas", peringkatPrioritas(false))+`, nomor_antrian`, tanggal, idPoli)
 	if err != nil {
 		return model.ErrorResponse(c, 500, "Gagal mengambil data antrian")
 	}
 	defer queryRows.Close()
 
+	menitPerPasien := 0.0
+	if idPoli > 0 {
+		menitPerPasien = lajuLayananPoli(idPoli, tanggal)
+	}
+	menunggu := 0
 	for queryRows.Next() {
 		var nomor int
-		var status string
-		queryRows.Scan(&nomor, &status)
-		if nomor >= 1 && nomor <= 50 {
+		var status, prioritas string
+		var dipanggil bool
+		queryRows.Scan(&nomor, &status, &prioritas, &dipanggil)
+		if nomor >= 1 && nomor <= kapasitas {
 			boxes[nomor-1].Status = status
+			boxes[nomor-1].Prioritas = prioritas
+			if status == "belum_dikelola" && !dipanggil && idPoli > 0 {
+				boxes[nomor-1].EstimasiTunggu = menitTunggu(menunggu, menitPerPasien)
+			}
+		}
+		if status == "belum_dikelola" && !dipanggil {
+			menunggu++
+		}
+	}
+
+	msg := "Berhasil"
+	if tutup != "" {
+		msg = tutup
+	}
+	return model.SuccessResponse(c, 200, msg, boxes)
+}
+
+// ─── POST /antrian/panggil ───────────────────────────────────────────────────
+
+// PanggilAntrianBerikutnya memanggil antrian berikutnya di poli hari ini:
+// kategori prioritas lebih dulu, lalu nomor terkecil. Setelah beberapa
+// panggilan prioritas berturut-turut, satu pasien reguler didahulukan agar
+// antrian reguler tetap bergerak. Waktu panggil menjadi awal durasi layanan
+// untuk estimasi.
+func PanggilAntrianBerikutnya(c *fiber.Ctx) error {
+	var req model.PanggilAntrianRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+	if req.IDPoli <= 0 {
+		return model.ErrorResponse(c, 400, "Poli harus dipilih")
+	}
+
+	tanggal := time.Now().Format("2006-01-02")
+	if code, msg := cekDokterPemanggil(req.IDDokter, req.IDPoli, tanggal); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+	idLoket, code, msg := loketPemanggil(c, req.IDLoket)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+	userID, _ := c.Locals("user_id").(int)
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memanggil antrian")
+	}
+	defer tx.Rollback(ctx)
+
+	peringkat := peringkatPrioritas(false)
+	if maks := config.GetMaksPrioritasBeruntun(); maks > 0 && prioritasBeruntun(tx, req.IDPoli, tanggal, peringkat) >= maks {
+		peringkat = peringkatPrioritas(true)
+	}
+
+	// SKIP LOCKED supaya dua dokter yang menekan panggil bersamaan tidak
+	// mendapat nomor yang sama
+	var id int
+	err = tx.QueryRow(ctx,
+		`SELECT id_antrian FROM antrian
+		 WHERE id_poli = $1 AND tanggal_kunjungan = $2 AND status = 'belum_dikelola' AND dipanggil_at IS NULL
+		 ORDER BY `+rankPrioritasSQL("prioritas", peringkat)+`, nomor_antrian LIMIT 1
+		 FOR UPDATE SKIP LOCKED`, req.IDPoli, tanggal,
+	).Scan(&id)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tidak ada antrian yang menunggu")
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE antrian SET dipanggil_at = NOW(), terakhir_dipanggil_at = NOW(), id_dokter = $1,
+		 id_loket = $2, dipanggil_oleh = NULLIF($3, 0), updated_at = NOW()
+		 WHERE id_antrian = $4`,
+		req.IDDokter, idLoket, userID, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memanggil antrian")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memanggil antrian")
+	}
+
+	a, _ := scanAntrian(config.DB.QueryRow(ctx, antrianSelectSQL()+`WHERE a.id_antrian = $1`, id))
+	a.Pengumuman = pengumumanAntrian(a)
+	return model.SuccessResponse(c, 200, "Antrian "+a.KodeAntrian+" dipanggil", a)
+}
+
+// ─── POST /antrian/:id/panggil ───────────────────────────────────────────────
+
+// PanggilAntrian memanggil (atau memanggil ulang) antrian tertentu; waktu
+// panggil pertama tidak berubah agar durasi layanan tetap akurat. Pasien yang
+// ditandai tidak hadir tapi datang terlambat di hari yang sama bisa dipanggil
+// lagi.
+func PanggilAntrian(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.PanggilAntrianRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	a, err := scanAntrian(config.DB.QueryRow(context.Background(),
+		antrianSelectSQL()+`WHERE a.id_antrian = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Antrian tidak ditemukan")
+	}
+	switch a.Status {
+	case "sudah_dikelola":
+		return model.ErrorResponse(c, 409, "Antrian sudah selesai dilayani")
+	case "kedaluwarsa":
+		return model.ErrorResponse(c, 409, "Antrian sudah kedaluwarsa")
+	case "tidak_hadir":
+		if a.TanggalKunjungan != time.Now().Format("2006-01-02") {
+			return model.ErrorResponse(c, 409, "Antrian sudah ditutup")
+		}
+	}
+	if req.IDDokter != nil && a.IDPoli != nil {
+		if code, msg := cekDokterPemanggil(req.IDDokter, *a.IDPoli, a.TanggalKunjungan); code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
+	}
+	idLoket, code, msg := loketPemanggil(c, req.IDLoket)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+	userID, _ := c.Locals("user_id").(int)
+
+	// Panggil ulang dari loket lain memindahkan pasien ke loket tersebut
+	config.DB.Exec(context.Background(),
+		`UPDATE antrian SET status = 'belum_dikelola', dipanggil_at = COALESCE(dipanggil_at, NOW()),
+		 terakhir_dipanggil_at = NOW(), id_dokter = COALESCE($1, id_dokter), id_loket = COALESCE($2, id_loket),
+		 dipanggil_oleh = COALESCE(NULLIF($3, 0), dipanggil_oleh), updated_at = NOW() WHERE id_antrian = $4`,
+		req.IDDokter, idLoket, userID, id)
+
+	a, _ = scanAntrian(config.DB.QueryRow(context.Background(),
+		antrianSelectSQL()+`WHERE a.id_antrian = $1`, id))
+	a.Pengumuman = pengumumanAntrian(a)
+	return model.SuccessResponse(c, 200, "Antrian "+a.KodeAntrian+" dipanggil", a)
+}
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// buatAntrian mengambil nomor antrian berikutnya untuk pasien pada poli dan
+// tanggal tertentu. Booking online yang masih aktif ikut mengurangi kuota,
+// kecuali booking yang sedang di-check-in (idBooking). Prioritas kosong
+// ditentukan dari usia pasien. Mengembalikan id_antrian, atau kode HTTP dan
+// pesan error.
+func buatAntrian(nik string, idPoli *int, tanggal, prioritas string, idBooking int) (int, int, string) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return 0, 500, "Gagal membuat antrian"
+	}
+	defer tx.Rollback(ctx)
+
+	idAntrian, code, msg := buatAntrianTx(ctx, tx, nik, idPoli, tanggal, prioritas, idBooking)
+	if code != 0 {
+		return 0, code, msg
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 500, "Gagal membuat antrian"
+	}
+	return idAntrian, 0, ""
+}
+
+// buatAntrianTx seperti buatAntrian di dalam tx milik pemanggil, supaya
+// perubahan lain (misal status booking) ikut commit atau batal bersama
+func buatAntrianTx(ctx context.Context, tx pgx.Tx, nik string, idPoli *int, tanggal, prioritas string, idBooking int) (int, int, string) {
+	// Kunci per poli agar dua pendaftaran bersamaan tidak mendapat nomor sama
+	// atau sama-sama lolos cek kuota
+	kunci := 0
+	if idPoli != nil {
+		kunci = *idPoli
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('antrian'), $1)`, kunci); err != nil {
+		return 0, 500, "Gagal membuat antrian"
+	}
+
+	// Cek pasien ada
+	var pasienExists bool
+	tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM pasien WHERE nik = $1)`, nik,
+	).Scan(&pasienExists)
+
+	if !pasienExists {
+		return 0, 404, "Pasien dengan NIK tersebut tidak ditemukan"
+	}
+
+	// Cek poli tujuan masih aktif dan buka pada tanggal tersebut
+	if idPoli != nil {
+		if code, msg := cekPoliAktif(*idPoli); code != 0 {
+			return 0, code, msg
+		}
+	}
+	kuota, tutup, err := kapasitasHarian(idPoli, tanggal)
+	if err != nil {
+		return 0, 500, "Gagal mengambil kapasitas poli"
+	}
+	if tutup != "" {
+		return 0, 409, tutup
+	}
+
+	// Cek sudah ada antrian hari yang sama
+	var dupExists bool
+	tx.QueryRow(ctx,
+		`SELECT EXISTS(SELECT 1 FROM antrian WHERE nik = $1 AND tanggal_kunjungan = $2)`,
+		nik, tanggal,
+	).Scan(&dupExists)
+
+	if dupExists {
+		return 0, 409, "Pasien sudah memiliki antrian pada hari ini"
+	}
+
+	// Cek kuota antrian poli
+	var totalHari, nomorTerakhir, dipesan int
+	tx.QueryRow(ctx,
+		`SELECT COUNT(*), COALESCE(MAX(nomor_antrian), 0) FROM antrian
+		 WHERE tanggal_kunjungan = $1 AND id_poli IS NOT DISTINCT FROM $2`,
+		tanggal, idPoli,
+	).Scan(&totalHari, &nomorTerakhir)
+	tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM booking
+		 WHERE tanggal = $1 AND id_poli IS NOT DISTINCT FROM $2 AND status = 'aktif' AND id_booking <> $3`,
+		tanggal, idPoli, idBooking,
+	).Scan(&dipesan)
+
+	if totalHari+dipesan >= kuota {
+		return 0, 409, "Antrian hari ini sudah penuh (max " + strconv.Itoa(kuota) + ")"
+	}
+
+	// Nomor antrian berikutnya
+	nomorAntrian := nomorTerakhir + 1
+
+	if prioritas == "" {
+		prioritas = prioritasOtomatis(nik, tanggal)
+	}
+
+	var idAntrian int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO antrian (nik, id_poli, nomor_antrian, tanggal_kunjungan, status, prioritas)
+		 VALUES ($1, $2, $3, $4, 'belum_dikelola', $5)
+		 RETURNING id_antrian`,
+		nik, idPoli, nomorAntrian, tanggal, prioritas,
+	).Scan(&idAntrian)
+
+	if err != nil {
+		return 0, 500, "Gagal membuat antrian: " + err.Error()
+	}
+
+	return idAntrian, 0, ""
+}
+
+// prioritasOtomatis dipakai jika kategori tidak diisi: pasien yang sudah
+// mencapai usia lansia pada tanggal kunjungan otomatis diprioritaskan
+func prioritasOtomatis(nik, tanggal string) string {
+	var usia int
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXTRACT(YEAR FROM age($2::date, tanggal_lahir))::int FROM pasien WHERE nik = $1`,
+		nik, tanggal,
+	).Scan(&usia)
+	if usia >= config.GetUsiaLansia() {
+		return model.PrioritasLansia
+	}
+	return model.PrioritasReguler
+}
+
+// estimasiTunggu menghitung antrian yang belum dipanggil di depan nomor
+// tertentu (sesuai urutan prioritas) dan perkiraan waktu tunggunya dalam menit
+func estimasiTunggu(idPoli int, tanggal string, nomor int, prioritas string) (menunggu, menit int) {
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM antrian
+		 WHERE id_poli = $1 AND tanggal_kunjungan = $2 AND status = 'belum_dikelola'
+		   AND dipanggil_at IS NULL
+		   AND `+didepanSQL("prioritas", "nomor_antrian", "$4::text", "$3::int", peringkatPrioritas(false)),
+		idPoli, tanggal, nomor, prioritas,
+	).Scan(&menunggu)
+
+	if idPoli <= 0 {
+		return menunggu, 0
+	}
+	return menunggu, *menitTunggu(menunggu, lajuLayananPoli(idPoli, tanggal))
+}
+
+// isiEstimasiAntrian mengisi EstimasiTunggu untuk antrian yang belum
+// dipanggil. laju menyimpan menit per pasien per poli agar daftar antrian
+// tidak menghitung ulang statistik poli yang sama.
+func isiEstimasiAntrian(a *model.AntrianResponse, laju map[int]float64) {
+	if a.IDPoli == nil || a.Status != "belum_dikelola" || a.DipanggilAt != nil {
+		return
+	}
+	m, ok := laju[*a.IDPoli]
+	if !ok {
+		m = lajuLayananPoli(*a.IDPoli, a.TanggalKunjungan)
+		laju[*a.IDPoli] = m
+	}
+	a.EstimasiTunggu = menitTunggu(a.JumlahMenunggu, m)
+}
+
+func menitTunggu(menunggu int, menitPerPasien float64) *int {
+	m := int(math.Ceil(float64(menunggu) * menitPerPasien))
+	return &m
+}
+
+// Riwayat layanan yang dipakai untuk estimasi: 30 hari terakhir, durasi wajar
+// (di bawah 3 jam), dan minimal 5 data agar rata-rata tidak liar
+const (
+	hariRiwayatLayanan   = 30
+	minimalDataLayanan   = 5
+	maksDurasiLayananSQL = `INTERVAL '3 hours'`
+)
+
+// lajuLayananPoli mengembalikan rata-rata menit per pasien di poli pada
+// tanggal tertentu. Durasi layanan diambil dari riwayat dipanggil_at ->
+// selesai_at per dokter; dokter yang bertugas bekerja paralel sehingga laju
+// poli = jumlah (1 / durasi dokter). Dokter dengan riwayat kurang memakai
+// rata-rata poli, dan poli tanpa riwayat memakai durasi_slot / kuota_slot.
+func lajuLayananPoli(idPoli int, tanggal string) float64 {
+	durasi, kuotaSlot := 30, 3
+	config.DB.QueryRow(context.Background(),
+		`SELECT durasi_slot, kuota_slot FROM poli WHERE id_poli = $1`, idPoli,
+	).Scan(&durasi, &kuotaSlot)
+	rataPoli := float64(durasi) / float64(kuotaSlot)
+
+	riwayat := `FROM antrian
+		 WHERE id_poli = $1 AND selesai_at IS NOT NULL AND dipanggil_at IS NOT NULL
+		   AND selesai_at > dipanggil_at AND selesai_at - dipanggil_at < ` + maksDurasiLayananSQL + `
+		   AND tanggal_kunjungan BETWEEN $2::date - ` + strconv.Itoa(hariRiwayatLayanan) + ` AND $2::date`
+
+	var rata *float64
+	var jumlah int
+	config.DB.QueryRow(context.Background(),
+		`SELECT AVG(EXTRACT(EPOCH FROM selesai_at - dipanggil_at)) / 60, COUNT(*) `+riwayat,
+		idPoli, tanggal,
+	).Scan(&rata, &jumlah)
+	if rata != nil && jumlah >= minimalDataLayanan {
+		rataPoli = *rata
+	}
+
+	tgl, err := time.Parse("2006-01-02", tanggal)
+	if err != nil {
+		return rataPoli
+	}
+
+	// Dokter bertugas hari itu beserta rata-rata durasi layanannya masing-masing
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT h.rata, COALESCE(h.jumlah, 0)
+		 FROM (SELECT DISTINCT j.id_dokter FROM jadwal_dokter j
+		       JOIN dokter d ON j.id_dokter = d.id_dokter
+		       WHERE j.id_poli = $1 AND j.hari = $3 AND d.aktif
+		         AND NOT EXISTS(SELECT 1 FROM cuti_dokter ct WHERE ct.id_dokter = d.id_dokter
+		                        AND $2::date BETWEEN ct.tanggal_mulai AND ct.tanggal_selesai)) bt
+		 LEFT JOIN (SELECT id_dokter, AVG(EXTRACT(EPOCH FROM selesai_at - dipanggil_at)) / 60 AS rata, COUNT(*) AS jumlah
+		            `+riwayat+` AND id_dokter IS NOT NULL
+		            GROUP BY id_dokter) h ON h.id_dokter = bt.id_dokter`,
+		idPoli, tanggal, model.HariISO(tgl))
+	if err != nil {
+		return rataPoli
+	}
+	defer rows.Close()
+
+	laju := 0.0 // pasien per menit
+	for rows.Next() {
+		var rataDokter *float64
+		var n int
+		rows.Scan(&rataDokter, &n)
+		if rataDokter != nil && n >= minimalDataLayanan && *rataDokter > 0 {
+			laju += 1 / *rataDokter
+		} else {
+			laju += 1 / rataPoli
+		}
+	}
+	if laju == 0 {
+		return rataPoli
+	}
+	return 1 / laju
+}
+
+// cekDokterPemanggil memastikan dokter (jika diisi) bertugas di poli pada
+// tanggal tersebut
+func cekDokterPemanggil(idDokter *int, idPoli int, tanggal string) (int, string) {
+	if idDokter == nil {
+		return 0, ""
+	}
+	if msg := cekJadwalDokter(*idDokter, idPoli, tanggal); msg != "" {
+		return 400, msg
+	}
+	return 0, ""
+}
+
+// rankLainnya adalah peringkat reguler dan kategori yang tidak disebut di
+// urutan prioritas
+const rankLainnya = 100
+
+// peringkatPrioritas memetakan kategori ke urutan panggil (makin kecil makin
+// didahulukan) sesuai ANTRIAN_URUTAN_PRIORITAS. Pada giliran reguler (adil),
+// semua kategori prioritas kecuali darurat mundur ke belakang reguler.
+func peringkatPrioritas(adil bool) map[string]int {
+	peringkat := map[string]int{}
+	for _, k := range config.GetUrutanPrioritas() {
+		if _, ada := peringkat[k]; ada || !model.IsPrioritasValid(k) || k == model.PrioritasReguler {
+			continue
+		}
+		peringkat[k] = len(peringkat) + 1
+	}
+	if adil {
+		for k := range peringkat {
+			if k != model.PrioritasDarurat {
+				peringkat[k] += rankLainnya
+			}
+		}
+	}
+	return peringkat
+}
+
+func rankPrioritas(prioritas string, peringkat map[string]int) int {
+	if r, ok := peringkat[prioritas]; ok {
+		return r
+	}
+	return rankLainnya
+}
+
+// rankPrioritasSQL mengubah peringkat menjadi ekspresi CASE. Kategori sudah
+// divalidasi terhadap model.KategoriPrioritas sehingga aman ditulis langsung.
+func rankPrioritasSQL(kolom string, peringkat map[string]int) string {
+	sql := "CASE " + kolom
+	for _, k := range model.KategoriPrioritas {
+		if r, ok := peringkat[k]; ok {
+			sql += " WHEN '" + k + "' THEN " + strconv.Itoa(r)
 		}
 	}
+	return sql + " ELSE " + strconv.Itoa(rankLainnya) + " END"
+}
+
+// didepanSQL adalah kondisi antrian x dipanggil sebelum antrian a
+func didepanSQL(xPrioritas, xNomor, aPrioritas, aNomor string, peringkat map[string]int) string {
+	rx, ra := rankPrioritasSQL(xPrioritas, peringkat), rankPrioritasSQL(aPrioritas, peringkat)
+	return "(" + rx + " < " + ra + " OR (" + rx + " = " + ra + " AND " + xNomor + " < " + aNomor + "))"
+}
 
-	return model.SuccessResponse(c, 200, "Berhasil", boxes)
+// prioritasBeruntun menghitung panggilan prioritas berturut-turut terakhir di
+// poli hari ini; panggilan darurat dilewati, panggilan reguler memutus hitungan
+func prioritasBeruntun(tx pgx.Tx, idPoli int, tanggal string, peringkat map[string]int) int {
+	rows, err := tx.Query(context.Background(),
+		`SELECT prioritas FROM antrian
+		 WHERE id_poli = $1 AND tanggal_kunjungan = $2 AND dipanggil_at IS NOT NULL
+		 ORDER BY dipanggil_at DESC LIMIT 50`, idPoli, tanggal)
+	if err != nil {
+		return 0
+	}
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var p string
+		rows.Scan(&p)
+		if p == model.PrioritasDarurat {
+			continue
+		}
+		if rankPrioritas(p, peringkat) >= rankLainnya {
+			break
+		}
+		n++
+	}
+	return n
 }
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const aturanHargaSelectSQL = `SELECT id_aturan, nama_aturan, jenis, metode_pembayaran, kategori, id_tarif,
+	umur_min, umur_max, persen, berlaku_mulai, berlaku_sampai, prioritas, aktif, keterangan
+	FROM aturan_harga
+	`
+
+func scanAturanHarga(row pgx.Row) (model.AturanHarga, error) {
+	var a model.AturanHarga
+	var mulai, sampai interface{}
+	err := row.Scan(&a.IDAturan, &a.NamaAturan, &a.Jenis, &a.MetodePembayaran, &a.Kategori, &a.IDTarif,
+		&a.UmurMin, &a.UmurMax, &a.Persen, &mulai, &sampai, &a.Prioritas, &a.Aktif, &a.Keterangan)
+	a.BerlakuMulai = formatDate(mulai)
+	if sampai != nil {
+		s := formatDate(sampai)
+		a.BerlakuSampai = &s
+	}
+	return a, err
+}
+
+// loadAturanHargaBerlaku mengambil aturan aktif yang berlaku pada tanggal
+// tertentu; pencocokan per item dilakukan di model.HitungPotongan
+func loadAturanHargaBerlaku(ctx context.Context, tx pgx.Tx, tanggal string) ([]model.AturanHarga, error) {
+	rows, err := tx.Query(ctx, aturanHargaSelectSQL+`
+		WHERE aktif = TRUE AND berlaku_mulai <= $1 AND (berlaku_sampai IS NULL OR berlaku_sampai >= $1)
+		ORDER BY prioritas, id_aturan`, tanggal)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aturan []model.AturanHarga
+	for rows.Next() {
+		a, err := scanAturanHarga(rows)
+		if err != nil {
+			return nil, err
+		}
+		aturan = append(aturan, a)
+	}
+	return aturan, rows.Err()
+}
+
+// normalisasiAturanHarga membuang spasi dan mengubah isian kosong menjadi
+// "berlaku untuk semua"
+func normalisasiAturanHarga(req *model.AturanHargaRequest) {
+	req.NamaAturan = strings.TrimSpace(req.NamaAturan)
+	req.Jenis = strings.TrimSpace(req.Jenis)
+	req.BerlakuMulai = strings.TrimSpace(req.BerlakuMulai)
+	req.Keterangan = strings.TrimSpace(req.Keterangan)
+
+	kosongkan := func(s *string) *string {
+		if s == nil || strings.TrimSpace(*s) == "" {
+			return nil
+		}
+		v := strings.TrimSpace(*s)
+		return &v
+	}
+	req.MetodePembayaran = kosongkan(req.MetodePembayaran)
+	req.Kategori = kosongkan(req.Kategori)
+	req.BerlakuSampai = kosongkan(req.BerlakuSampai)
+}
+
+// cekReferensiAturanHarga cek penjamin dan tarif yang dirujuk aturan
+func cekReferensiAturanHarga(req *model.AturanHargaRequest) (int, string) {
+	if req.MetodePembayaran != nil {
+		var exists bool
+		config.DB.QueryRow(context.Background(),
+			`SELECT EXISTS(SELECT 1 FROM penjamin WHERE kode_penjamin = $1)`, *req.MetodePembayaran,
+		).Scan(&exists)
+		if !exists {
+			return 404, "Penjamin '" + *req.MetodePembayaran + "' tidak ditemukan"
+		}
+	}
+	if req.IDTarif != nil {
+		var exists bool
+		config.DB.QueryRow(context.Background(),
+			`SELECT EXISTS(SELECT 1 FROM tarif WHERE id_tarif = $1)`, *req.IDTarif,
+		).Scan(&exists)
+		if !exists {
+			return 404, "Tarif tidak ditemukan"
+		}
+	}
+	return 0, ""
+}
+
+// ─── GET /aturan-harga ───────────────────────────────────────────────────────
+
+func GetAllAturanHarga(c *fiber.Ctx) error {
+	metode := strings.TrimSpace(c.Query("metode_pembayaran", ""))
+	aktif := strings.TrimSpace(c.Query("aktif", ""))
+
+	where := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if metode != "" {
+		where += ` AND (metode_pembayaran IS NULL OR metode_pembayaran = $` + strconv.Itoa(argIdx) + `)`
+		args = append(args, metode)
+		argIdx++
+	}
+	if aktif == "true" || aktif == "false" {
+		where += ` AND aktif = $` + strconv.Itoa(argIdx)
+		args = append(args, aktif == "true")
+		argIdx++
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		aturanHargaSelectSQL+where+` ORDER BY prioritas, id_aturan`, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data aturan harga")
+	}
+	defer rows.Close()
+
+	aturan := []model.AturanHarga{}
+	for rows.Next() {
+		a, _ := scanAturanHarga(rows)
+		aturan = append(aturan, a)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", aturan)
+}
+
+// ─── GET /aturan-harga/:id ───────────────────────────────────────────────────
+
+func GetAturanHargaByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	a, err := scanAturanHarga(config.DB.QueryRow(context.Background(),
+		aturanHargaSelectSQL+`WHERE id_aturan = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Aturan harga tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", a)
+}
+
+// ─── POST /aturan-harga ──────────────────────────────────────────────────────
+
+func CreateAturanHarga(c *fiber.Ctx) error {
+	var req model.AturanHargaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	normalisasiAturanHarga(&req)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if code, msg := cekReferensiAturanHarga(&req); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	aktif := true
+	if req.Aktif != nil {
+		aktif = *req.Aktif
+	}
+
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO aturan_harga (nama_aturan, jenis, metode_pembayaran, kategori, id_tarif,
+			umur_min, umur_max, persen, berlaku_mulai, berlaku_sampai, prioritas, aktif, keterangan)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		 RETURNING id_aturan`,
+		req.NamaAturan, req.Jenis, req.MetodePembayaran, req.Kategori, req.IDTarif,
+		req.UmurMin, req.UmurMax, req.Persen, req.BerlakuMulai, req.BerlakuSampai, req.Prioritas, aktif, req.Keterangan,
+	).Scan(&id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menambah aturan harga: "+err.Error())
+	}
+
+	a, _ := scanAturanHarga(config.DB.QueryRow(context.Background(),
+		aturanHargaSelectSQL+`WHERE id_aturan = $1`, id))
+
+	return model.SuccessResponse(c, 201, "Aturan harga berhasil ditambahkan", a)
+}
+
+// ─── PUT /aturan-harga/:id ───────────────────────────────────────────────────
+
+// UpdateAturanHarga hanya berpengaruh pada tagihan yang dihitung ulang
+// setelahnya; tagihan lunas tetap memakai nilai yang tersimpan
+func UpdateAturanHarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.AturanHargaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	normalisasiAturanHarga(&req)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if code, msg := cekReferensiAturanHarga(&req); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE aturan_harga SET nama_aturan=$1, jenis=$2, metode_pembayaran=$3, kategori=$4, id_tarif=$5,
+			umur_min=$6, umur_max=$7, persen=$8, berlaku_mulai=$9, berlaku_sampai=$10, prioritas=$11,
+			aktif=COALESCE($12, aktif), keterangan=$13, updated_at=NOW()
+		 WHERE id_aturan=$14`,
+		req.NamaAturan, req.Jenis, req.MetodePembayaran, req.Kategori, req.IDTarif,
+		req.UmurMin, req.UmurMax, req.Persen, req.BerlakuMulai, req.BerlakuSampai, req.Prioritas,
+		req.Aktif, req.Keterangan, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update aturan harga: "+err.Error())
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Aturan harga tidak ditemukan")
+	}
+
+	a, _ := scanAturanHarga(config.DB.QueryRow(context.Background(),
+		aturanHargaSelectSQL+`WHERE id_aturan = $1`, id))
+
+	return model.SuccessResponse(c, 200, "Aturan harga berhasil diupdate", a)
+}
+
+// ─── DELETE /aturan-harga/:id ────────────────────────────────────────────────
+
+func DeleteAturanHarga(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM aturan_harga WHERE id_aturan = $1`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus aturan harga")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Aturan harga tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Aturan harga berhasil dihapus", nil)
+}
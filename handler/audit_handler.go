@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// catatAudit menyimpan satu baris audit log. Aktor diambil dari Locals yang
+// diisi AuthRequired (user) atau KioskRequired (kiosk). Kegagalan hanya
+// dicatat ke log supaya tidak membatalkan aksi yang sudah berhasil.
+func catatAudit(c *fiber.Ctx, aksi, objek, idObjek, keterangan string) {
+	jenis, idAktor, nama := "user", 0, ""
+	if id, ok := c.Locals("kiosk_id").(int); ok {
+		jenis, idAktor = "kiosk", id
+		nama, _ = c.Locals("kiosk_nama").(string)
+	} else {
+		idAktor, _ = c.Locals("user_id").(int)
+		nama, _ = c.Locals("username").(string)
+	}
+
+	_, err := config.DB.Exec(context.Background(),
+		`INSERT INTO audit_log (jenis_aktor, id_aktor, nama_aktor, aksi, objek, id_objek, keterangan, ip)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		jenis, idAktor, nama, aksi, objek, idObjek, keterangan, c.IP())
+	if err != nil {
+		log.Printf("⚠️  Gagal mencatat audit %s: %v", aksi, err)
+	}
+}
+
+// ─── GET /audit-log ──────────────────────────────────────────────────────────
+
+func GetAllAuditLog(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	jenisAktor := strings.TrimSpace(c.Query("jenis_aktor", ""))
+	idAktor, _ := strconv.Atoi(c.Query("id_aktor", "0"))
+	aksi := strings.TrimSpace(c.Query("aksi", ""))
+	tanggalMulai := strings.TrimSpace(c.Query("tanggal_mulai", ""))
+	tanggalSelesai := strings.TrimSpace(c.Query("tanggal_selesai", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if jenisAktor != "" {
+		baseWhere += ` AND jenis_aktor = $` + strconv.Itoa(argIdx)
+		args = append(args, jenisAktor)
+		argIdx++
+	}
+	if idAktor > 0 {
+		baseWhere += ` AND id_aktor = $` + strconv.Itoa(argIdx)
+		args = append(args, idAktor)
+		argIdx++
+	}
+	if aksi != "" {
+		baseWhere += ` AND aksi LIKE $` + strconv.Itoa(argIdx) + `||'%'`
+		args = append(args, aksi)
+		argIdx++
+	}
+	if tanggalMulai != "" {
+		baseWhere += ` AND created_at >= $` + strconv.Itoa(argIdx) + `::date`
+		args = append(args, tanggalMulai)
+		argIdx++
+	}
+	if tanggalSelesai != "" {
+		baseWhere += ` AND created_at < $` + strconv.Itoa(argIdx) + `::date + 1`
+		args = append(args, tanggalSelesai)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM audit_log `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := `SELECT id_audit, jenis_aktor, id_aktor, nama_aktor, aksi, objek, id_objek, keterangan, ip,
+		to_char(created_at, 'YYYY-MM-DD HH24:MI:SS')
+		FROM audit_log ` + baseWhere + `
+		ORDER BY id_audit DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil audit log")
+	}
+	defer queryRows.Close()
+
+	var rows []model.AuditLog
+	for queryRows.Next() {
+		var a model.AuditLog
+		queryRows.Scan(&a.IDAudit, &a.JenisAktor, &a.IDAktor, &a.NamaAktor, &a.Aksi, &a.Objek,
+			&a.IDObjek, &a.Keterangan, &a.IP, &a.CreatedAt)
+		rows = append(rows, a)
+	}
+
+	if rows == nil {
+		rows = []model.AuditLog{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
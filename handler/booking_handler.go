@@ -0,0 +1,575 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const bookingSelectSQL = `SELECT b.id_booking, b.kode_booking, b.nik, p.nama_pasien, b.id_poli, po.nama_poli,
+	b.tanggal, to_char(b.jam_mulai, 'HH24:MI'), to_char(b.jam_selesai, 'HH24:MI'), b.status,
+	b.id_antrian, COALESCE(po.prefix_antrian, ''), COALESCE(a.nomor_antrian, 0),
+	to_char(b.checkin_at, 'YYYY-MM-DD HH24:MI:SS')
+	FROM booking b
+	JOIN pasien p ON b.nik = p.nik
+	JOIN poli po ON b.id_poli = po.id_poli
+	LEFT JOIN antrian a ON b.id_antrian = a.id_antrian
+	`
+
+func scanBooking(row pgx.Row) (model.Booking, error) {
+	var b model.Booking
+	var tg interface{}
+	var prefix string
+	var nomor int
+	err := row.Scan(&b.IDBooking, &b.KodeBooking, &b.NIK, &b.NamaPasien, &b.IDPoli, &b.NamaPoli,
+		&tg, &b.JamMulai, &b.JamSelesai, &b.Status,
+		&b.IDAntrian, &prefix, &nomor, &b.CheckinAt)
+	b.Tanggal = formatDate(tg)
+	if nomor > 0 {
+		b.KodeAntrian = model.FormatKodeAntrian(prefix, nomor)
+	}
+	return b, err
+}
+
+// Tanpa huruf/angka yang mudah tertukar (0/O, 1/I)
+const kodeBookingChars = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func generateKodeBooking() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	for i := range b {
+		b[i] = kodeBookingChars[int(b[i])%len(kodeBookingChars)]
+	}
+	return string(b)
+}
+
+// ─── Slot ────────────────────────────────────────────────────────────────────
+
+// slotBooking menyusun slot booking poli pada tanggal tertentu dari jadwal
+// praktik dokter. Kapasitas slot = dokter yang bertugas penuh sepanjang slot x
+// kuota_slot, dibatasi sisa kapasitas harian poli. Slot hari ini yang sudah lewat
+// tidak ditampilkan. Mengembalikan slot, atau kode HTTP dan pesan error.
+func slotBooking(idPoli int, tanggal string) ([]model.SlotBooking, int, string) {
+	tgl, err := time.ParseInLocation("2006-01-02", tanggal, time.Local)
+	if err != nil {
+		return nil, 400, "Format tanggal harus YYYY-MM-DD"
+	}
+
+	now := time.Now()
+	hariIni := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if tgl.Before(hariIni) {
+		return nil, 400, "Tanggal booking sudah lewat"
+	}
+	maksHari := config.GetBookingMaksHari()
+	if tgl.After(hariIni.AddDate(0, 0, maksHari)) {
+		return nil, 400, "Booking hanya bisa dilakukan maksimal " + strconv.Itoa(maksHari) + " hari ke depan"
+	}
+
+	if code, msg := cekPoliAktif(idPoli); code != 0 {
+		return nil, code, msg
+	}
+
+	kuotaHarian, tutup, err := kapasitasHarian(&idPoli, tanggal)
+	if err != nil {
+		return nil, 500, "Gagal mengambil kapasitas poli"
+	}
+	if tutup != "" {
+		return nil, 409, tutup
+	}
+
+	var durasi, kuotaSlot int
+	config.DB.QueryRow(context.Background(),
+		`SELECT durasi_slot, kuota_slot FROM poli WHERE id_poli = $1`, idPoli,
+	).Scan(&durasi, &kuotaSlot)
+
+	// Jadwal dokter aktif yang tidak cuti, dalam menit sejak 00:00
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT (EXTRACT(EPOCH FROM j.jam_mulai) / 60)::int, (EXTRACT(EPOCH FROM j.jam_selesai) / 60)::int
+		 FROM jadwal_dokter j
+		 JOIN dokter d ON j.id_dokter = d.id_dokter
+		 WHERE j.id_poli = $1 AND j.hari = $2 AND d.aktif
+		   AND NOT EXISTS(SELECT 1 FROM cuti_dokter ct WHERE ct.id_dokter = d.id_dokter
+		                  AND $3::date BETWEEN ct.tanggal_mulai AND ct.tanggal_selesai)`,
+		idPoli, model.HariISO(tgl), tanggal)
+	if err != nil {
+		return nil, 500, "Gagal mengambil jadwal dokter"
+	}
+	type rentang struct{ mulai, selesai int }
+	var jadwal []rentang
+	awal, akhir := 24*60, 0
+	for rows.Next() {
+		var j rentang
+		rows.Scan(&j.mulai, &j.selesai)
+		jadwal = append(jadwal, j)
+		if j.mulai < awal {
+			awal = j.mulai
+		}
+		if j.selesai > akhir {
+			akhir = j.selesai
+		}
+	}
+	rows.Close()
+
+	// Booking per jam mulai slot
+	terisi := map[string]int{}
+	rows, err = config.DB.Query(context.Background(),
+		`SELECT to_char(jam_mulai, 'HH24:MI'), COUNT(*) FROM booking
+		 WHERE id_poli = $1 AND tanggal = $2 AND status IN ('aktif', 'checkin')
+		 GROUP BY jam_mulai`, idPoli, tanggal)
+	if err != nil {
+		return nil, 500, "Gagal mengambil data booking"
+	}
+	for rows.Next() {
+		var jam string
+		var n int
+		rows.Scan(&jam, &n)
+		terisi[jam] = n
+	}
+	rows.Close()
+
+	// Sisa kuota harian: antrian yang sudah ada + booking yang belum check-in
+	var pakaiHarian int
+	config.DB.QueryRow(context.Background(),
+		`SELECT (SELECT COUNT(*) FROM antrian WHERE id_poli = $1 AND tanggal_kunjungan = $2)
+		      + (SELECT COUNT(*) FROM booking WHERE id_poli = $1 AND tanggal = $2 AND status = 'aktif')`,
+		idPoli, tanggal,
+	).Scan(&pakaiHarian)
+	sisaHarian := kuotaHarian - pakaiHarian
+	if sisaHarian < 0 {
+		sisaHarian = 0
+	}
+
+	menitSekarang := -1
+	if tgl.Equal(hariIni) {
+		menitSekarang = now.Hour()*60 + now.Minute()
+	}
+
+	slots := []model.SlotBooking{}
+	for mulai := awal; mulai+durasi <= akhir; mulai += durasi {
+		if mulai <= menitSekarang {
+			continue
+		}
+
+		dokter := 0
+		for _, j := range jadwal {
+			if j.mulai <= mulai && j.selesai >= mulai+durasi {
+				dokter++
+			}
+		}
+		if dokter == 0 {
+			continue
+		}
+
+		s := model.SlotBooking{
+			JamMulai:     formatMenit(mulai),
+			JamSelesai:   formatMenit(mulai + durasi),
+			JumlahDokter: dokter,
+			Kapasitas:    dokter * kuotaSlot,
+		}
+		s.Terisi = terisi[s.JamMulai]
+		s.Sisa = s.Kapasitas - s.Terisi
+		if s.Sisa > sisaHarian {
+			s.Sisa = sisaHarian
+		}
+		if s.Sisa < 0 {
+			s.Sisa = 0
+		}
+		slots = append(slots, s)
+	}
+
+	return slots, 0, ""
+}
+
+// formatMenit mengubah menit sejak 00:00 menjadi HH:MM
+func formatMenit(m int) string {
+	return time.Date(2000, 1, 1, 0, m, 0, 0, time.UTC).Format("15:04")
+}
+
+// cariPasienBooking mencocokkan NIK dengan tanggal lahir; pesan sama untuk
+// NIK tidak terdaftar dan tanggal lahir salah agar NIK tidak bisa ditebak
+func cariPasienBooking(nik, tanggalLahir string) bool {
+	var cocok bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM pasien WHERE nik = $1 AND tanggal_lahir = $2::date)`,
+		nik, tanggalLahir,
+	).Scan(&cocok)
+	return cocok
+}
+
+// kedaluwarsakanBooking menandai booking aktif yang slotnya sudah lewat
+// melebihi toleransi
+func kedaluwarsakanBooking(ctx context.Context) (int64, error) {
+	result, err := config.DB.Exec(ctx,
+		`UPDATE booking SET status = 'kedaluwarsa', updated_at = NOW()
+		 WHERE status = 'aktif' AND tanggal + jam_selesai + make_interval(secs => $1) < NOW()`,
+		config.GetBookingToleransi().Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// JalankanWorkerBooking menandai booking kedaluwarsa setiap menit sampai
+// ctx selesai
+func JalankanWorkerBooking(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if n, err := kedaluwarsakanBooking(ctx); err != nil {
+			log.Printf("⚠️  Booking kedaluwarsa: %v", err)
+		} else if n > 0 {
+			log.Printf("⌛ %d booking kedaluwarsa", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ─── GET /public/booking/slot ────────────────────────────────────────────────
+
+func GetSlotBooking(c *fiber.Ctx) error {
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+
+	if idPoli <= 0 {
+		return model.ErrorResponse(c, 400, "Poli harus dipilih")
+	}
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+
+	slots, code, msg := slotBooking(idPoli, tanggal)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", slots)
+}
+
+// ─── POST /public/booking ────────────────────────────────────────────────────
+
+func CreateBooking(c *fiber.Ctx) error {
+	var req model.CreateBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NIK = strings.TrimSpace(req.NIK)
+	req.TanggalLahir = strings.TrimSpace(req.TanggalLahir)
+	req.Tanggal = strings.TrimSpace(req.Tanggal)
+	req.JamMulai = strings.TrimSpace(req.JamMulai)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if !cariPasienBooking(req.NIK, req.TanggalLahir) {
+		return model.ErrorResponse(c, 404, "NIK atau tanggal lahir tidak cocok dengan data pasien")
+	}
+
+	// Pasien yang berulang kali tidak hadir hanya bisa mengambil antrian di loket
+	if maks := config.GetBookingMaksTidakHadir(); maks > 0 {
+		if n := jumlahTidakHadir(req.NIK); n >= maks {
+			return model.ErrorResponse(c, 403, "Booking online tidak tersedia karena pasien tidak hadir "+strconv.Itoa(n)+
+				" kali dalam "+strconv.Itoa(config.GetBookingPeriodeTidakHadir())+" hari terakhir, silakan ambil antrian di loket")
+		}
+	}
+
+	slots, code, msg := slotBooking(req.IDPoli, req.Tanggal)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+	var slot *model.SlotBooking
+	for i := range slots {
+		if slots[i].JamMulai == req.JamMulai {
+			slot = &slots[i]
+			break
+		}
+	}
+	if slot == nil {
+		return model.ErrorResponse(c, 400, "Slot "+req.JamMulai+" tidak tersedia pada tanggal tersebut")
+	}
+	if slot.Sisa <= 0 {
+		return model.ErrorResponse(c, 409, "Slot "+req.JamMulai+" sudah penuh")
+	}
+
+	var dupExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM booking WHERE nik = $1 AND tanggal = $2 AND status IN ('aktif', 'checkin'))
+		     OR EXISTS(SELECT 1 FROM antrian WHERE nik = $1 AND tanggal_kunjungan = $2)`,
+		req.NIK, req.Tanggal,
+	).Scan(&dupExists)
+	if dupExists {
+		return model.ErrorResponse(c, 409, "Pasien sudah memiliki booking atau antrian pada tanggal tersebut")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat booking")
+	}
+	defer tx.Rollback(ctx)
+
+	// Kunci per poli agar dua booking bersamaan tidak melebihi kapasitas slot
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('booking'), $1)`, req.IDPoli); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat booking")
+	}
+
+	var terisi int
+	tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM booking WHERE id_poli = $1 AND tanggal = $2 AND jam_mulai = $3::time
+		 AND status IN ('aktif', 'checkin')`,
+		req.IDPoli, req.Tanggal, req.JamMulai,
+	).Scan(&terisi)
+	if terisi >= slot.Kapasitas {
+		return model.ErrorResponse(c, 409, "Slot "+req.JamMulai+" sudah penuh")
+	}
+
+	var idBooking int
+	for percobaan := 0; ; percobaan++ {
+		err = tx.QueryRow(ctx,
+			`INSERT INTO booking (kode_booking, nik, id_poli, tanggal, jam_mulai, jam_selesai, ip_pemesan)
+			 VALUES ($1, $2, $3, $4, $5::time, $6::time, $7)
+			 ON CONFLICT (kode_booking) DO NOTHING
+			 RETURNING id_booking`,
+			generateKodeBooking(), req.NIK, req.IDPoli, req.Tanggal, req.JamMulai, slot.JamSelesai, c.IP(),
+		).Scan(&idBooking)
+		if err != pgx.ErrNoRows || percobaan >= 5 {
+			break
+		}
+	}
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Pasien sudah memiliki booking pada tanggal tersebut")
+		}
+		return model.ErrorResponse(c, 500, "Gagal membuat booking")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat booking")
+	}
+
+	b, _ := scanBooking(config.DB.QueryRow(ctx, bookingSelectSQL+`WHERE b.id_booking = $1`, idBooking))
+	return model.SuccessResponse(c, 201, "Booking berhasil dibuat, simpan kode booking untuk check-in", b)
+}
+
+// ─── POST /public/booking/:kode/cek ──────────────────────────────────────────
+
+func CekBookingPublik(c *fiber.Ctx) error {
+	var req model.IdentitasBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NIK = strings.TrimSpace(req.NIK)
+	req.TanggalLahir = strings.TrimSpace(req.TanggalLahir)
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	b, err := scanBooking(config.DB.QueryRow(context.Background(),
+		bookingSelectSQL+`WHERE b.kode_booking = $1 AND b.nik = $2 AND p.tanggal_lahir = $3::date`,
+		strings.ToUpper(c.Params("kode")), req.NIK, req.TanggalLahir))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Booking tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", b)
+}
+
+// ─── POST /public/booking/:kode/batal ────────────────────────────────────────
+
+func BatalBookingPublik(c *fiber.Ctx) error {
+	var req model.IdentitasBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NIK = strings.TrimSpace(req.NIK)
+	req.TanggalLahir = strings.TrimSpace(req.TanggalLahir)
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE booking b SET status = 'batal', updated_at = NOW()
+		 FROM pasien p
+		 WHERE b.nik = p.nik AND b.kode_booking = $1 AND b.nik = $2 AND p.tanggal_lahir = $3::date
+		   AND b.status = 'aktif'`,
+		strings.ToUpper(c.Params("kode")), req.NIK, req.TanggalLahir)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan booking")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Booking aktif tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Booking berhasil dibatalkan", nil)
+}
+
+// ─── GET /booking ────────────────────────────────────────────────────────────
+
+func GetAllBooking(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	search := strings.TrimSpace(c.Query("search", ""))
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	status := strings.TrimSpace(c.Query("status", ""))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE b.tanggal = $1`
+	args := []interface{}{tanggal}
+	argIdx := 2
+
+	if idPoli > 0 {
+		baseWhere += ` AND b.id_poli = $` + strconv.Itoa(argIdx)
+		args = append(args, idPoli)
+		argIdx++
+	}
+	if status != "" {
+		baseWhere += ` AND b.status = $` + strconv.Itoa(argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+	if search != "" {
+		baseWhere += ` AND (b.nik LIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR p.nama_pasien ILIKE '%'||$` + strconv.Itoa(argIdx) +
+			`||'%' OR b.kode_booking = UPPER($` + strconv.Itoa(argIdx) + `))`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM booking b JOIN pasien p ON b.nik = p.nik `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := bookingSelectSQL + baseWhere + `
+		ORDER BY b.jam_mulai, b.id_booking
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data booking")
+	}
+	defer queryRows.Close()
+
+	var rows []model.Booking
+	for queryRows.Next() {
+		b, _ := scanBooking(queryRows)
+		rows = append(rows, b)
+	}
+
+	if rows == nil {
+		rows = []model.Booking{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /booking/:kode ──────────────────────────────────────────────────────
+
+func GetBookingByKode(c *fiber.Ctx) error {
+	b, err := scanBooking(config.DB.QueryRow(context.Background(),
+		bookingSelectSQL+`WHERE b.kode_booking = $1`, strings.ToUpper(c.Params("kode"))))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Booking tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", b)
+}
+
+// ─── POST /booking/:kode/checkin ─────────────────────────────────────────────
+
+// CheckinBooking mengubah booking menjadi nomor antrian saat pasien datang
+func CheckinBooking(c *fiber.Ctx) error {
+	ctx := context.Background()
+	kedaluwarsakanBooking(ctx)
+
+	b, err := scanBooking(config.DB.QueryRow(ctx,
+		bookingSelectSQL+`WHERE b.kode_booking = $1`, strings.ToUpper(c.Params("kode"))))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Booking tidak ditemukan")
+	}
+
+	if code, msg := checkinBooking(&b); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	return model.SuccessResponse(c, 200, "Check-in berhasil, nomor antrian "+b.KodeAntrian, b)
+}
+
+// checkinBooking memvalidasi status booking lalu membuat antrian; b diperbarui
+// dengan data setelah check-in. Baris booking dikunci selama transaksi supaya
+// dua check-in bersamaan tidak membuat dua antrian.
+func checkinBooking(b *model.Booking) (int, string) {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return 500, "Gagal check-in booking"
+	}
+	defer tx.Rollback(ctx)
+
+	terkunci, err := scanBooking(tx.QueryRow(ctx,
+		bookingSelectSQL+`WHERE b.id_booking = $1 FOR UPDATE OF b`, b.IDBooking))
+	if err != nil {
+		return 404, "Booking tidak ditemukan"
+	}
+	*b = terkunci
+
+	switch b.Status {
+	case "checkin":
+		return 409, "Booking sudah check-in dengan nomor antrian " + b.KodeAntrian
+	case "kedaluwarsa":
+		return 409, "Booking sudah kedaluwarsa"
+	case "batal":
+		return 409, "Booking sudah dibatalkan"
+	}
+	if b.Tanggal != time.Now().Format("2006-01-02") {
+		return 400, "Check-in hanya bisa dilakukan pada tanggal booking (" + b.Tanggal + ")"
+	}
+
+	idPoli := b.IDPoli
+	idAntrian, code, msg := buatAntrianTx(ctx, tx, b.NIK, &idPoli, b.Tanggal, "", b.IDBooking)
+	if code != 0 {
+		return code, msg
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE booking SET status = 'checkin', id_antrian = $1, checkin_at = NOW(), updated_at = NOW()
+		 WHERE id_booking = $2`, idAntrian, b.IDBooking); err != nil {
+		return 500, "Gagal check-in booking"
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 500, "Gagal check-in booking"
+	}
+
+	updated, _ := scanBooking(config.DB.QueryRow(ctx,
+		bookingSelectSQL+`WHERE b.id_booking = $1`, b.IDBooking))
+	*b = updated
+	return 0, ""
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/bpjs"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const bpjsSinkronSelectSQL = `SELECT id_pemeriksaan, peserta_aktif, ket_peserta, status, no_kunjungan,
+	pesan_error, percobaan, to_char(terakhir_dikirim, 'YYYY-MM-DD HH24:MI:SS')
+	FROM bpjs_sinkron `
+
+func scanBPJSSinkron(row pgx.Row) (model.BPJSSinkron, error) {
+	var s model.BPJSSinkron
+	err := row.Scan(&s.IDPemeriksaan, &s.PesertaAktif, &s.KetPeserta, &s.Status, &s.NoKunjungan,
+		&s.PesanError, &s.Percobaan, &s.TerakhirDikirim)
+	return s, err
+}
+
+// isPenjaminBPJS cek apakah kode penjamin berjenis bpjs (bridging PCare)
+func isPenjaminBPJS(kode string) bool {
+	var jenis string
+	config.DB.QueryRow(context.Background(),
+		`SELECT jenis FROM penjamin WHERE kode_penjamin = $1`, kode).Scan(&jenis)
+	return jenis == "bpjs"
+}
+
+// pesanErrorBPJS membedakan penolakan dari BPJS dengan gangguan koneksi
+func pesanErrorBPJS(err error) string {
+	var be *bpjs.Error
+	if errors.As(err, &be) {
+		return "BPJS: " + be.Message
+	}
+	return err.Error()
+}
+
+// dataKunjunganBPJS menyusun data kunjungan dari pemeriksaan, mengembalikan
+// pesan error bila data belum lengkap untuk dikirim
+func dataKunjunganBPJS(idPem int) (bpjs.Kunjungan, string) {
+	var k bpjs.Kunjungan
+	var tp interface{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COALESCE(pe.nomor_penjamin, ''), pe.tanggal_pemeriksaan, pe.keluhan,
+		 COALESCE(pe.kode_diagnosa, ''), COALESCE(po.kode_poli_bpjs, ''), COALESCE(d.kode_dokter_bpjs, '')
+		 FROM pemeriksaan pe
+		 JOIN poli po ON pe.id_poli = po.id_poli
+		 LEFT JOIN dokter d ON pe.id_dokter = d.id_dokter
+		 WHERE pe.id_pemeriksaan = $1`, idPem,
+	).Scan(&k.NoKartu, &tp, &k.Keluhan, &k.KodeDiag1, &k.KodePoli, &k.KodeDokter)
+	if err != nil {
+		return k, "Pemeriksaan tidak ditemukan"
+	}
+	k.TglDaftar = formatDate(tp)
+
+	switch {
+	case k.NoKartu == "":
+		return k, "Nomor kartu BPJS belum diisi"
+	case k.KodeDiag1 == "":
+		return k, "Kode diagnosa belum diisi"
+	case k.KodePoli == "":
+		return k, "Kode Poli BPJS belum diatur pada poli"
+	}
+	return k, ""
+}
+
+// kirimKunjunganBPJS mengirim kunjungan lalu mencatat hasilnya di bpjs_sinkron
+func kirimKunjunganBPJS(client bpjs.Client, idPem int, k bpjs.Kunjungan) (model.BPJSSinkron, error) {
+	ctx := context.Background()
+	hasil, kirimErr := client.KirimKunjungan(ctx, k)
+
+	var err error
+	if kirimErr != nil {
+		_, err = config.DB.Exec(ctx,
+			`UPDATE bpjs_sinkron SET status = 'gagal', pesan_error = $1, percobaan = percobaan + 1,
+			 terakhir_dikirim = NOW(), updated_at = NOW()
+			 WHERE id_pemeriksaan = $2`, pesanErrorBPJS(kirimErr), idPem)
+	} else {
+		_, err = config.DB.Exec(ctx,
+			`UPDATE bpjs_sinkron SET status = 'terkirim', no_kunjungan = $1, pesan_error = '',
+			 percobaan = percobaan + 1, terakhir_dikirim = NOW(), updated_at = NOW()
+			 WHERE id_pemeriksaan = $2`, hasil.NoKunjungan, idPem)
+	}
+	if err != nil {
+		return model.BPJSSinkron{}, err
+	}
+
+	return scanBPJSSinkron(config.DB.QueryRow(ctx,
+		bpjsSinkronSelectSQL+`WHERE id_pemeriksaan = $1`, idPem))
+}
+
+// ─── GET /bpjs/peserta/:no_kartu ─────────────────────────────────────────────
+
+func CekPesertaBPJS(c *fiber.Ctx) error {
+	client := config.GetBPJSClient()
+	if client == nil {
+		return model.ErrorResponse(c, 503, "Bridging BPJS tidak aktif")
+	}
+
+	noKartu := strings.TrimSpace(c.Params("no_kartu"))
+	if len(noKartu) != 13 || strings.Trim(noKartu, "0123456789") != "" {
+		return model.ErrorResponse(c, 400, "Nomor kartu BPJS harus 13 angka")
+	}
+
+	peserta, err := client.CekPeserta(context.Background(), noKartu)
+	if err != nil {
+		var be *bpjs.Error
+		if errors.As(err, &be) {
+			return model.ErrorResponse(c, 404, pesanErrorBPJS(err))
+		}
+		return model.ErrorResponse(c, 502, "Gagal cek kepesertaan BPJS: "+err.Error())
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", peserta)
+}
+
+// ─── GET /bpjs/sinkron ───────────────────────────────────────────────────────
+
+func GetAllSinkronBPJS(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	status := strings.TrimSpace(c.Query("status", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if status != "" {
+		baseWhere += ` AND status = $` + strconv.Itoa(argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM bpjs_sinkron `+baseWhere, args...).Scan(&totalData)
+
+	fetchSQL := bpjsSinkronSelectSQL + baseWhere + `
+		ORDER BY updated_at DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data sinkronisasi BPJS")
+	}
+	defer queryRows.Close()
+
+	var rows []model.BPJSSinkron
+	for queryRows.Next() {
+		s, _ := scanBPJSSinkron(queryRows)
+		rows = append(rows, s)
+	}
+
+	if rows == nil {
+		rows = []model.BPJSSinkron{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /pemeriksaan/:id/bpjs ───────────────────────────────────────────────
+
+func GetSinkronBPJS(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	s, err := scanBPJSSinkron(config.DB.QueryRow(context.Background(),
+		bpjsSinkronSelectSQL+`WHERE id_pemeriksaan = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Pemeriksaan belum tercatat di bridging BPJS")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", s)
+}
+
+// ─── POST /pemeriksaan/:id/bpjs/kirim ────────────────────────────────────────
+
+// KirimKunjunganBPJS mengirim (ulang) kunjungan yang belum terkirim atau gagal
+func KirimKunjunganBPJS(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	client := config.GetBPJSClient()
+	if client == nil {
+		return model.ErrorResponse(c, 503, "Bridging BPJS tidak aktif")
+	}
+
+	var metode string
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT metode_pembayaran FROM pemeriksaan WHERE id_pemeriksaan = $1`, id).Scan(&metode)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Pemeriksaan tidak ditemukan")
+	}
+	if !isPenjaminBPJS(metode) {
+		return model.ErrorResponse(c, 400, "Pemeriksaan bukan pasien BPJS")
+	}
+
+	var status string
+	config.DB.QueryRow(context.Background(),
+		`SELECT status FROM bpjs_sinkron WHERE id_pemeriksaan = $1`, id).Scan(&status)
+	if status == "terkirim" {
+		return model.ErrorResponse(c, 409, "Kunjungan sudah terkirim ke BPJS")
+	}
+
+	k, msg := dataKunjunganBPJS(id)
+	if msg != "" {
+		return model.ErrorResponse(c, 400, msg)
+	}
+
+	// Pemeriksaan lama yang dibuat sebelum bridging aktif belum punya baris sinkron
+	config.DB.Exec(context.Background(),
+		`INSERT INTO bpjs_sinkron (id_pemeriksaan) VALUES ($1) ON CONFLICT (id_pemeriksaan) DO NOTHING`, id)
+
+	s, err := kirimKunjunganBPJS(client, id, k)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan status sinkronisasi BPJS")
+	}
+	if s.Status == "gagal" {
+		return model.ErrorResponse(c, 502, "Gagal mengirim kunjungan ke BPJS: "+s.PesanError)
+	}
+
+	return model.SuccessResponse(c, 200, "Kunjungan berhasil dikirim ke BPJS", s)
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/struk"
+)
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// loadTiketAntrian mengambil isi tiket antrian beserta estimasi tunggu terkini
+func loadTiketAntrian(id int) (model.TiketAntrian, error) {
+	var t model.TiketAntrian
+	var namaPasien, prefix string
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT a.id_antrian, a.nomor_antrian, COALESCE(a.id_poli, 0), COALESCE(po.nama_poli, ''),
+			COALESCE(po.prefix_antrian, ''), p.nama_pasien, to_char(a.tanggal_kunjungan, 'YYYY-MM-DD'),
+			COALESCE(b.kode_booking, ''), a.prioritas, to_char(a.created_at, 'YYYY-MM-DD HH24:MI:SS')
+		 FROM antrian a
+		 JOIN pasien p ON a.nik = p.nik
+		 LEFT JOIN poli po ON a.id_poli = po.id_poli
+		 LEFT JOIN booking b ON b.id_antrian = a.id_antrian
+		 WHERE a.id_antrian = $1`, id,
+	).Scan(&t.IDAntrian, &t.NomorAntrian, &t.IDPoli, &t.NamaPoli, &prefix,
+		&namaPasien, &t.TanggalKunjungan, &t.KodeBooking, &t.Prioritas, &t.DicetakAt)
+	if err != nil {
+		return t, err
+	}
+
+	t.KodeAntrian = model.FormatKodeAntrian(prefix, t.NomorAntrian)
+	t.NamaPasien = model.SamarkanNama(namaPasien)
+	t.JumlahMenunggu, t.EstimasiTunggu = estimasiTunggu(t.IDPoli, t.TanggalKunjungan, t.NomorAntrian, t.Prioritas)
+	return t, nil
+}
+
+// qrTiketAntrian adalah isi QR tiket: kode booking jika ada, selain itu id antrian
+func qrTiketAntrian(t model.TiketAntrian) string {
+	if t.KodeBooking != "" {
+		return t.KodeBooking
+	}
+	return "ANTRIAN-" + strconv.Itoa(t.IDAntrian)
+}
+
+// layoutTiketAntrian menyusun tiket antrian; tidak membaca DB / env / jam
+// sehingga hasil render bisa dibandingkan dengan golden file
+func layoutTiketAntrian(namaPuskesmas, alamat string, t model.TiketAntrian, k struk.Kertas) *struk.Struk {
+	s := struk.New(k)
+	s.Teks(struk.Tengah, 1, true, strings.ToUpper(namaPuskesmas))
+	if alamat != "" {
+		s.Teks(struk.Tengah, 1, false, alamat)
+	}
+	s.Garis()
+	s.Teks(struk.Tengah, 1, false, "NOMOR ANTRIAN")
+	s.Teks(struk.Tengah, 4, true, t.KodeAntrian)
+	if t.NamaPoli != "" {
+		s.Teks(struk.Tengah, 2, true, t.NamaPoli)
+	}
+	if t.Prioritas != "" && t.Prioritas != model.PrioritasReguler {
+		s.Teks(struk.Tengah, 1, true, "PRIORITAS: "+strings.ToUpper(model.LabelPrioritas(t.Prioritas)))
+	}
+	s.Kosong()
+	s.Kolom("Pasien", t.NamaPasien, false)
+	s.Kolom("Tanggal", formatTanggalJam(t.DicetakAt), false)
+	if t.KodeBooking != "" {
+		s.Kolom("Kode Booking", t.KodeBooking, false)
+	}
+	if t.JumlahMenunggu > 0 {
+		s.Kolom("Menunggu", strconv.Itoa(t.JumlahMenunggu)+" orang", false)
+		s.Kolom("Estimasi", "+/- "+strconv.Itoa(t.EstimasiTunggu)+" menit", false)
+	}
+	s.Garis()
+	s.QR(qrTiketAntrian(t))
+	s.Teks(struk.Tengah, 1, false, "Tunjukkan tiket ini saat dipanggil")
+	s.Teks(struk.Tengah, 1, false, "Tiket hanya berlaku pada tanggal tercetak")
+	return s
+}
+
+// layoutKwitansi menyusun kwitansi pembayaran untuk printer thermal
+func layoutKwitansi(namaPuskesmas, alamat string, t model.TagihanResponse, k struk.Kertas) *struk.Struk {
+	s := struk.New(k)
+	s.Teks(struk.Tengah, 1, true, strings.ToUpper(namaPuskesmas))
+	if alamat != "" {
+		s.Teks(struk.Tengah, 1, false, alamat)
+	}
+	s.Teks(struk.Tengah, 1, true, "KWITANSI PEMBAYARAN")
+	s.Garis()
+
+	s.Kolom("No", t.NomorTagihan, false)
+	s.Kolom("Tanggal", t.Pembayaran.DibayarAt.Format("02-01-2006 15:04"), false)
+	s.Kolom("Pasien", t.NamaPasien, false)
+	s.Kolom("Poli", t.NamaPoli, false)
+	s.Kolom("Penjamin", t.NamaPenjamin, false)
+	s.Garis()
+
+	for _, it := range t.Items {
+		s.Teks(struk.Kiri, 1, false, it.NamaItem)
+		s.Kolom(fmt.Sprintf("  %d x %s", it.Jumlah, model.FormatRupiah(it.HargaSatuan)), model.FormatRupiah(it.Subtotal), false)
+	}
+	s.Garis()
+
+	s.Kolom("Total", model.FormatRupiah(t.Total), false)
+	if t.Ditanggung > 0 {
+		s.Kolom("Ditanggung", "-"+model.FormatRupiah(t.Ditanggung), false)
+	}
+	if t.Diskon > 0 {
+		s.Kolom("Diskon", "-"+model.FormatRupiah(t.Diskon), false)
+	}
+	s.Kolom("Harus Dibayar", model.FormatRupiah(t.TotalBayar), true)
+	s.Kolom("Dibayar ("+strings.ToUpper(t.Pembayaran.Metode)+")", model.FormatRupiah(t.Pembayaran.JumlahDibayar), false)
+	s.Kolom("Kembalian", model.FormatRupiah(t.Pembayaran.Kembalian), false)
+	if t.Pembayaran.ReferensiQRIS != "" {
+		s.Kolom("Ref QRIS", t.Pembayaran.ReferensiQRIS, false)
+	}
+	s.Garis()
+
+	s.Teks(struk.Tengah, 1, false, "Kasir: "+t.Pembayaran.NamaKasir)
+	s.Teks(struk.Tengah, 1, false, "Terima kasih, semoga lekas sembuh")
+	return s
+}
+
+// formatTanggalJam mengubah YYYY-MM-DD HH:MM:SS menjadi DD-MM-YYYY HH:MM
+func formatTanggalJam(s string) string {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		return s
+	}
+	return t.Format("02-01-2006 15:04")
+}
+
+// parseFormatCetak membaca query format (escpos / pdf / png) dan kertas (58 / 80)
+func parseFormatCetak(c *fiber.Ctx) (string, struk.Kertas, string) {
+	format := strings.ToLower(strings.TrimSpace(c.Query("format", "pdf")))
+	if format != "escpos" && format != "pdf" && format != "png" {
+		return "", 0, "Format harus escpos, pdf, atau png"
+	}
+
+	kertas := struk.Kertas(config.GetPrinterKertas())
+	switch c.Query("kertas", "") {
+	case "":
+	case "58":
+		kertas = struk.Kertas58
+	case "80":
+		kertas = struk.Kertas80
+	default:
+		return "", 0, "Kertas harus 58 atau 80"
+	}
+
+	return format, kertas, ""
+}
+
+// kirimStruk merender struk sesuai format dan mengirimkannya
+func kirimStruk(c *fiber.Ctx, s *struk.Struk, format, nama string) error {
+	switch format {
+	case "escpos":
+		c.Set("Content-Type", "application/octet-stream")
+		c.Set("Content-Disposition", `attachment; filename="`+nama+`.bin"`)
+		return c.Send(s.ESCPOS())
+	case "png":
+		c.Set("Content-Type", "image/png")
+		c.Set("Content-Disposition", `inline; filename="`+nama+`.png"`)
+		return c.Send(s.PNG())
+	default:
+		c.Set("Content-Type", "application/pdf")
+		c.Set("Content-Disposition", `inline; filename="`+nama+`.pdf"`)
+		return c.Send(s.PDF())
+	}
+}
+
+// ─── GET /antrian/:id/tiket ──────────────────────────────────────────────────
+
+func GetTiketAntrian(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	format, kertas, msg := parseFormatCetak(c)
+	if msg != "" {
+		return model.ErrorResponse(c, 400, msg)
+	}
+
+	t, err := loadTiketAntrian(id)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Antrian tidak ditemukan")
+	}
+
+	// Antrian lama tanpa poli (id_poli 0) hanya untuk role yang tidak dibatasi
+	cakupan, err := cakupanPoliUser(c, "antrian", strconv.Itoa(id))
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.boleh(t.IDPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
+	s := layoutTiketAntrian(config.GetPuskesmasNama(), config.GetPuskesmasAlamat(), t, kertas)
+	return kirimStruk(c, s, format, "tiket-"+strconv.Itoa(t.IDAntrian))
+}
+
+// ─── GET /kiosk/antrian/:id/tiket ────────────────────────────────────────────
+
+// GetTiketAntrianKiosk mencetak ulang tiket dari kiosk, terbatas antrian hari ini
+func GetTiketAntrianKiosk(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	format, kertas, msg := parseFormatCetak(c)
+	if msg != "" {
+		return model.ErrorResponse(c, 400, msg)
+	}
+
+	t, err := loadTiketAntrian(id)
+	if err != nil || t.TanggalKunjungan != time.Now().Format("2006-01-02") {
+		return model.ErrorResponse(c, 404, "Antrian tidak ditemukan")
+	}
+
+	catatAudit(c, "antrian.cetak_tiket", "antrian", strconv.Itoa(id), t.KodeAntrian+" ("+format+")")
+
+	s := layoutTiketAntrian(config.GetPuskesmasNama(), config.GetPuskesmasAlamat(), t, kertas)
+	return kirimStruk(c, s, format, "tiket-"+strconv.Itoa(t.IDAntrian))
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sikupas/backend/model"
+	"sikupas/backend/struk"
+)
+
+// Jalankan `go test ./handler -update` untuk menulis ulang file golden setelah
+// perubahan layout yang disengaja, lalu periksa hasilnya sebelum commit.
+var update = flag.Bool("update", false, "tulis ulang file golden di testdata")
+
+func cocokGolden(t *testing.T, nama string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", nama+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden %s tidak ada, jalankan dengan -update: %v", path, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s berbeda dari golden (%d byte, golden %d byte); jalankan dengan -update jika perubahan disengaja",
+			nama, len(got), len(want))
+	}
+}
+
+var contohTiket = model.TiketAntrian{
+	IDAntrian:        42,
+	KodeAntrian:      "U-007",
+	NomorAntrian:     7,
+	IDPoli:           1,
+	NamaPoli:         "Poli Umum",
+	NamaPasien:       "Bud* San****",
+	TanggalKunjungan: "2026-10-19",
+	Prioritas:        model.PrioritasLansia,
+	KodeBooking:      "BK7Q2M9X",
+	JumlahMenunggu:   6,
+	EstimasiTunggu:   45,
+	DicetakAt:        "2026-10-19 08:15:00",
+}
+
+// contohKwitansi memuat nama item yang lebih panjang dari lebar kertas dan
+// karakter non-ASCII
+var contohKwitansi = model.TagihanResponse{
+	NomorTagihan: "TG-20261019-0042",
+	NamaPasien:   "Siti Aminah",
+	NamaPoli:     "Poli Gigi",
+	NamaPenjamin: "Umum",
+	Total:        65000,
+	Diskon:       5000,
+	TotalBayar:   60000,
+	Items: []model.TagihanItem{
+		{NamaItem: "Pemeriksaan gigi dan konsultasi dokter gigi spesialis", HargaSatuan: 50000, Jumlah: 1, Subtotal: 50000},
+		{NamaItem: "Obat – Amoxicillin 500mg", HargaSatuan: 1500, Jumlah: 10, Subtotal: 15000},
+	},
+	Pembayaran: &model.Pembayaran{
+		Metode:        "qris",
+		JumlahDibayar: 60000,
+		ReferensiQRIS: "QRIS-REF-0000000000123456789",
+		NamaKasir:     "kasir01",
+		DibayarAt:     time.Date(2026, 10, 19, 10, 2, 0, 0, time.UTC),
+	},
+}
+
+// Layout tiket dan kwitansi tidak membaca DB / env / jam, jadi hasil render
+// untuk data yang sama harus selalu sama dengan golden file
+func TestLayoutCetakGolden(t *testing.T) {
+	const nama, alamat = "Puskesmas Sukasari", "Jl. Raya Sukasari No. 12, Kecamatan Sukasari, Kota Bandung"
+	layout := map[string]func(struk.Kertas) *struk.Struk{
+		"tiket": func(k struk.Kertas) *struk.Struk {
+			return layoutTiketAntrian(nama, alamat, contohTiket, k)
+		},
+		"kwitansi": func(k struk.Kertas) *struk.Struk {
+			return layoutKwitansi(nama, alamat, contohKwitansi, k)
+		},
+	}
+	format := map[string]func(*struk.Struk) []byte{
+		"escpos": (*struk.Struk).ESCPOS,
+		"pdf":    (*struk.Struk).PDF,
+		"png":    (*struk.Struk).PNG,
+	}
+
+	for namaLayout, buat := range layout {
+		for _, k := range []struct {
+			kertas struk.Kertas
+			nama   string
+		}{{struk.Kertas58, "58"}, {struk.Kertas80, "80"}} {
+			for namaFormat, render := range format {
+				namaFile := namaLayout + "-" + k.nama + "." + namaFormat
+				t.Run(namaFile, func(t *testing.T) {
+					got := render(buat(k.kertas))
+					// Render kedua harus identik byte per byte
+					if !bytes.Equal(got, render(buat(k.kertas))) {
+						t.Fatal("render tidak deterministik")
+					}
+					cocokGolden(t, namaFile, got)
+				})
+			}
+		}
+	}
+}
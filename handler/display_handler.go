@@ -0,0 +1,403 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/suara"
+)
+
+// Layar memeriksa perubahan antrian setiap intervalDisplay; jika tidak ada
+// perubahan, komentar ping dikirim supaya koneksi tidak diputus proxy
+const (
+	intervalDisplay = 3 * time.Second
+	intervalPing    = 15 * time.Second
+)
+
+// Panggilan dicari mundur sejauh jedaPanggilan dari pemeriksaan sebelumnya
+// supaya transaksi yang commit terlambat tidak terlewat; duplikatnya disaring
+const jedaPanggilan = 10 * time.Second
+
+// pengumumanAntrian menyusun pengumuman untuk antrian yang dipanggil; tujuan
+// adalah loket pemanggil, atau poli jika dipanggil tanpa loket
+func pengumumanAntrian(a model.AntrianResponse) *model.Pengumuman {
+	prefix := ""
+	if i := strings.LastIndex(a.KodeAntrian, "-"); i >= 0 {
+		prefix = a.KodeAntrian[:i]
+	}
+	p := suara.Panggilan(prefix, a.NomorAntrian, tujuanPanggilan(a.NamaLoket, a.NamaPoli))
+	return &p
+}
+
+func tujuanPanggilan(loket *string, poli string) string {
+	if loket != nil {
+		return *loket
+	}
+	return poli
+}
+
+// parsePoliDisplay membaca id_poli=1,2,3; kosong berarti semua poli aktif
+func parsePoliDisplay(c *fiber.Ctx) ([]int, string) {
+	var ids []int
+	for _, s := range strings.Split(c.Query("id_poli", ""), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		id, err := strconv.Atoi(s)
+		if err != nil || id <= 0 {
+			return nil, "id_poli tidak valid"
+		}
+		ids = append(ids, id)
+	}
+	return ids, ""
+}
+
+// dataDisplay menyusun isi layar untuk poli yang diminta pada hari ini
+func dataDisplay(ids []int) ([]model.DisplayPoli, error) {
+	tanggal := time.Now().Format("2006-01-02")
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id_poli, nama_poli, COALESCE(prefix_antrian, '') FROM poli
+		 WHERE aktif = TRUE AND (cardinality($1::int[]) = 0 OR id_poli = ANY($1))
+		 ORDER BY urutan, id_poli`, ids)
+	if err != nil {
+		return nil, err
+	}
+	type poliDisplay struct {
+		model.DisplayPoli
+		prefix string
+	}
+	var polis []poliDisplay
+	for rows.Next() {
+		var p poliDisplay
+		rows.Scan(&p.IDPoli, &p.NamaPoli, &p.prefix)
+		polis = append(polis, p)
+	}
+	rows.Close()
+
+	// Menunggu ditampilkan dalam urutan panggil, prioritas lebih dulu
+	urutan := rankPrioritasSQL("a.prioritas", peringkatPrioritas(false))
+
+	hasil := []model.DisplayPoli{}
+	for _, p := range polis {
+		d := p.DisplayPoli
+		d.Dipanggil = []model.DisplayAntrian{}
+		d.Menunggu = []model.DisplayAntrian{}
+		d.MenitPerPasien = float64(int(lajuLayananPoli(p.IDPoli, tanggal)*10+0.5)) / 10
+
+		antrianRows, err := config.DB.Query(context.Background(),
+			`SELECT a.nomor_antrian, a.prioritas, to_char(COALESCE(a.terakhir_dipanggil_at, a.dipanggil_at), 'HH24:MI:SS'),
+				l.nama_loket
+			 FROM antrian a
+			 LEFT JOIN loket l ON a.id_loket = l.id_loket
+			 WHERE a.id_poli = $1 AND a.tanggal_kunjungan = $2 AND a.status = 'belum_dikelola'
+			 ORDER BY COALESCE(a.terakhir_dipanggil_at, a.dipanggil_at) DESC NULLS LAST, `+urutan+`, a.nomor_antrian`, p.IDPoli, tanggal)
+		if err != nil {
+			return nil, err
+		}
+		for antrianRows.Next() {
+			var a model.DisplayAntrian
+			antrianRows.Scan(&a.NomorAntrian, &a.Prioritas, &a.DipanggilAt, &a.Loket)
+			a.KodeAntrian = model.FormatKodeAntrian(p.prefix, a.NomorAntrian)
+
+			if a.DipanggilAt != nil {
+				if len(d.Dipanggil) < 3 {
+					d.Dipanggil = append(d.Dipanggil, a)
+				}
+				continue
+			}
+			if len(d.Menunggu) < 10 {
+				a.EstimasiTunggu = menitTunggu(d.JumlahMenunggu, d.MenitPerPasien)
+				d.Menunggu = append(d.Menunggu, a)
+			}
+			d.JumlahMenunggu++
+		}
+		antrianRows.Close()
+
+		hasil = append(hasil, d)
+	}
+
+	return hasil, nil
+}
+
+// panggilanDisplay adalah satu panggilan beserta kunci untuk menyaring
+// pengumuman ganda
+type panggilanDisplay struct {
+	model.DisplayPanggilan
+	idAntrian int
+	waktu     time.Time
+}
+
+// panggilanBaru mengambil antrian yang dipanggil (atau dipanggil ulang)
+// setelah waktu sejak, beserta pengumumannya, urut dari yang paling lama
+func panggilanBaru(ids []int, sejak time.Time) ([]panggilanDisplay, error) {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT a.id_antrian, a.nomor_antrian, po.id_poli, po.nama_poli, COALESCE(po.prefix_antrian, ''),
+			l.nama_loket, a.terakhir_dipanggil_at, to_char(a.terakhir_dipanggil_at, 'HH24:MI:SS')
+		 FROM antrian a
+		 JOIN poli po ON a.id_poli = po.id_poli
+		 LEFT JOIN loket l ON a.id_loket = l.id_loket
+		 WHERE a.tanggal_kunjungan = CURRENT_DATE AND a.terakhir_dipanggil_at > $1
+		   AND po.aktif = TRUE AND (cardinality($2::int[]) = 0 OR po.id_poli = ANY($2))
+		 ORDER BY a.terakhir_dipanggil_at`, sejak, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hasil []panggilanDisplay
+	for rows.Next() {
+		var p panggilanDisplay
+		var nomor int
+		var prefix string
+		rows.Scan(&p.idAntrian, &nomor, &p.IDPoli, &p.NamaPoli, &prefix, &p.Loket, &p.waktu, &p.DipanggilAt)
+		p.KodeAntrian = model.FormatKodeAntrian(prefix, nomor)
+		p.Pengumuman = suara.Panggilan(prefix, nomor, tujuanPanggilan(p.Loket, p.NamaPoli))
+		hasil = append(hasil, p)
+	}
+	return hasil, nil
+}
+
+// ─── GET /public/display ─────────────────────────────────────────────────────
+
+// GetDisplayAntrian mengembalikan isi layar sekali, untuk layar yang polling
+func GetDisplayAntrian(c *fiber.Ctx) error {
+	ids, msg := parsePoliDisplay(c)
+	if msg != "" {
+		return model.ErrorResponse(c, 400, msg)
+	}
+
+	data, err := dataDisplay(ids)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data antrian")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", data)
+}
+
+// ─── GET /public/display/stream ──────────────────────────────────────────────
+
+// StreamDisplayAntrian mengirim isi layar lewat Server-Sent Events setiap kali
+// antrian bergerak (dipanggil, selesai, atau ada nomor baru). Setiap panggilan
+// juga dikirim sebagai event "panggil" berisi pengumuman untuk diputar.
+// Layar dengan filter poli yang sama berbagi satu siaranDisplay, jadi DB
+// hanya diperiksa sekali per interval berapa pun jumlah layarnya.
+func StreamDisplayAntrian(c *fiber.Ctx) error {
+	ids, msg := parsePoliDisplay(c)
+	if msg != "" {
+		return model.ErrorResponse(c, 400, msg)
+	}
+
+	ch, berhenti, ok := langgananDisplay(ids)
+	if !ok {
+		return model.ErrorResponse(c, 503, "Terlalu banyak layar display tersambung")
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer berhenti()
+		for {
+			select {
+			case frame, ok := <-ch:
+				// Channel ditutup berarti layar ini tertinggal terlalu jauh
+				if !ok {
+					return
+				}
+				w.Write(frame)
+			case <-time.After(intervalPing):
+				fmt.Fprint(w, ": ping\n\n")
+			}
+
+			// Flush gagal berarti layar sudah memutus koneksi
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// ─── Siaran Display ──────────────────────────────────────────────────────────
+
+// Frame yang belum terkirim ke satu layar; bila penuh layar itu diputus dan
+// akan tersambung ulang sendiri (EventSource)
+const bufferDisplay = 32
+
+// siaranDisplay memeriksa antrian untuk satu filter poli lalu membagikan
+// frame SSE ke semua layar yang berlangganan
+type siaranDisplay struct {
+	ids       []int
+	pelanggan map[chan []byte]struct{}
+	terakhir  []byte // frame "antrian" terakhir, dikirim ke layar yang baru tersambung
+}
+
+var hubDisplay = struct {
+	sync.Mutex
+	siaran map[string]*siaranDisplay
+	jumlah int
+}{siaran: map[string]*siaranDisplay{}}
+
+// langgananDisplay mendaftarkan satu layar; false bila batas
+// GetDisplayMaksStream sudah tercapai. berhenti wajib dipanggil setelah
+// layar selesai.
+func langgananDisplay(ids []int) (ch chan []byte, berhenti func(), ok bool) {
+	ids = append([]int{}, ids...)
+	sort.Ints(ids)
+	var bagian []string
+	for i, id := range ids {
+		if i == 0 || id != ids[i-1] {
+			bagian = append(bagian, strconv.Itoa(id))
+		}
+	}
+	kunci := strings.Join(bagian, ",")
+
+	hubDisplay.Lock()
+	defer hubDisplay.Unlock()
+
+	if hubDisplay.jumlah >= config.GetDisplayMaksStream() {
+		return nil, nil, false
+	}
+	s := hubDisplay.siaran[kunci]
+	if s == nil {
+		s = &siaranDisplay{ids: ids, pelanggan: map[chan []byte]struct{}{}}
+		hubDisplay.siaran[kunci] = s
+		go s.jalankan(kunci)
+	}
+
+	ch = make(chan []byte, bufferDisplay)
+	if s.terakhir != nil {
+		ch <- s.terakhir
+	}
+	s.pelanggan[ch] = struct{}{}
+	hubDisplay.jumlah++
+
+	return ch, func() {
+		hubDisplay.Lock()
+		defer hubDisplay.Unlock()
+		s.lepas(ch)
+	}, true
+}
+
+// lepas dipanggil dengan hubDisplay terkunci
+func (s *siaranDisplay) lepas(ch chan []byte) {
+	if _, ada := s.pelanggan[ch]; ada {
+		delete(s.pelanggan, ch)
+		close(ch)
+		hubDisplay.jumlah--
+	}
+}
+
+// siarkan mengirim frame ke semua layar tanpa menunggu layar yang lambat
+func (s *siaranDisplay) siarkan(frame []byte, antrian bool) {
+	hubDisplay.Lock()
+	defer hubDisplay.Unlock()
+	if antrian {
+		s.terakhir = frame
+	}
+	for ch := range s.pelanggan {
+		select {
+		case ch <- frame:
+		default:
+			s.lepas(ch)
+		}
+	}
+}
+
+// jalankan memeriksa antrian setiap intervalDisplay sampai tidak ada lagi
+// layar yang berlangganan
+func (s *siaranDisplay) jalankan(kunci string) {
+	// Panggilan sebelum siaran dimulai tidak diumumkan ulang
+	var sejak time.Time
+	config.DB.QueryRow(context.Background(), `SELECT LOCALTIMESTAMP`).Scan(&sejak)
+	sudahDiumumkan := map[string]time.Time{}
+
+	for {
+		hubDisplay.Lock()
+		if len(s.pelanggan) == 0 {
+			delete(hubDisplay.siaran, kunci)
+			hubDisplay.Unlock()
+			return
+		}
+		hubDisplay.Unlock()
+
+		if panggilan, err := panggilanBaru(s.ids, sejak.Add(-jedaPanggilan)); err == nil {
+			for _, p := range panggilan {
+				k := fmt.Sprintf("%d|%d", p.idAntrian, p.waktu.UnixMicro())
+				if _, ada := sudahDiumumkan[k]; ada {
+					continue
+				}
+				isi, _ := json.Marshal(p.DisplayPanggilan)
+				s.siarkan([]byte(fmt.Sprintf("event: panggil\ndata: %s\n\n", isi)), false)
+				sudahDiumumkan[k] = p.waktu
+				if p.waktu.After(sejak) {
+					sejak = p.waktu
+				}
+			}
+			// Yang sudah di luar jendela pencarian tidak perlu diingat lagi
+			for k, t := range sudahDiumumkan {
+				if t.Before(sejak.Add(-jedaPanggilan)) {
+					delete(sudahDiumumkan, k)
+				}
+			}
+		}
+
+		if data, err := dataDisplay(s.ids); err == nil {
+			isi, _ := json.Marshal(data)
+			frame := []byte(fmt.Sprintf("event: antrian\ndata: %s\n\n", isi))
+			if !bytes.Equal(frame, s.terakhirDibaca()) {
+				s.siarkan(frame, true)
+			}
+		}
+
+		time.Sleep(intervalDisplay)
+	}
+}
+
+func (s *siaranDisplay) terakhirDibaca() []byte {
+	hubDisplay.Lock()
+	defer hubDisplay.Unlock()
+	return s.terakhir
+}
+
+// ─── GET /public/display/klip ────────────────────────────────────────────────
+
+// GetKlipPengumuman mendaftar ID klip audio yang harus direkam untuk semua
+// poli dan loket aktif; nama file di PC display mengikuti ID ini (misal
+// "belas.mp3")
+func GetKlipPengumuman(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT COALESCE(prefix_antrian, ''), nama_poli FROM poli WHERE aktif = TRUE
+		 UNION ALL
+		 SELECT '', nama_loket FROM loket WHERE aktif = TRUE`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data poli")
+	}
+	defer rows.Close()
+
+	var prefix, tujuan []string
+	for rows.Next() {
+		var p, nama string
+		rows.Scan(&p, &nama)
+		prefix = append(prefix, p)
+		tujuan = append(tujuan, nama)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", suara.KlipDibutuhkan(prefix, tujuan))
+}
@@ -0,0 +1,505 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// ─── GET /dokter ─────────────────────────────────────────────────────────────
+
+func GetAllDokter(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	search := strings.TrimSpace(c.Query("search", ""))
+	aktif := strings.TrimSpace(c.Query("aktif", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if aktif == "true" || aktif == "false" {
+		baseWhere += ` AND aktif = $` + strconv.Itoa(argIdx)
+		args = append(args, aktif == "true")
+		argIdx++
+	}
+	if search != "" {
+		baseWhere += ` AND (nama_dokter ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR nomor_sip ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM dokter `+baseWhere, args...).Scan(&totalData)
+
+	fetchSQL := `SELECT id_dokter, id_user, nama_dokter, nomor_sip, spesialisasi, COALESCE(kode_dokter_bpjs, ''), aktif
+		FROM dokter ` + baseWhere + `
+		ORDER BY nama_dokter ASC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data dokter")
+	}
+	defer queryRows.Close()
+
+	var rows []model.DokterResponse
+	for queryRows.Next() {
+		var d model.DokterResponse
+		queryRows.Scan(&d.IDDokter, &d.IDUser, &d.NamaDokter, &d.NomorSIP, &d.Spesialisasi, &d.KodeDokterBPJS, &d.Aktif)
+		rows = append(rows, d)
+	}
+
+	if rows == nil {
+		rows = []model.DokterResponse{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /dokter/:id ─────────────────────────────────────────────────────────
+
+func GetDokterByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var d model.DokterResponse
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT id_dokter, id_user, nama_dokter, nomor_sip, spesialisasi, COALESCE(kode_dokter_bpjs, ''), aktif
+		 FROM dokter WHERE id_dokter = $1`, id,
+	).Scan(&d.IDDokter, &d.IDUser, &d.NamaDokter, &d.NomorSIP, &d.Spesialisasi, &d.KodeDokterBPJS, &d.Aktif)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Dokter tidak ditemukan")
+	}
+
+	// Jadwal mingguan
+	jadwalRows, err := config.DB.Query(context.Background(),
+		`SELECT j.id_jadwal, j.id_dokter, j.id_poli, po.nama_poli, j.hari,
+			to_char(j.jam_mulai, 'HH24:MI'), to_char(j.jam_selesai, 'HH24:MI')
+		 FROM jadwal_dokter j JOIN poli po ON j.id_poli = po.id_poli
+		 WHERE j.id_dokter = $1 ORDER BY j.hari, j.jam_mulai`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil jadwal dokter")
+	}
+	defer jadwalRows.Close()
+
+	d.Jadwal = []model.JadwalDokter{}
+	for jadwalRows.Next() {
+		var j model.JadwalDokter
+		jadwalRows.Scan(&j.IDJadwal, &j.IDDokter, &j.IDPoli, &j.NamaPoli, &j.Hari, &j.JamMulai, &j.JamSelesai)
+		j.NamaHari = model.NamaHari(j.Hari)
+		d.Jadwal = append(d.Jadwal, j)
+	}
+
+	// Cuti yang belum lewat
+	cutiRows, err := config.DB.Query(context.Background(),
+		`SELECT id_cuti, id_dokter, tanggal_mulai, tanggal_selesai, keterangan
+		 FROM cuti_dokter WHERE id_dokter = $1 AND tanggal_selesai >= CURRENT_DATE
+		 ORDER BY tanggal_mulai`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil cuti dokter")
+	}
+	defer cutiRows.Close()
+
+	d.Cuti = []model.CutiDokter{}
+	for cutiRows.Next() {
+		var ct model.CutiDokter
+		var tm, ts interface{}
+		cutiRows.Scan(&ct.IDCuti, &ct.IDDokter, &tm, &ts, &ct.Keterangan)
+		ct.TanggalMulai = formatDate(tm)
+		ct.TanggalSelesai = formatDate(ts)
+		d.Cuti = append(d.Cuti, ct)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", d)
+}
+
+// ─── POST /dokter ────────────────────────────────────────────────────────────
+
+func CreateDokter(c *fiber.Ctx) error {
+	var req model.DokterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaDokter = strings.TrimSpace(req.NamaDokter)
+	req.NomorSIP = strings.TrimSpace(req.NomorSIP)
+	req.Spesialisasi = strings.TrimSpace(req.Spesialisasi)
+	req.KodeDokterBPJS = strings.TrimSpace(req.KodeDokterBPJS)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if req.IDUser != nil {
+		var userExists bool
+		config.DB.QueryRow(context.Background(),
+			`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, *req.IDUser,
+		).Scan(&userExists)
+		if !userExists {
+			return model.ErrorResponse(c, 404, "User tidak ditemukan")
+		}
+	}
+
+	aktif := true
+	if req.Aktif != nil {
+		aktif = *req.Aktif
+	}
+
+	var d model.DokterResponse
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO dokter (id_user, nama_dokter, nomor_sip, spesialisasi, kode_dokter_bpjs, aktif)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		 RETURNING id_dokter, id_user, nama_dokter, nomor_sip, spesialisasi, COALESCE(kode_dokter_bpjs, ''), aktif`,
+		req.IDUser, req.NamaDokter, req.NomorSIP, req.Spesialisasi, req.KodeDokterBPJS, aktif,
+	).Scan(&d.IDDokter, &d.IDUser, &d.NamaDokter, &d.NomorSIP, &d.Spesialisasi, &d.KodeDokterBPJS, &d.Aktif)
+
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Nomor SIP atau akun user sudah dipakai dokter lain")
+		}
+		return model.ErrorResponse(c, 500, "Gagal menambah dokter: "+err.Error())
+	}
+
+	return model.SuccessResponse(c, 201, "Dokter berhasil ditambahkan", d)
+}
+
+// ─── PUT /dokter/:id ─────────────────────────────────────────────────────────
+
+func UpdateDokter(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.DokterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaDokter = strings.TrimSpace(req.NamaDokter)
+	req.NomorSIP = strings.TrimSpace(req.NomorSIP)
+	req.Spesialisasi = strings.TrimSpace(req.Spesialisasi)
+	req.KodeDokterBPJS = strings.TrimSpace(req.KodeDokterBPJS)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if req.IDUser != nil {
+		var userExists bool
+		config.DB.QueryRow(context.Background(),
+			`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, *req.IDUser,
+		).Scan(&userExists)
+		if !userExists {
+			return model.ErrorResponse(c, 404, "User tidak ditemukan")
+		}
+	}
+
+	var d model.DokterResponse
+	err = config.DB.QueryRow(context.Background(),
+		`UPDATE dokter SET id_user=$1, nama_dokter=$2, nomor_sip=$3, spesialisasi=$4,
+		 kode_dokter_bpjs=NULLIF($5, ''), aktif=COALESCE($6, aktif), updated_at=NOW()
+		 WHERE id_dokter=$7
+		 RETURNING id_dokter, id_user, nama_dokter, nomor_sip, spesialisasi, COALESCE(kode_dokter_bpjs, ''), aktif`,
+		req.IDUser, req.NamaDokter, req.NomorSIP, req.Spesialisasi, req.KodeDokterBPJS, req.Aktif, id,
+	).Scan(&d.IDDokter, &d.IDUser, &d.NamaDokter, &d.NomorSIP, &d.Spesialisasi, &d.KodeDokterBPJS, &d.Aktif)
+
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Nomor SIP atau akun user sudah dipakai dokter lain")
+		}
+		return model.ErrorResponse(c, 404, "Dokter tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Dokter berhasil diupdate", d)
+}
+
+// ─── DELETE /dokter/:id ──────────────────────────────────────────────────────
+
+func DeleteDokter(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM dokter WHERE id_dokter = $1`, id)
+	if err != nil {
+		if config.IsForeignKeyViolation(err) {
+			return model.ErrorResponse(c, 409, "Dokter sudah memiliki riwayat pemeriksaan, nonaktifkan saja")
+		}
+		return model.ErrorResponse(c, 500, "Gagal menghapus dokter")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Dokter tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Dokter berhasil dihapus", nil)
+}
+
+// ─── POST /dokter/:id/jadwal ─────────────────────────────────────────────────
+
+func CreateJadwalDokter(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.JadwalDokterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.JamMulai = strings.TrimSpace(req.JamMulai)
+	req.JamSelesai = strings.TrimSpace(req.JamSelesai)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var dokterExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM dokter WHERE id_dokter = $1)`, id,
+	).Scan(&dokterExists)
+	if !dokterExists {
+		return model.ErrorResponse(c, 404, "Dokter tidak ditemukan")
+	}
+
+	var poliExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM poli WHERE id_poli = $1)`, req.IDPoli,
+	).Scan(&poliExists)
+	if !poliExists {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	// Dokter tidak boleh praktik di dua tempat pada jam yang beririsan
+	var bentrok bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM jadwal_dokter
+		 WHERE id_dokter = $1 AND hari = $2 AND jam_mulai < $4::time AND jam_selesai > $3::time)`,
+		id, req.Hari, req.JamMulai, req.JamSelesai,
+	).Scan(&bentrok)
+	if bentrok {
+		return model.ErrorResponse(c, 409, "Jadwal bentrok dengan jadwal dokter yang sudah ada")
+	}
+
+	j := model.JadwalDokter{
+		IDDokter:   id,
+		IDPoli:     req.IDPoli,
+		Hari:       req.Hari,
+		NamaHari:   model.NamaHari(req.Hari),
+		JamMulai:   req.JamMulai,
+		JamSelesai: req.JamSelesai,
+	}
+	err = config.DB.QueryRow(context.Background(),
+		`INSERT INTO jadwal_dokter (id_dokter, id_poli, hari, jam_mulai, jam_selesai)
+		 VALUES ($1, $2, $3, $4::time, $5::time)
+		 RETURNING id_jadwal, (SELECT nama_poli FROM poli WHERE id_poli = $2)`,
+		id, req.IDPoli, req.Hari, req.JamMulai, req.JamSelesai,
+	).Scan(&j.IDJadwal, &j.NamaPoli)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menambah jadwal: "+err.Error())
+	}
+
+	return model.SuccessResponse(c, 201, "Jadwal berhasil ditambahkan", j)
+}
+
+// ─── DELETE /dokter/:id/jadwal/:id_jadwal ───────────────────────────────────
+
+func DeleteJadwalDokter(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+	idJadwal, err := strconv.Atoi(c.Params("id_jadwal"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID Jadwal tidak valid")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM jadwal_dokter WHERE id_jadwal = $1 AND id_dokter = $2`, idJadwal, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus jadwal")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Jadwal tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Jadwal berhasil dihapus", nil)
+}
+
+// ─── POST /dokter/:id/cuti ───────────────────────────────────────────────────
+
+func CreateCutiDokter(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.CutiDokterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.TanggalMulai = strings.TrimSpace(req.TanggalMulai)
+	req.TanggalSelesai = strings.TrimSpace(req.TanggalSelesai)
+	req.Keterangan = strings.TrimSpace(req.Keterangan)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var dokterExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM dokter WHERE id_dokter = $1)`, id,
+	).Scan(&dokterExists)
+	if !dokterExists {
+		return model.ErrorResponse(c, 404, "Dokter tidak ditemukan")
+	}
+
+	ct := model.CutiDokter{
+		IDDokter:       id,
+		TanggalMulai:   req.TanggalMulai,
+		TanggalSelesai: req.TanggalSelesai,
+		Keterangan:     req.Keterangan,
+	}
+	err = config.DB.QueryRow(context.Background(),
+		`INSERT INTO cuti_dokter (id_dokter, tanggal_mulai, tanggal_selesai, keterangan)
+		 VALUES ($1, $2, $3, $4) RETURNING id_cuti`,
+		id, req.TanggalMulai, req.TanggalSelesai, req.Keterangan,
+	).Scan(&ct.IDCuti)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menambah cuti: "+err.Error())
+	}
+
+	return model.SuccessResponse(c, 201, "Cuti dokter berhasil ditambahkan", ct)
+}
+
+// ─── DELETE /dokter/:id/cuti/:id_cuti ───────────────────────────────────────
+
+func DeleteCutiDokter(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+	idCuti, err := strconv.Atoi(c.Params("id_cuti"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID Cuti tidak valid")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM cuti_dokter WHERE id_cuti = $1 AND id_dokter = $2`, idCuti, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus cuti")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Cuti tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Cuti dokter berhasil dihapus", nil)
+}
+
+// ─── GET /dokter/bertugas ────────────────────────────────────────────────────
+
+// GetDokterBertugas mengembalikan dokter yang praktik pada tanggal tertentu,
+// sudah dikurangi dokter yang sedang cuti
+func GetDokterBertugas(c *fiber.Ctx) error {
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
+
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+	tgl, err := time.Parse("2006-01-02", tanggal)
+	if err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal harus YYYY-MM-DD")
+	}
+
+	queryRows, err := config.DB.Query(context.Background(),
+		`SELECT j.id_jadwal, j.id_dokter, d.nama_dokter, j.id_poli, po.nama_poli, j.hari,
+			to_char(j.jam_mulai, 'HH24:MI'), to_char(j.jam_selesai, 'HH24:MI')
+		 FROM jadwal_dokter j
+		 JOIN dokter d ON j.id_dokter = d.id_dokter
+		 JOIN poli po ON j.id_poli = po.id_poli
+		 WHERE j.hari = $1 AND d.aktif AND ($3 = 0 OR j.id_poli = $3)
+		   AND NOT EXISTS(SELECT 1 FROM cuti_dokter ct WHERE ct.id_dokter = d.id_dokter
+		                  AND $2::date BETWEEN ct.tanggal_mulai AND ct.tanggal_selesai)
+		 ORDER BY po.nama_poli, j.jam_mulai`,
+		model.HariISO(tgl), tanggal, idPoli)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil jadwal dokter")
+	}
+	defer queryRows.Close()
+
+	rows := []model.JadwalDokter{}
+	for queryRows.Next() {
+		var j model.JadwalDokter
+		queryRows.Scan(&j.IDJadwal, &j.IDDokter, &j.NamaDokter, &j.IDPoli, &j.NamaPoli, &j.Hari, &j.JamMulai, &j.JamSelesai)
+		j.NamaHari = model.NamaHari(j.Hari)
+		rows = append(rows, j)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", rows)
+}
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// cekJadwalDokter memastikan dokter aktif, punya jadwal di poli tersebut pada
+// hari tanggal pemeriksaan, dan tidak sedang cuti. Mengembalikan pesan error
+// atau string kosong jika valid.
+func cekJadwalDokter(idDokter, idPoli int, tanggal string) string {
+	tgl, err := time.Parse("2006-01-02", tanggal)
+	if err != nil {
+		return "Tanggal pemeriksaan tidak valid"
+	}
+
+	var aktif bool
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT aktif FROM dokter WHERE id_dokter = $1`, idDokter,
+	).Scan(&aktif)
+	if err != nil {
+		return "Dokter tidak ditemukan"
+	}
+	if !aktif {
+		return "Dokter sudah tidak aktif"
+	}
+
+	var terjadwal bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM jadwal_dokter WHERE id_dokter = $1 AND id_poli = $2 AND hari = $3)`,
+		idDokter, idPoli, model.HariISO(tgl),
+	).Scan(&terjadwal)
+	if !terjadwal {
+		return "Dokter tidak memiliki jadwal praktik di poli ini pada hari " + model.NamaHari(model.HariISO(tgl))
+	}
+
+	var cuti bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM cuti_dokter WHERE id_dokter = $1
+		 AND $2::date BETWEEN tanggal_mulai AND tanggal_selesai)`,
+		idDokter, tanggal,
+	).Scan(&cuti)
+	if cuti {
+		return "Dokter sedang cuti pada tanggal " + tanggal
+	}
+
+	return ""
+}
@@ -0,0 +1,365 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/totp"
+)
+
+// status2FA mengembalikan apakah user sudah mengaktifkan 2FA dan apakah
+// role-nya mewajibkan 2FA
+func status2FA(userID int) (aktif, wajib bool, err error) {
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT u.totp_aktif, COALESCE(r.wajib_2fa, FALSE)
+		 FROM users u LEFT JOIN role r ON u.role = r.kode_role
+		 WHERE u.id = $1`, userID,
+	).Scan(&aktif, &wajib)
+	return aktif, wajib, err
+}
+
+// simpanKodePemulihan mengganti semua kode pemulihan user dengan yang baru
+func simpanKodePemulihan(userID int) ([]string, error) {
+	kode := model.GenerateKodePemulihan()
+	hash := make([]string, len(kode))
+	for i, k := range kode {
+		hash[i] = model.HashKodePemulihan(k)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_pemulihan WHERE id_user = $1`, userID); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO totp_pemulihan (id_user, kode_hash) SELECT $1, h FROM unnest($2::text[]) AS h`,
+		userID, hash)
+	if err != nil {
+		return nil, err
+	}
+	return kode, tx.Commit(ctx)
+}
+
+// cekKode2FA memverifikasi kode authenticator atau kode pemulihan milik user
+// yang 2FA-nya aktif. Kode authenticator yang sudah pernah dipakai ditolak;
+// kode pemulihan hangus setelah dipakai.
+func cekKode2FA(userID int, req model.Kode2FARequest) (ok, pakaiPemulihan bool) {
+	ctx := context.Background()
+
+	if kode := strings.TrimSpace(req.KodePemulihan); kode != "" {
+		result, err := config.DB.Exec(ctx,
+			`UPDATE totp_pemulihan SET dipakai_at = NOW()
+			 WHERE id_user = $1 AND kode_hash = $2 AND dipakai_at IS NULL`,
+			userID, model.HashKodePemulihan(kode))
+		return err == nil && result.RowsAffected() == 1, true
+	}
+
+	var secret *string
+	config.DB.QueryRow(ctx,
+		`SELECT totp_secret FROM users WHERE id = $1 AND totp_aktif = TRUE`, userID,
+	).Scan(&secret)
+	if secret == nil {
+		return false, false
+	}
+
+	step, cocok := totp.Verifikasi(*secret, req.Kode, time.Now())
+	if !cocok {
+		return false, false
+	}
+	result, err := config.DB.Exec(ctx,
+		`UPDATE users SET totp_step_terakhir = $2 WHERE id = $1 AND totp_step_terakhir < $2`,
+		userID, step)
+	return err == nil && result.RowsAffected() == 1, false
+}
+
+// aktifkan2FA memverifikasi kode pertama terhadap secret yang sedang
+// didaftarkan, lalu mengaktifkan 2FA dan membuat kode pemulihan
+func aktifkan2FA(c *fiber.Ctx, userID int, kode string) ([]string, int, string) {
+	var secret *string
+	var aktif bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT totp_secret, totp_aktif FROM users WHERE id = $1`, userID,
+	).Scan(&secret, &aktif)
+	if aktif {
+		return nil, 409, "2FA sudah aktif"
+	}
+	if secret == nil {
+		return nil, 400, "Mulai pendaftaran 2FA terlebih dahulu"
+	}
+
+	step, cocok := totp.Verifikasi(*secret, kode, time.Now())
+	if !cocok {
+		return nil, 401, "Kode 2FA salah"
+	}
+
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE users SET totp_aktif = TRUE, totp_step_terakhir = $2, updated_at = NOW() WHERE id = $1`,
+		userID, step)
+	if err != nil {
+		return nil, 500, "Gagal mengaktifkan 2FA"
+	}
+	kodePemulihan, err := simpanKodePemulihan(userID)
+	if err != nil {
+		return nil, 500, "Gagal membuat kode pemulihan"
+	}
+
+	catatAudit(c, "2fa.aktifkan", "user", strconv.Itoa(userID), "")
+	return kodePemulihan, 0, ""
+}
+
+// ─── POST /me/2fa/daftar & /auth/2fa/daftar ──────────────────────────────────
+
+// Daftar2FA membuat secret baru yang belum berlaku sampai kode pertama
+// diverifikasi. Dipakai dari /me, atau dengan token tantangan saat role user
+// mewajibkan 2FA tetapi user belum mendaftar.
+func Daftar2FA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+	username, _ := c.Locals("username").(string)
+
+	aktif, _, err := status2FA(userID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa status 2FA")
+	}
+	if aktif {
+		return model.ErrorResponse(c, 409, "2FA sudah aktif, nonaktifkan terlebih dahulu untuk mendaftar ulang")
+	}
+
+	secret := totp.BuatSecret()
+	_, err = config.DB.Exec(context.Background(),
+		`UPDATE users SET totp_secret = $1, updated_at = NOW() WHERE id = $2`, secret, userID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai pendaftaran 2FA")
+	}
+
+	return model.SuccessResponse(c, 200, "Pindai QR code dengan aplikasi authenticator, lalu masukkan kodenya",
+		model.Daftar2FAResponse{
+			Secret: secret,
+			URI:    totp.ProvisioningURI(config.GetPuskesmasNama(), username, secret),
+		})
+}
+
+// ─── POST /me/2fa/aktifkan ───────────────────────────────────────────────────
+
+func Aktifkan2FA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var req model.Kode2FARequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+	if strings.TrimSpace(req.Kode) == "" {
+		return model.ErrorResponse(c, 400, "Kode 2FA harus diisi")
+	}
+
+	kodePemulihan, code, msg := aktifkan2FA(c, userID, req.Kode)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	return model.SuccessResponse(c, 200, "2FA aktif, simpan kode pemulihan karena tidak akan ditampilkan lagi",
+		model.KodePemulihanResponse{KodePemulihan: kodePemulihan})
+}
+
+// ─── POST /auth/2fa/verifikasi ───────────────────────────────────────────────
+
+// Verifikasi2FA adalah langkah kedua login. Token tantangan dari langkah
+// pertama ditukar dengan token akses jika kode benar. User yang wajib 2FA
+// tetapi belum terdaftar mengirim kode pertamanya di sini dan menerima kode
+// pemulihan bersama token akses.
+func Verifikasi2FA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var req model.Kode2FARequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var user model.User
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id, nama, username, role FROM users WHERE id = $1`, userID,
+	).Scan(&user.ID, &user.Nama, &user.Username, &user.Role)
+	if err != nil {
+		return model.ErrorResponse(c, 401, "User tidak ditemukan")
+	}
+
+	// Tebakan kode dihitung bersama tebakan password
+	if sisa, terkunci := cekKunciLogin(user.Username, c.IP()); sisa > 0 {
+		catatPercobaanLogin(c, user.Username, false, "terkunci")
+		return tolakLoginTerkunci(c, sisa, terkunci)
+	}
+
+	gagal := func(msg string) error {
+		catatPercobaanLogin(c, user.Username, false, "kode_2fa_salah")
+		catatGagalLogin(user.Username, c.IP())
+		return model.ErrorResponse(c, 401, msg)
+	}
+
+	var kodePemulihan []string
+	pesan := ""
+	aktif, wajib, err := status2FA(user.ID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa status 2FA")
+	}
+	switch {
+	case aktif:
+		ok, pakaiPemulihan := cekKode2FA(user.ID, req)
+		if !ok {
+			return gagal("Kode 2FA salah")
+		}
+		if pakaiPemulihan {
+			var sisa int
+			config.DB.QueryRow(context.Background(),
+				`SELECT COUNT(*) FROM totp_pemulihan WHERE id_user = $1 AND dipakai_at IS NULL`, user.ID,
+			).Scan(&sisa)
+			catatAudit(c, "2fa.pakai_pemulihan", "user", strconv.Itoa(user.ID), "sisa "+strconv.Itoa(sisa))
+			pesan = "Kode pemulihan dipakai, tersisa " + strconv.Itoa(sisa)
+		}
+	case wajib:
+		var code int
+		var msg string
+		kodePemulihan, code, msg = aktifkan2FA(c, user.ID, req.Kode)
+		if code == 401 {
+			return gagal(msg)
+		}
+		if code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
+	default:
+		return model.ErrorResponse(c, 400, "2FA tidak aktif untuk user ini")
+	}
+
+	resetKunciLogin(user.Username)
+	catatPercobaanLogin(c, user.Username, true, "berhasil_2fa")
+	if pesan != "" {
+		c.Set("X-Peringatan", pesan)
+	}
+
+	return selesaikanLogin(c, user, req.IDLoket, kodePemulihan)
+}
+
+// ─── DELETE /me/2fa ──────────────────────────────────────────────────────────
+
+// Nonaktifkan2FA mematikan 2FA user sendiri; perlu kode yang masih berlaku dan
+// tidak bisa dilakukan jika role mewajibkan 2FA
+func Nonaktifkan2FA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var req model.Kode2FARequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	aktif, wajib, err := status2FA(userID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa status 2FA")
+	}
+	if !aktif {
+		return model.ErrorResponse(c, 400, "2FA tidak aktif")
+	}
+	if wajib {
+		return model.ErrorResponse(c, 400, "Role Anda wajib memakai 2FA")
+	}
+	if ok, _ := cekKode2FA(userID, req); !ok {
+		return model.ErrorResponse(c, 401, "Kode 2FA salah")
+	}
+
+	if err := hapus2FA(userID); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menonaktifkan 2FA")
+	}
+	catatAudit(c, "2fa.nonaktifkan", "user", strconv.Itoa(userID), "")
+
+	return model.SuccessResponse(c, 200, "2FA dinonaktifkan", nil)
+}
+
+// ─── POST /me/2fa/kode-pemulihan ─────────────────────────────────────────────
+
+// BuatUlangKodePemulihan mengganti semua kode pemulihan; kode lama langsung
+// tidak berlaku
+func BuatUlangKodePemulihan(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var req model.Kode2FARequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+	if strings.TrimSpace(req.Kode) == "" {
+		return model.ErrorResponse(c, 400, "Kode 2FA harus diisi")
+	}
+	req.KodePemulihan = ""
+
+	if ok, _ := cekKode2FA(userID, req); !ok {
+		return model.ErrorResponse(c, 401, "Kode 2FA salah")
+	}
+
+	kodePemulihan, err := simpanKodePemulihan(userID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat kode pemulihan")
+	}
+	catatAudit(c, "2fa.buat_ulang_pemulihan", "user", strconv.Itoa(userID), "")
+
+	return model.SuccessResponse(c, 200, "Kode pemulihan baru dibuat, simpan karena tidak akan ditampilkan lagi",
+		model.KodePemulihanResponse{KodePemulihan: kodePemulihan})
+}
+
+// ─── POST /users/:id/reset-2fa ───────────────────────────────────────────────
+
+// Reset2FAUser menghapus 2FA user yang kehilangan HP sekaligus kode
+// pemulihannya. Jika role-nya wajib 2FA, user diminta mendaftar ulang saat
+// login berikutnya.
+func Reset2FAUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var username string
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT username FROM users WHERE id = $1`, id).Scan(&username)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "User tidak ditemukan")
+	}
+
+	if err := hapus2FA(id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal me-reset 2FA user")
+	}
+	catatAudit(c, "user.reset_2fa", "user", strconv.Itoa(id), username)
+
+	return model.SuccessResponse(c, 200, "2FA user berhasil di-reset", nil)
+}
+
+// hapus2FA menghapus secret dan kode pemulihan dalam satu transaksi supaya
+// tidak tersisa kode pemulihan yang masih berlaku
+func hapus2FA(userID int) error {
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET totp_secret = NULL, totp_aktif = FALSE, totp_step_terakhir = 0, updated_at = NOW()
+		 WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM totp_pemulihan WHERE id_user = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
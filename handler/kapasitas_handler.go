@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// kuotaTanpaPoli adalah kuota harian antrian lama yang tidak terikat poli
+const kuotaTanpaPoli = 50
+
+// kapasitasHarian menghitung kuota antrian poli pada tanggal tertentu:
+// hari libur -> kapasitas per tanggal -> kapasitas mingguan -> kuota_harian.
+// tutup berisi alasan jika tidak ada antrian yang boleh dibuat (kuota 0).
+func kapasitasHarian(idPoli *int, tanggal string) (kuota int, tutup string, err error) {
+	var libur string
+	config.DB.QueryRow(context.Background(),
+		`SELECT nama FROM hari_libur WHERE tanggal = $1`, tanggal,
+	).Scan(&libur)
+	if libur != "" {
+		return 0, "Puskesmas libur pada tanggal ini (" + libur + ")", nil
+	}
+
+	if idPoli == nil {
+		return kuotaTanpaPoli, "", nil
+	}
+
+	var sumber, keterangan string
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT COALESCE(kt.kuota, kp.kuota, po.kuota_harian),
+			CASE WHEN kt.kuota IS NOT NULL THEN 'tanggal' WHEN kp.kuota IS NOT NULL THEN 'hari' ELSE 'poli' END,
+			COALESCE(kt.keterangan, '')
+		 FROM poli po
+		 LEFT JOIN kapasitas_tanggal kt ON kt.id_poli = po.id_poli AND kt.tanggal = $2
+		 LEFT JOIN kapasitas_poli kp ON kp.id_poli = po.id_poli AND kp.hari = EXTRACT(ISODOW FROM $2::date)
+		 WHERE po.id_poli = $1`, *idPoli, tanggal,
+	).Scan(&kuota, &sumber, &keterangan)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if kuota == 0 {
+		switch sumber {
+		case "tanggal":
+			tutup = "Poli tutup pada tanggal ini"
+			if keterangan != "" {
+				tutup += " (" + keterangan + ")"
+			}
+		default:
+			tutup = "Poli tidak buka pada hari ini"
+			if tgl, err := time.Parse("2006-01-02", tanggal); err == nil {
+				tutup = "Poli tidak buka pada hari " + model.NamaHari(model.HariISO(tgl))
+			}
+		}
+	}
+	return kuota, tutup, nil
+}
+
+// ─── GET /poli/:id/kapasitas ─────────────────────────────────────────────────
+
+func GetKapasitasPoli(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	k := model.KapasitasPoliResponse{IDPoli: id}
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT nama_poli, kuota_harian FROM poli WHERE id_poli = $1`, id,
+	).Scan(&k.NamaPoli, &k.KuotaHarian)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	// Mingguan: 7 hari, yang tidak diatur memakai kuota_harian
+	k.Mingguan = make([]model.KapasitasHari, 7)
+	for i := range k.Mingguan {
+		k.Mingguan[i] = model.KapasitasHari{Hari: i + 1, NamaHari: model.NamaHari(i + 1), Kuota: k.KuotaHarian}
+	}
+	hariRows, err := config.DB.Query(context.Background(),
+		`SELECT hari, kuota FROM kapasitas_poli WHERE id_poli = $1`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil kapasitas poli")
+	}
+	for hariRows.Next() {
+		var hari, kuota int
+		hariRows.Scan(&hari, &kuota)
+		if hari >= 1 && hari <= 7 {
+			k.Mingguan[hari-1].Kuota = kuota
+		}
+	}
+	hariRows.Close()
+
+	// Pengaturan per tanggal yang belum lewat
+	tanggalRows, err := config.DB.Query(context.Background(),
+		`SELECT id_kapasitas, id_poli, tanggal, kuota, keterangan FROM kapasitas_tanggal
+		 WHERE id_poli = $1 AND tanggal >= CURRENT_DATE ORDER BY tanggal`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil kapasitas poli")
+	}
+	defer tanggalRows.Close()
+
+	k.Tanggal = []model.KapasitasTanggal{}
+	for tanggalRows.Next() {
+		var kt model.KapasitasTanggal
+		var tg interface{}
+		tanggalRows.Scan(&kt.IDKapasitas, &kt.IDPoli, &tg, &kt.Kuota, &kt.Keterangan)
+		kt.Tanggal = formatDate(tg)
+		k.Tanggal = append(k.Tanggal, kt)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", k)
+}
+
+// ─── PUT /poli/:id/kapasitas/mingguan ────────────────────────────────────────
+
+func UpdateKapasitasMingguan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.KapasitasMingguanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var poliExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM poli WHERE id_poli = $1)`, id,
+	).Scan(&poliExists)
+	if !poliExists {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan kapasitas poli")
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM kapasitas_poli WHERE id_poli = $1`, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan kapasitas poli")
+	}
+	for _, k := range req.Kapasitas {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO kapasitas_poli (id_poli, hari, kuota) VALUES ($1, $2, $3)`,
+			id, k.Hari, k.Kuota); err != nil {
+			return model.ErrorResponse(c, 500, "Gagal menyimpan kapasitas poli: "+err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan kapasitas poli")
+	}
+
+	return GetKapasitasPoli(c)
+}
+
+// ─── POST /poli/:id/kapasitas/tanggal ────────────────────────────────────────
+
+// SetKapasitasTanggal menambah atau mengganti kapasitas poli pada satu tanggal
+func SetKapasitasTanggal(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.KapasitasTanggalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.Tanggal = strings.TrimSpace(req.Tanggal)
+	req.Keterangan = strings.TrimSpace(req.Keterangan)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var poliExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM poli WHERE id_poli = $1)`, id,
+	).Scan(&poliExists)
+	if !poliExists {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	kt := model.KapasitasTanggal{
+		IDPoli:     id,
+		Tanggal:    req.Tanggal,
+		Kuota:      *req.Kuota,
+		Keterangan: req.Keterangan,
+	}
+	err = config.DB.QueryRow(context.Background(),
+		`INSERT INTO kapasitas_tanggal (id_poli, tanggal, kuota, keterangan)
+		 VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (id_poli, tanggal) DO UPDATE SET kuota = EXCLUDED.kuota, keterangan = EXCLUDED.keterangan
+		 RETURNING id_kapasitas`,
+		id, req.Tanggal, *req.Kuota, req.Keterangan,
+	).Scan(&kt.IDKapasitas)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan kapasitas tanggal: "+err.Error())
+	}
+
+	return model.SuccessResponse(c, 200, "Kapasitas tanggal berhasil disimpan", kt)
+}
+
+// ─── DELETE /poli/:id/kapasitas/tanggal/:id_kapasitas ────────────────────────
+
+func DeleteKapasitasTanggal(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+	idKapasitas, err := strconv.Atoi(c.Params("id_kapasitas"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID Kapasitas tidak valid")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM kapasitas_tanggal WHERE id_kapasitas = $1 AND id_poli = $2`, idKapasitas, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus kapasitas tanggal")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Kapasitas tanggal tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Kapasitas tanggal berhasil dihapus", nil)
+}
+
+// ─── GET /hari-libur ─────────────────────────────────────────────────────────
+
+func GetAllHariLibur(c *fiber.Ctx) error {
+	tahun, _ := strconv.Atoi(c.Query("tahun", strconv.Itoa(time.Now().Year())))
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id_libur, tanggal, nama FROM hari_libur
+		 WHERE EXTRACT(YEAR FROM tanggal) = $1 ORDER BY tanggal`, tahun)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil hari libur")
+	}
+	defer rows.Close()
+
+	libur := []model.HariLibur{}
+	for rows.Next() {
+		var h model.HariLibur
+		var tg interface{}
+		rows.Scan(&h.IDLibur, &tg, &h.Nama)
+		h.Tanggal = formatDate(tg)
+		libur = append(libur, h)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", libur)
+}
+
+// ─── POST /hari-libur ────────────────────────────────────────────────────────
+
+func CreateHariLibur(c *fiber.Ctx) error {
+	var req model.HariLiburRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.Tanggal = strings.TrimSpace(req.Tanggal)
+	req.Nama = strings.TrimSpace(req.Nama)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	h := model.HariLibur{Tanggal: req.Tanggal, Nama: req.Nama}
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO hari_libur (tanggal, nama) VALUES ($1, $2) RETURNING id_libur`,
+		req.Tanggal, req.Nama,
+	).Scan(&h.IDLibur)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Tanggal tersebut sudah terdaftar sebagai hari libur")
+		}
+		return model.ErrorResponse(c, 500, "Gagal menambah hari libur: "+err.Error())
+	}
+
+	// Antrian dan booking yang sudah terlanjur dibuat tidak dihapus otomatis;
+	// petugas diberi tahu supaya pasien bisa dihubungi
+	var terdampak int
+	config.DB.QueryRow(context.Background(),
+		`SELECT (SELECT COUNT(*) FROM antrian WHERE tanggal_kunjungan = $1 AND status = 'belum_dikelola')
+		      + (SELECT COUNT(*) FROM booking WHERE tanggal = $1 AND status = 'aktif')`, req.Tanggal,
+	).Scan(&terdampak)
+
+	msg := "Hari libur berhasil ditambahkan"
+	if terdampak > 0 {
+		msg += "; " + strconv.Itoa(terdampak) + " antrian/booking pada tanggal ini perlu dibatalkan"
+	}
+	return model.SuccessResponse(c, 201, msg, h)
+}
+
+// ─── DELETE /hari-libur/:id ──────────────────────────────────────────────────
+
+func DeleteHariLibur(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM hari_libur WHERE id_libur = $1`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus hari libur")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Hari libur tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Hari libur berhasil dihapus", nil)
+}
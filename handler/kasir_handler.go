@@ -0,0 +1,709 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/pdf"
+)
+
+const tagihanSelectSQL = `SELECT t.id_tagihan, t.nomor_tagihan, t.id_pemeriksaan,
+	pe.nik_pasien, p.nama_pasien, po.nama_poli, pe.metode_pembayaran,
+	COALESCE(pj.nama_penjamin, pe.metode_pembayaran), pe.tanggal_pemeriksaan,
+	t.status, t.total, t.ditanggung, t.diskon, t.total_bayar
+	FROM tagihan t
+	JOIN pemeriksaan pe ON t.id_pemeriksaan = pe.id_pemeriksaan
+	JOIN pasien p ON pe.nik_pasien = p.nik
+	JOIN poli po ON pe.id_poli = po.id_poli
+	LEFT JOIN penjamin pj ON pe.metode_pembayaran = pj.kode_penjamin
+	`
+
+func scanTagihan(row pgx.Row) (model.TagihanResponse, error) {
+	var t model.TagihanResponse
+	var tp interface{}
+	err := row.Scan(&t.IDTagihan, &t.NomorTagihan, &t.IDPemeriksaan,
+		&t.NIKPasien, &t.NamaPasien, &t.NamaPoli, &t.MetodePembayaran, &t.NamaPenjamin, &tp,
+		&t.Status, &t.Total, &t.Ditanggung, &t.Diskon, &t.TotalBayar)
+	t.TanggalPemeriksaan = formatDate(tp)
+	return t, err
+}
+
+// loadTagihan mengambil tagihan lengkap dengan item dan pembayaran
+func loadTagihan(id int) (model.TagihanResponse, error) {
+	t, err := scanTagihan(config.DB.QueryRow(context.Background(),
+		tagihanSelectSQL+`WHERE t.id_tagihan = $1`, id))
+	if err != nil {
+		return t, err
+	}
+
+	itemRows, err := config.DB.Query(context.Background(),
+		`SELECT id_item, id_tarif, nama_item, kategori, harga_satuan, jumlah, subtotal, ditanggung, diskon
+		 FROM tagihan_item WHERE id_tagihan = $1 ORDER BY id_item`, id)
+	if err != nil {
+		return t, err
+	}
+	defer itemRows.Close()
+
+	t.Items = []model.TagihanItem{}
+	for itemRows.Next() {
+		var it model.TagihanItem
+		itemRows.Scan(&it.IDItem, &it.IDTarif, &it.NamaItem, &it.Kategori, &it.HargaSatuan, &it.Jumlah, &it.Subtotal,
+			&it.Ditanggung, &it.Diskon)
+		t.Items = append(t.Items, it)
+	}
+
+	var pb model.Pembayaran
+	var ref *string
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT pb.id_pembayaran, pb.metode, pb.jumlah_dibayar, pb.kembalian, pb.referensi_qris,
+			pb.id_kasir, u.nama, pb.dibayar_at
+		 FROM pembayaran pb JOIN users u ON pb.id_kasir = u.id
+		 WHERE pb.id_tagihan = $1`, id,
+	).Scan(&pb.IDPembayaran, &pb.Metode, &pb.JumlahDibayar, &pb.Kembalian, &ref,
+		&pb.IDKasir, &pb.NamaKasir, &pb.DibayarAt)
+	if err == nil {
+		if ref != nil {
+			pb.ReferensiQRIS = *ref
+		}
+		t.Pembayaran = &pb
+	}
+
+	return t, nil
+}
+
+// tambahItemTagihan menyalin nama tarif dan harga yang berlaku pada tanggal
+// pemeriksaan ke tagihan_item, sehingga perubahan harga tidak mengubah
+// tagihan yang sudah dibuat
+func tambahItemTagihan(ctx context.Context, tx pgx.Tx, idTagihan int, item model.TagihanItemRequest) (int, string) {
+	var nama, kategori string
+	var harga float64
+	var aktif bool
+	err := tx.QueryRow(ctx,
+		`SELECT t.nama_layanan, t.kategori, `+hargaBerlakuSQL("pe.tanggal_pemeriksaan")+`, t.aktif
+		 FROM tarif t, tagihan tg JOIN pemeriksaan pe ON tg.id_pemeriksaan = pe.id_pemeriksaan
+		 WHERE t.id_tarif = $1 AND tg.id_tagihan = $2`, item.IDTarif, idTagihan,
+	).Scan(&nama, &kategori, &harga, &aktif)
+	if err != nil {
+		return 404, "Tarif dengan ID " + strconv.Itoa(item.IDTarif) + " tidak ditemukan"
+	}
+	if !aktif {
+		return 409, "Tarif " + nama + " sudah tidak aktif"
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO tagihan_item (id_tagihan, id_tarif, nama_item, kategori, harga_satuan, jumlah, subtotal)
+		 VALUES ($1, $2, $3, $4, $5, $6, $5 * $6)`,
+		idTagihan, item.IDTarif, nama, kategori, harga, item.Jumlah)
+	if err != nil {
+		return 500, "Gagal menambah item tagihan: " + err.Error()
+	}
+	return 0, ""
+}
+
+// hitungUlangTagihan menerapkan aturan harga ke setiap item lalu memperbarui
+// total tagihan. Nominal pada pemeriksaan ikut disamakan dengan yang harus
+// dibayar pasien, sehingga nominal tidak pernah diisi manual.
+func hitungUlangTagihan(ctx context.Context, tx pgx.Tx, idTagihan int) error {
+	var metode string
+	var idPem, umur int
+	var tp interface{}
+	err := tx.QueryRow(ctx,
+		`SELECT pe.metode_pembayaran, pe.id_pemeriksaan, pe.tanggal_pemeriksaan,
+			DATE_PART('year', AGE(pe.tanggal_pemeriksaan, p.tanggal_lahir))::int
+		 FROM tagihan t
+		 JOIN pemeriksaan pe ON t.id_pemeriksaan = pe.id_pemeriksaan
+		 JOIN pasien p ON pe.nik_pasien = p.nik
+		 WHERE t.id_tagihan = $1`, idTagihan,
+	).Scan(&metode, &idPem, &tp, &umur)
+	if err != nil {
+		return err
+	}
+	tanggal := formatDate(tp)
+
+	aturan, err := loadAturanHargaBerlaku(ctx, tx, tanggal)
+	if err != nil {
+		return err
+	}
+
+	itemRows, err := tx.Query(ctx,
+		`SELECT id_item, id_tarif, kategori, subtotal FROM tagihan_item WHERE id_tagihan = $1`, idTagihan)
+	if err != nil {
+		return err
+	}
+	var items []model.TagihanItem
+	for itemRows.Next() {
+		var it model.TagihanItem
+		itemRows.Scan(&it.IDItem, &it.IDTarif, &it.Kategori, &it.Subtotal)
+		items = append(items, it)
+	}
+	itemRows.Close()
+
+	var total, ditanggung, diskon float64
+	for _, it := range items {
+		d, k := model.HitungPotongan(it.Subtotal, model.KonteksHarga{
+			MetodePembayaran: metode,
+			Kategori:         it.Kategori,
+			IDTarif:          it.IDTarif,
+			Umur:             umur,
+			Tanggal:          tanggal,
+		}, aturan)
+		if _, err := tx.Exec(ctx,
+			`UPDATE tagihan_item SET ditanggung = $1, diskon = $2 WHERE id_item = $3`,
+			d, k, it.IDItem); err != nil {
+			return err
+		}
+		total += it.Subtotal
+		ditanggung += d
+		diskon += k
+	}
+	totalBayar := total - ditanggung - diskon
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE tagihan SET total = $1, ditanggung = $2, diskon = $3, total_bayar = $4, updated_at = NOW()
+		 WHERE id_tagihan = $5`, total, ditanggung, diskon, totalBayar, idTagihan); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE pemeriksaan SET nominal_pembayaran = $1, updated_at = NOW() WHERE id_pemeriksaan = $2`,
+		totalBayar, idPem)
+	return err
+}
+
+// ─── GET /kasir/tagihan ──────────────────────────────────────────────────────
+
+func GetAllTagihan(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	search := strings.TrimSpace(c.Query("search", ""))
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	status := strings.TrimSpace(c.Query("status", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if tanggal != "" {
+		baseWhere += ` AND pe.tanggal_pemeriksaan = $` + strconv.Itoa(argIdx)
+		args = append(args, tanggal)
+		argIdx++
+	}
+	if status != "" {
+		baseWhere += ` AND t.status = $` + strconv.Itoa(argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+	if search != "" {
+		baseWhere += ` AND (p.nik LIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR p.nama_pasien ILIKE '%'||$` + strconv.Itoa(argIdx) +
+			`||'%' OR t.nomor_tagihan ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM tagihan t
+		 JOIN pemeriksaan pe ON t.id_pemeriksaan = pe.id_pemeriksaan
+		 JOIN pasien p ON pe.nik_pasien = p.nik `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := tagihanSelectSQL + baseWhere + `
+		ORDER BY t.id_tagihan DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data tagihan")
+	}
+	defer queryRows.Close()
+
+	var rows []model.TagihanResponse
+	for queryRows.Next() {
+		t, _ := scanTagihan(queryRows)
+		rows = append(rows, t)
+	}
+
+	if rows == nil {
+		rows = []model.TagihanResponse{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /kasir/tagihan/:id ──────────────────────────────────────────────────
+
+func GetTagihanByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	t, err := loadTagihan(id)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tagihan tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", t)
+}
+
+// ─── POST /kasir/tagihan ─────────────────────────────────────────────────────
+
+func CreateTagihan(c *fiber.Ctx) error {
+	var req model.CreateTagihanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var pemExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM pemeriksaan WHERE id_pemeriksaan = $1)`, req.IDPemeriksaan,
+	).Scan(&pemExists)
+	if !pemExists {
+		return model.ErrorResponse(c, 404, "Pemeriksaan tidak ditemukan")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat tagihan")
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('tagihan_nomor'))`); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat tagihan")
+	}
+
+	now := time.Now()
+	prefix := "INV/" + now.Format("20060102") + "/"
+
+	var urutan int
+	tx.QueryRow(ctx,
+		`SELECT COALESCE(MAX(SPLIT_PART(nomor_tagihan, '/', 3)::int), 0) + 1
+		 FROM tagihan WHERE nomor_tagihan LIKE $1 || '%'`, prefix,
+	).Scan(&urutan)
+
+	var idTagihan int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO tagihan (nomor_tagihan, id_pemeriksaan) VALUES ($1, $2) RETURNING id_tagihan`,
+		model.FormatNomorTagihan(now, urutan), req.IDPemeriksaan,
+	).Scan(&idTagihan)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Pemeriksaan ini sudah memiliki tagihan")
+		}
+		return model.ErrorResponse(c, 500, "Gagal membuat tagihan: "+err.Error())
+	}
+
+	for _, item := range req.Items {
+		if code, msg := tambahItemTagihan(ctx, tx, idTagihan, item); code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
+	}
+
+	if err := hitungUlangTagihan(ctx, tx, idTagihan); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghitung tagihan: "+err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat tagihan: "+err.Error())
+	}
+
+	t, _ := loadTagihan(idTagihan)
+	return model.SuccessResponse(c, 201, "Tagihan berhasil dibuat", t)
+}
+
+// ─── POST /kasir/tagihan/:id/item ────────────────────────────────────────────
+
+func AddTagihanItem(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.TagihanItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menambah item tagihan")
+	}
+	defer tx.Rollback(ctx)
+
+	if code, msg := kunciTagihanTerbuka(ctx, tx, id); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	if code, msg := tambahItemTagihan(ctx, tx, id, req); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	if err := hitungUlangTagihan(ctx, tx, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghitung tagihan: "+err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menambah item tagihan")
+	}
+
+	t, _ := loadTagihan(id)
+	return model.SuccessResponse(c, 201, "Item tagihan berhasil ditambahkan", t)
+}
+
+// ─── DELETE /kasir/tagihan/:id/item/:id_item ─────────────────────────────────
+
+func DeleteTagihanItem(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+	idItem, err := strconv.Atoi(c.Params("id_item"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID Item tidak valid")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus item tagihan")
+	}
+	defer tx.Rollback(ctx)
+
+	if code, msg := kunciTagihanTerbuka(ctx, tx, id); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	result, err := tx.Exec(ctx,
+		`DELETE FROM tagihan_item WHERE id_item = $1 AND id_tagihan = $2`, idItem, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus item tagihan")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Item tagihan tidak ditemukan")
+	}
+
+	if err := hitungUlangTagihan(ctx, tx, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghitung tagihan: "+err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus item tagihan")
+	}
+
+	t, _ := loadTagihan(id)
+	return model.SuccessResponse(c, 200, "Item tagihan berhasil dihapus", t)
+}
+
+// ─── POST /kasir/tagihan/:id/bayar ───────────────────────────────────────────
+
+func BayarTagihan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.BayarTagihanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.Metode = strings.TrimSpace(req.Metode)
+	req.ReferensiQRIS = strings.TrimSpace(req.ReferensiQRIS)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memproses pembayaran")
+	}
+	defer tx.Rollback(ctx)
+
+	if code, msg := kunciTagihanTerbuka(ctx, tx, id); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	var totalBayar float64
+	tx.QueryRow(ctx, `SELECT total_bayar FROM tagihan WHERE id_tagihan = $1`, id).Scan(&totalBayar)
+
+	// QRIS selalu pas sesuai tagihan; tunai boleh lebih dan dihitung kembaliannya
+	if req.Metode == "qris" {
+		req.JumlahDibayar = totalBayar
+	} else if req.JumlahDibayar < totalBayar {
+		return model.ErrorResponse(c, 400, "Jumlah dibayar kurang dari total tagihan ("+model.FormatRupiah(totalBayar)+")")
+	}
+	kembalian := model.HitungKembalian(req.JumlahDibayar, totalBayar)
+
+	var ref *string
+	if req.ReferensiQRIS != "" {
+		ref = &req.ReferensiQRIS
+	}
+
+	userID := c.Locals("user_id").(int)
+	_, err = tx.Exec(ctx,
+		`INSERT INTO pembayaran (id_tagihan, metode, jumlah_dibayar, kembalian, referensi_qris, id_kasir)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, req.Metode, req.JumlahDibayar, kembalian, ref, userID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan pembayaran: "+err.Error())
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE tagihan SET status = 'lunas', updated_at = NOW() WHERE id_tagihan = $1`, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memproses pembayaran")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memproses pembayaran")
+	}
+
+	t, _ := loadTagihan(id)
+	return model.SuccessResponse(c, 200, "Pembayaran berhasil, kembalian "+model.FormatRupiah(kembalian), t)
+}
+
+// ─── POST /kasir/tagihan/:id/batal ───────────────────────────────────────────
+
+func BatalTagihan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan tagihan")
+	}
+	defer tx.Rollback(ctx)
+
+	var idPem int
+	err = tx.QueryRow(ctx,
+		`UPDATE tagihan SET status = 'batal', updated_at = NOW()
+		 WHERE id_tagihan = $1 AND status = 'belum_lunas'
+		 RETURNING id_pemeriksaan`, id,
+	).Scan(&idPem)
+	if err == pgx.ErrNoRows {
+		return model.ErrorResponse(c, 409, "Tagihan tidak ditemukan atau sudah lunas/batal")
+	}
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan tagihan")
+	}
+
+	// Nominal pemeriksaan mengikuti tagihan aktif; tanpa tagihan berarti nol
+	if _, err := tx.Exec(ctx,
+		`UPDATE pemeriksaan SET nominal_pembayaran = 0, updated_at = NOW() WHERE id_pemeriksaan = $1`,
+		idPem); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan tagihan")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan tagihan")
+	}
+
+	return model.SuccessResponse(c, 200, "Tagihan berhasil dibatalkan", nil)
+}
+
+// ─── GET /kasir/tagihan/:id/kwitansi ─────────────────────────────────────────
+
+// GetKwitansi mengirim kwitansi lunas; format=pdf (default) untuk kertas A4
+// tinggi bebas, escpos / png untuk printer thermal
+func GetKwitansi(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	format, kertas, msg := parseFormatCetak(c)
+	if msg != "" {
+		return model.ErrorResponse(c, 400, msg)
+	}
+
+	t, err := loadTagihan(id)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tagihan tidak ditemukan")
+	}
+	if t.Status != "lunas" || t.Pembayaran == nil {
+		return model.ErrorResponse(c, 409, "Kwitansi hanya tersedia untuk tagihan yang sudah lunas")
+	}
+
+	if format != "pdf" {
+		s := layoutKwitansi(config.GetPuskesmasNama(), config.GetPuskesmasAlamat(), t, kertas)
+		return kirimStruk(c, s, format, "kwitansi-"+strconv.Itoa(t.IDTagihan))
+	}
+
+	// Kertas struk 80mm, tinggi menyesuaikan jumlah item
+	const width = 226.0
+	const left = 10.0
+	const right = width - 10.0
+	height := 270.0 + float64(len(t.Items))*24
+	doc := pdf.New(width, height)
+
+	y := 20.0
+	doc.TextCenter(y, 10, true, config.GetPuskesmasNama())
+	y += 12
+	if alamat := config.GetPuskesmasAlamat(); alamat != "" {
+		doc.TextCenter(y, 7, false, alamat)
+		y += 10
+	}
+	doc.TextCenter(y, 9, true, "KWITANSI PEMBAYARAN")
+	y += 8
+	doc.Line(left, y, right, y)
+	y += 12
+
+	row := func(label, value string, bold bool) {
+		doc.Text(left, y, 7, bold, label)
+		doc.Text(right-pdf.TextWidth(value, 7), y, 7, bold, value)
+		y += 10
+	}
+
+	row("No", t.NomorTagihan, false)
+	row("Tanggal", t.Pembayaran.DibayarAt.Format("02-01-2006 15:04"), false)
+	row("Pasien", t.NamaPasien, false)
+	row("Poli", t.NamaPoli, false)
+	row("Penjamin", t.NamaPenjamin, false)
+	doc.Line(left, y, right, y)
+	y += 12
+
+	for _, it := range t.Items {
+		doc.Text(left, y, 7, false, it.NamaItem)
+		y += 10
+		row(fmt.Sprintf("  %d x %s", it.Jumlah, model.FormatRupiah(it.HargaSatuan)), model.FormatRupiah(it.Subtotal), false)
+		y += 4
+	}
+	doc.Line(left, y, right, y)
+	y += 12
+
+	row("Total", model.FormatRupiah(t.Total), false)
+	if t.Ditanggung > 0 {
+		row("Ditanggung", "-"+model.FormatRupiah(t.Ditanggung), false)
+	}
+	if t.Diskon > 0 {
+		row("Diskon", "-"+model.FormatRupiah(t.Diskon), false)
+	}
+	row("Harus Dibayar", model.FormatRupiah(t.TotalBayar), true)
+	row("Dibayar ("+strings.ToUpper(t.Pembayaran.Metode)+")", model.FormatRupiah(t.Pembayaran.JumlahDibayar), false)
+	row("Kembalian", model.FormatRupiah(t.Pembayaran.Kembalian), false)
+	if t.Pembayaran.ReferensiQRIS != "" {
+		row("Ref QRIS", t.Pembayaran.ReferensiQRIS, false)
+	}
+	doc.Line(left, y, right, y)
+	y += 12
+
+	doc.TextCenter(y, 7, false, "Kasir: "+t.Pembayaran.NamaKasir)
+	y += 10
+	doc.TextCenter(y, 7, false, "Terima kasih, semoga lekas sembuh")
+
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf(`inline; filename="kwitansi-%d.pdf"`, t.IDTagihan))
+	return c.Send(doc.Bytes())
+}
+
+// ─── GET /kasir/tutup-kas ────────────────────────────────────────────────────
+
+func GetTutupKas(c *fiber.Ctx) error {
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+	if _, err := time.Parse("2006-01-02", tanggal); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal harus YYYY-MM-DD")
+	}
+
+	report := model.TutupKasReport{Tanggal: tanggal}
+
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*), COALESCE(SUM(t.total_bayar), 0), COALESCE(SUM(t.ditanggung), 0), COALESCE(SUM(t.diskon), 0)
+		 FROM pembayaran pb JOIN tagihan t ON pb.id_tagihan = t.id_tagihan
+		 WHERE pb.dibayar_at::date = $1`, tanggal,
+	).Scan(&report.JumlahTransaksi, &report.TotalPenerimaan, &report.TotalDitanggung, &report.TotalDiskon)
+
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM tagihan t JOIN pemeriksaan pe ON t.id_pemeriksaan = pe.id_pemeriksaan
+		 WHERE pe.tanggal_pemeriksaan = $1 AND t.status = 'belum_lunas'`, tanggal,
+	).Scan(&report.TagihanBelumLunas)
+
+	metodeRows, err := config.DB.Query(context.Background(),
+		`SELECT pb.metode, COUNT(*), COALESCE(SUM(t.total_bayar), 0)
+		 FROM pembayaran pb JOIN tagihan t ON pb.id_tagihan = t.id_tagihan
+		 WHERE pb.dibayar_at::date = $1
+		 GROUP BY pb.metode ORDER BY pb.metode`, tanggal)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan tutup kas")
+	}
+	defer metodeRows.Close()
+
+	report.PerMetode = []model.TutupKasMetode{}
+	for metodeRows.Next() {
+		var m model.TutupKasMetode
+		metodeRows.Scan(&m.Metode, &m.JumlahTransaksi, &m.Total)
+		report.PerMetode = append(report.PerMetode, m)
+	}
+
+	kasirRows, err := config.DB.Query(context.Background(),
+		`SELECT pb.id_kasir, u.nama, COUNT(*),
+			COALESCE(SUM(t.total_bayar) FILTER (WHERE pb.metode = 'tunai'), 0),
+			COALESCE(SUM(t.total_bayar) FILTER (WHERE pb.metode = 'qris'), 0)
+		 FROM pembayaran pb
+		 JOIN tagihan t ON pb.id_tagihan = t.id_tagihan
+		 JOIN users u ON pb.id_kasir = u.id
+		 WHERE pb.dibayar_at::date = $1
+		 GROUP BY pb.id_kasir, u.nama ORDER BY u.nama`, tanggal)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan tutup kas")
+	}
+	defer kasirRows.Close()
+
+	report.PerKasir = []model.TutupKasKasir{}
+	for kasirRows.Next() {
+		var k model.TutupKasKasir
+		kasirRows.Scan(&k.IDKasir, &k.NamaKasir, &k.JumlahTransaksi, &k.TotalTunai, &k.TotalQRIS)
+		report.PerKasir = append(report.PerKasir, k)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", report)
+}
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// kunciTagihanTerbuka mengunci baris tagihan dan memastikan masih bisa diubah
+func kunciTagihanTerbuka(ctx context.Context, tx pgx.Tx, id int) (int, string) {
+	var status string
+	err := tx.QueryRow(ctx,
+		`SELECT status FROM tagihan WHERE id_tagihan = $1 FOR UPDATE`, id,
+	).Scan(&status)
+	if err != nil {
+		return 404, "Tagihan tidak ditemukan"
+	}
+	if status != "belum_lunas" {
+		return 409, "Tagihan sudah " + strings.ReplaceAll(status, "_", " ") + " dan tidak bisa diubah"
+	}
+	return 0, ""
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const kioskSelectSQL = `SELECT id_kiosk, nama_kiosk, lokasi, key_prefix, izin, aktif,
+	to_char(terakhir_aktif, 'YYYY-MM-DD HH24:MI:SS')
+	FROM kiosk `
+
+func scanKiosk(row pgx.Row) (model.Kiosk, error) {
+	var k model.Kiosk
+	err := row.Scan(&k.IDKiosk, &k.NamaKiosk, &k.Lokasi, &k.KeyPrefix, &k.Izin, &k.Aktif, &k.TerakhirAktif)
+	return k, err
+}
+
+// ─── GET /perangkat-kiosk ────────────────────────────────────────────────────
+
+func GetAllKiosk(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(), kioskSelectSQL+`ORDER BY id_kiosk`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data kiosk")
+	}
+	defer rows.Close()
+
+	var kiosks []model.Kiosk
+	for rows.Next() {
+		k, _ := scanKiosk(rows)
+		kiosks = append(kiosks, k)
+	}
+
+	if kiosks == nil {
+		kiosks = []model.Kiosk{}
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", kiosks)
+}
+
+// ─── POST /perangkat-kiosk ───────────────────────────────────────────────────
+
+func CreateKiosk(c *fiber.Ctx) error {
+	var req model.KioskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaKiosk = strings.TrimSpace(req.NamaKiosk)
+	req.Lokasi = strings.TrimSpace(req.Lokasi)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	key, prefix := model.GenerateKioskKey()
+
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO kiosk (nama_kiosk, lokasi, key_prefix, key_hash, izin)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id_kiosk`,
+		req.NamaKiosk, req.Lokasi, prefix, model.HashKioskKey(key), model.IzinKioskBuatAntrian,
+	).Scan(&id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat kiosk")
+	}
+
+	catatAudit(c, "kiosk.buat", "kiosk", strconv.Itoa(id), req.NamaKiosk)
+
+	k, _ := scanKiosk(config.DB.QueryRow(context.Background(), kioskSelectSQL+`WHERE id_kiosk = $1`, id))
+	return model.SuccessResponse(c, 201, "Kiosk berhasil dibuat, simpan API key karena tidak akan ditampilkan lagi",
+		model.KioskKeyResponse{Kiosk: k, APIKey: key})
+}
+
+// ─── PUT /perangkat-kiosk/:id ────────────────────────────────────────────────
+
+func UpdateKiosk(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.KioskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaKiosk = strings.TrimSpace(req.NamaKiosk)
+	req.Lokasi = strings.TrimSpace(req.Lokasi)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE kiosk SET nama_kiosk = $1, lokasi = $2, aktif = COALESCE($3, aktif), updated_at = NOW()
+		 WHERE id_kiosk = $4`,
+		req.NamaKiosk, req.Lokasi, req.Aktif, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update kiosk")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Kiosk tidak ditemukan")
+	}
+
+	keterangan := req.NamaKiosk
+	if req.Aktif != nil {
+		keterangan += ", aktif=" + strconv.FormatBool(*req.Aktif)
+	}
+	catatAudit(c, "kiosk.ubah", "kiosk", strconv.Itoa(id), keterangan)
+
+	k, _ := scanKiosk(config.DB.QueryRow(context.Background(), kioskSelectSQL+`WHERE id_kiosk = $1`, id))
+	return model.SuccessResponse(c, 200, "Kiosk berhasil diupdate", k)
+}
+
+// ─── POST /perangkat-kiosk/:id/reset-key ─────────────────────────────────────
+
+// ResetKeyKiosk membuat API key baru; key lama langsung tidak berlaku
+func ResetKeyKiosk(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	key, prefix := model.GenerateKioskKey()
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE kiosk SET key_prefix = $1, key_hash = $2, updated_at = NOW() WHERE id_kiosk = $3`,
+		prefix, model.HashKioskKey(key), id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal reset API key kiosk")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Kiosk tidak ditemukan")
+	}
+
+	catatAudit(c, "kiosk.reset_key", "kiosk", strconv.Itoa(id), "")
+
+	k, _ := scanKiosk(config.DB.QueryRow(context.Background(), kioskSelectSQL+`WHERE id_kiosk = $1`, id))
+	return model.SuccessResponse(c, 200, "API key kiosk berhasil di-reset, simpan API key karena tidak akan ditampilkan lagi",
+		model.KioskKeyResponse{Kiosk: k, APIKey: key})
+}
+
+// ─── GET /kiosk/poli ─────────────────────────────────────────────────────────
+
+// GetPoliKiosk mengembalikan poli aktif untuk pilihan di layar kiosk
+func GetPoliKiosk(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(),
+		poliSelectSQL+`WHERE aktif = TRUE ORDER BY urutan, id_poli`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data poli")
+	}
+	defer rows.Close()
+
+	var polis []model.Poli
+	for rows.Next() {
+		p, _ := scanPoli(rows)
+		polis = append(polis, p)
+	}
+
+	if polis == nil {
+		polis = []model.Poli{}
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", polis)
+}
+
+// ─── POST /kiosk/antrian ─────────────────────────────────────────────────────
+
+// CreateAntrianKiosk mengambilkan nomor antrian hari ini untuk pasien yang
+// sudah terdaftar. Pasien dengan booking aktif di poli yang sama langsung
+// di-check-in. Setiap percobaan, berhasil atau ditolak, masuk audit log.
+func CreateAntrianKiosk(c *fiber.Ctx) error {
+	var req model.KioskAntrianRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NIK = strings.TrimSpace(req.NIK)
+	req.Prioritas = strings.TrimSpace(req.Prioritas)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	tanggal := time.Now().Format("2006-01-02")
+	tolak := func(code int, msg string) error {
+		catatAudit(c, "antrian.buat_ditolak", "pasien", req.NIK,
+			"poli "+strconv.Itoa(req.IDPoli)+": "+msg)
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	var idAntrian int
+	b, err := scanBooking(config.DB.QueryRow(context.Background(),
+		bookingSelectSQL+`WHERE b.nik = $1 AND b.tanggal = $2 AND b.status = 'aktif'`, req.NIK, tanggal))
+	if err == nil {
+		if b.IDPoli != req.IDPoli {
+			return tolak(409, "Pasien memiliki booking di "+b.NamaPoli+" hari ini, pilih poli tersebut")
+		}
+		if code, msg := checkinBooking(&b); code != 0 {
+			return tolak(code, msg)
+		}
+		idAntrian = *b.IDAntrian
+
+		// Kategori yang dipilih di kiosk menggantikan hasil otomatis saat check-in
+		if req.Prioritas != "" {
+			config.DB.Exec(context.Background(),
+				`UPDATE antrian SET prioritas = $1 WHERE id_antrian = $2`, req.Prioritas, idAntrian)
+		}
+	} else {
+		idPoli := req.IDPoli
+		var code int
+		var msg string
+		idAntrian, code, msg = buatAntrian(req.NIK, &idPoli, tanggal, req.Prioritas, 0)
+		if code != 0 {
+			return tolak(code, msg)
+		}
+	}
+
+	tiket, _ := loadTiketAntrian(idAntrian)
+
+	keterangan := tiket.KodeAntrian
+	if tiket.Prioritas != model.PrioritasReguler {
+		keterangan += " [" + tiket.Prioritas + "]"
+	}
+	if b.KodeBooking != "" {
+		keterangan += " (check-in booking " + b.KodeBooking + ")"
+	}
+	catatAudit(c, "antrian.buat", "antrian", strconv.Itoa(idAntrian), keterangan)
+
+	return model.SuccessResponse(c, 201, "Nomor antrian berhasil dibuat", tiket)
+}
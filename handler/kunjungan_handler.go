@@ -0,0 +1,737 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/suara"
+)
+
+const tahapSelectSQL = `SELECT id_tahap, kode_tahap, nama_tahap, urutan, per_poli, prefix_antrian, aktif
+	FROM tahap_layanan `
+
+func scanTahap(row pgx.Row) (model.TahapLayanan, error) {
+	var t model.TahapLayanan
+	err := row.Scan(&t.IDTahap, &t.KodeTahap, &t.NamaTahap, &t.Urutan, &t.PerPoli, &t.PrefixAntrian, &t.Aktif)
+	return t, err
+}
+
+const kunjunganSelectSQL = `SELECT k.id_kunjungan, k.nik, p.nama_pasien, k.id_poli, po.nama_poli, k.tanggal,
+	k.prioritas, k.status, k.id_tahap, t.nama_tahap,
+	to_char(k.created_at, 'YYYY-MM-DD HH24:MI:SS'), to_char(k.selesai_at, 'YYYY-MM-DD HH24:MI:SS')
+	FROM kunjungan k
+	JOIN pasien p ON k.nik = p.nik
+	JOIN poli po ON k.id_poli = po.id_poli
+	LEFT JOIN tahap_layanan t ON k.id_tahap = t.id_tahap
+	`
+
+func scanKunjungan(row pgx.Row) (model.Kunjungan, error) {
+	var k model.Kunjungan
+	var tg interface{}
+	err := row.Scan(&k.IDKunjungan, &k.NIK, &k.NamaPasien, &k.IDPoli, &k.NamaPoli, &tg,
+		&k.Prioritas, &k.Status, &k.IDTahap, &k.NamaTahap, &k.CreatedAt, &k.SelesaiAt)
+	k.Tanggal = formatDate(tg)
+	return k, err
+}
+
+// Tahap per_poli mengambil waktu panggil dan loket dari antrian poli selama
+// tahap masih berjalan; saat tahap ditutup nilainya disalin ke kunjungan_tahap
+const kunjunganTahapSelectSQL = `SELECT kt.id_kunjungan_tahap, kt.id_kunjungan, kt.id_tahap, t.nama_tahap,
+	kt.nomor_antrian, CASE WHEN t.per_poli THEN COALESCE(po.prefix_antrian, '') ELSE t.prefix_antrian END,
+	kt.id_antrian,
+	CASE WHEN kt.status = 'menunggu' AND a.dipanggil_at IS NOT NULL THEN 'dipanggil' ELSE kt.status END,
+	l.id_loket, l.nama_loket,
+	to_char(kt.masuk_at, 'YYYY-MM-DD HH24:MI:SS'),
+	to_char(COALESCE(kt.dipanggil_at, a.dipanggil_at), 'YYYY-MM-DD HH24:MI:SS'),
+	to_char(kt.selesai_at, 'YYYY-MM-DD HH24:MI:SS')
+	FROM kunjungan_tahap kt
+	JOIN tahap_layanan t ON kt.id_tahap = t.id_tahap
+	JOIN kunjungan k ON kt.id_kunjungan = k.id_kunjungan
+	JOIN poli po ON k.id_poli = po.id_poli
+	LEFT JOIN antrian a ON kt.id_antrian = a.id_antrian
+	LEFT JOIN loket l ON l.id_loket = COALESCE(kt.id_loket, a.id_loket)
+	`
+
+func scanKunjunganTahap(row pgx.Row) (model.KunjunganTahap, error) {
+	var kt model.KunjunganTahap
+	var prefix string
+	err := row.Scan(&kt.IDKunjunganTahap, &kt.IDKunjungan, &kt.IDTahap, &kt.NamaTahap,
+		&kt.NomorAntrian, &prefix, &kt.IDAntrian, &kt.Status, &kt.IDLoket, &kt.NamaLoket,
+		&kt.MasukAt, &kt.DipanggilAt, &kt.SelesaiAt)
+	kt.KodeAntrian = model.FormatKodeAntrian(prefix, kt.NomorAntrian)
+	return kt, err
+}
+
+// ─── GET /tahap-layanan ──────────────────────────────────────────────────────
+
+func GetAllTahapLayanan(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(), tahapSelectSQL+`ORDER BY urutan, id_tahap`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data tahap layanan")
+	}
+	defer rows.Close()
+
+	tahap := []model.TahapLayanan{}
+	for rows.Next() {
+		t, _ := scanTahap(rows)
+		tahap = append(tahap, t)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", tahap)
+}
+
+// ─── POST /tahap-layanan ─────────────────────────────────────────────────────
+
+func CreateTahapLayanan(c *fiber.Ctx) error {
+	var req model.TahapLayananRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodeTahap = strings.TrimSpace(req.KodeTahap)
+	req.NamaTahap = strings.TrimSpace(req.NamaTahap)
+	req.PrefixAntrian = strings.ToUpper(strings.TrimSpace(req.PrefixAntrian))
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO tahap_layanan (kode_tahap, nama_tahap, urutan, per_poli, prefix_antrian)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING id_tahap`,
+		req.KodeTahap, req.NamaTahap, req.Urutan, req.PerPoli, req.PrefixAntrian,
+	).Scan(&id)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Kode tahap sudah dipakai")
+		}
+		return model.ErrorResponse(c, 500, "Gagal membuat tahap layanan")
+	}
+
+	catatAudit(c, "tahap_layanan.buat", "tahap_layanan", strconv.Itoa(id), req.KodeTahap)
+
+	t, _ := scanTahap(config.DB.QueryRow(context.Background(), tahapSelectSQL+`WHERE id_tahap = $1`, id))
+	return model.SuccessResponse(c, 201, "Tahap layanan berhasil dibuat", t)
+}
+
+// ─── PUT /tahap-layanan/:id ──────────────────────────────────────────────────
+
+// UpdateTahapLayanan mengubah tahap; kunjungan yang sedang berada di tahap
+// ini tetap diselesaikan seperti biasa, perubahan urutan berlaku untuk
+// perpindahan berikutnya
+func UpdateTahapLayanan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.TahapLayananRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodeTahap = strings.TrimSpace(req.KodeTahap)
+	req.NamaTahap = strings.TrimSpace(req.NamaTahap)
+	req.PrefixAntrian = strings.ToUpper(strings.TrimSpace(req.PrefixAntrian))
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE tahap_layanan SET kode_tahap = $1, nama_tahap = $2, urutan = $3, per_poli = $4,
+			prefix_antrian = $5, aktif = COALESCE($6, aktif), updated_at = NOW()
+		 WHERE id_tahap = $7`,
+		req.KodeTahap, req.NamaTahap, req.Urutan, req.PerPoli, req.PrefixAntrian, req.Aktif, id)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Kode tahap sudah dipakai")
+		}
+		return model.ErrorResponse(c, 500, "Gagal update tahap layanan")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Tahap layanan tidak ditemukan")
+	}
+
+	keterangan := req.KodeTahap + ", urutan=" + strconv.Itoa(req.Urutan)
+	if req.Aktif != nil {
+		keterangan += ", aktif=" + strconv.FormatBool(*req.Aktif)
+	}
+	catatAudit(c, "tahap_layanan.ubah", "tahap_layanan", strconv.Itoa(id), keterangan)
+
+	t, _ := scanTahap(config.DB.QueryRow(context.Background(), tahapSelectSQL+`WHERE id_tahap = $1`, id))
+	return model.SuccessResponse(c, 200, "Tahap layanan berhasil diupdate", t)
+}
+
+// ─── GET /kunjungan ──────────────────────────────────────────────────────────
+
+func GetAllKunjungan(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	search := strings.TrimSpace(c.Query("search", ""))
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	status := strings.TrimSpace(c.Query("status", ""))
+	idTahap, _ := strconv.Atoi(c.Query("id_tahap", "0"))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	if tanggal == "" {
+		tanggal = time.Now().Format("2006-01-02")
+	}
+	offset := (page - 1) * perPage
+
+	cakupan, err := cakupanPoliUser(c, "kunjungan", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+
+	baseWhere := `WHERE k.tanggal = $1`
+	args := []interface{}{tanggal}
+	argIdx := 2
+
+	if !cakupan.semua {
+		baseWhere += ` AND k.id_poli = ANY($` + strconv.Itoa(argIdx) + `)`
+		args = append(args, cakupan.idPoli)
+		argIdx++
+	}
+	if status != "" {
+		baseWhere += ` AND k.status = $` + strconv.Itoa(argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+	if idTahap > 0 {
+		baseWhere += ` AND k.id_tahap = $` + strconv.Itoa(argIdx)
+		args = append(args, idTahap)
+		argIdx++
+	}
+	if search != "" {
+		baseWhere += ` AND (k.nik LIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR p.nama_pasien ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM kunjungan k JOIN pasien p ON k.nik = p.nik `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := kunjunganSelectSQL + baseWhere + `
+		ORDER BY k.created_at
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data kunjungan")
+	}
+	defer queryRows.Close()
+
+	rows := []model.Kunjungan{}
+	for queryRows.Next() {
+		k, _ := scanKunjungan(queryRows)
+		rows = append(rows, k)
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /kunjungan/:id ──────────────────────────────────────────────────────
+
+func GetKunjunganByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	k, err := detailKunjungan(id)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Kunjungan tidak ditemukan")
+	}
+
+	cakupan, err := cakupanPoliUser(c, "kunjungan", strconv.Itoa(id))
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.boleh(k.IDPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", k)
+}
+
+// ─── POST /kunjungan ─────────────────────────────────────────────────────────
+
+// CreateKunjungan membuka kunjungan hari ini dan langsung memasukkannya ke
+// antrian tahap pertama yang aktif
+func CreateKunjungan(c *fiber.Ctx) error {
+	var req model.CreateKunjunganRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NIK = strings.TrimSpace(req.NIK)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var pasienExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM pasien WHERE nik = $1)`, req.NIK,
+	).Scan(&pasienExists)
+	if !pasienExists {
+		return model.ErrorResponse(c, 404, "Pasien dengan NIK tersebut tidak ditemukan")
+	}
+	if code, msg := cekPoliAktif(req.IDPoli); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	tanggal := time.Now().Format("2006-01-02")
+	if req.Prioritas == "" {
+		req.Prioritas = prioritasOtomatis(req.NIK, tanggal)
+	}
+
+	pertama, err := scanTahap(config.DB.QueryRow(context.Background(),
+		tahapSelectSQL+`WHERE aktif = TRUE ORDER BY urutan, id_tahap LIMIT 1`))
+	if err != nil {
+		return model.ErrorResponse(c, 409, "Belum ada tahap layanan yang aktif")
+	}
+
+	k := kunjunganBerjalan{
+		nik:       req.NIK,
+		idPoli:    req.IDPoli,
+		tanggal:   tanggal,
+		prioritas: req.Prioritas,
+	}
+	antrianPoli, code, msg := siapkanAntrianTahap(k, pertama)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	ctx := context.Background()
+	err = func() error {
+		tx, err := config.DB.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if err := tx.QueryRow(ctx,
+			`INSERT INTO kunjungan (nik, id_poli, tanggal, prioritas) VALUES ($1, $2, $3, $4)
+			 RETURNING id_kunjungan`, k.nik, k.idPoli, k.tanggal, k.prioritas,
+		).Scan(&k.id); err != nil {
+			return err
+		}
+		if err := masukTahap(ctx, tx, k, pertama, antrianPoli); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}()
+	if err != nil {
+		batalkanAntrianTahap(antrianPoli)
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Pasien masih memiliki kunjungan aktif hari ini")
+		}
+		return model.ErrorResponse(c, 500, "Gagal membuat kunjungan")
+	}
+
+	detail, _ := detailKunjungan(k.id)
+	return model.SuccessResponse(c, 201, "Kunjungan dibuat, pasien masuk antrian "+pertama.NamaTahap, detail)
+}
+
+// ─── POST /kunjungan/:id/lanjut ──────────────────────────────────────────────
+
+// LanjutKunjungan menyelesaikan tahap berjalan dan memindahkan kunjungan ke
+// tahap aktif berikutnya. Tahap per_poli sekaligus menandai antrian poli
+// selesai. Jika tidak ada tahap lagi, kunjungan selesai.
+func LanjutKunjungan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var k kunjunganBerjalan
+	var status string
+	var urutan int
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT k.id_kunjungan, k.nik, k.id_poli, k.tanggal::text, k.prioritas, k.status, COALESCE(t.urutan, 0), k.id_tahap
+		 FROM kunjungan k LEFT JOIN tahap_layanan t ON k.id_tahap = t.id_tahap
+		 WHERE k.id_kunjungan = $1`, id,
+	).Scan(&k.id, &k.nik, &k.idPoli, &k.tanggal, &k.prioritas, &status, &urutan, &k.idTahap)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Kunjungan tidak ditemukan")
+	}
+	if status != "aktif" {
+		return model.ErrorResponse(c, 409, "Kunjungan sudah "+status)
+	}
+
+	// Tahap dengan urutan sama diurutkan menurut id agar tidak dilompati
+	berikut, errBerikut := scanTahap(config.DB.QueryRow(context.Background(),
+		tahapSelectSQL+`WHERE aktif = TRUE AND (urutan, id_tahap) > ($1, $2)
+		 ORDER BY urutan, id_tahap LIMIT 1`, urutan, k.idTahap))
+	adaBerikut := errBerikut == nil
+
+	var antrianPoli *antrianTahap
+	if adaBerikut {
+		var code int
+		var msg string
+		if antrianPoli, code, msg = siapkanAntrianTahap(k, berikut); code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
+	}
+
+	ctx := context.Background()
+	err = func() error {
+		tx, err := config.DB.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if err := tutupTahap(ctx, tx, k.id, model.TahapSelesai); err != nil {
+			return err
+		}
+		if adaBerikut {
+			if err := masukTahap(ctx, tx, k, berikut, antrianPoli); err != nil {
+				return err
+			}
+		} else if _, err := tx.Exec(ctx,
+			`UPDATE kunjungan SET status = 'selesai', id_tahap = NULL, selesai_at = NOW(), updated_at = NOW()
+			 WHERE id_kunjungan = $1`, k.id); err != nil {
+			return err
+		}
+		return tx.Commit(ctx)
+	}()
+	if err != nil {
+		batalkanAntrianTahap(antrianPoli)
+		return model.ErrorResponse(c, 500, "Gagal memindahkan kunjungan")
+	}
+
+	pesan := "Kunjungan selesai"
+	if adaBerikut {
+		pesan = "Pasien masuk antrian " + berikut.NamaTahap
+	}
+	detail, _ := detailKunjungan(k.id)
+	return model.SuccessResponse(c, 200, pesan, detail)
+}
+
+// ─── POST /kunjungan/:id/batal ───────────────────────────────────────────────
+
+// BatalKunjungan menghentikan kunjungan, misal pasien pulang sebelum selesai.
+// Antrian poli yang belum dipanggil ikut dihapus agar tidak muncul di layar.
+func BatalKunjungan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan kunjungan")
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	if err := tx.QueryRow(ctx,
+		`SELECT status FROM kunjungan WHERE id_kunjungan = $1 FOR UPDATE`, id,
+	).Scan(&status); err != nil {
+		return model.ErrorResponse(c, 404, "Kunjungan tidak ditemukan")
+	}
+	if status != "aktif" {
+		return model.ErrorResponse(c, 409, "Kunjungan sudah "+status)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM antrian WHERE id_antrian IN (
+			SELECT id_antrian FROM kunjungan_tahap WHERE id_kunjungan = $1 AND status IN ('menunggu', 'dipanggil'))
+		 AND status = 'belum_dikelola' AND dipanggil_at IS NULL`, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan kunjungan")
+	}
+	if err := tutupTahap(ctx, tx, id, model.TahapBatal); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan kunjungan")
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE kunjungan SET status = 'batal', selesai_at = NOW(), updated_at = NOW() WHERE id_kunjungan = $1`,
+		id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan kunjungan")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membatalkan kunjungan")
+	}
+
+	catatAudit(c, "kunjungan.batal", "kunjungan", strconv.Itoa(id), "")
+
+	detail, _ := detailKunjungan(id)
+	return model.SuccessResponse(c, 200, "Kunjungan dibatalkan", detail)
+}
+
+// ─── POST /kunjungan/panggil ─────────────────────────────────────────────────
+
+// PanggilTahapBerikutnya memanggil pasien berikutnya pada tahap non-poli
+// (misal pendaftaran atau farmasi), prioritas lebih dulu lalu nomor terkecil.
+// Antrian tahap per_poli dipanggil lewat /antrian/panggil.
+func PanggilTahapBerikutnya(c *fiber.Ctx) error {
+	var req model.PanggilTahapRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+	if req.IDTahap <= 0 {
+		return model.ErrorResponse(c, 400, "Tahap harus dipilih")
+	}
+
+	t, err := scanTahap(config.DB.QueryRow(context.Background(),
+		tahapSelectSQL+`WHERE id_tahap = $1`, req.IDTahap))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tahap layanan tidak ditemukan")
+	}
+	if t.PerPoli {
+		return model.ErrorResponse(c, 400, "Antrian tahap "+t.NamaTahap+" dipanggil dari antrian poli")
+	}
+	idLoket, code, msg := loketPemanggil(c, req.IDLoket)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memanggil antrian")
+	}
+	defer tx.Rollback(ctx)
+
+	var idKT int
+	err = tx.QueryRow(ctx,
+		`SELECT kt.id_kunjungan_tahap FROM kunjungan_tahap kt
+		 JOIN kunjungan k ON kt.id_kunjungan = k.id_kunjungan
+		 WHERE kt.id_tahap = $1 AND kt.tanggal = $2 AND kt.status = 'menunggu'
+		 ORDER BY `+rankPrioritasSQL("k.prioritas", peringkatPrioritas(false))+`, kt.nomor_antrian LIMIT 1
+		 FOR UPDATE OF kt SKIP LOCKED`, t.IDTahap, time.Now().Format("2006-01-02"),
+	).Scan(&idKT)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tidak ada antrian "+t.NamaTahap+" yang menunggu")
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE kunjungan_tahap SET status = 'dipanggil', dipanggil_at = NOW(), id_loket = $1
+		 WHERE id_kunjungan_tahap = $2`, idLoket, idKT); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memanggil antrian")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memanggil antrian")
+	}
+
+	kt, _ := scanKunjunganTahap(config.DB.QueryRow(ctx,
+		kunjunganTahapSelectSQL+`WHERE kt.id_kunjungan_tahap = $1`, idKT))
+	p := suara.Panggilan(t.PrefixAntrian, kt.NomorAntrian, tujuanPanggilan(kt.NamaLoket, t.NamaTahap))
+	kt.Pengumuman = &p
+	return model.SuccessResponse(c, 200, "Antrian "+kt.KodeAntrian+" dipanggil", kt)
+}
+
+// ─── GET /laporan/kunjungan ──────────────────────────────────────────────────
+
+// GetReportKunjungan menghitung waktu total kunjungan (dari dibuat sampai
+// tahap terakhir selesai) dan rata-rata tunggu serta layanan per tahap
+func GetReportKunjungan(c *fiber.Ctx) error {
+	hariIni := time.Now().Format("2006-01-02")
+	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", hariIni))
+	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", hariIni))
+
+	if _, err := time.Parse("2006-01-02", tanggalDari); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_dari harus YYYY-MM-DD")
+	}
+	if _, err := time.Parse("2006-01-02", tanggalSampai); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_sampai harus YYYY-MM-DD")
+	}
+
+	report := model.ReportKunjungan{
+		TanggalDari:   tanggalDari,
+		TanggalSampai: tanggalSampai,
+		PerTahap:      []model.LaporanTahap{},
+	}
+
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE status = 'selesai'), COUNT(*) FILTER (WHERE status = 'batal'),
+			ROUND(AVG(menit)::numeric, 1)::float8,
+			ROUND((percentile_cont(0.5) WITHIN GROUP (ORDER BY menit))::numeric, 1)::float8,
+			ROUND((percentile_cont(0.9) WITHIN GROUP (ORDER BY menit))::numeric, 1)::float8
+		 FROM (SELECT status,
+				CASE WHEN status = 'selesai' THEN EXTRACT(EPOCH FROM selesai_at - created_at) / 60 END AS menit
+			FROM kunjungan WHERE tanggal BETWEEN $1 AND $2) x`, tanggalDari, tanggalSampai,
+	).Scan(&report.Total, &report.Selesai, &report.Batal,
+		&report.RataTurnaroundMenit, &report.MedianTurnaroundMenit, &report.P90TurnaroundMenit)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan kunjungan")
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT t.id_tahap, t.nama_tahap, COUNT(kt.id_kunjungan_tahap),
+			ROUND((AVG(EXTRACT(EPOCH FROM kt.dipanggil_at - kt.masuk_at) / 60)
+				FILTER (WHERE kt.dipanggil_at >= kt.masuk_at))::numeric, 1)::float8,
+			ROUND((AVG(EXTRACT(EPOCH FROM kt.selesai_at - kt.dipanggil_at) / 60)
+				FILTER (WHERE kt.selesai_at >= kt.dipanggil_at))::numeric, 1)::float8
+		 FROM tahap_layanan t
+		 LEFT JOIN kunjungan_tahap kt ON kt.id_tahap = t.id_tahap AND kt.status = 'selesai'
+			AND kt.tanggal BETWEEN $1 AND $2
+		 GROUP BY t.id_tahap
+		 HAVING t.aktif OR COUNT(kt.id_kunjungan_tahap) > 0
+		 ORDER BY t.urutan, t.id_tahap`, tanggalDari, tanggalSampai)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan kunjungan")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l model.LaporanTahap
+		rows.Scan(&l.IDTahap, &l.NamaTahap, &l.Jumlah, &l.RataTungguMenit, &l.RataLayananMenit)
+		report.PerTahap = append(report.PerTahap, l)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", report)
+}
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// kunjunganBerjalan berisi data kunjungan yang dibutuhkan saat pindah tahap
+type kunjunganBerjalan struct {
+	id        int
+	nik       string
+	idPoli    int
+	tanggal   string
+	prioritas string
+	idTahap   *int
+}
+
+// antrianTahap adalah antrian poli untuk tahap per_poli; baru berarti dibuat
+// oleh kunjungan ini (bukan antrian kiosk/booking yang sudah ada) sehingga
+// perlu dihapus jika perpindahan tahap gagal
+type antrianTahap struct {
+	idAntrian int
+	nomor     int
+	baru      bool
+}
+
+// detailKunjungan mengambil kunjungan beserta riwayat tahapnya
+func detailKunjungan(id int) (model.Kunjungan, error) {
+	k, err := scanKunjungan(config.DB.QueryRow(context.Background(),
+		kunjunganSelectSQL+`WHERE k.id_kunjungan = $1`, id))
+	if err != nil {
+		return k, err
+	}
+
+	k.Tahap = []model.KunjunganTahap{}
+	rows, err := config.DB.Query(context.Background(),
+		kunjunganTahapSelectSQL+`WHERE kt.id_kunjungan = $1 ORDER BY kt.masuk_at, kt.id_kunjungan_tahap`, id)
+	if err != nil {
+		return k, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		kt, _ := scanKunjunganTahap(rows)
+		k.Tahap = append(k.Tahap, kt)
+	}
+	return k, nil
+}
+
+// siapkanAntrianTahap mengambil nomor antrian poli untuk tahap per_poli
+// sebelum transaksi perpindahan dimulai. Antrian hari ini yang sudah ada
+// (dari kiosk atau booking) dipakai ulang. Tahap lain mengembalikan nil.
+func siapkanAntrianTahap(k kunjunganBerjalan, t model.TahapLayanan) (*antrianTahap, int, string) {
+	if !t.PerPoli {
+		return nil, 0, ""
+	}
+
+	a := antrianTahap{}
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT id_antrian, nomor_antrian FROM antrian
+		 WHERE nik = $1 AND id_poli = $2 AND tanggal_kunjungan = $3 AND status = 'belum_dikelola'`,
+		k.nik, k.idPoli, k.tanggal,
+	).Scan(&a.idAntrian, &a.nomor)
+	if err == nil {
+		return &a, 0, ""
+	}
+
+	idPoli := k.idPoli
+	id, code, msg := buatAntrian(k.nik, &idPoli, k.tanggal, k.prioritas, 0)
+	if code != 0 {
+		return nil, code, msg
+	}
+	a.idAntrian, a.baru = id, true
+	config.DB.QueryRow(context.Background(),
+		`SELECT nomor_antrian FROM antrian WHERE id_antrian = $1`, id,
+	).Scan(&a.nomor)
+	return &a, 0, ""
+}
+
+// batalkanAntrianTahap menghapus antrian poli yang baru dibuat jika
+// perpindahan tahap gagal
+func batalkanAntrianTahap(a *antrianTahap) {
+	if a != nil && a.baru {
+		config.DB.Exec(context.Background(), `DELETE FROM antrian WHERE id_antrian = $1`, a.idAntrian)
+	}
+}
+
+// masukTahap mencatat kunjungan masuk ke antrian tahap t. Tahap non-poli
+// mendapat nomor urut per tahap per hari; lock advisory mencegah dua
+// petugas mendapat nomor yang sama.
+func masukTahap(ctx context.Context, tx pgx.Tx, k kunjunganBerjalan, t model.TahapLayanan, a *antrianTahap) error {
+	var idAntrian *int
+	nomor := 0
+	if a != nil {
+		idAntrian, nomor = &a.idAntrian, a.nomor
+	} else {
+		if _, err := tx.Exec(ctx,
+			`SELECT pg_advisory_xact_lock(hashtext('kunjungan_tahap'), $1)`, t.IDTahap); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(ctx,
+			`SELECT COALESCE(MAX(nomor_antrian), 0) + 1 FROM kunjungan_tahap WHERE id_tahap = $1 AND tanggal = $2`,
+			t.IDTahap, k.tanggal,
+		).Scan(&nomor); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO kunjungan_tahap (id_kunjungan, id_tahap, tanggal, nomor_antrian, id_antrian)
+		 VALUES ($1, $2, $3, $4, $5)`, k.id, t.IDTahap, k.tanggal, nomor, idAntrian); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		`UPDATE kunjungan SET id_tahap = $1, updated_at = NOW() WHERE id_kunjungan = $2`, t.IDTahap, k.id)
+	return err
+}
+
+// tutupTahap menutup tahap kunjungan yang masih berjalan. Untuk tahap
+// per_poli, antrian poli ditandai selesai dan waktu panggil/selesainya
+// disalin agar laporan per tahap memakai waktu layanan yang sebenarnya.
+func tutupTahap(ctx context.Context, tx pgx.Tx, idKunjungan int, status string) error {
+	if status == model.TahapSelesai {
+		if _, err := tx.Exec(ctx,
+			`UPDATE antrian SET status = 'sudah_dikelola', selesai_at = COALESCE(selesai_at, NOW()), updated_at = NOW()
+			 WHERE id_antrian IN (
+				SELECT id_antrian FROM kunjungan_tahap WHERE id_kunjungan = $1 AND status IN ('menunggu', 'dipanggil'))
+			 AND status = 'belum_dikelola'`, idKunjungan); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(ctx,
+		`UPDATE kunjungan_tahap kt SET status = $2,
+			dipanggil_at = COALESCE(kt.dipanggil_at, (SELECT dipanggil_at FROM antrian WHERE id_antrian = kt.id_antrian)),
+			id_loket = COALESCE(kt.id_loket, (SELECT id_loket FROM antrian WHERE id_antrian = kt.id_antrian)),
+			selesai_at = COALESCE((SELECT selesai_at FROM antrian WHERE id_antrian = kt.id_antrian), NOW())
+		 WHERE kt.id_kunjungan = $1 AND kt.status IN ('menunggu', 'dipanggil')`, idKunjungan, status)
+	return err
+}
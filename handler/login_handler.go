@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// Setelah gagal kedua untuk username yang sama, login berikutnya harus
+// menunggu tundaAwalLogin yang berlipat dua setiap gagal, paling lama
+// tundaMaksLogin
+const (
+	tundaAwalLogin = time.Second
+	tundaMaksLogin = time.Minute
+)
+
+func tundaLogin(gagal int) time.Duration {
+	if gagal < 2 {
+		return 0
+	}
+	tunda := tundaAwalLogin << (gagal - 2)
+	if tunda <= 0 || tunda > tundaMaksLogin {
+		return tundaMaksLogin
+	}
+	return tunda
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// hashPasswordDummy adalah hash bcrypt dengan cost yang sama dengan password
+// user, dibandingkan saat username tidak ditemukan supaya lama respons login
+// sama dengan password salah
+func hashPasswordDummy() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = model.HashPassword("sikupas-username-tidak-ada")
+	})
+	return dummyHash
+}
+
+func potong(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// cekKunciLogin mengembalikan sisa waktu tunggu untuk username dan IP ini;
+// terkunci berarti batas gagal sudah tercapai, bukan sekadar back-off
+func cekKunciLogin(username, ip string) (sisa time.Duration, terkunci bool) {
+	var detik *float64
+	var kunci *bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXTRACT(EPOCH FROM MAX(GREATEST(tunda_sampai, terkunci_sampai)) - NOW())::float8,
+			bool_or(terkunci_sampai > NOW())
+		 FROM login_kunci
+		 WHERE (jenis = 'username' AND nilai = $1) OR (jenis = 'ip' AND nilai = $2)`,
+		potong(username, 64), ip,
+	).Scan(&detik, &kunci)
+
+	if detik == nil || *detik <= 0 {
+		return 0, false
+	}
+	return time.Duration(*detik * float64(time.Second)), kunci != nil && *kunci
+}
+
+// catatGagalLogin menaikkan penghitung gagal username dan IP lalu menetapkan
+// back-off dan kunci. Back-off hanya untuk username; IP hanya dikunci saat
+// batas GetLoginMaksGagalIP tercapai karena satu IP NAT bisa dipakai seluruh
+// petugas puskesmas. Penghitung mulai dari nol lagi jika gagal terakhir
+// lebih lama dari GetLoginLamaKunci.
+func catatGagalLogin(username, ip string) (terkunci bool) {
+	lama := config.GetLoginLamaKunci()
+	for _, k := range []struct {
+		jenis, nilai string
+		maks         int
+		backoff      bool
+	}{
+		{model.KunciUsername, potong(username, 64), config.GetLoginMaksGagal(), true},
+		{model.KunciIP, ip, config.GetLoginMaksGagalIP(), false},
+	} {
+		var gagal int
+		err := config.DB.QueryRow(context.Background(),
+			`INSERT INTO login_kunci (jenis, nilai, gagal, terakhir_gagal_at) VALUES ($1, $2, 1, NOW())
+			 ON CONFLICT (jenis, nilai) DO UPDATE SET
+				gagal = CASE WHEN login_kunci.terakhir_gagal_at < NOW() - make_interval(secs => $3)
+					THEN 1 ELSE login_kunci.gagal + 1 END,
+				terakhir_gagal_at = NOW()
+			 RETURNING gagal`,
+			k.jenis, k.nilai, lama.Seconds(),
+		).Scan(&gagal)
+		if err != nil {
+			log.Printf("⚠️  Gagal mencatat login gagal %s %s: %v", k.jenis, k.nilai, err)
+			continue
+		}
+
+		kunci := gagal >= k.maks
+		var tunda time.Duration
+		if k.backoff {
+			tunda = tundaLogin(gagal)
+		}
+		config.DB.Exec(context.Background(),
+			`UPDATE login_kunci SET tunda_sampai = NOW() + make_interval(secs => $3),
+				terkunci_sampai = CASE WHEN $4 THEN NOW() + make_interval(secs => $5) END
+			 WHERE jenis = $1 AND nilai = $2`,
+			k.jenis, k.nilai, tunda.Seconds(), kunci, lama.Seconds())
+		if kunci && k.jenis == model.KunciUsername {
+			terkunci = true
+		}
+	}
+	return terkunci
+}
+
+// resetKunciLogin menghapus penghitung username setelah login berhasil.
+// Penghitung IP dibiarkan supaya satu akun valid tidak bisa dipakai untuk
+// menghapus jejak tebakan ke akun lain dari IP yang sama.
+func resetKunciLogin(username string) {
+	config.DB.Exec(context.Background(),
+		`DELETE FROM login_kunci WHERE jenis = 'username' AND nilai = $1`, username)
+}
+
+func catatPercobaanLogin(c *fiber.Ctx, username string, berhasil bool, keterangan string) {
+	_, err := config.DB.Exec(context.Background(),
+		`INSERT INTO login_percobaan (username, ip, user_agent, berhasil, keterangan)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		potong(username, 50), potong(c.IP(), 64), potong(c.Get("User-Agent"), 255), berhasil, keterangan)
+	if err != nil {
+		log.Printf("⚠️  Gagal mencatat percobaan login %s: %v", username, err)
+	}
+}
+
+// tolakLoginTerkunci mengembalikan 429 dengan header Retry-After
+func tolakLoginTerkunci(c *fiber.Ctx, sisa time.Duration, terkunci bool) error {
+	detik := int(math.Ceil(sisa.Seconds()))
+	c.Set("Retry-After", strconv.Itoa(detik))
+	if terkunci {
+		menit := int(math.Ceil(sisa.Minutes()))
+		return model.ErrorResponse(c, 429,
+			"Akun terkunci sementara karena terlalu banyak percobaan gagal, coba lagi dalam "+strconv.Itoa(menit)+" menit")
+	}
+	return model.ErrorResponse(c, 429, "Terlalu banyak percobaan login, coba lagi dalam "+strconv.Itoa(detik)+" detik")
+}
+
+// ─── GET /login-percobaan ────────────────────────────────────────────────────
+
+func GetAllLoginPercobaan(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "20"))
+	username := strings.TrimSpace(c.Query("username", ""))
+	ip := strings.TrimSpace(c.Query("ip", ""))
+	berhasil := strings.TrimSpace(c.Query("berhasil", ""))
+	tanggalMulai := strings.TrimSpace(c.Query("tanggal_mulai", ""))
+	tanggalSelesai := strings.TrimSpace(c.Query("tanggal_selesai", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 20
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if username != "" {
+		baseWhere += ` AND username = $` + strconv.Itoa(argIdx)
+		args = append(args, username)
+		argIdx++
+	}
+	if ip != "" {
+		baseWhere += ` AND ip = $` + strconv.Itoa(argIdx)
+		args = append(args, ip)
+		argIdx++
+	}
+	if berhasil == "true" || berhasil == "false" {
+		baseWhere += ` AND berhasil = $` + strconv.Itoa(argIdx)
+		args = append(args, berhasil == "true")
+		argIdx++
+	}
+	if tanggalMulai != "" {
+		baseWhere += ` AND created_at >= $` + strconv.Itoa(argIdx) + `::date`
+		args = append(args, tanggalMulai)
+		argIdx++
+	}
+	if tanggalSelesai != "" {
+		baseWhere += ` AND created_at < $` + strconv.Itoa(argIdx) + `::date + 1`
+		args = append(args, tanggalSelesai)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*) FROM login_percobaan `+baseWhere, args...,
+	).Scan(&totalData)
+
+	fetchSQL := `SELECT id_percobaan, username, ip, user_agent, berhasil, keterangan,
+		to_char(created_at, 'YYYY-MM-DD HH24:MI:SS')
+		FROM login_percobaan ` + baseWhere + `
+		ORDER BY id_percobaan DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data percobaan login")
+	}
+	defer queryRows.Close()
+
+	rows := []model.LoginPercobaan{}
+	for queryRows.Next() {
+		var p model.LoginPercobaan
+		queryRows.Scan(&p.IDPercobaan, &p.Username, &p.IP, &p.UserAgent, &p.Berhasil, &p.Keterangan, &p.CreatedAt)
+		rows = append(rows, p)
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── POST /users/:id/buka-kunci ──────────────────────────────────────────────
+
+// BukaKunciUser menghapus kunci dan penghitung gagal login sebuah akun
+func BukaKunciUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var username string
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT username FROM users WHERE id = $1`, id).Scan(&username)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "User tidak ditemukan")
+	}
+
+	resetKunciLogin(username)
+	catatAudit(c, "user.buka_kunci", "user", strconv.Itoa(id), username)
+
+	return model.SuccessResponse(c, 200, "Kunci login user berhasil dibuka", nil)
+}
+
+// ─── GET /login-kunci ────────────────────────────────────────────────────────
+
+// GetAllLoginKunci mendaftar username dan IP yang sedang ditunda atau dikunci
+func GetAllLoginKunci(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT jenis, nilai, gagal,
+			to_char(tunda_sampai, 'YYYY-MM-DD HH24:MI:SS'), to_char(terkunci_sampai, 'YYYY-MM-DD HH24:MI:SS')
+		 FROM login_kunci
+		 WHERE tunda_sampai > NOW() OR terkunci_sampai > NOW()
+		 ORDER BY jenis, terakhir_gagal_at DESC`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data kunci login")
+	}
+	defer rows.Close()
+
+	hasil := []model.LoginKunci{}
+	for rows.Next() {
+		var k model.LoginKunci
+		rows.Scan(&k.Jenis, &k.Nilai, &k.Gagal, &k.TundaSampai, &k.TerkunciSampai)
+		hasil = append(hasil, k)
+	}
+
+	return model.SuccessResponse(c, 200, "Data kunci login berhasil diambil", hasil)
+}
+
+// ─── DELETE /login-kunci/:jenis/:nilai ───────────────────────────────────────
+
+// HapusKunciLogin membuka kunci satu username atau IP, misalnya IP NAT
+// puskesmas yang terkunci karena banyak petugas salah ketik password
+func HapusKunciLogin(c *fiber.Ctx) error {
+	jenis := c.Params("jenis")
+	nilai := strings.TrimSpace(c.Params("nilai"))
+	if jenis != model.KunciUsername && jenis != model.KunciIP {
+		return model.ErrorResponse(c, 400, "Jenis kunci harus username atau ip")
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM login_kunci WHERE jenis = $1 AND nilai = $2`, jenis, nilai)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuka kunci login")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Kunci login tidak ditemukan")
+	}
+
+	catatAudit(c, "login.buka_kunci", "login_kunci", jenis+":"+nilai, "")
+
+	return model.SuccessResponse(c, 200, "Kunci login berhasil dibuka", nil)
+}
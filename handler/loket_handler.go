@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const loketSelectSQL = `SELECT l.id_loket, l.nama_loket, l.aktif, s.id_user, u.username,
+	to_char(s.mulai_at, 'YYYY-MM-DD HH24:MI:SS')
+	FROM loket l
+	LEFT JOIN sesi_loket s ON s.id_loket = l.id_loket AND s.selesai_at IS NULL
+	LEFT JOIN users u ON s.id_user = u.id
+	`
+
+func scanLoket(row pgx.Row) (model.Loket, error) {
+	var l model.Loket
+	err := row.Scan(&l.IDLoket, &l.NamaLoket, &l.Aktif, &l.IDPetugas, &l.Petugas, &l.MulaiAt)
+	return l, err
+}
+
+// ─── GET /loket ──────────────────────────────────────────────────────────────
+
+func GetAllLoket(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(), loketSelectSQL+`ORDER BY l.nama_loket`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data loket")
+	}
+	defer rows.Close()
+
+	lokets := []model.Loket{}
+	for rows.Next() {
+		l, _ := scanLoket(rows)
+		lokets = append(lokets, l)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", lokets)
+}
+
+// ─── GET /auth/loket ─────────────────────────────────────────────────────────
+
+// GetLoketLogin mendaftar loket aktif untuk pilihan di halaman login; hanya
+// id dan nama, tanpa data petugas
+func GetLoketLogin(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id_loket, nama_loket FROM loket WHERE aktif = TRUE ORDER BY nama_loket`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data loket")
+	}
+	defer rows.Close()
+
+	lokets := []model.Loket{}
+	for rows.Next() {
+		l := model.Loket{Aktif: true}
+		rows.Scan(&l.IDLoket, &l.NamaLoket)
+		lokets = append(lokets, l)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", lokets)
+}
+
+// ─── POST /loket ─────────────────────────────────────────────────────────────
+
+func CreateLoket(c *fiber.Ctx) error {
+	var req model.LoketRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaLoket = strings.TrimSpace(req.NamaLoket)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO loket (nama_loket) VALUES ($1) RETURNING id_loket`, req.NamaLoket,
+	).Scan(&id)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Nama loket sudah dipakai")
+		}
+		return model.ErrorResponse(c, 500, "Gagal membuat loket")
+	}
+
+	catatAudit(c, "loket.buat", "loket", strconv.Itoa(id), req.NamaLoket)
+
+	l, _ := scanLoket(config.DB.QueryRow(context.Background(), loketSelectSQL+`WHERE l.id_loket = $1`, id))
+	return model.SuccessResponse(c, 201, "Loket berhasil dibuat", l)
+}
+
+// ─── PUT /loket/:id ──────────────────────────────────────────────────────────
+
+// UpdateLoket mengubah nama atau status loket. Loket yang dinonaktifkan
+// langsung ditinggalkan petugasnya.
+func UpdateLoket(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.LoketRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaLoket = strings.TrimSpace(req.NamaLoket)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE loket SET nama_loket = $1, aktif = COALESCE($2, aktif), updated_at = NOW() WHERE id_loket = $3`,
+		req.NamaLoket, req.Aktif, id)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Nama loket sudah dipakai")
+		}
+		return model.ErrorResponse(c, 500, "Gagal update loket")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Loket tidak ditemukan")
+	}
+
+	keterangan := req.NamaLoket
+	if req.Aktif != nil {
+		keterangan += ", aktif=" + strconv.FormatBool(*req.Aktif)
+		if !*req.Aktif {
+			config.DB.Exec(context.Background(),
+				`UPDATE sesi_loket SET selesai_at = NOW() WHERE id_loket = $1 AND selesai_at IS NULL`, id)
+		}
+	}
+	catatAudit(c, "loket.ubah", "loket", strconv.Itoa(id), keterangan)
+
+	l, _ := scanLoket(config.DB.QueryRow(context.Background(), loketSelectSQL+`WHERE l.id_loket = $1`, id))
+	return model.SuccessResponse(c, 200, "Loket berhasil diupdate", l)
+}
+
+// ─── PUT /me/loket ───────────────────────────────────────────────────────────
+
+// PilihLoket memindahkan petugas yang login ke loket lain, atau meninggalkan
+// loket jika id_loket kosong
+func PilihLoket(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(int)
+
+	var req model.PilihLoketRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if code, msg := pilihLoket(userID, req.IDLoket); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	return GetCurrentUser(c)
+}
+
+// ─── GET /laporan/loket ──────────────────────────────────────────────────────
+
+// GetReportLoket menghitung throughput tiap loket: jumlah panggilan, pasien
+// yang dilayani, dan pasien dilayani per jam sesi petugas
+func GetReportLoket(c *fiber.Ctx) error {
+	hariIni := time.Now().Format("2006-01-02")
+	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", hariIni))
+	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", hariIni))
+
+	if _, err := time.Parse("2006-01-02", tanggalDari); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_dari harus YYYY-MM-DD")
+	}
+	if _, err := time.Parse("2006-01-02", tanggalSampai); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_sampai harus YYYY-MM-DD")
+	}
+
+	// Sesi yang lupa ditutup dihitung sampai akhir hari mulainya
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT l.id_loket, l.nama_loket,
+			COALESCE(a.dipanggil, 0), COALESCE(a.dilayani, 0), COALESCE(a.tidak_hadir, 0),
+			ROUND(a.rata_layanan::numeric, 1)::float8,
+			ROUND((COALESCE(s.detik, 0) / 3600)::numeric, 2)::float8
+		 FROM loket l
+		 LEFT JOIN (
+			SELECT id_loket, COUNT(*) AS dipanggil,
+				COUNT(*) FILTER (WHERE status = 'sudah_dikelola') AS dilayani,
+				COUNT(*) FILTER (WHERE status = 'tidak_hadir') AS tidak_hadir,
+				AVG(EXTRACT(EPOCH FROM selesai_at - dipanggil_at) / 60) FILTER (WHERE selesai_at > dipanggil_at) AS rata_layanan
+			FROM antrian
+			WHERE id_loket IS NOT NULL AND tanggal_kunjungan BETWEEN $1 AND $2
+			GROUP BY id_loket
+		 ) a ON a.id_loket = l.id_loket
+		 LEFT JOIN (
+			SELECT id_loket, SUM(EXTRACT(EPOCH FROM
+				COALESCE(selesai_at, LEAST(LOCALTIMESTAMP, mulai_at::date + 1)) - mulai_at)) AS detik
+			FROM sesi_loket
+			WHERE mulai_at::date BETWEEN $1 AND $2
+			GROUP BY id_loket
+		 ) s ON s.id_loket = l.id_loket
+		 WHERE l.aktif OR a.dipanggil > 0
+		 ORDER BY l.nama_loket`, tanggalDari, tanggalSampai)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan loket")
+	}
+	defer rows.Close()
+
+	report := model.ReportLoket{
+		TanggalDari:   tanggalDari,
+		TanggalSampai: tanggalSampai,
+		PerLoket:      []model.LaporanLoket{},
+	}
+	for rows.Next() {
+		var l model.LaporanLoket
+		rows.Scan(&l.IDLoket, &l.NamaLoket, &l.Dipanggil, &l.Dilayani, &l.TidakHadir,
+			&l.RataLayananMenit, &l.JamBertugas)
+		if l.JamBertugas > 0 {
+			perJam := float64(int(float64(l.Dilayani)/l.JamBertugas*10+0.5)) / 10
+			l.DilayaniPerJam = &perJam
+		}
+		report.PerLoket = append(report.PerLoket, l)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", report)
+}
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// pilihLoket menutup sesi loket petugas yang masih terbuka lalu membuka sesi
+// di loket baru (jika idLoket diisi). Petugas lain yang masih tercatat di
+// loket tersebut dianggap sudah berganti shift. Mengembalikan kode HTTP dan
+// pesan error, atau 0 jika berhasil.
+func pilihLoket(userID int, idLoket *int) (int, string) {
+	if idLoket != nil {
+		if code, msg := cekLoketAktif(*idLoket); code != 0 {
+			return code, msg
+		}
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return 500, "Gagal memilih loket"
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE sesi_loket SET selesai_at = NOW()
+		 WHERE selesai_at IS NULL AND (id_user = $1 OR id_loket = $2)`, userID, idLoket); err != nil {
+		return 500, "Gagal memilih loket"
+	}
+	if idLoket != nil {
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO sesi_loket (id_loket, id_user) VALUES ($1, $2)`, *idLoket, userID); err != nil {
+			return 500, "Gagal memilih loket"
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 500, "Gagal memilih loket"
+	}
+	return 0, ""
+}
+
+// cekLoketAktif memastikan loket ada dan masih aktif
+func cekLoketAktif(idLoket int) (int, string) {
+	var aktif bool
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT aktif FROM loket WHERE id_loket = $1`, idLoket,
+	).Scan(&aktif)
+	if err != nil {
+		return 404, "Loket tidak ditemukan"
+	}
+	if !aktif {
+		return 409, "Loket sedang tidak aktif"
+	}
+	return 0, ""
+}
+
+// loketPemanggil menentukan loket untuk panggilan: id_loket dari request,
+// atau loket yang sedang dipegang petugas. Bisa nil jika petugas tidak
+// memegang loket (misal dokter memanggil langsung dari poli).
+func loketPemanggil(c *fiber.Ctx, idLoket *int) (*int, int, string) {
+	if idLoket != nil {
+		if code, msg := cekLoketAktif(*idLoket); code != 0 {
+			return nil, code, msg
+		}
+		return idLoket, 0, ""
+	}
+
+	userID, _ := c.Locals("user_id").(int)
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT s.id_loket FROM sesi_loket s JOIN loket l ON s.id_loket = l.id_loket
+		 WHERE s.id_user = $1 AND s.selesai_at IS NULL AND l.aktif`, userID,
+	).Scan(&id)
+	if err != nil {
+		return nil, 0, ""
+	}
+	return &id, 0, ""
+}
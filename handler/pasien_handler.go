@@ -4,6 +4,7 @@ import (
 	"context"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"sikupas/backend/config"
@@ -100,6 +101,8 @@ func GetPasienByNIK(c *fiber.Ctx) error {
 	}
 
 	p.TanggalLahir = formatDate(tl)
+	tidakHadir := jumlahTidakHadir(p.NIK)
+	p.JumlahTidakHadir = &tidakHadir
 	return model.SuccessResponse(c, 200, "Berhasil", p)
 }
 
@@ -179,6 +182,8 @@ func UpdatePasien(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 500, "Gagal update pasien: "+err.Error())
 	}
 
+	tandaiSinkronUlangSatusehat("Patient", nik)
+
 	return model.SuccessResponse(c, 200, "Pasien berhasil diupdate", model.PasienResponse{
 		NIK:          nik,
 		NamaPasien:   req.NamaPasien,
@@ -226,6 +231,8 @@ func formatDate(val interface{}) string {
 			return s[:10]
 		}
 		return s
+	case time.Time:
+		return v.Format("2006-01-02")
 	default:
 		return ""
 	}
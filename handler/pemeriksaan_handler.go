@@ -2,37 +2,54 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"log"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/bpjs"
 	"sikupas/backend/config"
 	"sikupas/backend/model"
 )
 
-// ─── GET /poli ───────────────────────────────────────────────────────────────
-
-func GetAllPoli(c *fiber.Ctx) error {
-	rows, err := config.DB.Query(context.Background(),
-		`SELECT id_poli, nama_poli, nama_dokter FROM poli ORDER BY id_poli`)
-	if err != nil {
-		return model.ErrorResponse(c, 500, "Gagal mengambil data poli")
-	}
-	defer rows.Close()
-
-	var polis []model.Poli
-	for rows.Next() {
-		var p model.Poli
-		rows.Scan(&p.IDPoli, &p.NamaPoli, &p.NamaDokter)
-		polis = append(polis, p)
-	}
-
-	if polis == nil {
-		polis = []model.Poli{}
+// Nama dokter diambil dari dokter pemeriksa; data lama tanpa id_dokter
+// memakai dokter default poli
+const pemeriksaanSelectSQL = `SELECT pe.id_pemeriksaan, pe.nik_pasien, p.nama_pasien,
+	pe.tanggal_pemeriksaan, pe.id_antrian, COALESCE(pa.prefix_antrian, ''), an.nomor_antrian, pe.id_kunjungan,
+	pe.keluhan, pe.id_poli, po.nama_poli,
+	pe.id_dokter, COALESCE(d.nama_dokter, po.nama_dokter),
+	pe.metode_pembayaran, COALESCE(pj.nama_penjamin, pe.metode_pembayaran), pe.nomor_penjamin,
+	pe.nominal_pembayaran, pe.kode_diagnosa, bs.status
+	FROM pemeriksaan pe
+	JOIN pasien p ON pe.nik_pasien = p.nik
+	JOIN poli po ON pe.id_poli = po.id_poli
+	LEFT JOIN dokter d ON pe.id_dokter = d.id_dokter
+	LEFT JOIN penjamin pj ON pe.metode_pembayaran = pj.kode_penjamin
+	LEFT JOIN bpjs_sinkron bs ON pe.id_pemeriksaan = bs.id_pemeriksaan
+	LEFT JOIN antrian an ON pe.id_antrian = an.id_antrian
+	LEFT JOIN poli pa ON an.id_poli = pa.id_poli
+	`
+
+func scanPemeriksaan(row pgx.Row) (model.PemeriksaanResponse, error) {
+	var pm model.PemeriksaanResponse
+	var tp interface{}
+	var prefix string
+	var nomor *int
+	err := row.Scan(&pm.IDPemeriksaan, &pm.NIKPasien, &pm.NamaPasien,
+		&tp, &pm.IDAntrian, &prefix, &nomor, &pm.IDKunjungan,
+		&pm.Keluhan, &pm.IDPoli, &pm.NamaPoli,
+		&pm.IDDokter, &pm.NamaDokter,
+		&pm.MetodePembayaran, &pm.NamaPenjamin, &pm.NomorPenjamin,
+		&pm.NominalPembayaran, &pm.KodeDiagnosa, &pm.StatusBPJS)
+	pm.TanggalPemeriksaan = formatDate(tp)
+	if nomor != nil {
+		kode := model.FormatKodeAntrian(prefix, *nomor)
+		pm.KodeAntrian = &kode
 	}
-
-	return model.SuccessResponse(c, 200, "Berhasil", polis)
+	return pm, err
 }
 
 // ─── GET /pemeriksaan ────────────────────────────────────────────────────────
@@ -51,6 +68,11 @@ func GetAllPemeriksaan(c *fiber.Ctx) error {
 	}
 	offset := (page - 1) * perPage
 
+	cakupan, err := cakupanPoliUser(c, "pemeriksaan", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+
 	var totalData int
 	var rows []model.PemeriksaanResponse
 
@@ -58,6 +80,12 @@ func GetAllPemeriksaan(c *fiber.Ctx) error {
 	args := []interface{}{}
 	argIdx := 1
 
+	if !cakupan.semua {
+		baseWhere += ` AND pe.id_poli = ANY($` + strconv.Itoa(argIdx) + `)`
+		args = append(args, cakupan.idPoli)
+		argIdx++
+	}
+
 	if tanggal != "" {
 		baseWhere += ` AND pe.tanggal_pemeriksaan = $` + strconv.Itoa(argIdx)
 		args = append(args, tanggal)
@@ -75,13 +103,7 @@ func GetAllPemeriksaan(c *fiber.Ctx) error {
 	config.DB.QueryRow(context.Background(), countSQL, args...).Scan(&totalData)
 
 	// Fetch
-	fetchSQL := `SELECT pe.id_pemeriksaan, pe.nik_pasien, p.nama_pasien,
-		pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli, po.nama_dokter,
-		pe.metode_pembayaran, pe.nominal_pembayaran
-		FROM pemeriksaan pe
-		JOIN pasien p ON pe.nik_pasien = p.nik
-		JOIN poli po ON pe.id_poli = po.id_poli
-		` + baseWhere + `
+	fetchSQL := pemeriksaanSelectSQL + baseWhere + `
 		ORDER BY pe.tanggal_pemeriksaan DESC, pe.id_pemeriksaan DESC
 		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
 
@@ -94,12 +116,7 @@ func GetAllPemeriksaan(c *fiber.Ctx) error {
 	defer queryRows.Close()
 
 	for queryRows.Next() {
-		var pm model.PemeriksaanResponse
-		var tp interface{}
-		queryRows.Scan(&pm.IDPemeriksaan, &pm.NIKPasien, &pm.NamaPasien,
-			&tp, &pm.Keluhan, &pm.NamaPoli, &pm.NamaDokter,
-			&pm.MetodePembayaran, &pm.NominalPembayaran)
-		pm.TanggalPemeriksaan = formatDate(tp)
+		pm, _ := scanPemeriksaan(queryRows)
 		rows = append(rows, pm)
 	}
 
@@ -118,25 +135,20 @@ func GetPemeriksaanByID(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 400, "ID tidak valid")
 	}
 
-	var pm model.PemeriksaanResponse
-	var tp interface{}
-	err = config.DB.QueryRow(context.Background(),
-		`SELECT pe.id_pemeriksaan, pe.nik_pasien, p.nama_pasien,
-			pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli, po.nama_dokter,
-			pe.metode_pembayaran, pe.nominal_pembayaran
-			FROM pemeriksaan pe
-			JOIN pasien p ON pe.nik_pasien = p.nik
-			JOIN poli po ON pe.id_poli = po.id_poli
-			WHERE pe.id_pemeriksaan = $1`, id,
-	).Scan(&pm.IDPemeriksaan, &pm.NIKPasien, &pm.NamaPasien,
-		&tp, &pm.Keluhan, &pm.NamaPoli, &pm.NamaDokter,
-		&pm.MetodePembayaran, &pm.NominalPembayaran)
-
+	pm, err := scanPemeriksaan(config.DB.QueryRow(context.Background(),
+		pemeriksaanSelectSQL+`WHERE pe.id_pemeriksaan = $1`, id))
 	if err != nil {
 		return model.ErrorResponse(c, 404, "Pemeriksaan tidak ditemukan")
 	}
 
-	pm.TanggalPemeriksaan = formatDate(tp)
+	cakupan, err := cakupanPoliUser(c, "pemeriksaan", strconv.Itoa(id))
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.boleh(pm.IDPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
 	return model.SuccessResponse(c, 200, "Berhasil", pm)
 }
 
@@ -149,13 +161,20 @@ func CreatePemeriksaan(c *fiber.Ctx) error {
 	}
 
 	req.NIKPasien = strings.TrimSpace(req.NIKPasien)
+	req.TanggalPemeriksaan = strings.TrimSpace(req.TanggalPemeriksaan)
 	req.Keluhan = strings.TrimSpace(req.Keluhan)
 	req.MetodePembayaran = strings.TrimSpace(req.MetodePembayaran)
+	req.NomorPenjamin = strings.TrimSpace(req.NomorPenjamin)
+	req.KodeDiagnosa = strings.ToUpper(strings.TrimSpace(req.KodeDiagnosa))
 
 	if errs := req.Validate(); len(errs) > 0 {
 		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
 	}
 
+	if code, msg := cekPenjamin(req.MetodePembayaran, req.NomorPenjamin, false); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
 	// Cek pasien ada
 	var pasienExists bool
 	config.DB.QueryRow(context.Background(),
@@ -165,55 +184,112 @@ func CreatePemeriksaan(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 404, "Pasien tidak ditemukan")
 	}
 
-	// Cek poli ada
-	var poliExists bool
-	config.DB.QueryRow(context.Background(),
-		`SELECT EXISTS(SELECT 1 FROM poli WHERE id_poli = $1)`, req.IDPoli,
-	).Scan(&poliExists)
-	if !poliExists {
-		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	// Cek poli ada dan aktif
+	if code, msg := cekPoliAktif(req.IDPoli); code != 0 {
+		return model.ErrorResponse(c, code, msg)
 	}
 
-	// Jika BPJS, set nominal = 0
-	if req.MetodePembayaran == "BPJS" {
-		req.NominalPembayaran = 0
+	cakupan, err := cakupanPoliUser(c, "pemeriksaan", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.boleh(req.IDPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
+	// Tiket antrian/kunjungan harus milik pasien yang sama pada tanggal yang
+	// sama; tanggal pemeriksaan mengikuti tiket untuk input susulan
+	asal, code, msg := cariAsalPemeriksaan(req.NIKPasien, req.IDPoli, req.TanggalPemeriksaan, req.IDAntrian, req.IDKunjungan, 0)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+	tanggalPem := asal.tanggal
+
+	// Cek dokter pemeriksa sesuai jadwal praktik
+	if req.IDDokter != nil {
+		if msg := cekJadwalDokter(*req.IDDokter, req.IDPoli, tanggalPem); msg != "" {
+			return model.ErrorResponse(c, 400, msg)
+		}
+	}
+
+	// Bridging BPJS hanya untuk penjamin jenis bpjs dan bila client dikonfigurasi
+	bpjsClient := config.GetBPJSClient()
+	bridging := bpjsClient != nil && isPenjaminBPJS(req.MetodePembayaran)
+	cekEligibilitas := config.BPJSCekEligibilitasDefault()
+	if req.CekEligibilitas != nil {
+		cekEligibilitas = *req.CekEligibilitas
+	}
+	kirimBPJS := config.BPJSKirimKunjunganDefault()
+	if req.KirimBPJS != nil {
+		kirimBPJS = *req.KirimBPJS
+	}
+
+	// Peserta tidak aktif ditolak; gangguan koneksi ke BPJS tidak menghalangi
+	// pendaftaran, hanya dicatat di bpjs_sinkron
+	var pesertaAktif *bool
+	var ketPeserta string
+	if bridging && cekEligibilitas {
+		peserta, err := bpjsClient.CekPeserta(context.Background(), req.NomorPenjamin)
+		var be *bpjs.Error
+		switch {
+		case errors.As(err, &be):
+			return model.ErrorResponse(c, 400, "Kepesertaan BPJS tidak valid: "+be.Message)
+		case err != nil:
+			ketPeserta = "Gagal cek kepesertaan: " + err.Error()
+		case !peserta.Aktif:
+			return model.ErrorResponse(c, 400, "Peserta BPJS tidak aktif: "+peserta.KetAktif)
+		default:
+			pesertaAktif = &peserta.Aktif
+			ketPeserta = peserta.KetAktif
+		}
 	}
 
-	tanggalHari := time.Now().Format("2006-01-02")
-
 	var idPem int
-	err := config.DB.QueryRow(context.Background(),
-		`INSERT INTO pemeriksaan (nik_pasien, tanggal_pemeriksaan, keluhan, id_poli, metode_pembayaran, nominal_pembayaran)
-		 VALUES ($1, $2, $3, $4, $5, $6)
+	err = config.DB.QueryRow(context.Background(),
+		`INSERT INTO pemeriksaan (nik_pasien, tanggal_pemeriksaan, id_antrian, id_kunjungan, keluhan, id_poli, id_dokter, metode_pembayaran, nomor_penjamin, kode_diagnosa)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), NULLIF($10, ''))
 		 RETURNING id_pemeriksaan`,
-		req.NIKPasien, tanggalHari, req.Keluhan, req.IDPoli, req.MetodePembayaran, req.NominalPembayaran,
+		req.NIKPasien, tanggalPem, asal.idAntrian, asal.idKunjungan, req.Keluhan, req.IDPoli, req.IDDokter, req.MetodePembayaran, req.NomorPenjamin, req.KodeDiagnosa,
 	).Scan(&idPem)
 
 	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Antrian tersebut sudah memiliki pemeriksaan")
+		}
 		return model.ErrorResponse(c, 500, "Gagal membuat pemeriksaan: "+err.Error())
 	}
 
-	// Auto update status antrian -> sudah_dikelola
-	config.DB.Exec(context.Background(),
-		`UPDATE antrian SET status = 'sudah_dikelola', updated_at = NOW()
-		 WHERE nik = $1 AND tanggal_kunjungan = $2 AND status = 'belum_dikelola'`,
-		req.NIKPasien, tanggalHari)
+	if tanggalPem < time.Now().Format("2006-01-02") {
+		catatAudit(c, "pemeriksaan.susulan", "pemeriksaan", strconv.Itoa(idPem), "tanggal="+tanggalPem)
+	}
+
+	// Gagal kirim tidak membatalkan pemeriksaan; status bisa dilihat dan
+	// dikirim ulang lewat /pemeriksaan/:id/bpjs
+	if bridging && (cekEligibilitas || kirimBPJS) {
+		_, err := config.DB.Exec(context.Background(),
+			`INSERT INTO bpjs_sinkron (id_pemeriksaan, peserta_aktif, ket_peserta) VALUES ($1, $2, $3)`,
+			idPem, pesertaAktif, ketPeserta)
+		if err != nil {
+			log.Printf("⚠️  Gagal mencatat sinkron BPJS pemeriksaan %d: %v", idPem, err)
+		} else if kirimBPJS {
+			if k, msg := dataKunjunganBPJS(idPem); msg == "" {
+				if _, err := kirimKunjunganBPJS(bpjsClient, idPem, k); err != nil {
+					log.Printf("⚠️  Gagal menyimpan hasil kirim BPJS pemeriksaan %d: %v", idPem, err)
+				}
+			} else {
+				config.DB.Exec(context.Background(),
+					`UPDATE bpjs_sinkron SET pesan_error = $1, updated_at = NOW() WHERE id_pemeriksaan = $2`,
+					msg, idPem)
+			}
+		}
+	}
+
+	// Hanya antrian asal yang ditandai sudah_dikelola
+	selesaikanAntrianPemeriksaan(asal)
 
 	// Ambil data lengkap untuk response
-	var pm model.PemeriksaanResponse
-	var tp interface{}
-	config.DB.QueryRow(context.Background(),
-		`SELECT pe.id_pemeriksaan, pe.nik_pasien, p.nama_pasien,
-			pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli, po.nama_dokter,
-			pe.metode_pembayaran, pe.nominal_pembayaran
-			FROM pemeriksaan pe
-			JOIN pasien p ON pe.nik_pasien = p.nik
-			JOIN poli po ON pe.id_poli = po.id_poli
-			WHERE pe.id_pemeriksaan = $1`, idPem,
-	).Scan(&pm.IDPemeriksaan, &pm.NIKPasien, &pm.NamaPasien,
-		&tp, &pm.Keluhan, &pm.NamaPoli, &pm.NamaDokter,
-		&pm.MetodePembayaran, &pm.NominalPembayaran)
-	pm.TanggalPemeriksaan = formatDate(tp)
+	pm, _ := scanPemeriksaan(config.DB.QueryRow(context.Background(),
+		pemeriksaanSelectSQL+`WHERE pe.id_pemeriksaan = $1`, idPem))
 
 	return model.SuccessResponse(c, 201, "Pemeriksaan berhasil ditambahkan", pm)
 }
@@ -227,56 +303,139 @@ func UpdatePemeriksaan(c *fiber.Ctx) error {
 	}
 
 	// Cek pemeriksaan ada
-	var exists bool
-	config.DB.QueryRow(context.Background(),
-		`SELECT EXISTS(SELECT 1 FROM pemeriksaan WHERE id_pemeriksaan = $1)`, id,
-	).Scan(&exists)
-	if !exists {
+	var tanggalPem interface{}
+	var idPoliLama int
+	var metodeLama string
+	var idAntrianLama, idKunjunganLama *int
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT tanggal_pemeriksaan, id_poli, metode_pembayaran, id_antrian, id_kunjungan
+		 FROM pemeriksaan WHERE id_pemeriksaan = $1`, id,
+	).Scan(&tanggalPem, &idPoliLama, &metodeLama, &idAntrianLama, &idKunjunganLama)
+	if err != nil {
 		return model.ErrorResponse(c, 404, "Pemeriksaan tidak ditemukan")
 	}
 
+	// Poli lama dan poli tujuan harus sama-sama dalam cakupan user
+	cakupan, err := cakupanPoliUser(c, "pemeriksaan", strconv.Itoa(id))
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+	if !cakupan.boleh(idPoliLama) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
 	var req model.UpdatePemeriksaanRequest
 	if err := c.BodyParser(&req); err != nil {
 		return model.ErrorResponse(c, 400, "Format request body tidak valid")
 	}
 
 	req.NIKPasien = strings.TrimSpace(req.NIKPasien)
+	req.TanggalPemeriksaan = strings.TrimSpace(req.TanggalPemeriksaan)
 	req.Keluhan = strings.TrimSpace(req.Keluhan)
 	req.MetodePembayaran = strings.TrimSpace(req.MetodePembayaran)
+	req.NomorPenjamin = strings.TrimSpace(req.NomorPenjamin)
+	req.KodeDiagnosa = strings.ToUpper(strings.TrimSpace(req.KodeDiagnosa))
 
 	if errs := req.Validate(); len(errs) > 0 {
 		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
 	}
+	if !cakupan.boleh(req.IDPoli) {
+		return model.ErrorResponse(c, 403, pesanDiluarPoli)
+	}
+
+	if code, msg := cekPenjamin(req.MetodePembayaran, req.NomorPenjamin, req.MetodePembayaran == metodeLama); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	// Tanggal, pasien dan tiket asal harus tetap saling cocok setelah diubah
+	if req.TanggalPemeriksaan == "" {
+		req.TanggalPemeriksaan = formatDate(tanggalPem)
+	}
+	if req.IDAntrian == nil {
+		req.IDAntrian = idAntrianLama
+	}
+	if req.IDKunjungan == nil {
+		req.IDKunjungan = idKunjunganLama
+	}
+	asal, code, msg := cariAsalPemeriksaan(req.NIKPasien, req.IDPoli, req.TanggalPemeriksaan, req.IDAntrian, req.IDKunjungan, id)
+	if code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	// Poli lama boleh dipertahankan walau sudah nonaktif; pindah poli harus ke poli aktif
+	if req.IDPoli != idPoliLama {
+		if code, msg := cekPoliAktif(req.IDPoli); code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
+	}
+
+	// Tagihan yang sudah lunas tidak bisa berganti penjamin
+	if req.MetodePembayaran != metodeLama {
+		var lunas bool
+		config.DB.QueryRow(context.Background(),
+			`SELECT EXISTS(SELECT 1 FROM tagihan WHERE id_pemeriksaan = $1 AND status = 'lunas')`, id,
+		).Scan(&lunas)
+		if lunas {
+			return model.ErrorResponse(c, 409, "Metode pembayaran tidak bisa diubah karena tagihan sudah lunas")
+		}
+	}
 
-	if req.MetodePembayaran == "BPJS" {
-		req.NominalPembayaran = 0
+	if req.IDDokter != nil {
+		if msg := cekJadwalDokter(*req.IDDokter, req.IDPoli, asal.tanggal); msg != "" {
+			return model.ErrorResponse(c, 400, msg)
+		}
 	}
 
-	_, err = config.DB.Exec(context.Background(),
-		`UPDATE pemeriksaan SET nik_pasien=$1, keluhan=$2, id_poli=$3,
-		 metode_pembayaran=$4, nominal_pembayaran=$5, updated_at=NOW()
-		 WHERE id_pemeriksaan=$6`,
-		req.NIKPasien, req.Keluhan, req.IDPoli, req.MetodePembayaran, req.NominalPembayaran, id)
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai transaksi")
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE pemeriksaan SET nik_pasien=$1, keluhan=$2, id_poli=$3, id_dokter=$4,
+		 metode_pembayaran=$5, nomor_penjamin=NULLIF($6, ''), kode_diagnosa=NULLIF($7, ''),
+		 tanggal_pemeriksaan=$8, id_antrian=$9, id_kunjungan=$10, updated_at=NOW()
+		 WHERE id_pemeriksaan=$11`,
+		req.NIKPasien, req.Keluhan, req.IDPoli, req.IDDokter, req.MetodePembayaran, req.NomorPenjamin, req.KodeDiagnosa,
+		asal.tanggal, asal.idAntrian, asal.idKunjungan, id)
 
 	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Antrian tersebut sudah memiliki pemeriksaan")
+		}
 		return model.ErrorResponse(c, 500, "Gagal update pemeriksaan: "+err.Error())
 	}
 
+	// Penjamin bisa berubah, tagihan yang belum lunas dihitung ulang
+	var idTagihan int
+	err = tx.QueryRow(ctx,
+		`SELECT id_tagihan FROM tagihan WHERE id_pemeriksaan = $1 AND status = 'belum_lunas'`, id,
+	).Scan(&idTagihan)
+	if err == nil {
+		if err := hitungUlangTagihan(ctx, tx, idTagihan); err != nil {
+			return model.ErrorResponse(c, 500, "Gagal menghitung ulang tagihan: "+err.Error())
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan pemeriksaan")
+	}
+
+	if asal.tanggal != formatDate(tanggalPem) {
+		catatAudit(c, "pemeriksaan.ubah_tanggal", "pemeriksaan", strconv.Itoa(id),
+			formatDate(tanggalPem)+" -> "+asal.tanggal)
+	}
+	selesaikanAntrianPemeriksaan(asal)
+
+	idLokal := strconv.Itoa(id)
+	tandaiSinkronUlangSatusehat("Encounter", idLokal)
+	tandaiSinkronUlangSatusehat("Condition", idLokal)
+
 	// Ambil data lengkap
-	var pm model.PemeriksaanResponse
-	var tp interface{}
-	config.DB.QueryRow(context.Background(),
-		`SELECT pe.id_pemeriksaan, pe.nik_pasien, p.nama_pasien,
-			pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli, po.nama_dokter,
-			pe.metode_pembayaran, pe.nominal_pembayaran
-			FROM pemeriksaan pe
-			JOIN pasien p ON pe.nik_pasien = p.nik
-			JOIN poli po ON pe.id_poli = po.id_poli
-			WHERE pe.id_pemeriksaan = $1`, id,
-	).Scan(&pm.IDPemeriksaan, &pm.NIKPasien, &pm.NamaPasien,
-		&tp, &pm.Keluhan, &pm.NamaPoli, &pm.NamaDokter,
-		&pm.MetodePembayaran, &pm.NominalPembayaran)
-	pm.TanggalPemeriksaan = formatDate(tp)
+	pm, _ := scanPemeriksaan(config.DB.QueryRow(context.Background(),
+		pemeriksaanSelectSQL+`WHERE pe.id_pemeriksaan = $1`, id))
 
 	return model.SuccessResponse(c, 200, "Pemeriksaan berhasil diupdate", pm)
 }
@@ -289,6 +448,10 @@ func DeletePemeriksaan(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 400, "ID tidak valid")
 	}
 
+	if code, msg := cakupanPemeriksaan(c, id); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
 	result, err := config.DB.Exec(context.Background(),
 		`DELETE FROM pemeriksaan WHERE id_pemeriksaan = $1`, id)
 
@@ -302,6 +465,148 @@ func DeletePemeriksaan(c *fiber.Ctx) error {
 	return model.SuccessResponse(c, 200, "Pemeriksaan berhasil dihapus", nil)
 }
 
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// asalPemeriksaan adalah tiket antrian dan kunjungan asal pemeriksaan
+type asalPemeriksaan struct {
+	idAntrian   *int
+	idKunjungan *int
+	idPoli      *int // poli antrian, untuk memperbarui ringkasan harian
+	tanggal     string
+}
+
+// cariAsalPemeriksaan memastikan antrian dan kunjungan yang dirujuk milik
+// pasien dan poli yang sama dan tanggalnya sama dengan tanggal pemeriksaan. Tanggal
+// kosong diambil dari tiket, atau hari ini. Pemeriksaan baru (idPemeriksaan
+// 0) tanpa rujukan otomatis ditautkan ke satu-satunya antrian pasien di poli
+// tersebut pada tanggal itu. Mengembalikan kode HTTP dan pesan error, atau 0
+// jika valid.
+func cariAsalPemeriksaan(nik string, idPoli int, tanggal string, idAntrian, idKunjungan *int, idPemeriksaan int) (asalPemeriksaan, int, string) {
+	asal := asalPemeriksaan{idAntrian: idAntrian, idKunjungan: idKunjungan, tanggal: tanggal}
+	ctx := context.Background()
+
+	cocokkan := func(jenis, nikTiket, tanggalTiket string) (int, string) {
+		if nikTiket != nik {
+			return 400, jenis + " tersebut milik pasien lain"
+		}
+		if asal.tanggal == "" {
+			asal.tanggal = tanggalTiket
+		} else if asal.tanggal != tanggalTiket {
+			return 400, jenis + " tersebut untuk tanggal " + tanggalTiket + ", bukan " + asal.tanggal
+		}
+		return 0, ""
+	}
+
+	if idKunjungan != nil {
+		var nikK, tanggalK string
+		var poliK int
+		if err := config.DB.QueryRow(ctx,
+			`SELECT nik, tanggal::text, id_poli FROM kunjungan WHERE id_kunjungan = $1`, *idKunjungan,
+		).Scan(&nikK, &tanggalK, &poliK); err != nil {
+			return asal, 404, "Kunjungan tidak ditemukan"
+		}
+		if code, msg := cocokkan("Kunjungan", nikK, tanggalK); code != 0 {
+			return asal, code, msg
+		}
+		if poliK != idPoli {
+			return asal, 400, "Kunjungan tersebut untuk poli lain"
+		}
+		if asal.idAntrian == nil {
+			var id int
+			if err := config.DB.QueryRow(ctx,
+				`SELECT id_antrian FROM kunjungan_tahap
+				 WHERE id_kunjungan = $1 AND id_antrian IS NOT NULL ORDER BY masuk_at DESC LIMIT 1`, *idKunjungan,
+			).Scan(&id); err == nil {
+				asal.idAntrian = &id
+			}
+		}
+	}
+
+	if asal.idAntrian == nil && asal.idKunjungan == nil && idPemeriksaan == 0 {
+		if asal.tanggal == "" {
+			asal.tanggal = time.Now().Format("2006-01-02")
+		}
+		var ids []int
+		rows, err := config.DB.Query(ctx,
+			`SELECT a.id_antrian FROM antrian a
+			 WHERE a.nik = $1 AND a.id_poli = $2 AND a.tanggal_kunjungan = $3
+			   AND NOT EXISTS(SELECT 1 FROM pemeriksaan pe WHERE pe.id_antrian = a.id_antrian)`,
+			nik, idPoli, asal.tanggal)
+		if err == nil {
+			for rows.Next() {
+				var id int
+				rows.Scan(&id)
+				ids = append(ids, id)
+			}
+			rows.Close()
+		}
+		if len(ids) == 1 {
+			asal.idAntrian = &ids[0]
+		}
+	}
+
+	if asal.idAntrian != nil {
+		var nikA, tanggalA string
+		if err := config.DB.QueryRow(ctx,
+			`SELECT nik, tanggal_kunjungan::text, id_poli FROM antrian WHERE id_antrian = $1`, *asal.idAntrian,
+		).Scan(&nikA, &tanggalA, &asal.idPoli); err != nil {
+			return asal, 404, "Antrian tidak ditemukan"
+		}
+		if code, msg := cocokkan("Antrian", nikA, tanggalA); code != 0 {
+			return asal, code, msg
+		}
+		// Antrian lama tanpa poli tetap boleh dipakai
+		if asal.idPoli != nil && *asal.idPoli != idPoli {
+			return asal, 400, "Antrian tersebut untuk poli lain"
+		}
+
+		var dipakai bool
+		config.DB.QueryRow(ctx,
+			`SELECT EXISTS(SELECT 1 FROM pemeriksaan WHERE id_antrian = $1 AND id_pemeriksaan <> $2)`,
+			*asal.idAntrian, idPemeriksaan,
+		).Scan(&dipakai)
+		if dipakai {
+			return asal, 409, "Antrian tersebut sudah memiliki pemeriksaan"
+		}
+
+		if asal.idKunjungan == nil {
+			var id int
+			if err := config.DB.QueryRow(ctx,
+				`SELECT id_kunjungan FROM kunjungan_tahap WHERE id_antrian = $1`, *asal.idAntrian,
+			).Scan(&id); err == nil {
+				asal.idKunjungan = &id
+			}
+		}
+	}
+
+	if asal.tanggal == "" {
+		asal.tanggal = time.Now().Format("2006-01-02")
+	}
+	return asal, 0, ""
+}
+
+// selesaikanAntrianPemeriksaan menandai antrian asal sudah dilayani. Waktu
+// selesai hanya dicatat untuk hari ini agar input susulan tidak merusak
+// estimasi durasi layanan; ringkasan hari yang sudah ditutup dihitung ulang.
+func selesaikanAntrianPemeriksaan(asal asalPemeriksaan) {
+	if asal.idAntrian == nil {
+		return
+	}
+
+	ctx := context.Background()
+	config.DB.Exec(ctx,
+		`UPDATE antrian SET status = 'sudah_dikelola',
+			selesai_at = CASE WHEN tanggal_kunjungan = CURRENT_DATE THEN COALESCE(selesai_at, NOW()) ELSE selesai_at END,
+			updated_at = NOW()
+		 WHERE id_antrian = $1 AND status <> 'sudah_dikelola'`, *asal.idAntrian)
+
+	if asal.tanggal < time.Now().Format("2006-01-02") {
+		if err := tutupAntrianPoli(ctx, asal.tanggal, asal.idPoli); err != nil {
+			log.Printf("⚠️  Gagal menghitung ulang ringkasan antrian %s: %v", asal.tanggal, err)
+		}
+	}
+}
+
 // ─── GET /laporan/pasien ─────────────────────────────────────────────────────
 
 func GetReportPasien(c *fiber.Ctx) error {
@@ -372,6 +677,7 @@ func GetReportPemeriksaan(c *fiber.Ctx) error {
 	search := strings.TrimSpace(c.Query("search", ""))
 	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", ""))
 	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", ""))
+	metode := strings.TrimSpace(c.Query("metode_pembayaran", ""))
 
 	if page < 1 { page = 1 }
 	if perPage < 1 || perPage > 100 { perPage = 10 }
@@ -391,6 +697,11 @@ func GetReportPemeriksaan(c *fiber.Ctx) error {
 		args = append(args, tanggalSampai)
 		argIdx++
 	}
+	if metode != "" {
+		baseWhere += ` AND pe.metode_pembayaran = $` + strconv.Itoa(argIdx)
+		args = append(args, metode)
+		argIdx++
+	}
 	if search != "" {
 		baseWhere += ` AND (p.nik LIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR p.nama_pasien ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
 		args = append(args, search)
@@ -402,11 +713,12 @@ func GetReportPemeriksaan(c *fiber.Ctx) error {
 	config.DB.QueryRow(context.Background(), countSQL, args...).Scan(&totalData)
 
 	fetchSQL := `SELECT pe.id_pemeriksaan, pe.nik_pasien, p.nama_pasien,
-		pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli, po.nama_dokter,
+		pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli, COALESCE(d.nama_dokter, po.nama_dokter),
 		pe.metode_pembayaran, pe.nominal_pembayaran
 		FROM pemeriksaan pe
 		JOIN pasien p ON pe.nik_pasien = p.nik
 		JOIN poli po ON pe.id_poli = po.id_poli
+		LEFT JOIN dokter d ON pe.id_dokter = d.id_dokter
 		` + baseWhere + `
 		ORDER BY pe.tanggal_pemeriksaan DESC
 		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
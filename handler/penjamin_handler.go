@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const penjaminSelectSQL = `SELECT kode_penjamin, nama_penjamin, jenis, wajib_nomor, panjang_nomor, aktif
+	FROM penjamin
+	`
+
+func scanPenjamin(row pgx.Row) (model.Penjamin, error) {
+	var p model.Penjamin
+	err := row.Scan(&p.KodePenjamin, &p.NamaPenjamin, &p.Jenis, &p.WajibNomor, &p.PanjangNomor, &p.Aktif)
+	return p, err
+}
+
+// cekPenjamin cek penjamin ada, aktif, dan nomor penjamin pasien sesuai
+// aturannya. Mengembalikan kode HTTP dan pesan; 0 berarti valid.
+// Penjamin nonaktif hanya boleh dipertahankan pada data lama.
+func cekPenjamin(kode, nomor string, bolehNonaktif bool) (int, string) {
+	p, err := scanPenjamin(config.DB.QueryRow(context.Background(),
+		penjaminSelectSQL+`WHERE kode_penjamin = $1`, kode))
+	if err != nil {
+		return 400, "Metode pembayaran '" + kode + "' tidak terdaftar"
+	}
+	if !p.Aktif && !bolehNonaktif {
+		return 409, "Penjamin " + p.NamaPenjamin + " sedang tidak aktif"
+	}
+	if msg := p.ValidasiNomor(nomor); msg != "" {
+		return 400, msg
+	}
+	return 0, ""
+}
+
+// ─── GET /penjamin ───────────────────────────────────────────────────────────
+
+func GetAllPenjamin(c *fiber.Ctx) error {
+	aktif := strings.TrimSpace(c.Query("aktif", ""))
+
+	where := ``
+	args := []interface{}{}
+	if aktif == "true" || aktif == "false" {
+		where = `WHERE aktif = $1 `
+		args = append(args, aktif == "true")
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		penjaminSelectSQL+where+`ORDER BY nama_penjamin`, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data penjamin")
+	}
+	defer rows.Close()
+
+	penjamin := []model.Penjamin{}
+	for rows.Next() {
+		p, _ := scanPenjamin(rows)
+		penjamin = append(penjamin, p)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", penjamin)
+}
+
+// ─── GET /penjamin/:kode ─────────────────────────────────────────────────────
+
+func GetPenjaminByKode(c *fiber.Ctx) error {
+	p, err := scanPenjamin(config.DB.QueryRow(context.Background(),
+		penjaminSelectSQL+`WHERE kode_penjamin = $1`, c.Params("kode")))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Penjamin tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", p)
+}
+
+// ─── POST /penjamin ──────────────────────────────────────────────────────────
+
+func CreatePenjamin(c *fiber.Ctx) error {
+	var req model.PenjaminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodePenjamin = strings.TrimSpace(req.KodePenjamin)
+	req.NamaPenjamin = strings.TrimSpace(req.NamaPenjamin)
+	req.Jenis = strings.TrimSpace(req.Jenis)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	aktif := true
+	if req.Aktif != nil {
+		aktif = *req.Aktif
+	}
+
+	p, err := scanPenjamin(config.DB.QueryRow(context.Background(),
+		`INSERT INTO penjamin (kode_penjamin, nama_penjamin, jenis, wajib_nomor, panjang_nomor, aktif)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING kode_penjamin, nama_penjamin, jenis, wajib_nomor, panjang_nomor, aktif`,
+		req.KodePenjamin, req.NamaPenjamin, req.Jenis, req.WajibNomor, req.PanjangNomor, aktif))
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Kode penjamin sudah terdaftar")
+		}
+		return model.ErrorResponse(c, 500, "Gagal menambah penjamin: "+err.Error())
+	}
+
+	return model.SuccessResponse(c, 201, "Penjamin berhasil ditambahkan", p)
+}
+
+// ─── PUT /penjamin/:kode ─────────────────────────────────────────────────────
+
+// UpdatePenjamin tidak mengubah kode karena kode tersimpan di pemeriksaan
+func UpdatePenjamin(c *fiber.Ctx) error {
+	kode := c.Params("kode")
+
+	var req model.PenjaminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodePenjamin = kode
+	req.NamaPenjamin = strings.TrimSpace(req.NamaPenjamin)
+	req.Jenis = strings.TrimSpace(req.Jenis)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	p, err := scanPenjamin(config.DB.QueryRow(context.Background(),
+		`UPDATE penjamin SET nama_penjamin=$1, jenis=$2, wajib_nomor=$3, panjang_nomor=$4,
+		 aktif=COALESCE($5, aktif), updated_at=NOW()
+		 WHERE kode_penjamin=$6
+		 RETURNING kode_penjamin, nama_penjamin, jenis, wajib_nomor, panjang_nomor, aktif`,
+		req.NamaPenjamin, req.Jenis, req.WajibNomor, req.PanjangNomor, req.Aktif, kode))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Penjamin tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Penjamin berhasil diupdate", p)
+}
+
+// ─── GET /laporan/pendapatan ─────────────────────────────────────────────────
+
+// GetReportPendapatan merekap tagihan per penjamin berdasarkan tanggal
+// pemeriksaan. Tagihan batal tidak dihitung.
+func GetReportPendapatan(c *fiber.Ctx) error {
+	hariIni := time.Now().Format("2006-01-02")
+	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", hariIni))
+	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", hariIni))
+
+	if _, err := time.Parse("2006-01-02", tanggalDari); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_dari harus YYYY-MM-DD")
+	}
+	if _, err := time.Parse("2006-01-02", tanggalSampai); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_sampai harus YYYY-MM-DD")
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT pj.kode_penjamin, pj.nama_penjamin,
+			COUNT(pe.id_pemeriksaan),
+			COUNT(t.id_tagihan),
+			COALESCE(SUM(t.total), 0),
+			COALESCE(SUM(t.ditanggung), 0),
+			COALESCE(SUM(t.diskon), 0),
+			COALESCE(SUM(t.total_bayar) FILTER (WHERE t.status = 'lunas'), 0),
+			COALESCE(SUM(t.total_bayar) FILTER (WHERE t.status = 'belum_lunas'), 0)
+		 FROM penjamin pj
+		 LEFT JOIN pemeriksaan pe ON pe.metode_pembayaran = pj.kode_penjamin
+			AND pe.tanggal_pemeriksaan BETWEEN $1 AND $2
+		 LEFT JOIN tagihan t ON t.id_pemeriksaan = pe.id_pemeriksaan AND t.status <> 'batal'
+		 GROUP BY pj.kode_penjamin, pj.nama_penjamin
+		 ORDER BY pj.nama_penjamin`, tanggalDari, tanggalSampai)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan pendapatan")
+	}
+	defer rows.Close()
+
+	report := model.ReportPendapatan{
+		TanggalDari:   tanggalDari,
+		TanggalSampai: tanggalSampai,
+		PerPenjamin:   []model.PendapatanPenjamin{},
+	}
+	for rows.Next() {
+		var p model.PendapatanPenjamin
+		rows.Scan(&p.KodePenjamin, &p.NamaPenjamin, &p.JumlahPemeriksaan, &p.JumlahTagihan,
+			&p.TotalTagihan, &p.Ditanggung, &p.Diskon, &p.DibayarPasien, &p.BelumLunas)
+		report.TotalTagihan += p.TotalTagihan
+		report.Ditanggung += p.Ditanggung
+		report.DibayarPasien += p.DibayarPasien
+		report.PerPenjamin = append(report.PerPenjamin, p)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", report)
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+const poliSelectSQL = `SELECT id_poli, nama_poli, nama_dokter, aktif, urutan,
+	to_char(jam_buka, 'HH24:MI'), to_char(jam_tutup, 'HH24:MI'), kuota_harian, COALESCE(prefix_antrian, ''),
+	COALESCE(kode_poli_bpjs, ''), durasi_slot, kuota_slot
+	FROM poli `
+
+func scanPoli(row pgx.Row) (model.Poli, error) {
+	var p model.Poli
+	err := row.Scan(&p.IDPoli, &p.NamaPoli, &p.NamaDokter, &p.Aktif, &p.Urutan,
+		&p.JamBuka, &p.JamTutup, &p.KuotaHarian, &p.PrefixAntrian, &p.KodePoliBPJS, &p.DurasiSlot, &p.KuotaSlot)
+	return p, err
+}
+
+// ─── GET /poli ───────────────────────────────────────────────────────────────
+
+func GetAllPoli(c *fiber.Ctx) error {
+	aktif := strings.TrimSpace(c.Query("aktif", ""))
+
+	where := ``
+	args := []interface{}{}
+	if aktif == "true" || aktif == "false" {
+		where = `WHERE aktif = $1 `
+		args = append(args, aktif == "true")
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		poliSelectSQL+where+`ORDER BY urutan, id_poli`, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data poli")
+	}
+	defer rows.Close()
+
+	var polis []model.Poli
+	for rows.Next() {
+		p, _ := scanPoli(rows)
+		polis = append(polis, p)
+	}
+
+	if polis == nil {
+		polis = []model.Poli{}
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", polis)
+}
+
+// ─── GET /poli/:id ───────────────────────────────────────────────────────────
+
+func GetPoliByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	p, err := scanPoli(config.DB.QueryRow(context.Background(), poliSelectSQL+`WHERE id_poli = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", p)
+}
+
+// ─── POST /poli ──────────────────────────────────────────────────────────────
+
+func CreatePoli(c *fiber.Ctx) error {
+	var req model.PoliRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaPoli = strings.TrimSpace(req.NamaPoli)
+	req.NamaDokter = strings.TrimSpace(req.NamaDokter)
+	req.JamBuka = strings.TrimSpace(req.JamBuka)
+	req.JamTutup = strings.TrimSpace(req.JamTutup)
+	req.PrefixAntrian = strings.ToUpper(strings.TrimSpace(req.PrefixAntrian))
+	req.KodePoliBPJS = strings.TrimSpace(req.KodePoliBPJS)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	// Poli baru ditaruh di urutan terakhir
+	var id int
+	err := config.DB.QueryRow(context.Background(),
+		`INSERT INTO poli (nama_poli, nama_dokter, jam_buka, jam_tutup, kuota_harian, prefix_antrian, kode_poli_bpjs,
+		 durasi_slot, kuota_slot, urutan)
+		 VALUES ($1, $2, $3::time, $4::time, $5, $6, NULLIF($7, ''), COALESCE(NULLIF($8, 0), 30), COALESCE(NULLIF($9, 0), 3),
+		 (SELECT COALESCE(MAX(urutan), 0) + 1 FROM poli))
+		 RETURNING id_poli`,
+		req.NamaPoli, req.NamaDokter, req.JamBuka, req.JamTutup, req.KuotaHarian, req.PrefixAntrian, req.KodePoliBPJS,
+		req.DurasiSlot, req.KuotaSlot,
+	).Scan(&id)
+
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Prefix antrian sudah dipakai poli lain")
+		}
+		return model.ErrorResponse(c, 500, "Gagal menambah poli: "+err.Error())
+	}
+
+	p, _ := scanPoli(config.DB.QueryRow(context.Background(), poliSelectSQL+`WHERE id_poli = $1`, id))
+	return model.SuccessResponse(c, 201, "Poli berhasil ditambahkan", p)
+}
+
+// ─── PUT /poli/:id ───────────────────────────────────────────────────────────
+
+func UpdatePoli(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.PoliRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaPoli = strings.TrimSpace(req.NamaPoli)
+	req.NamaDokter = strings.TrimSpace(req.NamaDokter)
+	req.JamBuka = strings.TrimSpace(req.JamBuka)
+	req.JamTutup = strings.TrimSpace(req.JamTutup)
+	req.PrefixAntrian = strings.ToUpper(strings.TrimSpace(req.PrefixAntrian))
+	req.KodePoliBPJS = strings.TrimSpace(req.KodePoliBPJS)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE poli SET nama_poli=$1, nama_dokter=$2, jam_buka=$3::time, jam_tutup=$4::time,
+		 kuota_harian=$5, prefix_antrian=$6, kode_poli_bpjs=NULLIF($7, ''),
+		 durasi_slot=COALESCE(NULLIF($8, 0), durasi_slot), kuota_slot=COALESCE(NULLIF($9, 0), kuota_slot), updated_at=NOW()
+		 WHERE id_poli=$10`,
+		req.NamaPoli, req.NamaDokter, req.JamBuka, req.JamTutup, req.KuotaHarian, req.PrefixAntrian, req.KodePoliBPJS,
+		req.DurasiSlot, req.KuotaSlot, id)
+
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Prefix antrian sudah dipakai poli lain")
+		}
+		return model.ErrorResponse(c, 500, "Gagal update poli: "+err.Error())
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	p, _ := scanPoli(config.DB.QueryRow(context.Background(), poliSelectSQL+`WHERE id_poli = $1`, id))
+	return model.SuccessResponse(c, 200, "Poli berhasil diupdate", p)
+}
+
+// ─── PUT /poli/:id/status ────────────────────────────────────────────────────
+
+// UpdatePoliStatus mengaktifkan / menonaktifkan poli. Poli tidak pernah
+// dihapus agar riwayat antrian dan pemeriksaan tetap utuh.
+func UpdatePoliStatus(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.UpdatePoliStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE poli SET aktif = $1, updated_at = NOW() WHERE id_poli = $2`, *req.Aktif, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update status poli")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Poli tidak ditemukan")
+	}
+
+	msg := "Poli berhasil dinonaktifkan"
+	if *req.Aktif {
+		msg = "Poli berhasil diaktifkan"
+	}
+	return model.SuccessResponse(c, 200, msg, nil)
+}
+
+// ─── PUT /poli/urutan ────────────────────────────────────────────────────────
+
+func ReorderPoli(c *fiber.Ctx) error {
+	var req model.ReorderPoliRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengurutkan poli")
+	}
+	defer tx.Rollback(ctx)
+
+	for i, id := range req.Urutan {
+		result, err := tx.Exec(ctx,
+			`UPDATE poli SET urutan = $1, updated_at = NOW() WHERE id_poli = $2`, i+1, id)
+		if err != nil {
+			return model.ErrorResponse(c, 500, "Gagal mengurutkan poli")
+		}
+		if result.RowsAffected() == 0 {
+			return model.ErrorResponse(c, 404, "Poli dengan ID "+strconv.Itoa(id)+" tidak ditemukan")
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengurutkan poli")
+	}
+
+	return GetAllPoli(c)
+}
+
+// ─── Helper ──────────────────────────────────────────────────────────────────
+
+// cekPoliAktif memastikan poli ada dan masih aktif. Mengembalikan kode HTTP
+// dan pesan error, atau 0 jika valid.
+func cekPoliAktif(idPoli int) (int, string) {
+	var aktif bool
+	err := config.DB.QueryRow(context.Background(),
+		`SELECT aktif FROM poli WHERE id_poli = $1`, idPoli,
+	).Scan(&aktif)
+	if err != nil {
+		return 404, "Poli tidak ditemukan"
+	}
+	if !aktif {
+		return 409, "Poli sedang tidak aktif"
+	}
+	return 0, ""
+}
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// ─── Penutupan Antrian Harian ────────────────────────────────────────────────
+
+// tutupAntrianHarian menutup antrian poli yang sudah lewat jam_tutup +
+// toleransi (atau tanggalnya sudah lewat): yang pernah dipanggil menjadi
+// tidak_hadir, sisanya kedaluwarsa, lalu ringkasan hariannya disimpan.
+// Mengembalikan jumlah poli-hari yang ditutup.
+func tutupAntrianHarian(ctx context.Context) (int, error) {
+	toleransi := config.GetAntrianToleransiTutup().Seconds()
+
+	// Poli-hari yang masih punya antrian terbuka, ditambah poli aktif hari ini
+	// yang sudah tutup tapi belum punya ringkasan (misal tidak ada pasien)
+	rows, err := config.DB.Query(ctx,
+		`SELECT DISTINCT a.tanggal_kunjungan, a.id_poli FROM antrian a
+		 LEFT JOIN poli po ON a.id_poli = po.id_poli
+		 WHERE a.status = 'belum_dikelola'
+		   AND (a.tanggal_kunjungan < CURRENT_DATE
+		        OR (a.tanggal_kunjungan = CURRENT_DATE AND po.id_poli IS NOT NULL
+		            AND CURRENT_DATE + po.jam_tutup + make_interval(secs => $1) < LOCALTIMESTAMP))
+		 UNION
+		 SELECT CURRENT_DATE, po.id_poli FROM poli po
+		 WHERE po.aktif AND CURRENT_DATE + po.jam_tutup + make_interval(secs => $1) < LOCALTIMESTAMP
+		   AND NOT EXISTS(SELECT 1 FROM ringkasan_antrian r WHERE r.tanggal = CURRENT_DATE AND r.id_poli = po.id_poli)`,
+		toleransi)
+	if err != nil {
+		return 0, err
+	}
+	type poliHari struct {
+		tanggal time.Time
+		idPoli  *int
+	}
+	var daftar []poliHari
+	for rows.Next() {
+		var ph poliHari
+		rows.Scan(&ph.tanggal, &ph.idPoli)
+		daftar = append(daftar, ph)
+	}
+	rows.Close()
+
+	ditutup := 0
+	for _, ph := range daftar {
+		if err := tutupAntrianPoli(ctx, ph.tanggal.Format("2006-01-02"), ph.idPoli); err != nil {
+			return ditutup, err
+		}
+		ditutup++
+	}
+	return ditutup, nil
+}
+
+// tutupAntrianPoli menutup antrian satu poli pada satu tanggal dan menyimpan
+// (atau memperbarui) ringkasannya dalam satu transaksi
+func tutupAntrianPoli(ctx context.Context, tanggal string, idPoli *int) error {
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE antrian SET status = CASE WHEN dipanggil_at IS NULL THEN 'kedaluwarsa' ELSE 'tidak_hadir' END,
+			updated_at = NOW()
+		 WHERE tanggal_kunjungan = $1 AND id_poli IS NOT DISTINCT FROM $2 AND status = 'belum_dikelola'`,
+		tanggal, idPoli); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO ringkasan_antrian (tanggal, id_poli, total, dilayani, tidak_hadir, kedaluwarsa, prioritas,
+			rata_tunggu_menit, rata_layanan_menit)
+		 SELECT $1::date, $2::int, COUNT(*),
+			COUNT(*) FILTER (WHERE status = 'sudah_dikelola'),
+			COUNT(*) FILTER (WHERE status = 'tidak_hadir'),
+			COUNT(*) FILTER (WHERE status = 'kedaluwarsa'),
+			COUNT(*) FILTER (WHERE prioritas <> 'reguler'),
+			ROUND((AVG(EXTRACT(EPOCH FROM dipanggil_at - created_at)) FILTER (WHERE dipanggil_at > created_at) / 60)::numeric, 1),
+			ROUND((AVG(EXTRACT(EPOCH FROM selesai_at - dipanggil_at)) FILTER (WHERE selesai_at > dipanggil_at) / 60)::numeric, 1)
+		 FROM antrian WHERE tanggal_kunjungan = $1 AND id_poli IS NOT DISTINCT FROM $2
+		 ON CONFLICT (tanggal, (COALESCE(id_poli, 0))) DO UPDATE SET
+			total = EXCLUDED.total, dilayani = EXCLUDED.dilayani, tidak_hadir = EXCLUDED.tidak_hadir,
+			kedaluwarsa = EXCLUDED.kedaluwarsa, prioritas = EXCLUDED.prioritas,
+			rata_tunggu_menit = EXCLUDED.rata_tunggu_menit, rata_layanan_menit = EXCLUDED.rata_layanan_menit,
+			ditutup_at = NOW()`,
+		tanggal, idPoli); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// JalankanWorkerTutupAntrian memeriksa poli yang sudah tutup setiap menit
+// sampai ctx selesai
+func JalankanWorkerTutupAntrian(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		if n, err := tutupAntrianHarian(ctx); err != nil {
+			log.Printf("⚠️  Tutup antrian harian: %v", err)
+		} else if n > 0 {
+			log.Printf("🔒 Antrian %d poli ditutup", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// jumlahTidakHadir menghitung ketidakhadiran pasien dalam periode terakhir:
+// booking yang kedaluwarsa tanpa check-in dan antrian yang tidak datang
+// saat dipanggil
+func jumlahTidakHadir(nik string) int {
+	var n int
+	config.DB.QueryRow(context.Background(),
+		`SELECT (SELECT COUNT(*) FROM antrian WHERE nik = $1 AND status = 'tidak_hadir'
+		           AND tanggal_kunjungan >= CURRENT_DATE - $2::int)
+		      + (SELECT COUNT(*) FROM booking WHERE nik = $1 AND status = 'kedaluwarsa'
+		           AND tanggal >= CURRENT_DATE - $2::int)`,
+		nik, config.GetBookingPeriodeTidakHadir(),
+	).Scan(&n)
+	return n
+}
+
+// ─── GET /laporan/antrian ────────────────────────────────────────────────────
+
+// GetReportAntrian menampilkan ringkasan harian antrian yang sudah ditutup
+func GetReportAntrian(c *fiber.Ctx) error {
+	hariIni := time.Now().Format("2006-01-02")
+	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", hariIni))
+	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", hariIni))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
+
+	if _, err := time.Parse("2006-01-02", tanggalDari); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_dari harus YYYY-MM-DD")
+	}
+	if _, err := time.Parse("2006-01-02", tanggalSampai); err != nil {
+		return model.ErrorResponse(c, 400, "Format tanggal_sampai harus YYYY-MM-DD")
+	}
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT r.id_ringkasan, r.tanggal, r.id_poli, COALESCE(po.nama_poli, ''), r.total, r.dilayani,
+			r.tidak_hadir, r.kedaluwarsa, r.prioritas, r.rata_tunggu_menit::float8, r.rata_layanan_menit::float8,
+			to_char(r.ditutup_at, 'YYYY-MM-DD HH24:MI:SS')
+		 FROM ringkasan_antrian r
+		 LEFT JOIN poli po ON r.id_poli = po.id_poli
+		 WHERE r.tanggal BETWEEN $1 AND $2 AND ($3 = 0 OR r.id_poli = $3)
+		 ORDER BY r.tanggal, po.urutan NULLS FIRST`, tanggalDari, tanggalSampai, idPoli)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan antrian")
+	}
+	defer rows.Close()
+
+	report := model.ReportAntrian{
+		TanggalDari:   tanggalDari,
+		TanggalSampai: tanggalSampai,
+		PerHari:       []model.RingkasanAntrian{},
+	}
+	for rows.Next() {
+		var r model.RingkasanAntrian
+		var tg interface{}
+		rows.Scan(&r.IDRingkasan, &tg, &r.IDPoli, &r.NamaPoli, &r.Total, &r.Dilayani,
+			&r.TidakHadir, &r.Kedaluwarsa, &r.Prioritas, &r.RataTungguMenit, &r.RataLayananMenit, &r.DitutupAt)
+		r.Tanggal = formatDate(tg)
+		report.Total += r.Total
+		report.Dilayani += r.Dilayani
+		report.TidakHadir += r.TidakHadir
+		report.Kedaluwarsa += r.Kedaluwarsa
+		report.PerHari = append(report.PerHari, r)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", report)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// riwayatUnionSQL menggabungkan semua kejadian klinis pasien menjadi satu
+// timeline. Setiap sumber baru cukup ditambahkan sebagai cabang UNION ALL
+// dengan kolom yang sama. Tahap kunjungan per_poli tidak ikut karena sudah
+// tampil sebagai antrian poli.
+const riwayatUnionSQL = `
+	SELECT 'antrian' AS tipe, a.id_antrian AS id, a.created_at AS waktu,
+		a.tanggal_kunjungan AS tanggal, a.id_poli AS id_poli, COALESCE(po.nama_poli, '') AS nama_poli,
+		'Antrian nomor ' || a.nomor_antrian AS ringkasan,
+		json_build_object('nomor_antrian', a.nomor_antrian, 'status', a.status) AS detail
+	FROM antrian a LEFT JOIN poli po ON a.id_poli = po.id_poli
+	WHERE a.nik = $1
+
+	UNION ALL
+
+	SELECT 'pemeriksaan', pe.id_pemeriksaan, pe.created_at,
+		pe.tanggal_pemeriksaan, pe.id_poli, po.nama_poli,
+		pe.keluhan,
+		json_build_object('keluhan', pe.keluhan, 'id_dokter', pe.id_dokter,
+			'nama_dokter', COALESCE(d.nama_dokter, po.nama_dokter),
+			'metode_pembayaran', pe.metode_pembayaran, 'nominal_pembayaran', pe.nominal_pembayaran)
+	FROM pemeriksaan pe
+	JOIN poli po ON pe.id_poli = po.id_poli
+	LEFT JOIN dokter d ON pe.id_dokter = d.id_dokter
+	WHERE pe.nik_pasien = $1
+
+	UNION ALL
+
+	SELECT 'rujukan', r.id_rujukan, r.created_at,
+		r.tanggal_rujukan, pe.id_poli, po.nama_poli,
+		'Rujukan ' || r.jenis_rujukan || ' ke ' || r.faskes_tujuan,
+		json_build_object('nomor_rujukan', r.nomor_rujukan, 'id_pemeriksaan', r.id_pemeriksaan,
+			'jenis_rujukan', r.jenis_rujukan, 'faskes_tujuan', r.faskes_tujuan,
+			'spesialis', r.spesialis, 'diagnosa', r.diagnosa, 'alasan', r.alasan)
+	FROM rujukan r
+	JOIN pemeriksaan pe ON r.id_pemeriksaan = pe.id_pemeriksaan
+	JOIN poli po ON pe.id_poli = po.id_poli
+	WHERE pe.nik_pasien = $1
+
+	UNION ALL
+
+	SELECT 'kunjungan_tahap', kt.id_kunjungan_tahap, kt.masuk_at,
+		kt.tanggal, k.id_poli, po.nama_poli,
+		'Tahap ' || tl.nama_tahap || ' nomor ' || kt.nomor_antrian,
+		json_build_object('id_kunjungan', kt.id_kunjungan, 'kode_tahap', tl.kode_tahap,
+			'nama_tahap', tl.nama_tahap, 'nomor_antrian', kt.nomor_antrian, 'status', kt.status,
+			'dipanggil_at', kt.dipanggil_at, 'selesai_at', kt.selesai_at)
+	FROM kunjungan_tahap kt
+	JOIN tahap_layanan tl ON kt.id_tahap = tl.id_tahap
+	JOIN kunjungan k ON kt.id_kunjungan = k.id_kunjungan
+	JOIN poli po ON k.id_poli = po.id_poli
+	WHERE k.nik = $1 AND NOT tl.per_poli`
+
+// ─── GET /pasien/:nik/riwayat ────────────────────────────────────────────────
+
+func GetRiwayatPasien(c *fiber.Ctx) error {
+	nik := strings.TrimSpace(c.Params("nik"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	cursor := strings.TrimSpace(c.Query("cursor", ""))
+	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", ""))
+	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", ""))
+	idPoli, _ := strconv.Atoi(c.Query("id_poli", "0"))
+
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var errs []string
+	if tanggalDari != "" {
+		if _, err := time.Parse("2006-01-02", tanggalDari); err != nil {
+			errs = append(errs, "Format tanggal_dari harus YYYY-MM-DD")
+		}
+	}
+	if tanggalSampai != "" {
+		if _, err := time.Parse("2006-01-02", tanggalSampai); err != nil {
+			errs = append(errs, "Format tanggal_sampai harus YYYY-MM-DD")
+		}
+	}
+	if len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	var pasienExists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM pasien WHERE nik = $1)`, nik,
+	).Scan(&pasienExists)
+	if !pasienExists {
+		return model.ErrorResponse(c, 404, "Pasien tidak ditemukan")
+	}
+
+	cakupan, err := cakupanPoliUser(c, "pasien", nik)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{nik}
+	argIdx := 2
+
+	// Role yang dibatasi hanya melihat kejadian di poli tugasnya
+	if !cakupan.semua {
+		baseWhere += ` AND t.id_poli = ANY($` + strconv.Itoa(argIdx) + `)`
+		args = append(args, cakupan.idPoli)
+		argIdx++
+	}
+
+	if tanggalDari != "" {
+		baseWhere += ` AND t.tanggal >= $` + strconv.Itoa(argIdx)
+		args = append(args, tanggalDari)
+		argIdx++
+	}
+	if tanggalSampai != "" {
+		baseWhere += ` AND t.tanggal <= $` + strconv.Itoa(argIdx)
+		args = append(args, tanggalSampai)
+		argIdx++
+	}
+	if idPoli > 0 {
+		// Antrian lama tanpa poli ikut tersaring keluar
+		baseWhere += ` AND t.id_poli = $` + strconv.Itoa(argIdx)
+		args = append(args, idPoli)
+		argIdx++
+	}
+	if cursor != "" {
+		cur, err := model.DecodeRiwayatCursor(cursor)
+		if err != nil {
+			return model.ErrorResponse(c, 400, err.Error())
+		}
+		baseWhere += ` AND (t.tanggal, t.waktu, t.tipe, t.id) < ($` + strconv.Itoa(argIdx) + `::date, $` +
+			strconv.Itoa(argIdx+1) + `::timestamp, $` + strconv.Itoa(argIdx+2) + `, $` + strconv.Itoa(argIdx+3) + `)`
+		args = append(args, cur.Tanggal, cur.Waktu, cur.Tipe, cur.ID)
+		argIdx += 4
+	}
+
+	// Urut dan halaman mengikuti tanggal kejadian, sama dengan filter; waktu
+	// input hanya pemecah seri. Ambil satu baris lebih untuk mengetahui apakah
+	// masih ada halaman berikutnya.
+	fetchSQL := `SELECT t.tipe, t.id, t.waktu, t.tanggal, t.id_poli, t.nama_poli, t.ringkasan, t.detail
+		FROM (` + riwayatUnionSQL + `) t ` + baseWhere + `
+		ORDER BY t.tanggal DESC, t.waktu DESC, t.tipe DESC, t.id DESC
+		LIMIT $` + strconv.Itoa(argIdx)
+	args = append(args, limit+1)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil riwayat pasien: "+err.Error())
+	}
+	defer queryRows.Close()
+
+	items := []model.RiwayatItem{}
+	for queryRows.Next() {
+		var it model.RiwayatItem
+		var tg interface{}
+		queryRows.Scan(&it.Tipe, &it.ID, &it.Waktu, &tg, &it.IDPoli, &it.NamaPoli, &it.Ringkasan, &it.Detail)
+		it.Tanggal = formatDate(tg)
+		items = append(items, it)
+	}
+
+	nextCursor := ""
+	if len(items) > limit {
+		items = items[:limit]
+		nextCursor = model.NewRiwayatCursor(items[limit-1]).Encode()
+	}
+
+	return model.CursorSuccessResponse(c, items, nextCursor, limit)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/middleware"
+	"sikupas/backend/model"
+)
+
+const roleSelectSQL = `SELECT r.kode_role, r.nama_role, r.keterangan, r.sistem, r.batasi_poli, r.wajib_2fa,
+	COALESCE(ARRAY(SELECT izin FROM role_izin ri WHERE ri.kode_role = r.kode_role ORDER BY izin), '{}'),
+	(SELECT COUNT(*) FROM users u WHERE u.role = r.kode_role)
+	FROM role r `
+
+func scanRole(row pgx.Row) (model.Role, error) {
+	var r model.Role
+	err := row.Scan(&r.KodeRole, &r.NamaRole, &r.Keterangan, &r.Sistem, &r.BatasiPoli, &r.Wajib2FA, &r.Izin, &r.JumlahUser)
+	if r.KodeRole == model.RoleAdmin {
+		r.Izin = model.SemuaIzin()
+	}
+	return r, err
+}
+
+// simpanIzinRole mengganti seluruh izin role dalam transaksi yang sama
+func simpanIzinRole(ctx context.Context, tx pgx.Tx, kode string, izin []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM role_izin WHERE kode_role = $1`, kode); err != nil {
+		return err
+	}
+	if izin == nil {
+		izin = []string{}
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO role_izin (kode_role, izin) SELECT $1, i FROM unnest($2::text[]) AS i ON CONFLICT DO NOTHING`,
+		kode, izin)
+	return err
+}
+
+// ─── GET /role/izin ──────────────────────────────────────────────────────────
+
+// GetAllIzin mengembalikan katalog izin untuk pilihan di form role
+func GetAllIzin(c *fiber.Ctx) error {
+	return model.SuccessResponse(c, 200, "Berhasil", model.DaftarIzin)
+}
+
+// ─── GET /role ───────────────────────────────────────────────────────────────
+
+func GetAllRole(c *fiber.Ctx) error {
+	rows, err := config.DB.Query(context.Background(), roleSelectSQL+`ORDER BY r.sistem DESC, r.kode_role`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data role")
+	}
+	defer rows.Close()
+
+	roles := []model.Role{}
+	for rows.Next() {
+		r, _ := scanRole(rows)
+		roles = append(roles, r)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", roles)
+}
+
+// ─── POST /role ──────────────────────────────────────────────────────────────
+
+func CreateRole(c *fiber.Ctx) error {
+	var req model.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodeRole = strings.TrimSpace(req.KodeRole)
+	req.NamaRole = strings.TrimSpace(req.NamaRole)
+	req.Keterangan = strings.TrimSpace(req.Keterangan)
+
+	if errs := req.Validate(true); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat role")
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO role (kode_role, nama_role, keterangan, batasi_poli, wajib_2fa) VALUES ($1, $2, $3, $4, $5)`,
+		req.KodeRole, req.NamaRole, req.Keterangan, req.BatasiPoli, req.Wajib2FA)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Kode role sudah digunakan")
+		}
+		return model.ErrorResponse(c, 500, "Gagal membuat role")
+	}
+	if err := simpanIzinRole(ctx, tx, req.KodeRole, req.Izin); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan izin role")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat role")
+	}
+	middleware.ResetCacheIzin()
+
+	catatAudit(c, "role.buat", "role", req.KodeRole, strings.Join(req.Izin, ","))
+
+	r, _ := scanRole(config.DB.QueryRow(ctx, roleSelectSQL+`WHERE r.kode_role = $1`, req.KodeRole))
+	return model.SuccessResponse(c, 201, "Role berhasil dibuat", r)
+}
+
+// ─── PUT /role/:kode ─────────────────────────────────────────────────────────
+
+// UpdateRole mengubah nama dan izin role. Izin role admin tidak bisa diubah
+// karena admin selalu memegang semua izin, dan admin tidak dibatasi poli.
+func UpdateRole(c *fiber.Ctx) error {
+	kode := c.Params("kode")
+
+	var req model.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.NamaRole = strings.TrimSpace(req.NamaRole)
+	req.Keterangan = strings.TrimSpace(req.Keterangan)
+
+	if errs := req.Validate(false); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if kode == model.RoleAdmin {
+		req.BatasiPoli = false
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update role")
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx,
+		`UPDATE role SET nama_role = $1, keterangan = $2, batasi_poli = $3, wajib_2fa = $4, updated_at = NOW()
+		 WHERE kode_role = $5`,
+		req.NamaRole, req.Keterangan, req.BatasiPoli, req.Wajib2FA, kode)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update role")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Role tidak ditemukan")
+	}
+
+	keterangan := req.NamaRole + ", batasi_poli=" + strconv.FormatBool(req.BatasiPoli) +
+		", wajib_2fa=" + strconv.FormatBool(req.Wajib2FA)
+	if kode != model.RoleAdmin {
+		if err := simpanIzinRole(ctx, tx, kode, req.Izin); err != nil {
+			return model.ErrorResponse(c, 500, "Gagal menyimpan izin role")
+		}
+		keterangan += ": " + strings.Join(req.Izin, ",")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update role")
+	}
+	middleware.ResetCacheIzin()
+
+	catatAudit(c, "role.ubah", "role", kode, keterangan)
+
+	r, _ := scanRole(config.DB.QueryRow(ctx, roleSelectSQL+`WHERE r.kode_role = $1`, kode))
+	return model.SuccessResponse(c, 200, "Role berhasil diupdate", r)
+}
+
+// ─── DELETE /role/:kode ──────────────────────────────────────────────────────
+
+// DeleteRole menghapus role buatan admin yang tidak lagi dipakai user
+func DeleteRole(c *fiber.Ctx) error {
+	kode := c.Params("kode")
+
+	r, err := scanRole(config.DB.QueryRow(context.Background(), roleSelectSQL+`WHERE r.kode_role = $1`, kode))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Role tidak ditemukan")
+	}
+	if r.Sistem {
+		return model.ErrorResponse(c, 400, "Role bawaan sistem tidak bisa dihapus")
+	}
+	if r.JumlahUser > 0 {
+		return model.ErrorResponse(c, 409, "Role masih dipakai user, pindahkan user ke role lain terlebih dahulu")
+	}
+
+	_, err = config.DB.Exec(context.Background(), `DELETE FROM role WHERE kode_role = $1`, kode)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus role")
+	}
+	middleware.ResetCacheIzin()
+
+	catatAudit(c, "role.hapus", "role", kode, r.NamaRole)
+
+	return model.SuccessResponse(c, 200, "Role berhasil dihapus", nil)
+}
@@ -0,0 +1,455 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/pdf"
+)
+
+const rujukanSelectSQL = `SELECT r.id_rujukan, r.nomor_rujukan, r.id_pemeriksaan,
+	pe.nik_pasien, p.nama_pasien, pe.id_poli, po.nama_poli, COALESCE(d.nama_dokter, po.nama_dokter),
+	r.jenis_rujukan, r.faskes_tujuan, r.spesialis, r.diagnosa, r.alasan, r.tanggal_rujukan
+	FROM rujukan r
+	JOIN pemeriksaan pe ON r.id_pemeriksaan = pe.id_pemeriksaan
+	JOIN pasien p ON pe.nik_pasien = p.nik
+	JOIN poli po ON pe.id_poli = po.id_poli
+	LEFT JOIN dokter d ON pe.id_dokter = d.id_dokter `
+
+func scanRujukan(row pgx.Row) (model.RujukanResponse, error) {
+	var r model.RujukanResponse
+	var tr interface{}
+	err := row.Scan(&r.IDRujukan, &r.NomorRujukan, &r.IDPemeriksaan,
+		&r.NIKPasien, &r.NamaPasien, &r.IDPoli, &r.NamaPoli, &r.NamaDokter,
+		&r.JenisRujukan, &r.FaskesTujuan, &r.Spesialis, &r.Diagnosa, &r.Alasan, &tr)
+	r.TanggalRujukan = formatDate(tr)
+	return r, err
+}
+
+// cakupanRujukan memastikan poli pemeriksaan asal rujukan dalam cakupan user
+func cakupanRujukan(c *fiber.Ctx, r model.RujukanResponse) (int, string) {
+	cakupan, err := cakupanPoliUser(c, "rujukan", strconv.Itoa(r.IDRujukan))
+	if err != nil {
+		return 500, "Gagal memeriksa cakupan poli"
+	}
+	if !cakupan.boleh(r.IDPoli) {
+		return 403, pesanDiluarPoli
+	}
+	return 0, ""
+}
+
+// ─── GET /rujukan ────────────────────────────────────────────────────────────
+
+func GetAllRujukan(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	search := strings.TrimSpace(c.Query("search", ""))
+	tanggal := strings.TrimSpace(c.Query("tanggal", ""))
+	jenis := strings.TrimSpace(c.Query("jenis", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	cakupan, err := cakupanPoliUser(c, "rujukan", "")
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa cakupan poli")
+	}
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if !cakupan.semua {
+		baseWhere += ` AND pe.id_poli = ANY($` + strconv.Itoa(argIdx) + `)`
+		args = append(args, cakupan.idPoli)
+		argIdx++
+	}
+	if tanggal != "" {
+		baseWhere += ` AND r.tanggal_rujukan = $` + strconv.Itoa(argIdx)
+		args = append(args, tanggal)
+		argIdx++
+	}
+	if jenis != "" {
+		baseWhere += ` AND r.jenis_rujukan = $` + strconv.Itoa(argIdx)
+		args = append(args, jenis)
+		argIdx++
+	}
+	if search != "" {
+		baseWhere += ` AND (p.nik LIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR p.nama_pasien ILIKE '%'||$` + strconv.Itoa(argIdx) +
+			`||'%' OR r.nomor_rujukan ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	countSQL := `SELECT COUNT(*) FROM rujukan r
+		JOIN pemeriksaan pe ON r.id_pemeriksaan = pe.id_pemeriksaan
+		JOIN pasien p ON pe.nik_pasien = p.nik ` + baseWhere
+	config.DB.QueryRow(context.Background(), countSQL, args...).Scan(&totalData)
+
+	fetchSQL := rujukanSelectSQL + baseWhere + `
+		ORDER BY r.tanggal_rujukan DESC, r.id_rujukan DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data rujukan")
+	}
+	defer queryRows.Close()
+
+	var rows []model.RujukanResponse
+	for queryRows.Next() {
+		r, _ := scanRujukan(queryRows)
+		rows = append(rows, r)
+	}
+
+	if rows == nil {
+		rows = []model.RujukanResponse{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /rujukan/:id ────────────────────────────────────────────────────────
+
+func GetRujukanByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	r, err := scanRujukan(config.DB.QueryRow(context.Background(),
+		rujukanSelectSQL+`WHERE r.id_rujukan = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Rujukan tidak ditemukan")
+	}
+	if code, msg := cakupanRujukan(c, r); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", r)
+}
+
+// ─── POST /rujukan ───────────────────────────────────────────────────────────
+
+func CreateRujukan(c *fiber.Ctx) error {
+	var req model.CreateRujukanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.JenisRujukan = strings.TrimSpace(req.JenisRujukan)
+	req.FaskesTujuan = strings.TrimSpace(req.FaskesTujuan)
+	req.Spesialis = strings.TrimSpace(req.Spesialis)
+	req.Diagnosa = strings.TrimSpace(req.Diagnosa)
+	req.Alasan = strings.TrimSpace(req.Alasan)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	// Cek pemeriksaan ada dan dalam poli tugas user
+	if code, msg := cakupanPemeriksaan(c, req.IDPemeriksaan); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat rujukan")
+	}
+	defer tx.Rollback(ctx)
+
+	// Kunci penomoran agar dua request bersamaan tidak mendapat nomor sama
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('rujukan_nomor'))`); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat rujukan")
+	}
+
+	now := time.Now()
+	tanggal := now.Format("2006-01-02")
+
+	var urutan int
+	tx.QueryRow(ctx,
+		`SELECT COALESCE(MAX(SPLIT_PART(nomor_rujukan, '/', 3)::int), 0) + 1
+		 FROM rujukan WHERE tanggal_rujukan = $1`, tanggal,
+	).Scan(&urutan)
+	nomor := model.FormatNomorRujukan(now, urutan)
+
+	var idRujukan int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO rujukan (nomor_rujukan, id_pemeriksaan, jenis_rujukan, faskes_tujuan, spesialis, diagnosa, alasan, tanggal_rujukan)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id_rujukan`,
+		nomor, req.IDPemeriksaan, req.JenisRujukan, req.FaskesTujuan, req.Spesialis, req.Diagnosa, req.Alasan, tanggal,
+	).Scan(&idRujukan)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat rujukan: "+err.Error())
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat rujukan: "+err.Error())
+	}
+
+	r, _ := scanRujukan(config.DB.QueryRow(ctx, rujukanSelectSQL+`WHERE r.id_rujukan = $1`, idRujukan))
+	return model.SuccessResponse(c, 201, "Rujukan berhasil dibuat", r)
+}
+
+// ─── PUT /rujukan/:id ────────────────────────────────────────────────────────
+
+func UpdateRujukan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.UpdateRujukanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.JenisRujukan = strings.TrimSpace(req.JenisRujukan)
+	req.FaskesTujuan = strings.TrimSpace(req.FaskesTujuan)
+	req.Spesialis = strings.TrimSpace(req.Spesialis)
+	req.Diagnosa = strings.TrimSpace(req.Diagnosa)
+	req.Alasan = strings.TrimSpace(req.Alasan)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	lama, err := scanRujukan(config.DB.QueryRow(context.Background(), rujukanSelectSQL+`WHERE r.id_rujukan = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Rujukan tidak ditemukan")
+	}
+	if code, msg := cakupanRujukan(c, lama); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE rujukan SET jenis_rujukan=$1, faskes_tujuan=$2, spesialis=$3,
+		 diagnosa=$4, alasan=$5, updated_at=NOW()
+		 WHERE id_rujukan=$6`,
+		req.JenisRujukan, req.FaskesTujuan, req.Spesialis, req.Diagnosa, req.Alasan, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update rujukan: "+err.Error())
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Rujukan tidak ditemukan")
+	}
+
+	r, _ := scanRujukan(config.DB.QueryRow(context.Background(), rujukanSelectSQL+`WHERE r.id_rujukan = $1`, id))
+	return model.SuccessResponse(c, 200, "Rujukan berhasil diupdate", r)
+}
+
+// ─── DELETE /rujukan/:id ─────────────────────────────────────────────────────
+
+func DeleteRujukan(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	r, err := scanRujukan(config.DB.QueryRow(context.Background(), rujukanSelectSQL+`WHERE r.id_rujukan = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Rujukan tidak ditemukan")
+	}
+	if code, msg := cakupanRujukan(c, r); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	result, err := config.DB.Exec(context.Background(),
+		`DELETE FROM rujukan WHERE id_rujukan = $1`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menghapus rujukan")
+	}
+	if result.RowsAffected() == 0 {
+		return model.ErrorResponse(c, 404, "Rujukan tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Rujukan berhasil dihapus", nil)
+}
+
+// ─── GET /rujukan/:id/pdf ────────────────────────────────────────────────────
+
+func GetRujukanPDF(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	r, err := scanRujukan(config.DB.QueryRow(context.Background(),
+		rujukanSelectSQL+`WHERE r.id_rujukan = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Rujukan tidak ditemukan")
+	}
+	if code, msg := cakupanRujukan(c, r); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	var tanggalLahir interface{}
+	var umur int
+	var jk, alamat string
+	config.DB.QueryRow(context.Background(),
+		`SELECT tanggal_lahir, umur, jenis_kelamin, alamat FROM pasien WHERE nik = $1`, r.NIKPasien,
+	).Scan(&tanggalLahir, &umur, &jk, &alamat)
+
+	doc := pdf.New(pdf.A4Width, pdf.A4Height)
+	const left = 60.0
+	const right = pdf.A4Width - 60.0
+	const top = 60.0
+	const bottom = pdf.A4Height - 60.0
+
+	// Kop surat
+	doc.TextCenter(60, 16, true, strings.ToUpper(config.GetPuskesmasNama()))
+	if alamat := config.GetPuskesmasAlamat(); alamat != "" {
+		doc.TextCenter(78, 10, false, alamat)
+	}
+	doc.Line(left, 90, right, 90)
+
+	doc.TextCenter(120, 13, true, "SURAT RUJUKAN")
+	doc.TextCenter(136, 10, false, "Nomor: "+r.NomorRujukan)
+
+	y := 175.0
+	line := func(label, value string) {
+		doc.Text(left, y, 11, false, label)
+		doc.Text(left+130, y, 11, false, ": "+value)
+		y += 18
+	}
+	// ruang pindah ke halaman baru jika sisa halaman kurang dari h
+	ruang := func(h float64) {
+		if y+h > bottom {
+			doc.AddPage()
+			y = top
+		}
+	}
+
+	doc.Text(left, y, 11, false, "Kepada Yth.")
+	y += 18
+	doc.Text(left, y, 11, true, "Dokter Spesialis "+r.Spesialis)
+	y += 18
+	doc.Text(left, y, 11, false, "di "+r.FaskesTujuan)
+	y += 30
+
+	doc.Text(left, y, 11, false, "Mohon pemeriksaan dan penanganan lebih lanjut terhadap pasien:")
+	y += 24
+	line("Nama", r.NamaPasien)
+	line("NIK", r.NIKPasien)
+	line("Tanggal Lahir", fmt.Sprintf("%s (%d tahun)", formatDate(tanggalLahir), umur))
+	line("Jenis Kelamin", jk)
+	line("Alamat", alamat)
+	line("Poli Asal", r.NamaPoli)
+	line("Jenis Rujukan", strings.ToUpper(r.JenisRujukan[:1])+r.JenisRujukan[1:])
+	y += 8
+
+	ruang(16 + 15)
+	doc.Text(left, y, 11, true, "Diagnosa")
+	y += 16
+	for _, l := range pdf.Wrap(r.Diagnosa, 11, right-left) {
+		ruang(15)
+		doc.Text(left, y, 11, false, l)
+		y += 15
+	}
+	y += 8
+
+	ruang(16 + 15)
+	doc.Text(left, y, 11, true, "Alasan Rujukan")
+	y += 16
+	for _, l := range pdf.Wrap(r.Alasan, 11, right-left) {
+		ruang(15)
+		doc.Text(left, y, 11, false, l)
+		y += 15
+	}
+	y += 24
+
+	// Penutup dan tanda tangan tidak dipisah ke dua halaman
+	ruang(50 + 16 + 70)
+	doc.Text(left, y, 11, false, "Atas bantuan dan kerja samanya kami ucapkan terima kasih.")
+	y += 50
+
+	ttdX := right - 180
+	doc.Text(ttdX, y, 11, false, "Tanggal: "+r.TanggalRujukan)
+	y += 16
+	doc.Text(ttdX, y, 11, false, "Dokter Pemeriksa,")
+	y += 70
+	doc.Text(ttdX, y, 11, true, r.NamaDokter)
+
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf(`inline; filename="rujukan-%d.pdf"`, r.IDRujukan))
+	return c.Send(doc.Bytes())
+}
+
+// ─── GET /laporan/rujukan ────────────────────────────────────────────────────
+
+func GetReportRujukan(c *fiber.Ctx) error {
+	tanggalDari := strings.TrimSpace(c.Query("tanggal_dari", ""))
+	tanggalSampai := strings.TrimSpace(c.Query("tanggal_sampai", ""))
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if tanggalDari != "" {
+		baseWhere += ` AND r.tanggal_rujukan >= $` + strconv.Itoa(argIdx)
+		args = append(args, tanggalDari)
+		argIdx++
+	}
+	if tanggalSampai != "" {
+		baseWhere += ` AND r.tanggal_rujukan <= $` + strconv.Itoa(argIdx)
+		args = append(args, tanggalSampai)
+		argIdx++
+	}
+
+	var report model.ReportRujukan
+	config.DB.QueryRow(context.Background(),
+		`SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE r.jenis_rujukan = 'internal'),
+			COUNT(*) FILTER (WHERE r.jenis_rujukan = 'eksternal')
+		 FROM rujukan r `+baseWhere, args...,
+	).Scan(&report.TotalRujukan, &report.TotalInternal, &report.TotalEksternal)
+
+	groupBy := func(column string) ([]model.RujukanStatItem, error) {
+		queryRows, err := config.DB.Query(context.Background(),
+			`SELECT `+column+`, COUNT(*) FROM rujukan r
+			 JOIN pemeriksaan pe ON r.id_pemeriksaan = pe.id_pemeriksaan
+			 JOIN poli po ON pe.id_poli = po.id_poli `+baseWhere+`
+			 GROUP BY 1 ORDER BY 2 DESC, 1 ASC`, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer queryRows.Close()
+
+		items := []model.RujukanStatItem{}
+		for queryRows.Next() {
+			var it model.RujukanStatItem
+			queryRows.Scan(&it.Label, &it.Jumlah)
+			items = append(items, it)
+		}
+		return items, nil
+	}
+
+	var err error
+	if report.PerFaskes, err = groupBy("r.faskes_tujuan"); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan rujukan")
+	}
+	if report.PerSpesialis, err = groupBy("r.spesialis"); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan rujukan")
+	}
+	if report.PerPoliAsal, err = groupBy("po.nama_poli"); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil laporan rujukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", report)
+}
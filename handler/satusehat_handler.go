@@ -0,0 +1,420 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+	"sikupas/backend/satusehat"
+)
+
+const satusehatSinkronSelectSQL = `SELECT id_sinkron, jenis_resource, id_lokal, id_satusehat, status,
+	pesan_error, percobaan, to_char(coba_lagi_at, 'YYYY-MM-DD HH24:MI:SS'),
+	to_char(terakhir_dikirim, 'YYYY-MM-DD HH24:MI:SS')
+	FROM satusehat_sinkron `
+
+// Setelah batas ini baris gagal hanya dikirim lagi lewat endpoint kirim ulang
+const satusehatMaksPercobaan = 10
+
+// errBelumSiap: resource yang dirujuk (Patient/Encounter) belum terkirim
+var errBelumSiap = errors.New("resource rujukan belum terkirim")
+
+func scanSatusehatSinkron(row pgx.Row) (model.SatusehatSinkron, error) {
+	var s model.SatusehatSinkron
+	err := row.Scan(&s.IDSinkron, &s.JenisResource, &s.IDLokal, &s.IDSatusehat, &s.Status,
+		&s.PesanError, &s.Percobaan, &s.CobaLagiAt, &s.TerakhirDikirim)
+	return s, err
+}
+
+// tandaiSinkronUlangSatusehat menjadwalkan ulang resource yang sudah terkirim
+// setelah data lokalnya berubah; pola memakai sintaks LIKE
+func tandaiSinkronUlangSatusehat(jenis, pola string) {
+	_, err := config.DB.Exec(context.Background(),
+		`UPDATE satusehat_sinkron SET status = 'menunggu', percobaan = 0, coba_lagi_at = NULL, updated_at = NOW()
+		 WHERE jenis_resource = $1 AND id_lokal LIKE $2 AND status = 'terkirim'`, jenis, pola)
+	if err != nil {
+		log.Printf("⚠️  Gagal menjadwalkan ulang sinkron SATUSEHAT %s %s: %v", jenis, pola, err)
+	}
+}
+
+// ─── Worker Sinkronisasi ─────────────────────────────────────────────────────
+
+// JalankanWorkerSatusehat mengirim data ke SATUSEHAT secara berkala sampai ctx
+// selesai. Tidak melakukan apa pun bila SATUSEHAT belum dikonfigurasi.
+func JalankanWorkerSatusehat(ctx context.Context) {
+	client := config.GetSatusehatClient()
+	if client == nil {
+		return
+	}
+
+	ticker := time.NewTicker(config.GetSatusehatInterval())
+	defer ticker.Stop()
+
+	for {
+		if n, err := sinkronSatusehat(ctx, client); err != nil {
+			log.Printf("⚠️  Sinkronisasi SATUSEHAT: %v", err)
+		} else if n > 0 {
+			log.Printf("📤 SATUSEHAT: %d resource diproses", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sinkronSatusehat satu putaran: antrikan data baru lalu kirim yang jatuh tempo
+func sinkronSatusehat(ctx context.Context, client *satusehat.Client) (int, error) {
+	antrian := []string{
+		`INSERT INTO satusehat_sinkron (jenis_resource, id_lokal)
+		 SELECT 'Patient', nik FROM pasien
+		 ON CONFLICT (jenis_resource, id_lokal) DO NOTHING`,
+		`INSERT INTO satusehat_sinkron (jenis_resource, id_lokal)
+		 SELECT 'Encounter', id_pemeriksaan::text FROM pemeriksaan
+		 ON CONFLICT (jenis_resource, id_lokal) DO NOTHING`,
+		`INSERT INTO satusehat_sinkron (jenis_resource, id_lokal)
+		 SELECT 'Condition', id_pemeriksaan::text FROM pemeriksaan WHERE kode_diagnosa IS NOT NULL
+		 ON CONFLICT (jenis_resource, id_lokal) DO NOTHING`,
+		`INSERT INTO satusehat_sinkron (jenis_resource, id_lokal)
+		 SELECT 'Observation', tv.id_pemeriksaan || ':' || v.jenis
+		 FROM tanda_vital tv
+		 CROSS JOIN LATERAL (VALUES
+			('sistole', tv.sistole::numeric), ('diastole', tv.diastole::numeric),
+			('nadi', tv.nadi::numeric), ('pernapasan', tv.pernapasan::numeric),
+			('suhu', tv.suhu), ('berat_badan', tv.berat_badan), ('tinggi_badan', tv.tinggi_badan)
+		 ) AS v(jenis, nilai)
+		 WHERE v.nilai IS NOT NULL
+		 ON CONFLICT (jenis_resource, id_lokal) DO NOTHING`,
+	}
+	for _, sql := range antrian {
+		if _, err := config.DB.Exec(ctx, sql); err != nil {
+			return 0, fmt.Errorf("gagal mengantrikan data: %w", err)
+		}
+	}
+
+	// Patient dulu, lalu Encounter, baru Condition dan Observation yang merujuk keduanya
+	rows, err := config.DB.Query(ctx,
+		`SELECT id_sinkron, jenis_resource, id_lokal, COALESCE(id_satusehat, ''), percobaan
+		 FROM satusehat_sinkron
+		 WHERE status <> 'terkirim' AND percobaan < $1 AND (coba_lagi_at IS NULL OR coba_lagi_at <= NOW())
+		 ORDER BY CASE jenis_resource WHEN 'Patient' THEN 1 WHEN 'Encounter' THEN 2 ELSE 3 END, id_sinkron
+		 LIMIT 100`, satusehatMaksPercobaan)
+	if err != nil {
+		return 0, err
+	}
+
+	var daftar []tugasSatusehat
+	for rows.Next() {
+		var t tugasSatusehat
+		rows.Scan(&t.id, &t.jenis, &t.idLokal, &t.idSatusehat, &t.percobaan)
+		daftar = append(daftar, t)
+	}
+	rows.Close()
+
+	return kirimTugasSatusehat(ctx, client, daftar, sumberSatusehatDB{}), nil
+}
+
+type tugasSatusehat struct {
+	id          int
+	jenis       string
+	idLokal     string
+	idSatusehat string
+	percobaan   int
+}
+
+// sumberSatusehat memisahkan akses DB dari alur kirim supaya alurnya bisa
+// diuji terhadap server tiruan
+type sumberSatusehat interface {
+	susun(ctx context.Context, jenis, idLokal, orgID string) (satusehat.Resource, error)
+	terkirim(ctx context.Context, id int, idSatusehat string) error
+	gagal(ctx context.Context, id int, pesan string, jeda time.Duration) error
+}
+
+// kirimTugasSatusehat mengirim daftar sesuai urutannya, jadi Patient dan
+// Encounter yang baru terkirim langsung bisa dirujuk tugas sesudahnya
+func kirimTugasSatusehat(ctx context.Context, client *satusehat.Client, daftar []tugasSatusehat, sumber sumberSatusehat) int {
+	diproses := 0
+	for _, t := range daftar {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// errBelumSiap ikut dijadwalkan ulang dengan jeda yang sama, kalau
+		// tidak baris ini terpilih lagi di setiap putaran
+		res, err := sumber.susun(ctx, t.jenis, t.idLokal, client.OrgID)
+		if err == nil {
+			res.ID = t.idSatusehat
+			var idBaru string
+			if idBaru, err = client.Kirim(ctx, res); err == nil {
+				if err := sumber.terkirim(ctx, t.id, idBaru); err != nil {
+					log.Printf("⚠️  Gagal mencatat sinkron SATUSEHAT %d: %v", t.id, err)
+				}
+				diproses++
+				continue
+			}
+		}
+
+		if err := sumber.gagal(ctx, t.id, err.Error(), jedaKirimUlangSatusehat(t.percobaan)); err != nil {
+			log.Printf("⚠️  Gagal mencatat sinkron SATUSEHAT %d: %v", t.id, err)
+		}
+		diproses++
+	}
+	return diproses
+}
+
+// jedaKirimUlangSatusehat jeda berlipat: 1, 2, 4, ... menit, paling lama 6 jam
+func jedaKirimUlangSatusehat(percobaan int) time.Duration {
+	jeda := time.Minute << percobaan
+	if jeda > 6*time.Hour || jeda <= 0 {
+		jeda = 6 * time.Hour
+	}
+	return jeda
+}
+
+type sumberSatusehatDB struct{}
+
+func (sumberSatusehatDB) susun(ctx context.Context, jenis, idLokal, orgID string) (satusehat.Resource, error) {
+	return resourceSatusehat(ctx, jenis, idLokal, orgID)
+}
+
+func (sumberSatusehatDB) terkirim(ctx context.Context, id int, idSatusehat string) error {
+	_, err := config.DB.Exec(ctx,
+		`UPDATE satusehat_sinkron SET status = 'terkirim', id_satusehat = $1, pesan_error = '',
+		 percobaan = percobaan + 1, coba_lagi_at = NULL, terakhir_dikirim = NOW(), updated_at = NOW()
+		 WHERE id_sinkron = $2`, idSatusehat, id)
+	return err
+}
+
+func (sumberSatusehatDB) gagal(ctx context.Context, id int, pesan string, jeda time.Duration) error {
+	_, err := config.DB.Exec(ctx,
+		`UPDATE satusehat_sinkron SET status = 'gagal', pesan_error = $1, percobaan = percobaan + 1,
+		 coba_lagi_at = NOW() + make_interval(secs => $2), terakhir_dikirim = NOW(), updated_at = NOW()
+		 WHERE id_sinkron = $3`, pesan, jeda.Seconds(), id)
+	return err
+}
+
+// idSatusehat mengambil id resource yang sudah terkirim, errBelumSiap bila belum
+func idSatusehat(ctx context.Context, jenis, idLokal string) (string, error) {
+	var id string
+	config.DB.QueryRow(ctx,
+		`SELECT COALESCE(id_satusehat, '') FROM satusehat_sinkron
+		 WHERE jenis_resource = $1 AND id_lokal = $2`, jenis, idLokal).Scan(&id)
+	if id == "" {
+		return "", errBelumSiap
+	}
+	return id, nil
+}
+
+// resourceSatusehat menyusun resource FHIR dari data lokal
+func resourceSatusehat(ctx context.Context, jenis, idLokal, orgID string) (satusehat.Resource, error) {
+	switch jenis {
+	case "Patient":
+		var p satusehat.Pasien
+		var tl interface{}
+		err := config.DB.QueryRow(ctx,
+			`SELECT nik, nama_pasien, tanggal_lahir, jenis_kelamin, alamat FROM pasien WHERE nik = $1`, idLokal,
+		).Scan(&p.NIK, &p.Nama, &tl, &p.JenisKelamin, &p.Alamat)
+		if err != nil {
+			return satusehat.Resource{}, fmt.Errorf("pasien tidak ditemukan")
+		}
+		p.TanggalLahir = formatDate(tl)
+		return satusehat.PatientDari(p), nil
+
+	case "Encounter", "Condition":
+		idPem, _ := strconv.Atoi(idLokal)
+		var k satusehat.Kunjungan
+		var nik, kodeDiagnosa string
+		var tp interface{}
+		err := config.DB.QueryRow(ctx,
+			`SELECT pe.id_pemeriksaan, pe.nik_pasien, pe.tanggal_pemeriksaan, pe.keluhan, po.nama_poli,
+			 COALESCE(d.nama_dokter, po.nama_dokter), COALESCE(pe.kode_diagnosa, '')
+			 FROM pemeriksaan pe
+			 JOIN poli po ON pe.id_poli = po.id_poli
+			 LEFT JOIN dokter d ON pe.id_dokter = d.id_dokter
+			 WHERE pe.id_pemeriksaan = $1`, idPem,
+		).Scan(&k.IDPemeriksaan, &nik, &tp, &k.Keluhan, &k.NamaPoli, &k.NamaDokter, &kodeDiagnosa)
+		if err != nil {
+			return satusehat.Resource{}, fmt.Errorf("pemeriksaan tidak ditemukan")
+		}
+		k.Tanggal = formatDate(tp)
+
+		if k.IDPasienSS, err = idSatusehat(ctx, "Patient", nik); err != nil {
+			return satusehat.Resource{}, err
+		}
+		if jenis == "Encounter" {
+			return satusehat.EncounterDari(k, orgID), nil
+		}
+
+		if kodeDiagnosa == "" {
+			return satusehat.Resource{}, fmt.Errorf("kode diagnosa sudah dihapus")
+		}
+		idKunjungan, err := idSatusehat(ctx, "Encounter", idLokal)
+		if err != nil {
+			return satusehat.Resource{}, err
+		}
+		return satusehat.ConditionDari(kodeDiagnosa, k.Tanggal, k.IDPasienSS, idKunjungan), nil
+
+	case "Observation":
+		idLokalPem, kolom, _ := strings.Cut(idLokal, ":")
+		idPem, _ := strconv.Atoi(idLokalPem)
+		if _, ok := satusehat.JenisTandaVital[kolom]; !ok {
+			return satusehat.Resource{}, fmt.Errorf("jenis tanda vital '%s' tidak dikenal", kolom)
+		}
+
+		v := satusehat.TandaVital{Jenis: kolom}
+		var nilai *float64
+		var nik string
+		var tp interface{}
+		// kolom sudah dicek terhadap daftar JenisTandaVital
+		err := config.DB.QueryRow(ctx,
+			`SELECT tv.`+kolom+`::float8, pe.nik_pasien, pe.tanggal_pemeriksaan
+			 FROM tanda_vital tv JOIN pemeriksaan pe ON tv.id_pemeriksaan = pe.id_pemeriksaan
+			 WHERE tv.id_pemeriksaan = $1`, idPem,
+		).Scan(&nilai, &nik, &tp)
+		if err != nil {
+			return satusehat.Resource{}, fmt.Errorf("tanda vital tidak ditemukan")
+		}
+		if nilai == nil {
+			return satusehat.Resource{}, fmt.Errorf("nilai %s sudah dihapus", kolom)
+		}
+		v.Nilai = *nilai
+		v.Tanggal = formatDate(tp)
+
+		if v.IDPasienSS, err = idSatusehat(ctx, "Patient", nik); err != nil {
+			return satusehat.Resource{}, err
+		}
+		if v.IDKunjungan, err = idSatusehat(ctx, "Encounter", idLokalPem); err != nil {
+			return satusehat.Resource{}, err
+		}
+		return satusehat.ObservationDari(v)
+	}
+
+	return satusehat.Resource{}, fmt.Errorf("jenis resource '%s' tidak dikenal", jenis)
+}
+
+// ─── GET /satusehat/sinkron ──────────────────────────────────────────────────
+
+func GetAllSinkronSatusehat(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	jenis := strings.TrimSpace(c.Query("jenis_resource", ""))
+	status := strings.TrimSpace(c.Query("status", ""))
+	idLokal := strings.TrimSpace(c.Query("id_lokal", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if jenis != "" {
+		baseWhere += ` AND jenis_resource = $` + strconv.Itoa(argIdx)
+		args = append(args, jenis)
+		argIdx++
+	}
+	if status != "" {
+		baseWhere += ` AND status = $` + strconv.Itoa(argIdx)
+		args = append(args, status)
+		argIdx++
+	}
+	if idLokal != "" {
+		baseWhere += ` AND id_lokal = $` + strconv.Itoa(argIdx)
+		args = append(args, idLokal)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM satusehat_sinkron `+baseWhere, args...).Scan(&totalData)
+
+	fetchSQL := satusehatSinkronSelectSQL + baseWhere + `
+		ORDER BY updated_at DESC, id_sinkron DESC
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data sinkronisasi SATUSEHAT")
+	}
+	defer queryRows.Close()
+
+	var rows []model.SatusehatSinkron
+	for queryRows.Next() {
+		s, _ := scanSatusehatSinkron(queryRows)
+		rows = append(rows, s)
+	}
+
+	if rows == nil {
+		rows = []model.SatusehatSinkron{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /satusehat/sinkron/:id ──────────────────────────────────────────────
+
+func GetSinkronSatusehatByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	s, err := scanSatusehatSinkron(config.DB.QueryRow(context.Background(),
+		satusehatSinkronSelectSQL+`WHERE id_sinkron = $1`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Data sinkronisasi tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", s)
+}
+
+// ─── POST /satusehat/sinkron/:id/kirim-ulang ─────────────────────────────────
+
+// KirimUlangSatusehat menjadwalkan satu resource untuk dikirim pada putaran
+// worker berikutnya, termasuk yang sudah melewati batas percobaan
+func KirimUlangSatusehat(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	s, err := scanSatusehatSinkron(config.DB.QueryRow(context.Background(),
+		`UPDATE satusehat_sinkron SET status = 'menunggu', percobaan = 0, coba_lagi_at = NULL, updated_at = NOW()
+		 WHERE id_sinkron = $1
+		 RETURNING id_sinkron, jenis_resource, id_lokal, id_satusehat, status, pesan_error, percobaan,
+		 to_char(coba_lagi_at, 'YYYY-MM-DD HH24:MI:SS'), to_char(terakhir_dikirim, 'YYYY-MM-DD HH24:MI:SS')`, id))
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Data sinkronisasi tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Resource dijadwalkan untuk dikirim ulang", s)
+}
+
+// ─── POST /satusehat/sinkron/kirim-ulang-gagal ───────────────────────────────
+
+func KirimUlangSemuaGagalSatusehat(c *fiber.Ctx) error {
+	result, err := config.DB.Exec(context.Background(),
+		`UPDATE satusehat_sinkron SET status = 'menunggu', percobaan = 0, coba_lagi_at = NULL, updated_at = NOW()
+		 WHERE status = 'gagal'`)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menjadwalkan kirim ulang")
+	}
+
+	return model.SuccessResponse(c, 200, "Resource gagal dijadwalkan untuk dikirim ulang",
+		map[string]int64{"jumlah": result.RowsAffected()})
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sikupas/backend/satusehat"
+)
+
+func TestJedaKirimUlangSatusehat(t *testing.T) {
+	cases := []struct {
+		percobaan int
+		want      time.Duration
+	}{
+		{0, time.Minute},
+		{1, 2 * time.Minute},
+		{3, 8 * time.Minute},
+		{8, 256 * time.Minute},
+		{9, 6 * time.Hour},
+		{40, 6 * time.Hour},
+		{64, 6 * time.Hour},
+	}
+	for _, c := range cases {
+		if got := jedaKirimUlangSatusehat(c.percobaan); got != c.want {
+			t.Errorf("percobaan %d: jeda %v, want %v", c.percobaan, got, c.want)
+		}
+	}
+}
+
+// sumberTiruan menyimpan status sinkron di memori; susun menolak dengan
+// errBelumSiap selama rujukannya belum terkirim, sama seperti resourceSatusehat
+type sumberTiruan struct {
+	jenis   map[int]string
+	idSS    map[string]string // jenis -> id SATUSEHAT
+	urutan  []string          // jenis yang terkirim, sesuai urutan
+	ditunda map[int]time.Duration
+	pesan   map[int]string
+}
+
+func newSumberTiruan() *sumberTiruan {
+	return &sumberTiruan{
+		jenis:   map[int]string{1: "Patient", 2: "Encounter", 3: "Condition"},
+		idSS:    map[string]string{},
+		ditunda: map[int]time.Duration{},
+		pesan:   map[int]string{},
+	}
+}
+
+func (s *sumberTiruan) susun(_ context.Context, jenis, _, orgID string) (satusehat.Resource, error) {
+	switch jenis {
+	case "Patient":
+		return satusehat.PatientDari(satusehat.Pasien{NIK: "3273010101900001", Nama: "Budi Santoso"}), nil
+	case "Encounter":
+		if s.idSS["Patient"] == "" {
+			return satusehat.Resource{}, errBelumSiap
+		}
+		return satusehat.EncounterDari(satusehat.Kunjungan{IDPemeriksaan: 1, Tanggal: "2026-10-19", IDPasienSS: s.idSS["Patient"]}, orgID), nil
+	default:
+		if s.idSS["Patient"] == "" || s.idSS["Encounter"] == "" {
+			return satusehat.Resource{}, errBelumSiap
+		}
+		return satusehat.ConditionDari("J06.9", "2026-10-19", s.idSS["Patient"], s.idSS["Encounter"]), nil
+	}
+}
+
+func (s *sumberTiruan) terkirim(_ context.Context, id int, idSatusehat string) error {
+	s.idSS[s.jenis[id]] = idSatusehat
+	s.urutan = append(s.urutan, s.jenis[id])
+	return nil
+}
+
+func (s *sumberTiruan) gagal(_ context.Context, id int, pesan string, jeda time.Duration) error {
+	s.ditunda[id] = jeda
+	s.pesan[id] = pesan
+	return nil
+}
+
+func klienSatusehatTiruan(t *testing.T) (*satusehat.Client, *satusehat.MockServer) {
+	t.Helper()
+	mock := satusehat.NewMockServer("klien", "rahasia")
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+	return satusehat.NewClient(srv.URL+"/oauth2/v1", srv.URL+"/fhir-r4/v1", "klien", "rahasia", "org-1"), mock
+}
+
+// Dengan urutan dari query worker (Patient, Encounter, lalu Condition) satu
+// putaran cukup untuk mengirim kunjungan baru beserta rujukannya
+func TestKirimTugasSatusehatUrutan(t *testing.T) {
+	client, mock := klienSatusehatTiruan(t)
+	sumber := newSumberTiruan()
+	daftar := []tugasSatusehat{
+		{id: 1, jenis: "Patient", idLokal: "3273010101900001"},
+		{id: 2, jenis: "Encounter", idLokal: "1"},
+		{id: 3, jenis: "Condition", idLokal: "1"},
+	}
+
+	if n := kirimTugasSatusehat(context.Background(), client, daftar, sumber); n != 3 {
+		t.Errorf("diproses %d, want 3", n)
+	}
+	if len(sumber.ditunda) != 0 {
+		t.Errorf("ada yang ditunda: %v", sumber.pesan)
+	}
+	for _, jenis := range []string{"Patient", "Encounter", "Condition"} {
+		if n := mock.Jumlah(jenis); n != 1 {
+			t.Errorf("%s tersimpan %d, want 1", jenis, n)
+		}
+	}
+}
+
+// Tugas yang rujukannya belum terkirim dijadwalkan ulang dengan jeda sesuai
+// percobaannya, lalu terkirim pada putaran berikutnya
+func TestKirimTugasSatusehatBelumSiap(t *testing.T) {
+	client, mock := klienSatusehatTiruan(t)
+	sumber := newSumberTiruan()
+	ctx := context.Background()
+
+	kirimTugasSatusehat(ctx, client, []tugasSatusehat{
+		{id: 3, jenis: "Condition", idLokal: "1", percobaan: 2},
+		{id: 2, jenis: "Encounter", idLokal: "1"},
+		{id: 1, jenis: "Patient", idLokal: "3273010101900001"},
+	}, sumber)
+
+	if sumber.ditunda[3] != 4*time.Minute || sumber.ditunda[2] != time.Minute {
+		t.Errorf("jeda = %v, want Condition 4m dan Encounter 1m", sumber.ditunda)
+	}
+	if sumber.pesan[3] != errBelumSiap.Error() {
+		t.Errorf("pesan = %q", sumber.pesan[3])
+	}
+	if _, ok := sumber.ditunda[1]; ok {
+		t.Error("Patient tidak seharusnya ditunda")
+	}
+
+	kirimTugasSatusehat(ctx, client, []tugasSatusehat{
+		{id: 2, jenis: "Encounter", idLokal: "1", percobaan: 1},
+		{id: 3, jenis: "Condition", idLokal: "1", percobaan: 3},
+	}, sumber)
+
+	want := []string{"Patient", "Encounter", "Condition"}
+	if len(sumber.urutan) != len(want) {
+		t.Fatalf("terkirim %v, want %v", sumber.urutan, want)
+	}
+	for i := range want {
+		if sumber.urutan[i] != want[i] {
+			t.Fatalf("terkirim %v, want %v", sumber.urutan, want)
+		}
+	}
+	if n := mock.Jumlah("Condition"); n != 1 {
+		t.Errorf("Condition tersimpan %d, want 1", n)
+	}
+}
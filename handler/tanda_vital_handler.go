@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// ─── GET /pemeriksaan/:id/tanda-vital ────────────────────────────────────────
+
+func GetTandaVital(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	if code, msg := cakupanPemeriksaan(c, id); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	var tv model.TandaVital
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT id_pemeriksaan, sistole, diastole, nadi, pernapasan, suhu, berat_badan, tinggi_badan
+		 FROM tanda_vital WHERE id_pemeriksaan = $1`, id,
+	).Scan(&tv.IDPemeriksaan, &tv.Sistole, &tv.Diastole, &tv.Nadi, &tv.Pernapasan,
+		&tv.Suhu, &tv.BeratBadan, &tv.TinggiBadan)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tanda vital belum diisi")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", tv)
+}
+
+// ─── PUT /pemeriksaan/:id/tanda-vital ────────────────────────────────────────
+
+// SimpanTandaVital mengisi atau mengganti seluruh tanda vital pemeriksaan
+func SimpanTandaVital(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.TandaVitalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	if code, msg := cakupanPemeriksaan(c, id); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	var tv model.TandaVital
+	err = config.DB.QueryRow(context.Background(),
+		`INSERT INTO tanda_vital (id_pemeriksaan, sistole, diastole, nadi, pernapasan, suhu, berat_badan, tinggi_badan)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 ON CONFLICT (id_pemeriksaan) DO UPDATE SET sistole = EXCLUDED.sistole, diastole = EXCLUDED.diastole,
+		 nadi = EXCLUDED.nadi, pernapasan = EXCLUDED.pernapasan, suhu = EXCLUDED.suhu,
+		 berat_badan = EXCLUDED.berat_badan, tinggi_badan = EXCLUDED.tinggi_badan, updated_at = NOW()
+		 RETURNING id_pemeriksaan, sistole, diastole, nadi, pernapasan, suhu, berat_badan, tinggi_badan`,
+		id, req.Sistole, req.Diastole, req.Nadi, req.Pernapasan, req.Suhu, req.BeratBadan, req.TinggiBadan,
+	).Scan(&tv.IDPemeriksaan, &tv.Sistole, &tv.Diastole, &tv.Nadi, &tv.Pernapasan,
+		&tv.Suhu, &tv.BeratBadan, &tv.TinggiBadan)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan tanda vital: "+err.Error())
+	}
+
+	// Observation yang sudah terkirim diperbarui pada putaran worker berikutnya
+	tandaiSinkronUlangSatusehat("Observation", strconv.Itoa(id)+":%")
+
+	return model.SuccessResponse(c, 200, "Tanda vital berhasil disimpan", tv)
+}
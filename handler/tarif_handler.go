@@ -0,0 +1,365 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// hargaBerlakuSQL menghasilkan ekspresi harga tarif (alias t) yang berlaku
+// pada tanggal tertentu. Kolom tarif.harga hanya cadangan untuk tarif yang
+// belum punya riwayat harga.
+func hargaBerlakuSQL(tanggal string) string {
+	return `COALESCE((SELECT th.harga FROM tarif_harga th
+		WHERE th.id_tarif = t.id_tarif AND th.berlaku_mulai <= ` + tanggal + `
+		ORDER BY th.berlaku_mulai DESC LIMIT 1), t.harga)`
+}
+
+var tarifSelectSQL = `SELECT t.id_tarif, t.kode_tarif, t.nama_layanan, t.kategori,
+	` + hargaBerlakuSQL("CURRENT_DATE") + `, t.aktif
+	FROM tarif t
+	`
+
+// ─── GET /tarif ──────────────────────────────────────────────────────────────
+
+func GetAllTarif(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	perPage, _ := strconv.Atoi(c.Query("per_page", "10"))
+	search := strings.TrimSpace(c.Query("search", ""))
+	kategori := strings.TrimSpace(c.Query("kategori", ""))
+	aktif := strings.TrimSpace(c.Query("aktif", ""))
+
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+	offset := (page - 1) * perPage
+
+	baseWhere := `WHERE 1=1`
+	args := []interface{}{}
+	argIdx := 1
+
+	if kategori != "" {
+		baseWhere += ` AND t.kategori = $` + strconv.Itoa(argIdx)
+		args = append(args, kategori)
+		argIdx++
+	}
+	if aktif == "true" || aktif == "false" {
+		baseWhere += ` AND t.aktif = $` + strconv.Itoa(argIdx)
+		args = append(args, aktif == "true")
+		argIdx++
+	}
+	if search != "" {
+		baseWhere += ` AND (t.kode_tarif ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%' OR t.nama_layanan ILIKE '%'||$` + strconv.Itoa(argIdx) + `||'%')`
+		args = append(args, search)
+		argIdx++
+	}
+
+	var totalData int
+	config.DB.QueryRow(context.Background(), `SELECT COUNT(*) FROM tarif t `+baseWhere, args...).Scan(&totalData)
+
+	fetchSQL := tarifSelectSQL + baseWhere + `
+		ORDER BY t.kategori, t.nama_layanan
+		LIMIT $` + strconv.Itoa(argIdx) + ` OFFSET $` + strconv.Itoa(argIdx+1)
+	args = append(args, perPage, offset)
+
+	queryRows, err := config.DB.Query(context.Background(), fetchSQL, args...)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data tarif")
+	}
+	defer queryRows.Close()
+
+	var rows []model.TarifResponse
+	for queryRows.Next() {
+		var t model.TarifResponse
+		queryRows.Scan(&t.IDTarif, &t.KodeTarif, &t.NamaLayanan, &t.Kategori, &t.Harga, &t.Aktif)
+		rows = append(rows, t)
+	}
+
+	if rows == nil {
+		rows = []model.TarifResponse{}
+	}
+
+	return model.PaginatedSuccessResponse(c, rows, totalData, page, perPage)
+}
+
+// ─── GET /tarif/:id ──────────────────────────────────────────────────────────
+
+func GetTarifByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var t model.TarifResponse
+	err = config.DB.QueryRow(context.Background(), tarifSelectSQL+`WHERE t.id_tarif = $1`, id).Scan(&t.IDTarif, &t.KodeTarif, &t.NamaLayanan, &t.Kategori, &t.Harga, &t.Aktif)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tarif tidak ditemukan")
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", t)
+}
+
+// ─── POST /tarif ─────────────────────────────────────────────────────────────
+
+func CreateTarif(c *fiber.Ctx) error {
+	var req model.TarifRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodeTarif = strings.ToUpper(strings.TrimSpace(req.KodeTarif))
+	req.NamaLayanan = strings.TrimSpace(req.NamaLayanan)
+	req.Kategori = strings.TrimSpace(req.Kategori)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	aktif := true
+	if req.Aktif != nil {
+		aktif = *req.Aktif
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai transaksi")
+	}
+	defer tx.Rollback(ctx)
+
+	var t model.TarifResponse
+	err = tx.QueryRow(ctx,
+		`INSERT INTO tarif (kode_tarif, nama_layanan, kategori, harga, aktif)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id_tarif, kode_tarif, nama_layanan, kategori, harga, aktif`,
+		req.KodeTarif, req.NamaLayanan, req.Kategori, req.Harga, aktif,
+	).Scan(&t.IDTarif, &t.KodeTarif, &t.NamaLayanan, &t.Kategori, &t.Harga, &t.Aktif)
+
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Kode tarif sudah terdaftar")
+		}
+		return model.ErrorResponse(c, 500, "Gagal menambah tarif: "+err.Error())
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO tarif_harga (id_tarif, harga, berlaku_mulai) VALUES ($1, $2, CURRENT_DATE)`,
+		t.IDTarif, req.Harga); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan harga tarif")
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan tarif")
+	}
+
+	return model.SuccessResponse(c, 201, "Tarif berhasil ditambahkan", t)
+}
+
+// ─── PUT /tarif/:id ──────────────────────────────────────────────────────────
+
+// UpdateTarif tidak mengubah tagihan lama karena harga sudah disalin ke
+// tagihan_item saat item ditambahkan. Perubahan harga dicatat sebagai harga
+// yang berlaku mulai hari ini; harga untuk tanggal lain lewat /tarif/:id/harga.
+func UpdateTarif(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.TarifRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.KodeTarif = strings.ToUpper(strings.TrimSpace(req.KodeTarif))
+	req.NamaLayanan = strings.TrimSpace(req.NamaLayanan)
+	req.Kategori = strings.TrimSpace(req.Kategori)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai transaksi")
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE tarif SET kode_tarif=$1, nama_layanan=$2, kategori=$3,
+		 aktif=COALESCE($4, aktif), updated_at=NOW()
+		 WHERE id_tarif=$5`,
+		req.KodeTarif, req.NamaLayanan, req.Kategori, req.Aktif, id)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Kode tarif sudah terdaftar")
+		}
+		return model.ErrorResponse(c, 500, "Gagal update tarif: "+err.Error())
+	}
+
+	var t model.TarifResponse
+	err = tx.QueryRow(ctx, tarifSelectSQL+`WHERE t.id_tarif = $1`, id).Scan(&t.IDTarif, &t.KodeTarif, &t.NamaLayanan, &t.Kategori, &t.Harga, &t.Aktif)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Tarif tidak ditemukan")
+	}
+
+	if req.Harga != t.Harga {
+		if code, msg := simpanHargaTarif(ctx, tx, id, req.Harga, time.Now().Format("2006-01-02")); code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
+		t.Harga = req.Harga
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan tarif")
+	}
+
+	return model.SuccessResponse(c, 200, "Tarif berhasil diupdate", t)
+}
+
+// simpanHargaTarif mencatat harga yang berlaku mulai tanggal tertentu.
+// Kolom tarif.harga ikut disamakan bila harga tersebut sudah berlaku.
+func simpanHargaTarif(ctx context.Context, tx pgx.Tx, idTarif int, harga float64, berlakuMulai string) (int, string) {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO tarif_harga (id_tarif, harga, berlaku_mulai) VALUES ($1, $2, $3)
+		 ON CONFLICT (id_tarif, berlaku_mulai) DO UPDATE SET harga = EXCLUDED.harga`,
+		idTarif, harga, berlakuMulai)
+	if err != nil {
+		if config.IsForeignKeyViolation(err) {
+			return 404, "Tarif tidak ditemukan"
+		}
+		return 500, "Gagal menyimpan harga tarif: " + err.Error()
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE tarif t SET harga = `+hargaBerlakuSQL("CURRENT_DATE")+`, updated_at = NOW()
+		 WHERE t.id_tarif = $1`, idTarif)
+	if err != nil {
+		return 500, "Gagal menyimpan harga tarif: " + err.Error()
+	}
+	return 0, ""
+}
+
+// ─── GET /tarif/:id/harga ────────────────────────────────────────────────────
+
+func GetRiwayatHargaTarif(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var exists bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM tarif WHERE id_tarif = $1)`, id).Scan(&exists)
+	if !exists {
+		return model.ErrorResponse(c, 404, "Tarif tidak ditemukan")
+	}
+
+	queryRows, err := config.DB.Query(context.Background(),
+		`SELECT id_harga, id_tarif, harga, berlaku_mulai FROM tarif_harga
+		 WHERE id_tarif = $1 ORDER BY berlaku_mulai DESC`, id)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil riwayat harga")
+	}
+	defer queryRows.Close()
+
+	rows := []model.TarifHarga{}
+	for queryRows.Next() {
+		var h model.TarifHarga
+		var bm interface{}
+		queryRows.Scan(&h.IDHarga, &h.IDTarif, &h.Harga, &bm)
+		h.BerlakuMulai = formatDate(bm)
+		rows = append(rows, h)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", rows)
+}
+
+// ─── POST /tarif/:id/harga ───────────────────────────────────────────────────
+
+func CreateHargaTarif(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.TarifHargaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.BerlakuMulai = strings.TrimSpace(req.BerlakuMulai)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai transaksi")
+	}
+	defer tx.Rollback(ctx)
+
+	if code, msg := simpanHargaTarif(ctx, tx, id, req.Harga, req.BerlakuMulai); code != 0 {
+		return model.ErrorResponse(c, code, msg)
+	}
+
+	var h model.TarifHarga
+	var bm interface{}
+	err = tx.QueryRow(ctx,
+		`SELECT id_harga, id_tarif, harga, berlaku_mulai FROM tarif_harga
+		 WHERE id_tarif = $1 AND berlaku_mulai = $2`, id, req.BerlakuMulai,
+	).Scan(&h.IDHarga, &h.IDTarif, &h.Harga, &bm)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan harga tarif")
+	}
+	h.BerlakuMulai = formatDate(bm)
+
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan harga tarif")
+	}
+
+	return model.SuccessResponse(c, 201, "Harga tarif berhasil disimpan", h)
+}
+
+// ─── DELETE /tarif/:id/harga/:id_harga ───────────────────────────────────────
+
+// DeleteHargaTarif hanya untuk harga yang belum berlaku, riwayat harga yang
+// sudah dipakai tetap disimpan
+func DeleteHargaTarif(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+	idHarga, err := strconv.Atoi(c.Params("id_harga"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID harga tidak valid")
+	}
+
+	var belumBerlaku bool
+	err = config.DB.QueryRow(context.Background(),
+		`SELECT berlaku_mulai > CURRENT_DATE FROM tarif_harga WHERE id_harga = $1 AND id_tarif = $2`,
+		idHarga, id,
+	).Scan(&belumBerlaku)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "Harga tarif tidak ditemukan")
+	}
+	if !belumBerlaku {
+		return model.ErrorResponse(c, 409, "Harga yang sudah berlaku tidak bisa dihapus")
+	}
+
+	config.DB.Exec(context.Background(), `DELETE FROM tarif_harga WHERE id_harga = $1`, idHarga)
+
+	return model.SuccessResponse(c, 200, "Harga tarif berhasil dihapus", nil)
+}
@@ -2,16 +2,20 @@ package handler
 
 import (
 	"context"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"sikupas/backend/config"
 	"sikupas/backend/middleware"
 	"sikupas/backend/model"
 )
 
-// ─── Register ────────────────────────────────────────────────────────────────
+// ─── POST /users ─────────────────────────────────────────────────────────────
 
+// Register membuat akun petugas baru; hanya untuk user dengan izin
+// user.kelola dan role wajib disebut
 func Register(c *fiber.Ctx) error {
 	var req model.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -22,44 +26,108 @@ func Register(c *fiber.Ctx) error {
 	if errs := req.Validate(); len(errs) > 0 {
 		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
 	}
+	if !roleAda(strings.TrimSpace(req.Role)) {
+		return model.ErrorResponse(c, 400, "Role tidak ditemukan")
+	}
 
-	req.Nama = strings.TrimSpace(req.Nama)
-	req.Username = strings.TrimSpace(req.Username)
-	req.Password = strings.TrimSpace(req.Password)
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai transaksi")
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := buatUser(ctx, tx, req)
+	if err != nil {
+		if config.IsUniqueViolation(err) {
+			return model.ErrorResponse(c, 409, "Username sudah terdaftar")
+		}
+		return model.ErrorResponse(c, 500, "Gagal mendaftar user: "+err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan user")
+	}
+	catatAudit(c, "user.buat", "user", strconv.Itoa(user.ID), user.Username+" ("+user.Role+")")
+
+	return model.SuccessResponse(c, 201, "Registrasi berhasil", userResponseBaru(user))
+}
 
-	// Default role = admin jika kosong
-	if req.Role == "" {
-		req.Role = "admin"
+// ─── POST /auth/setup ────────────────────────────────────────────────────────
+
+// SetupAdmin membuat admin pertama. Hanya berlaku selama tabel users masih
+// kosong; setelah itu akun baru dibuat admin lewat POST /users.
+func SetupAdmin(c *fiber.Ctx) error {
+	var req model.RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
 	}
 
+	req.Role = model.RoleAdmin
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memulai transaksi")
+	}
+	defer tx.Rollback(ctx)
+
+	// Kunci supaya dua permintaan setup bersamaan tidak sama-sama lolos
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('setup_admin'))`); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengunci setup")
+	}
+	var adaUser bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM users)`).Scan(&adaUser); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa user")
+	}
+	if adaUser {
+		return model.ErrorResponse(c, 403, "Setup sudah dilakukan, minta admin membuatkan akun")
+	}
+
+	user, err := buatUser(ctx, tx, req)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal membuat admin: "+err.Error())
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal menyimpan admin")
+	}
+
+	c.Locals("user_id", user.ID)
+	c.Locals("username", user.Username)
+	catatAudit(c, "user.setup_admin", "user", strconv.Itoa(user.ID), user.Username)
+
+	return model.SuccessResponse(c, 201, "Admin pertama berhasil dibuat", userResponseBaru(user))
+}
+
+func buatUser(ctx context.Context, tx pgx.Tx, req model.RegisterRequest) (model.User, error) {
 	// Hash password
-	hashedPwd, err := model.HashPassword(req.Password)
+	hashedPwd, err := model.HashPassword(strings.TrimSpace(req.Password))
 	if err != nil {
-		return model.ErrorResponse(c, 500, "Gagal hash password")
+		return model.User{}, err
 	}
 
 	// Insert ke DB
 	var user model.User
-	err = config.DB.QueryRow(context.Background(),
+	err = tx.QueryRow(ctx,
 		`INSERT INTO users (nama, username, password, role)
 		 VALUES ($1, $2, $3, $4)
 		 RETURNING id, nama, username, role, created_at, updated_at`,
-		req.Nama, req.Username, hashedPwd, req.Role,
+		strings.TrimSpace(req.Nama), strings.TrimSpace(req.Username), hashedPwd, strings.TrimSpace(req.Role),
 	).Scan(&user.ID, &user.Nama, &user.Username, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	return user, err
+}
 
-	if err != nil {
-		if config.IsUniqueViolation(err) {
-			return model.ErrorResponse(c, 409, "Username sudah terdaftar")
-		}
-		return model.ErrorResponse(c, 500, "Gagal mendaftar user: "+err.Error())
-	}
-
-	return model.SuccessResponse(c, 201, "Registrasi berhasil", model.UserResponse{
+func userResponseBaru(user model.User) model.UserResponse {
+	resp := model.UserResponse{
 		ID:       user.ID,
 		Nama:     user.Nama,
 		Username: user.Username,
 		Role:     user.Role,
-	})
+	}
+	resp.Izin, _ = middleware.IzinRole(user.Role)
+	return resp
 }
 
 // ─── Login ───────────────────────────────────────────────────────────────────
@@ -77,6 +145,17 @@ func Login(c *fiber.Ctx) error {
 	req.Username = strings.TrimSpace(req.Username)
 	req.Password = strings.TrimSpace(req.Password)
 
+	// Tolak sebelum memeriksa password selama username atau IP masih
+	// dalam back-off atau terkunci
+	if sisa, terkunci := cekKunciLogin(req.Username, c.IP()); sisa > 0 {
+		keterangan := "ditunda"
+		if terkunci {
+			keterangan = "terkunci"
+		}
+		catatPercobaanLogin(c, req.Username, false, keterangan)
+		return tolakLoginTerkunci(c, sisa, terkunci)
+	}
+
 	// Cari user di DB
 	var user model.User
 	var hashedPwd string
@@ -85,13 +164,58 @@ func Login(c *fiber.Ctx) error {
 		req.Username,
 	).Scan(&user.ID, &user.Nama, &user.Username, &hashedPwd, &user.Role)
 
+	// Username tidak terdaftar diperlakukan sama dengan password salah
+	// supaya keberadaan akun tidak bisa ditebak, termasuk dari waktu respons
+	if err == nil {
+		err = model.ComparePassword(hashedPwd, req.Password)
+	} else {
+		model.ComparePassword(hashPasswordDummy(), req.Password)
+	}
 	if err != nil {
+		catatPercobaanLogin(c, req.Username, false, "password_salah")
+		if catatGagalLogin(req.Username, c.IP()) {
+			return model.ErrorResponse(c, 401, "Username atau password salah, akun dikunci sementara selama "+
+				strconv.Itoa(int(config.GetLoginLamaKunci().Minutes()))+" menit")
+		}
 		return model.ErrorResponse(c, 401, "Username atau password salah")
 	}
 
-	// Cek password
-	if err := model.ComparePassword(hashedPwd, req.Password); err != nil {
-		return model.ErrorResponse(c, 401, "Username atau password salah")
+	// User 2FA menerima token tantangan; token akses baru diberikan setelah
+	// kode diverifikasi di /auth/2fa/verifikasi
+	aktif, wajib, err := status2FA(user.ID)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa status 2FA")
+	}
+	if aktif || wajib {
+		catatPercobaanLogin(c, user.Username, false, "menunggu_2fa")
+		tantangan, err := middleware.GenerateTokenTantangan(user.ID, user.Username, user.Role)
+		if err != nil {
+			return model.ErrorResponse(c, 500, "Gagal generate token")
+		}
+		pesan := "Masukkan kode 2FA"
+		if !aktif {
+			pesan = "Role Anda wajib memakai 2FA, daftarkan aplikasi authenticator terlebih dahulu"
+		}
+		return model.SuccessResponse(c, 200, pesan, model.TantanganLoginResponse{
+			TokenTantangan: tantangan,
+			PerluDaftar:    !aktif,
+			BerlakuDetik:   int(middleware.BerlakuTantangan2FA.Seconds()),
+		})
+	}
+
+	resetKunciLogin(user.Username)
+	catatPercobaanLogin(c, user.Username, true, "berhasil")
+
+	return selesaikanLogin(c, user, req.IDLoket, nil)
+}
+
+// selesaikanLogin memilih loket (jika diminta) lalu menerbitkan token akses
+func selesaikanLogin(c *fiber.Ctx, user model.User, idLoket *int, kodePemulihan []string) error {
+	// Petugas pendaftaran memilih loket sekaligus saat login
+	if idLoket != nil {
+		if code, msg := pilihLoket(user.ID, idLoket); code != 0 {
+			return model.ErrorResponse(c, code, msg)
+		}
 	}
 
 	// Generate token
@@ -100,14 +224,22 @@ func Login(c *fiber.Ctx) error {
 		return model.ErrorResponse(c, 500, "Gagal generate token")
 	}
 
+	resp := model.UserResponse{
+		ID:       user.ID,
+		Nama:     user.Nama,
+		Username: user.Username,
+		Role:     user.Role,
+	}
+	isiLoketUser(&resp)
+	resp.Izin, _ = middleware.IzinRole(user.Role)
+	if resp.Aktif2FA, _, err = status2FA(user.ID); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal memeriksa status 2FA")
+	}
+
 	return model.SuccessResponse(c, 200, "Login berhasil", model.LoginResponse{
-		Token: token,
-		User: model.UserResponse{
-			ID:       user.ID,
-			Nama:     user.Nama,
-			Username: user.Username,
-			Role:     user.Role,
-		},
+		Token:         token,
+		User:          resp,
+		KodePemulihan: kodePemulihan,
 	})
 }
 
@@ -118,12 +250,207 @@ func GetCurrentUser(c *fiber.Ctx) error {
 
 	var user model.UserResponse
 	err := config.DB.QueryRow(context.Background(),
-		`SELECT id, nama, username, role FROM users WHERE id = $1`, userID,
-	).Scan(&user.ID, &user.Nama, &user.Username, &user.Role)
+		`SELECT id, nama, username, role, totp_aktif FROM users WHERE id = $1`, userID,
+	).Scan(&user.ID, &user.Nama, &user.Username, &user.Role, &user.Aktif2FA)
 
 	if err != nil {
 		return model.ErrorResponse(c, 404, "User tidak ditemukan")
 	}
+	isiLoketUser(&user)
+	isiCakupanUser(&user)
+
+	// Izin efektif dipakai frontend untuk menampilkan menu yang boleh diakses
+	user.Izin, err = middleware.IzinRole(user.Role)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil izin user")
+	}
 
 	return model.SuccessResponse(c, 200, "Berhasil", user)
 }
+
+// isiLoketUser mengisi loket yang sedang dipegang user, jika ada
+func isiLoketUser(u *model.UserResponse) {
+	config.DB.QueryRow(context.Background(),
+		`SELECT l.id_loket, l.nama_loket FROM sesi_loket s JOIN loket l ON s.id_loket = l.id_loket
+		 WHERE s.id_user = $1 AND s.selesai_at IS NULL`, u.ID,
+	).Scan(&u.IDLoket, &u.NamaLoket)
+}
+
+// roleAda cek kode role terdaftar di tabel role
+func roleAda(kode string) bool {
+	var ada bool
+	config.DB.QueryRow(context.Background(),
+		`SELECT EXISTS(SELECT 1 FROM role WHERE kode_role = $1)`, kode).Scan(&ada)
+	return ada
+}
+
+// ─── GET /.well-known/jwks.json ──────────────────────────────────────────────
+
+// GetJWKS menerbitkan public key penanda tangan token supaya sistem lain
+// bisa memverifikasi token SIKUPAS. Kosong jika server memakai HS256.
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=3600")
+	return c.JSON(fiber.Map{"keys": middleware.DaftarJWK()})
+}
+
+// ─── GET /users ──────────────────────────────────────────────────────────────
+
+func GetAllUser(c *fiber.Ctx) error {
+	role := strings.TrimSpace(c.Query("role", ""))
+
+	rows, err := config.DB.Query(context.Background(),
+		`SELECT id, nama, username, role, totp_aktif,
+			ARRAY(SELECT id_poli FROM user_poli up WHERE up.id_user = users.id ORDER BY id_poli),
+			(SELECT to_char(terkunci_sampai, 'YYYY-MM-DD HH24:MI:SS') FROM login_kunci lk
+			 WHERE lk.jenis = 'username' AND lk.nilai = users.username AND lk.terkunci_sampai > NOW())
+		 FROM users
+		 WHERE ($1 = '' OR role = $1)
+		 ORDER BY nama, id`, role)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal mengambil data user")
+	}
+	defer rows.Close()
+
+	users := []model.UserResponse{}
+	for rows.Next() {
+		var u model.UserResponse
+		rows.Scan(&u.ID, &u.Nama, &u.Username, &u.Role, &u.Aktif2FA, &u.IDPoli, &u.TerkunciSampai)
+		users = append(users, u)
+	}
+
+	return model.SuccessResponse(c, 200, "Berhasil", users)
+}
+
+// ─── PUT /users/:id/role ─────────────────────────────────────────────────────
+
+// UpdateRoleUser memindahkan user ke role lain; berlaku pada request
+// berikutnya tanpa perlu login ulang. Admin terakhir tidak bisa dipindahkan
+// supaya selalu ada yang bisa mengelola role.
+func UpdateRoleUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.UbahRoleUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	req.Role = strings.TrimSpace(req.Role)
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+	if !roleAda(req.Role) {
+		return model.ErrorResponse(c, 400, "Role tidak ditemukan")
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update role user")
+	}
+	defer tx.Rollback(ctx)
+
+	// Kunci baris admin supaya dua admin tidak saling menurunkan bersamaan
+	var roleLama string
+	var jumlahAdmin int
+	err = tx.QueryRow(ctx,
+		`SELECT role, (SELECT COUNT(*) FROM (SELECT 1 FROM users WHERE role = $2 FOR UPDATE) a)
+		 FROM users WHERE id = $1`, id, model.RoleAdmin,
+	).Scan(&roleLama, &jumlahAdmin)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "User tidak ditemukan")
+	}
+	if roleLama == model.RoleAdmin && req.Role != model.RoleAdmin && jumlahAdmin <= 1 {
+		return model.ErrorResponse(c, 409, "User ini adalah admin terakhir, role-nya tidak bisa diubah")
+	}
+
+	var user model.UserResponse
+	err = tx.QueryRow(ctx,
+		`UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2
+		 RETURNING id, nama, username, role`, req.Role, id,
+	).Scan(&user.ID, &user.Nama, &user.Username, &user.Role)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update role user")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update role user")
+	}
+
+	catatAudit(c, "user.ubah_role", "user", strconv.Itoa(id), roleLama+" -> "+req.Role)
+
+	user.Izin, _ = middleware.IzinRole(user.Role)
+	return model.SuccessResponse(c, 200, "Role user berhasil diupdate", user)
+}
+
+// ─── PUT /users/:id/poli ─────────────────────────────────────────────────────
+
+// UpdatePoliUser mengganti poli tugas user; hanya berpengaruh untuk role
+// dengan batasi_poli
+func UpdatePoliUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return model.ErrorResponse(c, 400, "ID tidak valid")
+	}
+
+	var req model.UserPoliRequest
+	if err := c.BodyParser(&req); err != nil {
+		return model.ErrorResponse(c, 400, "Format request body tidak valid")
+	}
+
+	if errs := req.Validate(); len(errs) > 0 {
+		return model.ErrorResponse(c, 400, "Validasi gagal", errs)
+	}
+	if req.IDPoli == nil {
+		req.IDPoli = []int{}
+	}
+
+	ctx := context.Background()
+	tx, err := config.DB.Begin(ctx)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update poli user")
+	}
+	defer tx.Rollback(ctx)
+
+	var user model.UserResponse
+	err = tx.QueryRow(ctx,
+		`SELECT id, nama, username, role FROM users WHERE id = $1 FOR UPDATE`, id,
+	).Scan(&user.ID, &user.Nama, &user.Username, &user.Role)
+	if err != nil {
+		return model.ErrorResponse(c, 404, "User tidak ditemukan")
+	}
+
+	var poliAda bool
+	tx.QueryRow(ctx,
+		`SELECT NOT EXISTS(SELECT 1 FROM unnest($1::int[]) AS p WHERE p NOT IN (SELECT id_poli FROM poli))`,
+		req.IDPoli,
+	).Scan(&poliAda)
+	if !poliAda {
+		return model.ErrorResponse(c, 400, "Poli tidak ditemukan")
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_poli WHERE id_user = $1`, id); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update poli user")
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO user_poli (id_user, id_poli) SELECT $1, p FROM unnest($2::int[]) AS p ON CONFLICT DO NOTHING`,
+		id, req.IDPoli)
+	if err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update poli user")
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.ErrorResponse(c, 500, "Gagal update poli user")
+	}
+
+	keterangan := make([]string, len(req.IDPoli))
+	for i, p := range req.IDPoli {
+		keterangan[i] = strconv.Itoa(p)
+	}
+	catatAudit(c, "user.ubah_poli", "user", strconv.Itoa(id), strings.Join(keterangan, ","))
+
+	isiCakupanUser(&user)
+	user.Izin, _ = middleware.IzinRole(user.Role)
+	return model.SuccessResponse(c, 200, "Poli user berhasil diupdate", user)
+}
@@ -14,32 +14,61 @@ import (
 )
 
 // ─── Simple JWT Implementation ───────────────────────────────────────────────
-// Menggunakan HS256 signing manual tanpa dependency tambahan
+// Signing manual tanpa dependency tambahan; HS256, RS256 atau EdDSA sesuai
+// kunci aktif (lihat kunci_jwt.go)
 
 type jwtHeader struct {
 	Alg string `json:"alg"`
 	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
 }
 
 type jwtPayload struct {
 	UserID   int    `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	Tipe     string `json:"tipe,omitempty"` // kosong = token akses, "2fa" = token tantangan login
 	Exp      int64  `json:"exp"`
 	Iat      int64  `json:"iat"`
 }
 
+// Token tantangan hanya untuk menyelesaikan langkah 2FA saat login
+const (
+	tipeTantangan2FA    = "2fa"
+	BerlakuTantangan2FA = 5 * time.Minute
+)
+
 // GenerateToken membuat JWT token
 func GenerateToken(userID int, username, role string) (string, error) {
-	header := jwtHeader{Alg: "HS256", Typ: "JWT"}
 	now := time.Now()
-	payload := jwtPayload{
+	return signToken(jwtPayload{
 		UserID:   userID,
 		Username: username,
 		Role:     role,
 		Iat:      now.Unix(),
 		Exp:      now.Add(24 * time.Hour).Unix(), // Token berlaku 24 jam
+	})
+}
+
+// GenerateTokenTantangan membuat token berumur pendek yang diberikan setelah
+// password benar, untuk ditukar dengan token akses setelah kode 2FA benar
+func GenerateTokenTantangan(userID int, username, role string) (string, error) {
+	now := time.Now()
+	return signToken(jwtPayload{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		Tipe:     tipeTantangan2FA,
+		Iat:      now.Unix(),
+		Exp:      now.Add(BerlakuTantangan2FA).Unix(),
+	})
+}
+
+func signToken(payload jwtPayload) (string, error) {
+	if kunciAktif == nil {
+		return "", fmt.Errorf("kunci JWT belum dimuat")
 	}
+	header := jwtHeader{Alg: kunciAktif.alg, Typ: "JWT", Kid: kunciAktif.kid}
 
 	headerJSON, _ := json.Marshal(header)
 	payloadJSON, _ := json.Marshal(payload)
@@ -48,9 +77,12 @@ func GenerateToken(userID int, username, role string) (string, error) {
 	payloadB64 := base64.RawURLEncoding.EncodeToString(payloadJSON)
 
 	signingInput := headerB64 + "." + payloadB64
-	signature := signHS256(signingInput, model.GetJWTSecret())
+	signature, err := kunciAktif.tandatangani(signingInput)
+	if err != nil {
+		return "", err
+	}
 
-	token := signingInput + "." + signature
+	token := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
 	return token, nil
 }
 
@@ -61,10 +93,31 @@ func ParseToken(tokenString string) (*jwtPayload, error) {
 		return nil, fmt.Errorf("format token tidak valid")
 	}
 
-	signingInput := parts[0] + "." + parts[1]
-	expectedSig := signHS256(signingInput, model.GetJWTSecret())
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("gagal decode header")
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, fmt.Errorf("gagal parse header")
+	}
 
-	if !hmac.Equal([]byte(parts[2]), []byte(expectedSig)) {
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("signature token tidak valid")
+	}
+
+	// Algoritma harus sama dengan algoritma kunci kid-nya supaya token tidak
+	// bisa memilih cara verifikasinya sendiri
+	signingInput := parts[0] + "." + parts[1]
+	valid := false
+	for _, k := range kunciUntuk(header.Kid, header.Alg) {
+		if k.verifikasi(signingInput, signature) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
 		return nil, fmt.Errorf("signature token tidak valid")
 	}
 
@@ -86,10 +139,10 @@ func ParseToken(tokenString string) (*jwtPayload, error) {
 	return &payload, nil
 }
 
-func signHS256(input, secret string) string {
-	mac := hmac.New(sha256.New, []byte(secret))
+func signHS256(input string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
 	mac.Write([]byte(input))
-	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return mac.Sum(nil)
 }
 
 // ─── Auth Middleware ─────────────────────────────────────────────────────────
@@ -111,6 +164,9 @@ func AuthRequired() fiber.Handler {
 		if err != nil {
 			return model.ErrorResponse(c, 401, err.Error())
 		}
+		if payload.Tipe != "" {
+			return model.ErrorResponse(c, 401, "Login belum selesai, verifikasi kode 2FA terlebih dahulu")
+		}
 
 		// Simpan user info di context
 		c.Locals("user_id", payload.UserID)
@@ -121,20 +177,27 @@ func AuthRequired() fiber.Handler {
 	}
 }
 
-// RoleRequired middleware – cek role tertentu diizinkan
-func RoleRequired(allowedRoles ...string) fiber.Handler {
+// TantanganRequired middleware – cek token tantangan 2FA dari langkah pertama
+// login; hanya dipakai endpoint penyelesaian login
+func TantanganRequired() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		role, ok := c.Locals("role").(string)
-		if !ok {
-			return model.ErrorResponse(c, 403, "Role tidak ditemukan")
+		tokenString := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+		if tokenString == "" {
+			return model.ErrorResponse(c, 401, "Token tantangan 2FA diperlukan")
 		}
 
-		for _, allowed := range allowedRoles {
-			if role == allowed {
-				return c.Next()
-			}
+		payload, err := ParseToken(tokenString)
+		if err != nil {
+			return model.ErrorResponse(c, 401, err.Error())
 		}
+		if payload.Tipe != tipeTantangan2FA {
+			return model.ErrorResponse(c, 401, "Token tantangan 2FA tidak valid")
+		}
+
+		c.Locals("user_id", payload.UserID)
+		c.Locals("username", payload.Username)
+		c.Locals("role", payload.Role)
 
-		return model.ErrorResponse(c, 403, "Anda tidak memiliki akses untuk fitur ini")
+		return c.Next()
 	}
 }
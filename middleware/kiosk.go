@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// ─── Kiosk Middleware ────────────────────────────────────────────────────────
+
+// KioskRequired middleware – cek header X-Kiosk-Key milik kiosk aktif yang
+// punya izin tertentu. Info kiosk disimpan di Locals kiosk_id / kiosk_nama.
+func KioskRequired(izin string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get("X-Kiosk-Key"))
+		if len(key) < 8 {
+			return model.ErrorResponse(c, 401, "API key kiosk diperlukan")
+		}
+
+		var idKiosk int
+		var nama, hash, izinKiosk string
+		var aktif bool
+		err := config.DB.QueryRow(context.Background(),
+			`SELECT id_kiosk, nama_kiosk, key_hash, izin, aktif FROM kiosk WHERE key_prefix = $1`,
+			key[:8],
+		).Scan(&idKiosk, &nama, &hash, &izinKiosk, &aktif)
+		if err != nil || subtle.ConstantTimeCompare([]byte(hash), []byte(model.HashKioskKey(key))) != 1 {
+			return model.ErrorResponse(c, 401, "API key kiosk tidak valid")
+		}
+
+		if !aktif {
+			return model.ErrorResponse(c, 403, "Kiosk sedang dinonaktifkan")
+		}
+		if izinKiosk != izin {
+			return model.ErrorResponse(c, 403, "Kiosk tidak memiliki izin untuk fitur ini")
+		}
+
+		config.DB.Exec(context.Background(),
+			`UPDATE kiosk SET terakhir_aktif = NOW() WHERE id_kiosk = $1`, idKiosk)
+
+		c.Locals("kiosk_id", idKiosk)
+		c.Locals("kiosk_nama", nama)
+
+		return c.Next()
+	}
+}
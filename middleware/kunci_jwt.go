@@ -0,0 +1,308 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"sikupas/backend/config"
+)
+
+// ─── Kunci JWT ───────────────────────────────────────────────────────────────
+// Setiap token membawa kid di header sehingga beberapa kunci bisa berlaku
+// bersamaan. Rotasi: pindahkan kunci aktif ke JWT_SECRET_LAMA atau
+// JWT_PUBLIC_KEY_LAMA, pasang kunci baru dengan JWT_KID baru, lalu hapus
+// kunci lama setelah token terakhirnya kedaluwarsa.
+
+// Secret bawaan hanya diterima jika APP_ENV=development diatur eksplisit
+const secretJWTDevelopment = "sikupas_default_secret_key_2024"
+
+const panjangMinSecretJWT = 32
+
+type kunciJWT struct {
+	kid     string
+	alg     string // HS256, RS256 atau EdDSA
+	rahasia []byte
+	privat  crypto.Signer // nil untuk kunci yang hanya memverifikasi
+	publik  crypto.PublicKey
+}
+
+var (
+	kunciAktif *kunciJWT
+	kunciKid   = map[string]*kunciJWT{}
+)
+
+// MuatKunciJWT membaca kunci dari environment. Dipanggil sekali saat start;
+// error berarti server tidak boleh berjalan.
+func MuatKunciJWT() error {
+	aktif := &kunciJWT{kid: config.GetJWTKid(), alg: config.GetJWTAlg()}
+	semua := map[string]*kunciJWT{}
+
+	if aktif.alg == "HS256" {
+		secret := config.GetJWTSecret()
+		if secret == "" && config.IsDevelopment() {
+			secret = secretJWTDevelopment
+		}
+		if err := cekSecretJWT(secret); err != nil {
+			return fmt.Errorf("JWT_SECRET: %v", err)
+		}
+		aktif.rahasia = []byte(secret)
+	} else {
+		path := config.GetJWTPrivateKeyFile()
+		if path == "" {
+			return fmt.Errorf("JWT_PRIVATE_KEY_FILE wajib diisi untuk %s", aktif.alg)
+		}
+		privat, err := bacaPrivateKey(path)
+		if err != nil {
+			return fmt.Errorf("JWT_PRIVATE_KEY_FILE: %v", err)
+		}
+		if algKunci(privat.Public()) != aktif.alg {
+			return fmt.Errorf("JWT_PRIVATE_KEY_FILE bukan kunci %s", aktif.alg)
+		}
+		aktif.privat = privat
+		aktif.publik = privat.Public()
+	}
+	semua[aktif.kid] = aktif
+
+	for kid, secret := range config.GetJWTSecretLama() {
+		if _, ada := semua[kid]; ada {
+			return fmt.Errorf("kid JWT %q dipakai lebih dari satu kunci", kid)
+		}
+		if err := cekSecretJWT(secret); err != nil {
+			return fmt.Errorf("JWT_SECRET_LAMA %s: %v", kid, err)
+		}
+		semua[kid] = &kunciJWT{kid: kid, alg: "HS256", rahasia: []byte(secret)}
+	}
+
+	for kid, path := range config.GetJWTPublicKeyLama() {
+		if _, ada := semua[kid]; ada {
+			return fmt.Errorf("kid JWT %q dipakai lebih dari satu kunci", kid)
+		}
+		publik, err := bacaPublicKey(path)
+		if err != nil {
+			return fmt.Errorf("JWT_PUBLIC_KEY_LAMA %s: %v", kid, err)
+		}
+		semua[kid] = &kunciJWT{kid: kid, alg: algKunci(publik), publik: publik}
+	}
+
+	kunciAktif = aktif
+	kunciKid = semua
+	return nil
+}
+
+// InfoKunciJWT ringkasan kunci aktif untuk log saat start
+func InfoKunciJWT() string {
+	if kunciAktif == nil {
+		return "belum dimuat"
+	}
+	info := kunciAktif.alg + " (kid " + kunciAktif.kid + ")"
+	if n := len(kunciKid) - 1; n > 0 {
+		info += fmt.Sprintf(", %d kunci lama", n)
+	}
+	if string(kunciAktif.rahasia) == secretJWTDevelopment {
+		info += " ⚠️  secret development"
+	}
+	return info
+}
+
+func cekSecretJWT(secret string) error {
+	if secret == "" {
+		return fmt.Errorf("wajib diisi, kecuali APP_ENV=development")
+	}
+	if config.IsDevelopment() {
+		return nil
+	}
+	if secret == secretJWTDevelopment {
+		return fmt.Errorf("secret bawaan tidak boleh dipakai di luar development")
+	}
+	if len(secret) < panjangMinSecretJWT {
+		return fmt.Errorf("minimal %d karakter", panjangMinSecretJWT)
+	}
+	return nil
+}
+
+func bacaPrivateKey(path string) (crypto.Signer, error) {
+	der, jenis, err := bacaPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if jenis == "RSA PRIVATE KEY" {
+		k, err := x509.ParsePKCS1PrivateKey(der)
+		if err != nil {
+			return nil, err
+		}
+		return k, cekKunciRSA(&k.PublicKey)
+	}
+
+	k, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch k := k.(type) {
+	case *rsa.PrivateKey:
+		return k, cekKunciRSA(&k.PublicKey)
+	case ed25519.PrivateKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("hanya kunci RSA atau Ed25519 yang didukung")
+}
+
+func bacaPublicKey(path string) (crypto.PublicKey, error) {
+	der, jenis, err := bacaPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if jenis == "RSA PUBLIC KEY" {
+		k, err := x509.ParsePKCS1PublicKey(der)
+		if err != nil {
+			return nil, err
+		}
+		return k, cekKunciRSA(k)
+	}
+
+	k, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	switch k := k.(type) {
+	case *rsa.PublicKey:
+		return k, cekKunciRSA(k)
+	case ed25519.PublicKey:
+		return k, nil
+	}
+	return nil, fmt.Errorf("hanya kunci RSA atau Ed25519 yang didukung")
+}
+
+func bacaPEM(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	blok, _ := pem.Decode(data)
+	if blok == nil {
+		return nil, "", fmt.Errorf("bukan file PEM")
+	}
+	return blok.Bytes, blok.Type, nil
+}
+
+func cekKunciRSA(k *rsa.PublicKey) error {
+	if k.N.BitLen() < 2048 {
+		return fmt.Errorf("kunci RSA minimal 2048 bit")
+	}
+	return nil
+}
+
+func algKunci(k crypto.PublicKey) string {
+	if _, ok := k.(ed25519.PublicKey); ok {
+		return "EdDSA"
+	}
+	return "RS256"
+}
+
+// ─── Tanda Tangan ────────────────────────────────────────────────────────────
+
+func (k *kunciJWT) tandatangani(input string) ([]byte, error) {
+	switch k.alg {
+	case "HS256":
+		return signHS256(input, k.rahasia), nil
+	case "RS256":
+		sum := sha256.Sum256([]byte(input))
+		return k.privat.Sign(rand.Reader, sum[:], crypto.SHA256)
+	case "EdDSA":
+		return k.privat.Sign(rand.Reader, []byte(input), crypto.Hash(0))
+	}
+	return nil, fmt.Errorf("algoritma %s tidak didukung", k.alg)
+}
+
+func (k *kunciJWT) verifikasi(input string, sig []byte) bool {
+	switch k.alg {
+	case "HS256":
+		return hmac.Equal(sig, signHS256(input, k.rahasia))
+	case "RS256":
+		sum := sha256.Sum256([]byte(input))
+		return rsa.VerifyPKCS1v15(k.publik.(*rsa.PublicKey), crypto.SHA256, sum[:], sig) == nil
+	case "EdDSA":
+		return ed25519.Verify(k.publik.(ed25519.PublicKey), []byte(input), sig)
+	}
+	return false
+}
+
+// kunciUntuk mencari kunci pemverifikasi token. Token tanpa kid diterbitkan
+// sebelum rotasi kunci ada dan selalu HS256, jadi dicoba ke semua secret.
+func kunciUntuk(kid, alg string) []*kunciJWT {
+	if kid != "" {
+		if k, ok := kunciKid[kid]; ok && k.alg == alg {
+			return []*kunciJWT{k}
+		}
+		return nil
+	}
+	if alg != "HS256" {
+		return nil
+	}
+	var hasil []*kunciJWT
+	for _, k := range kunciKid {
+		if k.alg == "HS256" {
+			hasil = append(hasil, k)
+		}
+	}
+	return hasil
+}
+
+// ─── JWKS ────────────────────────────────────────────────────────────────────
+
+// JWK public key dalam format RFC 7517. Secret HS256 tidak pernah
+// diterbitkan.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// DaftarJWK mengembalikan public key aktif dan lama, kunci aktif lebih dulu
+func DaftarJWK() []JWK {
+	b64 := base64.RawURLEncoding
+	keys := []JWK{}
+	for _, k := range kunciKid {
+		jwk := JWK{Kid: k.kid, Alg: k.alg, Use: "sig"}
+		switch pub := k.publik.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = b64.EncodeToString(pub.N.Bytes())
+			jwk.E = b64.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = b64.EncodeToString(pub)
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	aktif := ""
+	if kunciAktif != nil {
+		aktif = kunciAktif.kid
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i].Kid == aktif) != (keys[j].Kid == aktif) {
+			return keys[i].Kid == aktif
+		}
+		return keys[i].Kid < keys[j].Kid
+	})
+	return keys
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sikupas/backend/config"
+	"sikupas/backend/model"
+)
+
+// ─── Permission Middleware ───────────────────────────────────────────────────
+
+// Izin per role disimpan sebentar di memori supaya setiap request tidak perlu
+// membaca role_izin; perubahan role langsung mengosongkan cache di instance
+// yang sama, instance lain menyusul paling lama setelah umurCacheIzin
+const umurCacheIzin = time.Minute
+
+type cacheIzin struct {
+	izin    map[string]bool
+	diambil time.Time
+}
+
+var (
+	cacheIzinMu   sync.Mutex
+	cacheIzinRole = map[string]cacheIzin{}
+)
+
+// ResetCacheIzin dipanggil setelah role atau izinnya diubah
+func ResetCacheIzin() {
+	cacheIzinMu.Lock()
+	cacheIzinRole = map[string]cacheIzin{}
+	cacheIzinMu.Unlock()
+}
+
+func izinRole(role string) (map[string]bool, error) {
+	cacheIzinMu.Lock()
+	cached, ada := cacheIzinRole[role]
+	cacheIzinMu.Unlock()
+	if ada && time.Since(cached.diambil) < umurCacheIzin {
+		return cached.izin, nil
+	}
+
+	izin := map[string]bool{}
+	if role == model.RoleAdmin {
+		for _, kode := range model.SemuaIzin() {
+			izin[kode] = true
+		}
+	} else {
+		rows, err := config.DB.Query(context.Background(),
+			`SELECT izin FROM role_izin WHERE kode_role = $1`, role)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var kode string
+			rows.Scan(&kode)
+			izin[kode] = true
+		}
+		rows.Close()
+	}
+
+	cacheIzinMu.Lock()
+	cacheIzinRole[role] = cacheIzin{izin: izin, diambil: time.Now()}
+	cacheIzinMu.Unlock()
+	return izin, nil
+}
+
+// IzinRole mengembalikan izin efektif sebuah role, urut abjad
+func IzinRole(role string) ([]string, error) {
+	izin, err := izinRole(role)
+	if err != nil {
+		return nil, err
+	}
+	hasil := []string{}
+	for kode := range izin {
+		hasil = append(hasil, kode)
+	}
+	sort.Strings(hasil)
+	return hasil, nil
+}
+
+// PermissionRequired middleware – cek role user memegang semua izin yang
+// diminta. Role dibaca ulang dari tabel users supaya perubahan role atau
+// penghapusan user berlaku tanpa menunggu token kedaluwarsa.
+func PermissionRequired(izin ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(int)
+		if !ok {
+			return model.ErrorResponse(c, 403, "Role tidak ditemukan")
+		}
+
+		var role string
+		err := config.DB.QueryRow(context.Background(),
+			`SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+		if err != nil {
+			return model.ErrorResponse(c, 401, "User tidak ditemukan")
+		}
+		c.Locals("role", role)
+
+		dimiliki, err := izinRole(role)
+		if err != nil {
+			return model.ErrorResponse(c, 500, "Gagal memeriksa izin akses")
+		}
+		for _, i := range izin {
+			if !dimiliki[i] {
+				return model.ErrorResponse(c, 403, "Anda tidak memiliki akses untuk fitur ini")
+			}
+		}
+
+		return c.Next()
+	}
+}
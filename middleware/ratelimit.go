@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"sikupas/backend/model"
+)
+
+// RateLimit membatasi jumlah request per IP dalam rentang waktu tertentu,
+// dipakai endpoint publik sebagai pengganti CAPTCHA
+func RateLimit(max int, window time.Duration) fiber.Handler {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return model.ErrorResponse(c, 429, "Terlalu banyak permintaan, silakan coba lagi nanti")
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package model
+
+import "strings"
+
+// ─── Akses Darurat Model ─────────────────────────────────────────────────────
+// User dengan role batasi_poli hanya melihat data poli yang ditugaskan
+// kepadanya. Akses darurat (break-glass) membuka semua poli untuk sementara
+// dengan alasan tertulis; setiap akses selama itu dicatat di audit log.
+
+const (
+	DurasiAksesDaruratDefault = 30 // menit
+	DurasiAksesDaruratMaks    = 60
+)
+
+type AksesDarurat struct {
+	IDAkses     int     `json:"id_akses"`
+	IDUser      int     `json:"id_user"`
+	Username    string  `json:"username"`
+	Alasan      string  `json:"alasan"`
+	MulaiAt     string  `json:"mulai_at"`    // YYYY-MM-DD HH:MM:SS
+	BerakhirAt  string  `json:"berakhir_at"` // diperpendek jika diakhiri lebih awal
+	DiakhiriAt  *string `json:"diakhiri_at"`
+	Aktif       bool    `json:"aktif"`
+	JumlahAkses int     `json:"jumlah_akses"` // akses data yang tercatat di audit log
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type AksesDaruratRequest struct {
+	Alasan      string `json:"alasan"`
+	DurasiMenit int    `json:"durasi_menit"` // kosong = DurasiAksesDaruratDefault
+}
+
+// UserPoliRequest menetapkan poli tugas user; daftar kosong berarti user
+// dengan role batasi_poli tidak bisa melihat data poli mana pun
+type UserPoliRequest struct {
+	IDPoli []int `json:"id_poli"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *AksesDaruratRequest) Validate() []string {
+	var errs []string
+
+	alasan := strings.TrimSpace(r.Alasan)
+	if alasan == "" {
+		errs = append(errs, "Alasan akses darurat tidak boleh kosong")
+	} else if len(alasan) < 10 {
+		errs = append(errs, "Alasan akses darurat minimal 10 karakter")
+	} else if len(alasan) > 500 {
+		errs = append(errs, "Alasan akses darurat maksimal 500 karakter")
+	}
+
+	if r.DurasiMenit < 0 || r.DurasiMenit > DurasiAksesDaruratMaks {
+		errs = append(errs, "Durasi akses darurat maksimal 60 menit")
+	}
+
+	return errs
+}
+
+func (r *UserPoliRequest) Validate() []string {
+	var errs []string
+	for _, id := range r.IDPoli {
+		if id <= 0 {
+			errs = append(errs, "ID poli tidak valid")
+			break
+		}
+	}
+	return errs
+}
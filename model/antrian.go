@@ -11,47 +11,123 @@ type Antrian struct {
 	IDantrian        int       `json:"id_antrian"`
 	NIK              string    `json:"nik"`
 	NamaPasien       string    `json:"nama_pasien"`       // dari JOIN pasien
-	NomorAntrian     int       `json:"nomor_antrian"`
+	IDPoli           *int      `json:"id_poli"`           // kosong untuk antrian lama tanpa poli
+	NamaPoli         string    `json:"nama_poli"`         // dari JOIN poli
+	NomorAntrian     int       `json:"nomor_antrian"`     // urut per poli per hari
+	KodeAntrian      string    `json:"kode_antrian"`      // prefix poli + nomor, misal A-012
 	TanggalKunjungan string    `json:"tanggal_kunjungan"` // YYYY-MM-DD
-	Status           string    `json:"status"`            // belum_dikelola / sudah_dikelola
+	Status           string    `json:"status"`            // belum_dikelola / sudah_dikelola / tidak_hadir / kedaluwarsa
+	Prioritas        string    `json:"prioritas"`         // reguler / lansia / ibu_hamil / disabilitas / darurat
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
 
+// Kategori prioritas antrian; pelayanan publik wajib mendahulukan lansia, ibu
+// hamil dan penyandang disabilitas
+const (
+	PrioritasReguler     = "reguler"
+	PrioritasLansia      = "lansia"
+	PrioritasIbuHamil    = "ibu_hamil"
+	PrioritasDisabilitas = "disabilitas"
+	PrioritasDarurat     = "darurat"
+)
+
+// KategoriPrioritas berisi semua kategori dalam urutan tampilan
+var KategoriPrioritas = []string{
+	PrioritasReguler, PrioritasLansia, PrioritasIbuHamil, PrioritasDisabilitas, PrioritasDarurat,
+}
+
+var labelPrioritas = map[string]string{
+	PrioritasReguler:     "Reguler",
+	PrioritasLansia:      "Lansia",
+	PrioritasIbuHamil:    "Ibu Hamil",
+	PrioritasDisabilitas: "Disabilitas",
+	PrioritasDarurat:     "Darurat",
+}
+
+// IsPrioritasValid memeriksa kategori prioritas yang dikenal
+func IsPrioritasValid(p string) bool {
+	_, ok := labelPrioritas[p]
+	return ok
+}
+
+// LabelPrioritas mengembalikan nama kategori untuk layar dan tiket
+func LabelPrioritas(p string) string {
+	if l, ok := labelPrioritas[p]; ok {
+		return l
+	}
+	return p
+}
+
 // ─── Request DTO ─────────────────────────────────────────────────────────────
 
+// Prioritas kosong berarti ditentukan otomatis: lansia dari usia pasien,
+// selain itu reguler
 type CreateAntrianRequest struct {
 	NIK              string `json:"nik"`
+	IDPoli           *int   `json:"id_poli"`
 	TanggalKunjungan string `json:"tanggal_kunjungan"`
+	Prioritas        string `json:"prioritas"`
 }
 
 type UpdateAntrianStatusRequest struct {
 	Status string `json:"status"`
 }
 
+type UpdatePrioritasAntrianRequest struct {
+	Prioritas  string `json:"prioritas"`
+	Keterangan string `json:"keterangan"` // alasan perubahan, dicatat di audit log
+}
+
+// PanggilAntrianRequest dipakai untuk panggil berikutnya (id_poli wajib) dan
+// panggil antrian tertentu (id_poli diabaikan). id_loket kosong berarti loket
+// petugas yang sedang login.
+type PanggilAntrianRequest struct {
+	IDPoli   int  `json:"id_poli"`
+	IDDokter *int `json:"id_dokter"`
+	IDLoket  *int `json:"id_loket"`
+}
+
 // ─── Response DTO ───────────────────────────────────────────────────────────
 
 type AntrianResponse struct {
-	IDantrian        int    `json:"id_antrian"`
-	NIK              string `json:"nik"`
-	NamaPasien       string `json:"nama_pasien"`
-	NomorAntrian     int    `json:"nomor_antrian"`
-	TanggalKunjungan string `json:"tanggal_kunjungan"`
-	Status           string `json:"status"`
+	IDantrian        int         `json:"id_antrian"`
+	NIK              string      `json:"nik"`
+	NamaPasien       string      `json:"nama_pasien"`
+	IDPoli           *int        `json:"id_poli"`
+	NamaPoli         string      `json:"nama_poli"`
+	NomorAntrian     int         `json:"nomor_antrian"`
+	KodeAntrian      string      `json:"kode_antrian"`
+	TanggalKunjungan string      `json:"tanggal_kunjungan"`
+	Status           string      `json:"status"`
+	Prioritas        string      `json:"prioritas"`
+	IDDokter         *int        `json:"id_dokter"` // dokter yang memanggil
+	IDLoket          *int        `json:"id_loket"`  // loket tempat pasien dipanggil
+	NamaLoket        *string     `json:"nama_loket"`
+	DipanggilAt      *string     `json:"dipanggil_at"` // YYYY-MM-DD HH:MM:SS
+	SelesaiAt        *string     `json:"selesai_at"`
+	JumlahMenunggu   int         `json:"jumlah_menunggu"`      // antrian menunggu di depannya
+	EstimasiTunggu   *int        `json:"estimasi_tunggu"`      // menit, hanya untuk yang belum dipanggil
+	Pengumuman       *Pengumuman `json:"pengumuman,omitempty"` // hanya pada respons panggil
 }
 
 // ─── Dashboard Summary ──────────────────────────────────────────────────────
 
 type DashboardSummary struct {
-	TotalAntrian       int `json:"total_antrian"`
-	TotalSudahDikelola int `json:"total_sudah_dikelola"`
-	TotalBelumDikelola int `json:"total_belum_dikelola"`
-	NomorAntrianSekarang int `json:"nomor_antrian_sekarang"`
+	TotalAntrian         int            `json:"total_antrian"`
+	TotalSudahDikelola   int            `json:"total_sudah_dikelola"`
+	TotalBelumDikelola   int            `json:"total_belum_dikelola"`
+	TotalTidakHadir      int            `json:"total_tidak_hadir"`
+	TotalKedaluwarsa     int            `json:"total_kedaluwarsa"`
+	NomorAntrianSekarang int            `json:"nomor_antrian_sekarang"`
+	JumlahPrioritas      map[string]int `json:"jumlah_prioritas"` // per kategori, termasuk reguler
 }
 
 type AntrianBoxItem struct {
-	NomorAntrian int    `json:"nomor_antrian"`
-	Status       string `json:"status"` // belum_dikelola / sudah_dikelola / kosong
+	NomorAntrian   int    `json:"nomor_antrian"`
+	Status         string `json:"status"`                    // status antrian, atau kosong
+	Prioritas      string `json:"prioritas,omitempty"`       // kosong untuk box yang belum terisi
+	EstimasiTunggu *int   `json:"estimasi_tunggu,omitempty"` // menit, untuk yang belum dipanggil
 }
 
 // ─── Validation ──────────────────────────────────────────────────────────────
@@ -70,6 +146,14 @@ func (r *CreateAntrianRequest) Validate() []string {
 		errs = append(errs, "NIK harus berisi angka saja")
 	}
 
+	if r.IDPoli != nil && *r.IDPoli <= 0 {
+		errs = append(errs, "Poli tidak valid")
+	}
+
+	if r.Prioritas != "" && !IsPrioritasValid(r.Prioritas) {
+		errs = append(errs, "Prioritas harus reguler, lansia, ibu_hamil, disabilitas, atau darurat")
+	}
+
 	if tg == "" {
 		errs = append(errs, "Tanggal Kunjungan tidak boleh kosong")
 	} else {
@@ -85,8 +169,19 @@ func (r *CreateAntrianRequest) Validate() []string {
 func (r *UpdateAntrianStatusRequest) Validate() []string {
 	var errs []string
 	s := strings.TrimSpace(r.Status)
-	if s != "belum_dikelola" && s != "sudah_dikelola" {
-		errs = append(errs, "Status harus 'belum_dikelola' atau 'sudah_dikelola'")
+	if s != "belum_dikelola" && s != "sudah_dikelola" && s != "tidak_hadir" {
+		errs = append(errs, "Status harus 'belum_dikelola', 'sudah_dikelola' atau 'tidak_hadir'")
+	}
+	return errs
+}
+
+func (r *UpdatePrioritasAntrianRequest) Validate() []string {
+	var errs []string
+	if !IsPrioritasValid(strings.TrimSpace(r.Prioritas)) {
+		errs = append(errs, "Prioritas harus reguler, lansia, ibu_hamil, disabilitas, atau darurat")
+	}
+	if len(r.Keterangan) > 255 {
+		errs = append(errs, "Keterangan maksimal 255 karakter")
 	}
 	return errs
 }
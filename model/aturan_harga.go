@@ -0,0 +1,208 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ─── Aturan Harga Model ──────────────────────────────────────────────────────
+// Aturan diterapkan per item tagihan, berurutan sesuai prioritas, masing-masing
+// terhadap sisa nominal setelah aturan sebelumnya:
+//   - tanggungan: bagian yang dibayar penjamin (contoh: BPJS 100%)
+//   - diskon    : potongan untuk pasien (contoh: lansia gratis, diskon perda)
+
+type AturanHarga struct {
+	IDAturan         int     `json:"id_aturan"`
+	NamaAturan       string  `json:"nama_aturan"`
+	Jenis            string  `json:"jenis"`             // tanggungan / diskon
+	MetodePembayaran *string `json:"metode_pembayaran"` // kosong = semua metode
+	Kategori         *string `json:"kategori"`          // kosong = semua kategori
+	IDTarif          *int    `json:"id_tarif"`          // kosong = semua tarif
+	UmurMin          *int    `json:"umur_min"`
+	UmurMax          *int    `json:"umur_max"`
+	Persen           float64 `json:"persen"` // 0-100
+	BerlakuMulai     string  `json:"berlaku_mulai"`
+	BerlakuSampai    *string `json:"berlaku_sampai"`
+	Prioritas        int     `json:"prioritas"` // kecil = diterapkan lebih dulu
+	Aktif            bool    `json:"aktif"`
+	Keterangan       string  `json:"keterangan"` // dasar hukum, misal nomor perda
+}
+
+// KonteksHarga adalah data satu item tagihan yang dicocokkan ke aturan
+type KonteksHarga struct {
+	MetodePembayaran string
+	Kategori         string
+	IDTarif          int
+	Umur             int
+	Tanggal          string // YYYY-MM-DD
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type AturanHargaRequest struct {
+	NamaAturan       string  `json:"nama_aturan"`
+	Jenis            string  `json:"jenis"`
+	MetodePembayaran *string `json:"metode_pembayaran"`
+	Kategori         *string `json:"kategori"`
+	IDTarif          *int    `json:"id_tarif"`
+	UmurMin          *int    `json:"umur_min"`
+	UmurMax          *int    `json:"umur_max"`
+	Persen           float64 `json:"persen"`
+	BerlakuMulai     string  `json:"berlaku_mulai"`
+	BerlakuSampai    *string `json:"berlaku_sampai"`
+	Prioritas        int     `json:"prioritas"`
+	Aktif            *bool   `json:"aktif"`
+	Keterangan       string  `json:"keterangan"`
+}
+
+type TarifHargaRequest struct {
+	Harga        float64 `json:"harga"`
+	BerlakuMulai string  `json:"berlaku_mulai"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+type TarifHarga struct {
+	IDHarga      int     `json:"id_harga"`
+	IDTarif      int     `json:"id_tarif"`
+	Harga        float64 `json:"harga"`
+	BerlakuMulai string  `json:"berlaku_mulai"`
+}
+
+// ─── Perhitungan ─────────────────────────────────────────────────────────────
+
+// Cocok cek apakah aturan berlaku untuk item tagihan tertentu
+func (a *AturanHarga) Cocok(k KonteksHarga) bool {
+	if !a.Aktif {
+		return false
+	}
+	if a.MetodePembayaran != nil && *a.MetodePembayaran != k.MetodePembayaran {
+		return false
+	}
+	if a.Kategori != nil && *a.Kategori != k.Kategori {
+		return false
+	}
+	if a.IDTarif != nil && *a.IDTarif != k.IDTarif {
+		return false
+	}
+	if a.UmurMin != nil && k.Umur < *a.UmurMin {
+		return false
+	}
+	if a.UmurMax != nil && k.Umur > *a.UmurMax {
+		return false
+	}
+	// Format YYYY-MM-DD bisa dibandingkan sebagai string
+	if k.Tanggal < a.BerlakuMulai {
+		return false
+	}
+	if a.BerlakuSampai != nil && k.Tanggal > *a.BerlakuSampai {
+		return false
+	}
+	return true
+}
+
+// HitungPotongan menerapkan aturan yang cocok pada subtotal item dan
+// mengembalikan bagian yang ditanggung penjamin serta diskon pasien
+func HitungPotongan(subtotal float64, k KonteksHarga, aturan []AturanHarga) (ditanggung, diskon float64) {
+	cocok := make([]AturanHarga, 0, len(aturan))
+	for _, a := range aturan {
+		if a.Cocok(k) {
+			cocok = append(cocok, a)
+		}
+	}
+	sort.SliceStable(cocok, func(i, j int) bool { return cocok[i].Prioritas < cocok[j].Prioritas })
+
+	sisa := subtotal
+	for _, a := range cocok {
+		if sisa <= 0 {
+			break
+		}
+		potong := bulatkanRupiah(sisa * a.Persen / 100)
+		if potong > sisa {
+			potong = sisa
+		}
+		if a.Jenis == "tanggungan" {
+			ditanggung += potong
+		} else {
+			diskon += potong
+		}
+		sisa -= potong
+	}
+	return ditanggung, diskon
+}
+
+// bulatkanRupiah membulatkan ke rupiah terdekat
+func bulatkanRupiah(n float64) float64 {
+	return float64(int64(n + 0.5))
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *AturanHargaRequest) Validate() []string {
+	var errs []string
+
+	nama := strings.TrimSpace(r.NamaAturan)
+	if nama == "" {
+		errs = append(errs, "Nama Aturan tidak boleh kosong")
+	} else if len(nama) > 100 {
+		errs = append(errs, "Nama Aturan maksimal 100 karakter")
+	}
+
+	jenis := strings.TrimSpace(r.Jenis)
+	if jenis != "tanggungan" && jenis != "diskon" {
+		errs = append(errs, "Jenis harus 'tanggungan' atau 'diskon'")
+	}
+
+	if r.Kategori != nil && !isKategoriTarif(*r.Kategori) {
+		errs = append(errs, "Kategori harus salah satu dari: "+strings.Join(KategoriTarif, ", "))
+	}
+
+	if r.IDTarif != nil && *r.IDTarif <= 0 {
+		errs = append(errs, "Tarif tidak valid")
+	}
+
+	if r.UmurMin != nil && (*r.UmurMin < 0 || *r.UmurMin > 150) {
+		errs = append(errs, "Umur minimal harus antara 0-150")
+	}
+	if r.UmurMax != nil && (*r.UmurMax < 0 || *r.UmurMax > 150) {
+		errs = append(errs, "Umur maksimal harus antara 0-150")
+	}
+	if r.UmurMin != nil && r.UmurMax != nil && *r.UmurMax < *r.UmurMin {
+		errs = append(errs, "Umur maksimal tidak boleh kurang dari umur minimal")
+	}
+
+	if r.Persen <= 0 || r.Persen > 100 {
+		errs = append(errs, "Persen harus lebih dari 0 dan maksimal 100")
+	}
+
+	mulai, err := time.Parse("2006-01-02", strings.TrimSpace(r.BerlakuMulai))
+	if err != nil {
+		errs = append(errs, "Format Berlaku Mulai harus YYYY-MM-DD")
+	}
+	if r.BerlakuSampai != nil {
+		sampai, err2 := time.Parse("2006-01-02", strings.TrimSpace(*r.BerlakuSampai))
+		if err2 != nil {
+			errs = append(errs, "Format Berlaku Sampai harus YYYY-MM-DD")
+		} else if err == nil && sampai.Before(mulai) {
+			errs = append(errs, "Berlaku Sampai tidak boleh sebelum Berlaku Mulai")
+		}
+	}
+
+	if len(strings.TrimSpace(r.Keterangan)) > 255 {
+		errs = append(errs, "Keterangan maksimal 255 karakter")
+	}
+
+	return errs
+}
+
+func (r *TarifHargaRequest) Validate() []string {
+	var errs []string
+	if r.Harga < 0 {
+		errs = append(errs, "Harga tidak boleh negatif")
+	}
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(r.BerlakuMulai)); err != nil {
+		errs = append(errs, "Format Berlaku Mulai harus YYYY-MM-DD")
+	}
+	return errs
+}
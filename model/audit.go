@@ -0,0 +1,17 @@
+package model
+
+// ─── Audit Log ───────────────────────────────────────────────────────────────
+// Jejak aksi user dan perangkat kiosk; hanya ditambah, tidak pernah diubah
+
+type AuditLog struct {
+	IDAudit    int64  `json:"id_audit"`
+	JenisAktor string `json:"jenis_aktor"` // user / kiosk
+	IDAktor    int    `json:"id_aktor"`
+	NamaAktor  string `json:"nama_aktor"`
+	Aksi       string `json:"aksi"`     // misal antrian.buat, kiosk.reset_key
+	Objek      string `json:"objek"`    // nama tabel/entitas
+	IDObjek    string `json:"id_objek"` // id baris yang disentuh
+	Keterangan string `json:"keterangan"`
+	IP         string `json:"ip"`
+	CreatedAt  string `json:"created_at"` // YYYY-MM-DD HH:MM:SS
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ─── Booking Online Model ────────────────────────────────────────────────────
+// Pasien memesan slot jam pada poli dan tanggal tertentu; saat datang booking
+// di-check-in menjadi antrian biasa
+
+var StatusBooking = []string{"aktif", "checkin", "kedaluwarsa", "batal"}
+
+type Booking struct {
+	IDBooking   int     `json:"id_booking"`
+	KodeBooking string  `json:"kode_booking"`
+	NIK         string  `json:"nik"`
+	NamaPasien  string  `json:"nama_pasien"` // dari JOIN pasien
+	IDPoli      int     `json:"id_poli"`
+	NamaPoli    string  `json:"nama_poli"` // dari JOIN poli
+	Tanggal     string  `json:"tanggal"`   // YYYY-MM-DD
+	JamMulai    string  `json:"jam_mulai"` // HH:MM
+	JamSelesai  string  `json:"jam_selesai"`
+	Status      string  `json:"status"`     // aktif / checkin / kedaluwarsa / batal
+	IDAntrian   *int    `json:"id_antrian"` // terisi setelah check-in
+	KodeAntrian string  `json:"kode_antrian,omitempty"`
+	CheckinAt   *string `json:"checkin_at"`
+}
+
+type SlotBooking struct {
+	JamMulai     string `json:"jam_mulai"` // HH:MM
+	JamSelesai   string `json:"jam_selesai"`
+	JumlahDokter int    `json:"jumlah_dokter"`
+	Kapasitas    int    `json:"kapasitas"`
+	Terisi       int    `json:"terisi"`
+	Sisa         int    `json:"sisa"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+// NIK dan tanggal lahir dipakai sebagai identitas pasien pada endpoint publik
+type CreateBookingRequest struct {
+	NIK          string `json:"nik"`
+	TanggalLahir string `json:"tanggal_lahir"`
+	IDPoli       int    `json:"id_poli"`
+	Tanggal      string `json:"tanggal"`
+	JamMulai     string `json:"jam_mulai"`
+}
+
+type IdentitasBookingRequest struct {
+	NIK          string `json:"nik"`
+	TanggalLahir string `json:"tanggal_lahir"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *CreateBookingRequest) Validate() []string {
+	errs := validateIdentitasBooking(r.NIK, r.TanggalLahir)
+
+	if r.IDPoli <= 0 {
+		errs = append(errs, "Poli harus dipilih")
+	}
+
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(r.Tanggal)); err != nil {
+		errs = append(errs, "Format Tanggal harus YYYY-MM-DD")
+	}
+
+	if _, err := time.Parse("15:04", strings.TrimSpace(r.JamMulai)); err != nil {
+		errs = append(errs, "Format Jam Mulai harus HH:MM")
+	}
+
+	return errs
+}
+
+func (r *IdentitasBookingRequest) Validate() []string {
+	return validateIdentitasBooking(r.NIK, r.TanggalLahir)
+}
+
+func validateIdentitasBooking(nik, tanggalLahir string) []string {
+	var errs []string
+
+	nik = strings.TrimSpace(nik)
+	if nik == "" {
+		errs = append(errs, "NIK tidak boleh kosong")
+	} else if len(nik) != 16 || !isNumericStr(nik) {
+		errs = append(errs, "NIK harus 16 angka")
+	}
+
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(tanggalLahir)); err != nil {
+		errs = append(errs, "Format Tanggal Lahir harus YYYY-MM-DD")
+	}
+
+	return errs
+}
@@ -0,0 +1,50 @@
+package model
+
+import "strings"
+
+// ─── Sinkronisasi BPJS ───────────────────────────────────────────────────────
+// Satu baris per pemeriksaan BPJS: hasil cek kepesertaan dan status kirim
+// kunjungan ke PCare
+
+var StatusSinkronBPJS = []string{"menunggu", "terkirim", "gagal"}
+
+type BPJSSinkron struct {
+	IDPemeriksaan   int     `json:"id_pemeriksaan"`
+	PesertaAktif    *bool   `json:"peserta_aktif"` // null = belum / gagal dicek
+	KetPeserta      string  `json:"ket_peserta"`
+	Status          string  `json:"status"` // menunggu / terkirim / gagal
+	NoKunjungan     *string `json:"no_kunjungan"`
+	PesanError      string  `json:"pesan_error"`
+	Percobaan       int     `json:"percobaan"`
+	TerakhirDikirim *string `json:"terakhir_dikirim"` // YYYY-MM-DD HH:MM:SS
+}
+
+// ─── Validasi Kode Diagnosa ──────────────────────────────────────────────────
+
+// ValidasiKodeDiagnosa cek format ICD-10 (A00, J06.9, S52.50),
+// mengembalikan pesan error atau string kosong
+func ValidasiKodeDiagnosa(kode string) string {
+	kode = strings.TrimSpace(kode)
+	if kode == "" {
+		return ""
+	}
+
+	utama, sub, adaTitik := strings.Cut(kode, ".")
+	if len(utama) != 3 || !isUpperAlpha(utama[:1]) || !isNumericStr(utama[1:]) {
+		return "Kode Diagnosa harus format ICD-10, contoh J06.9"
+	}
+	if adaTitik && (sub == "" || len(sub) > 4 || !isUpperAlphaNum(sub)) {
+		return "Kode Diagnosa harus format ICD-10, contoh J06.9"
+	}
+	return ""
+}
+
+// isUpperAlphaNum cek string hanya huruf kapital A-Z atau angka
+func isUpperAlphaNum(s string) bool {
+	for _, c := range s {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package model
+
+// ─── Layar Display Antrian ───────────────────────────────────────────────────
+// Data untuk layar di ruang tunggu; hanya kode antrian, tanpa identitas pasien
+
+type DisplayPoli struct {
+	IDPoli         int              `json:"id_poli"`
+	NamaPoli       string           `json:"nama_poli"`
+	Dipanggil      []DisplayAntrian `json:"dipanggil"`        // sedang dilayani, terbaru dulu
+	Menunggu       []DisplayAntrian `json:"menunggu"`         // maksimal 10 nomor berikutnya
+	JumlahMenunggu int              `json:"jumlah_menunggu"`  // total yang belum dipanggil
+	MenitPerPasien float64          `json:"menit_per_pasien"` // dasar estimasi
+}
+
+type DisplayAntrian struct {
+	KodeAntrian    string  `json:"kode_antrian"`
+	NomorAntrian   int     `json:"nomor_antrian"`
+	Prioritas      string  `json:"prioritas"`
+	DipanggilAt    *string `json:"dipanggil_at,omitempty"`    // HH:MM:SS
+	Loket          *string `json:"loket,omitempty"`           // ditampilkan "012 → Loket 2"
+	EstimasiTunggu *int    `json:"estimasi_tunggu,omitempty"` // menit
+}
+
+// DisplayPanggilan dikirim sebagai event "panggil" di stream display setiap
+// kali antrian dipanggil atau dipanggil ulang
+type DisplayPanggilan struct {
+	KodeAntrian string     `json:"kode_antrian"`
+	IDPoli      int        `json:"id_poli"`
+	NamaPoli    string     `json:"nama_poli"`
+	Loket       *string    `json:"loket,omitempty"`
+	DipanggilAt string     `json:"dipanggil_at"` // HH:MM:SS
+	Pengumuman  Pengumuman `json:"pengumuman"`
+}
+
+// ─── Pengumuman Suara ────────────────────────────────────────────────────────
+
+// Pengumuman berisi kalimat panggilan dan urutan klip audio rekaman yang
+// disambung pemutar di PC display
+type Pengumuman struct {
+	Teks   string   `json:"teks"`   // "Nomor antrian A dua belas, silakan ke Poli Umum"
+	Nomor  string   `json:"nomor"`  // "A dua belas"
+	Tujuan string   `json:"tujuan"` // "Poli Umum"
+	Klip   []string `json:"klip"`   // bel, nomor_antrian, huruf_a, dua, belas, silakan_ke, poli, umum
+}
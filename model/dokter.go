@@ -0,0 +1,181 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ─── Dokter Model ────────────────────────────────────────────────────────────
+
+type Dokter struct {
+	IDDokter       int       `json:"id_dokter"`
+	IDUser         *int      `json:"id_user"` // akun login dokter (opsional)
+	NamaDokter     string    `json:"nama_dokter"`
+	NomorSIP       string    `json:"nomor_sip"`
+	Spesialisasi   string    `json:"spesialisasi"`
+	KodeDokterBPJS string    `json:"kode_dokter_bpjs"` // kode dokter di PCare
+	Aktif          bool      `json:"aktif"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type JadwalDokter struct {
+	IDJadwal   int    `json:"id_jadwal"`
+	IDDokter   int    `json:"id_dokter"`
+	NamaDokter string `json:"nama_dokter,omitempty"`
+	IDPoli     int    `json:"id_poli"`
+	NamaPoli   string `json:"nama_poli"`
+	Hari       int    `json:"hari"` // 1 = Senin ... 7 = Minggu
+	NamaHari   string `json:"nama_hari"`
+	JamMulai   string `json:"jam_mulai"`   // HH:MM
+	JamSelesai string `json:"jam_selesai"` // HH:MM
+}
+
+type CutiDokter struct {
+	IDCuti         int    `json:"id_cuti"`
+	IDDokter       int    `json:"id_dokter"`
+	TanggalMulai   string `json:"tanggal_mulai"`
+	TanggalSelesai string `json:"tanggal_selesai"`
+	Keterangan     string `json:"keterangan"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type DokterRequest struct {
+	IDUser         *int   `json:"id_user"`
+	NamaDokter     string `json:"nama_dokter"`
+	NomorSIP       string `json:"nomor_sip"`
+	Spesialisasi   string `json:"spesialisasi"`
+	KodeDokterBPJS string `json:"kode_dokter_bpjs"`
+	Aktif          *bool  `json:"aktif"`
+}
+
+type JadwalDokterRequest struct {
+	IDPoli     int    `json:"id_poli"`
+	Hari       int    `json:"hari"`
+	JamMulai   string `json:"jam_mulai"`
+	JamSelesai string `json:"jam_selesai"`
+}
+
+type CutiDokterRequest struct {
+	TanggalMulai   string `json:"tanggal_mulai"`
+	TanggalSelesai string `json:"tanggal_selesai"`
+	Keterangan     string `json:"keterangan"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+type DokterResponse struct {
+	IDDokter       int            `json:"id_dokter"`
+	IDUser         *int           `json:"id_user"`
+	NamaDokter     string         `json:"nama_dokter"`
+	NomorSIP       string         `json:"nomor_sip"`
+	Spesialisasi   string         `json:"spesialisasi"`
+	KodeDokterBPJS string         `json:"kode_dokter_bpjs"`
+	Aktif          bool           `json:"aktif"`
+	Jadwal         []JadwalDokter `json:"jadwal,omitempty"`
+	Cuti           []CutiDokter   `json:"cuti,omitempty"`
+}
+
+// ─── Hari ────────────────────────────────────────────────────────────────────
+
+var namaHari = []string{"", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// NamaHari mengubah nomor hari ISO (1 = Senin) menjadi nama hari
+func NamaHari(hari int) string {
+	if hari < 1 || hari > 7 {
+		return ""
+	}
+	return namaHari[hari]
+}
+
+// HariISO mengembalikan nomor hari ISO (1 = Senin ... 7 = Minggu)
+func HariISO(t time.Time) int {
+	wd := int(t.Weekday())
+	if wd == 0 {
+		return 7
+	}
+	return wd
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *DokterRequest) Validate() []string {
+	var errs []string
+
+	nama := strings.TrimSpace(r.NamaDokter)
+	if nama == "" {
+		errs = append(errs, "Nama Dokter tidak boleh kosong")
+	} else if len(nama) < 2 || len(nama) > 100 {
+		errs = append(errs, "Nama Dokter harus antara 2-100 karakter")
+	}
+
+	sip := strings.TrimSpace(r.NomorSIP)
+	if sip == "" {
+		errs = append(errs, "Nomor SIP tidak boleh kosong")
+	} else if len(sip) > 50 {
+		errs = append(errs, "Nomor SIP maksimal 50 karakter")
+	}
+
+	if len(strings.TrimSpace(r.Spesialisasi)) > 100 {
+		errs = append(errs, "Spesialisasi maksimal 100 karakter")
+	}
+
+	if len(strings.TrimSpace(r.KodeDokterBPJS)) > 20 {
+		errs = append(errs, "Kode Dokter BPJS maksimal 20 karakter")
+	}
+
+	if r.IDUser != nil && *r.IDUser <= 0 {
+		errs = append(errs, "ID User tidak valid")
+	}
+
+	return errs
+}
+
+func (r *JadwalDokterRequest) Validate() []string {
+	var errs []string
+
+	if r.IDPoli <= 0 {
+		errs = append(errs, "Poli harus dipilih")
+	}
+
+	if r.Hari < 1 || r.Hari > 7 {
+		errs = append(errs, "Hari harus antara 1 (Senin) sampai 7 (Minggu)")
+	}
+
+	mulai, err1 := time.Parse("15:04", strings.TrimSpace(r.JamMulai))
+	if err1 != nil {
+		errs = append(errs, "Format Jam Mulai harus HH:MM")
+	}
+	selesai, err2 := time.Parse("15:04", strings.TrimSpace(r.JamSelesai))
+	if err2 != nil {
+		errs = append(errs, "Format Jam Selesai harus HH:MM")
+	}
+	if err1 == nil && err2 == nil && !selesai.After(mulai) {
+		errs = append(errs, "Jam Selesai harus setelah Jam Mulai")
+	}
+
+	return errs
+}
+
+func (r *CutiDokterRequest) Validate() []string {
+	var errs []string
+
+	mulai, err1 := time.Parse("2006-01-02", strings.TrimSpace(r.TanggalMulai))
+	if err1 != nil {
+		errs = append(errs, "Format Tanggal Mulai harus YYYY-MM-DD")
+	}
+	selesai, err2 := time.Parse("2006-01-02", strings.TrimSpace(r.TanggalSelesai))
+	if err2 != nil {
+		errs = append(errs, "Format Tanggal Selesai harus YYYY-MM-DD")
+	}
+	if err1 == nil && err2 == nil && selesai.Before(mulai) {
+		errs = append(errs, "Tanggal Selesai tidak boleh sebelum Tanggal Mulai")
+	}
+
+	if len(strings.TrimSpace(r.Keterangan)) > 255 {
+		errs = append(errs, "Keterangan maksimal 255 karakter")
+	}
+
+	return errs
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ─── 2FA (TOTP) Model ────────────────────────────────────────────────────────
+// 2FA opsional untuk setiap user dan wajib untuk role dengan wajib_2fa.
+// Login menjadi dua langkah: password menghasilkan token tantangan, lalu
+// kode dari aplikasi authenticator (atau kode pemulihan) ditukar dengan
+// token akses.
+
+const JumlahKodePemulihan = 10
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+// Kode2FARequest berisi salah satu dari kode authenticator atau kode
+// pemulihan; id_loket dipakai pada langkah kedua login menggantikan id_loket
+// di request login
+type Kode2FARequest struct {
+	Kode          string `json:"kode"`
+	KodePemulihan string `json:"kode_pemulihan"`
+	IDLoket       *int   `json:"id_loket"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+// Daftar2FAResponse ditampilkan sebagai QR code (uri) beserta secret untuk
+// diketik manual
+type Daftar2FAResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TantanganLoginResponse dikembalikan login langkah pertama untuk user 2FA.
+// perlu_daftar berarti role user wajib 2FA tetapi belum terdaftar, sehingga
+// user harus mendaftar dulu memakai token tantangan ini.
+type TantanganLoginResponse struct {
+	TokenTantangan string `json:"token_tantangan"`
+	PerluDaftar    bool   `json:"perlu_daftar"`
+	BerlakuDetik   int    `json:"berlaku_detik"`
+}
+
+// KodePemulihanResponse hanya ditampilkan sekali
+type KodePemulihanResponse struct {
+	KodePemulihan []string `json:"kode_pemulihan"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *Kode2FARequest) Validate() []string {
+	var errs []string
+	if strings.TrimSpace(r.Kode) == "" && strings.TrimSpace(r.KodePemulihan) == "" {
+		errs = append(errs, "Kode 2FA atau kode pemulihan harus diisi")
+	}
+	return errs
+}
+
+// ─── Kode Pemulihan Helpers ──────────────────────────────────────────────────
+
+// GenerateKodePemulihan membuat kode sekali pakai berformat xxxxx-xxxxx
+func GenerateKodePemulihan() []string {
+	kode := make([]string, JumlahKodePemulihan)
+	for i := range kode {
+		b := make([]byte, 5)
+		rand.Read(b)
+		h := hex.EncodeToString(b)
+		kode[i] = h[:5] + "-" + h[5:]
+	}
+	return kode
+}
+
+// HashKodePemulihan menyamakan format (huruf kecil, tanpa tanda hubung dan
+// spasi) sebelum di-hash
+func HashKodePemulihan(kode string) string {
+	kode = strings.ToLower(kode)
+	kode = strings.NewReplacer("-", "", " ", "").Replace(kode)
+	sum := sha256.Sum256([]byte(kode))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ─── Kapasitas Antrian ───────────────────────────────────────────────────────
+// Kuota harian poli ditentukan berurutan: hari libur (tutup) -> kapasitas per
+// tanggal -> kapasitas per hari dalam minggu -> kuota_harian poli
+
+type KapasitasHari struct {
+	Hari     int    `json:"hari"` // 1 = Senin ... 7 = Minggu
+	NamaHari string `json:"nama_hari"`
+	Kuota    int    `json:"kuota"` // 0 = poli tidak buka pada hari tersebut
+}
+
+// KapasitasTanggal menggantikan kapasitas mingguan pada satu tanggal, misal
+// setengah hari kerja atau kampanye vaksinasi
+type KapasitasTanggal struct {
+	IDKapasitas int    `json:"id_kapasitas"`
+	IDPoli      int    `json:"id_poli"`
+	Tanggal     string `json:"tanggal"` // YYYY-MM-DD
+	Kuota       int    `json:"kuota"`   // 0 = poli tutup pada tanggal tersebut
+	Keterangan  string `json:"keterangan"`
+}
+
+type HariLibur struct {
+	IDLibur int    `json:"id_libur"`
+	Tanggal string `json:"tanggal"` // YYYY-MM-DD
+	Nama    string `json:"nama"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+// KapasitasMingguanRequest mengganti seluruh kapasitas mingguan poli; hari
+// yang tidak disebut kembali memakai kuota_harian poli
+type KapasitasMingguanRequest struct {
+	Kapasitas []KapasitasHari `json:"kapasitas"`
+}
+
+type KapasitasTanggalRequest struct {
+	Tanggal    string `json:"tanggal"`
+	Kuota      *int   `json:"kuota"`
+	Keterangan string `json:"keterangan"`
+}
+
+type HariLiburRequest struct {
+	Tanggal string `json:"tanggal"`
+	Nama    string `json:"nama"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+type KapasitasPoliResponse struct {
+	IDPoli      int                `json:"id_poli"`
+	NamaPoli    string             `json:"nama_poli"`
+	KuotaHarian int                `json:"kuota_harian"` // dipakai jika hari tidak diatur
+	Mingguan    []KapasitasHari    `json:"mingguan"`     // selalu 7 hari, sudah terisi default
+	Tanggal     []KapasitasTanggal `json:"tanggal"`      // pengaturan per tanggal mulai hari ini
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *KapasitasMingguanRequest) Validate() []string {
+	var errs []string
+
+	seen := map[int]bool{}
+	for _, k := range r.Kapasitas {
+		if k.Hari < 1 || k.Hari > 7 {
+			errs = append(errs, "Hari harus antara 1 (Senin) sampai 7 (Minggu)")
+			break
+		}
+		if seen[k.Hari] {
+			errs = append(errs, "Hari "+NamaHari(k.Hari)+" tidak boleh duplikat")
+			break
+		}
+		seen[k.Hari] = true
+		if k.Kuota < 0 || k.Kuota > 999 {
+			errs = append(errs, "Kuota "+NamaHari(k.Hari)+" harus antara 0-999")
+		}
+	}
+
+	return errs
+}
+
+func (r *KapasitasTanggalRequest) Validate() []string {
+	var errs []string
+
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(r.Tanggal)); err != nil {
+		errs = append(errs, "Format Tanggal harus YYYY-MM-DD")
+	}
+
+	if r.Kuota == nil {
+		errs = append(errs, "Kuota harus diisi (0 jika poli tutup)")
+	} else if *r.Kuota < 0 || *r.Kuota > 999 {
+		errs = append(errs, "Kuota harus antara 0-999")
+	}
+
+	if len(strings.TrimSpace(r.Keterangan)) > 255 {
+		errs = append(errs, "Keterangan maksimal 255 karakter")
+	}
+
+	return errs
+}
+
+func (r *HariLiburRequest) Validate() []string {
+	var errs []string
+
+	if _, err := time.Parse("2006-01-02", strings.TrimSpace(r.Tanggal)); err != nil {
+		errs = append(errs, "Format Tanggal harus YYYY-MM-DD")
+	}
+
+	nama := strings.TrimSpace(r.Nama)
+	if nama == "" {
+		errs = append(errs, "Nama Hari Libur tidak boleh kosong")
+	} else if len(nama) > 100 {
+		errs = append(errs, "Nama Hari Libur maksimal 100 karakter")
+	}
+
+	return errs
+}
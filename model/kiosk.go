@@ -0,0 +1,136 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// ─── Kiosk Model ─────────────────────────────────────────────────────────────
+// Perangkat kiosk di lobi masuk dengan API key (header X-Kiosk-Key), bukan
+// JWT user. Setiap kiosk hanya punya satu izin.
+
+const IzinKioskBuatAntrian = "antrian:buat" // ambil nomor antrian untuk pasien terdaftar
+
+type Kiosk struct {
+	IDKiosk       int     `json:"id_kiosk"`
+	NamaKiosk     string  `json:"nama_kiosk"`
+	Lokasi        string  `json:"lokasi"`
+	KeyPrefix     string  `json:"key_prefix"` // 8 karakter awal API key, untuk identifikasi
+	Izin          string  `json:"izin"`
+	Aktif         bool    `json:"aktif"`
+	TerakhirAktif *string `json:"terakhir_aktif"` // YYYY-MM-DD HH:MM:SS
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type KioskRequest struct {
+	NamaKiosk string `json:"nama_kiosk"`
+	Lokasi    string `json:"lokasi"`
+	Aktif     *bool  `json:"aktif"` // hanya dipakai saat update
+}
+
+// Kiosk hanya boleh memilih lansia, ibu hamil atau disabilitas; kategori
+// darurat ditetapkan petugas
+type KioskAntrianRequest struct {
+	NIK       string `json:"nik"`
+	IDPoli    int    `json:"id_poli"`
+	Prioritas string `json:"prioritas"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+// KioskKeyResponse dikembalikan sekali saat kiosk dibuat atau key di-reset;
+// API key tidak bisa dilihat lagi sesudahnya
+type KioskKeyResponse struct {
+	Kiosk  Kiosk  `json:"kiosk"`
+	APIKey string `json:"api_key"`
+}
+
+// TiketAntrian adalah isi tiket yang dicetak kiosk
+type TiketAntrian struct {
+	IDAntrian        int    `json:"id_antrian"`
+	KodeAntrian      string `json:"kode_antrian"`
+	NomorAntrian     int    `json:"nomor_antrian"`
+	IDPoli           int    `json:"id_poli"`
+	NamaPoli         string `json:"nama_poli"`
+	NamaPasien       string `json:"nama_pasien"` // disamarkan, misal "Bud* San****"
+	TanggalKunjungan string `json:"tanggal_kunjungan"`
+	Prioritas        string `json:"prioritas"`
+	KodeBooking      string `json:"kode_booking,omitempty"` // jika datang dari booking online
+	JumlahMenunggu   int    `json:"jumlah_menunggu"`        // antrian di depan pasien
+	EstimasiTunggu   int    `json:"estimasi_tunggu"`        // menit
+	DicetakAt        string `json:"dicetak_at"`             // YYYY-MM-DD HH:MM:SS
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *KioskRequest) Validate() []string {
+	var errs []string
+
+	nama := strings.TrimSpace(r.NamaKiosk)
+	if nama == "" {
+		errs = append(errs, "Nama Kiosk tidak boleh kosong")
+	} else if len(nama) > 100 {
+		errs = append(errs, "Nama Kiosk maksimal 100 karakter")
+	}
+
+	if len(strings.TrimSpace(r.Lokasi)) > 100 {
+		errs = append(errs, "Lokasi maksimal 100 karakter")
+	}
+
+	return errs
+}
+
+func (r *KioskAntrianRequest) Validate() []string {
+	var errs []string
+
+	nik := strings.TrimSpace(r.NIK)
+	if nik == "" {
+		errs = append(errs, "NIK tidak boleh kosong")
+	} else if len(nik) != 16 || !isNumericStr(nik) {
+		errs = append(errs, "NIK harus 16 angka")
+	}
+
+	if r.IDPoli <= 0 {
+		errs = append(errs, "Poli harus dipilih")
+	}
+
+	if r.Prioritas == PrioritasDarurat || (r.Prioritas != "" && !IsPrioritasValid(r.Prioritas)) {
+		errs = append(errs, "Prioritas harus reguler, lansia, ibu_hamil, atau disabilitas")
+	}
+
+	return errs
+}
+
+// ─── API Key Helpers ─────────────────────────────────────────────────────────
+
+// GenerateKioskKey membuat API key acak 40 karakter hex; 8 karakter pertama
+// menjadi prefix yang disimpan apa adanya
+func GenerateKioskKey() (key, prefix string) {
+	b := make([]byte, 20)
+	rand.Read(b)
+	key = hex.EncodeToString(b)
+	return key, key[:8]
+}
+
+func HashKioskKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SamarkanNama menyisakan huruf awal tiap kata supaya tiket yang tertinggal
+// di kiosk tidak membuka identitas pasien
+func SamarkanNama(nama string) string {
+	kata := strings.Fields(nama)
+	for i, k := range kata {
+		r := []rune(k)
+		n := 3
+		if len(r) <= 3 {
+			n = 1
+		}
+		kata[i] = string(r[:n]) + strings.Repeat("*", len(r)-n)
+	}
+	return strings.Join(kata, " ")
+}
@@ -0,0 +1,169 @@
+package model
+
+import "strings"
+
+// ─── Kunjungan Model ─────────────────────────────────────────────────────────
+// Kunjungan membawa pasien melewati tahap layanan secara berurutan. Setiap
+// tahap punya antrian sendiri; tahap per_poli memakai antrian poli biasa.
+
+// Status tahap kunjungan
+const (
+	TahapMenunggu  = "menunggu"
+	TahapDipanggil = "dipanggil"
+	TahapSelesai   = "selesai"
+	TahapBatal     = "batal"
+)
+
+type TahapLayanan struct {
+	IDTahap       int    `json:"id_tahap"`
+	KodeTahap     string `json:"kode_tahap"` // misal pendaftaran / poli / farmasi
+	NamaTahap     string `json:"nama_tahap"`
+	Urutan        int    `json:"urutan"`
+	PerPoli       bool   `json:"per_poli"`       // antrian mengikuti poli tujuan kunjungan
+	PrefixAntrian string `json:"prefix_antrian"` // untuk tahap yang bukan per_poli
+	Aktif         bool   `json:"aktif"`
+}
+
+type Kunjungan struct {
+	IDKunjungan int              `json:"id_kunjungan"`
+	NIK         string           `json:"nik"`
+	NamaPasien  string           `json:"nama_pasien"`
+	IDPoli      int              `json:"id_poli"`
+	NamaPoli    string           `json:"nama_poli"`
+	Tanggal     string           `json:"tanggal"` // YYYY-MM-DD
+	Prioritas   string           `json:"prioritas"`
+	Status      string           `json:"status"` // aktif / selesai / batal
+	IDTahap     *int             `json:"id_tahap"`
+	NamaTahap   *string          `json:"nama_tahap"` // tahap saat ini
+	CreatedAt   string           `json:"created_at"` // YYYY-MM-DD HH:MM:SS
+	SelesaiAt   *string          `json:"selesai_at"`
+	Tahap       []KunjunganTahap `json:"tahap,omitempty"` // hanya pada detail
+}
+
+type KunjunganTahap struct {
+	IDKunjunganTahap int         `json:"id_kunjungan_tahap"`
+	IDKunjungan      int         `json:"id_kunjungan"`
+	IDTahap          int         `json:"id_tahap"`
+	NamaTahap        string      `json:"nama_tahap"`
+	NomorAntrian     int         `json:"nomor_antrian"`
+	KodeAntrian      string      `json:"kode_antrian"`
+	IDAntrian        *int        `json:"id_antrian"` // antrian poli untuk tahap per_poli
+	Status           string      `json:"status"`
+	IDLoket          *int        `json:"id_loket"`
+	NamaLoket        *string     `json:"nama_loket"`
+	MasukAt          string      `json:"masuk_at"` // YYYY-MM-DD HH:MM:SS
+	DipanggilAt      *string     `json:"dipanggil_at"`
+	SelesaiAt        *string     `json:"selesai_at"`
+	Pengumuman       *Pengumuman `json:"pengumuman,omitempty"` // hanya pada respons panggil
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type TahapLayananRequest struct {
+	KodeTahap     string `json:"kode_tahap"`
+	NamaTahap     string `json:"nama_tahap"`
+	Urutan        int    `json:"urutan"`
+	PerPoli       bool   `json:"per_poli"`
+	PrefixAntrian string `json:"prefix_antrian"`
+	Aktif         *bool  `json:"aktif"` // hanya dipakai saat update
+}
+
+type CreateKunjunganRequest struct {
+	NIK       string `json:"nik"`
+	IDPoli    int    `json:"id_poli"`
+	Prioritas string `json:"prioritas"`
+}
+
+// PanggilTahapRequest memanggil antrian berikutnya pada tahap yang bukan
+// per_poli; id_loket kosong berarti loket petugas yang sedang login
+type PanggilTahapRequest struct {
+	IDTahap int  `json:"id_tahap"`
+	IDLoket *int `json:"id_loket"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+// LaporanTahap berisi rata-rata waktu tunggu dan layanan pada satu tahap
+type LaporanTahap struct {
+	IDTahap          int      `json:"id_tahap"`
+	NamaTahap        string   `json:"nama_tahap"`
+	Jumlah           int      `json:"jumlah"`
+	RataTungguMenit  *float64 `json:"rata_tunggu_menit"`
+	RataLayananMenit *float64 `json:"rata_layanan_menit"`
+}
+
+// ReportKunjungan berisi waktu total kunjungan dari pendaftaran sampai tahap
+// terakhir selesai, hanya untuk kunjungan yang selesai
+type ReportKunjungan struct {
+	TanggalDari           string         `json:"tanggal_dari"`
+	TanggalSampai         string         `json:"tanggal_sampai"`
+	Total                 int            `json:"total"`
+	Selesai               int            `json:"selesai"`
+	Batal                 int            `json:"batal"`
+	RataTurnaroundMenit   *float64       `json:"rata_turnaround_menit"`
+	MedianTurnaroundMenit *float64       `json:"median_turnaround_menit"`
+	P90TurnaroundMenit    *float64       `json:"p90_turnaround_menit"`
+	PerTahap              []LaporanTahap `json:"per_tahap"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *TahapLayananRequest) Validate() []string {
+	var errs []string
+
+	kode := strings.TrimSpace(r.KodeTahap)
+	if kode == "" {
+		errs = append(errs, "Kode tahap tidak boleh kosong")
+	} else if len(kode) > 30 {
+		errs = append(errs, "Kode tahap maksimal 30 karakter")
+	} else {
+		for _, c := range kode {
+			if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_') {
+				errs = append(errs, "Kode tahap hanya boleh huruf kecil, angka dan garis bawah")
+				break
+			}
+		}
+	}
+
+	nama := strings.TrimSpace(r.NamaTahap)
+	if nama == "" {
+		errs = append(errs, "Nama tahap tidak boleh kosong")
+	} else if len(nama) > 50 {
+		errs = append(errs, "Nama tahap maksimal 50 karakter")
+	}
+
+	if r.Urutan < 1 {
+		errs = append(errs, "Urutan minimal 1")
+	}
+
+	if prefix := strings.TrimSpace(r.PrefixAntrian); len(prefix) > 5 {
+		errs = append(errs, "Prefix antrian maksimal 5 karakter")
+	} else if !isAlphanumeric(prefix) {
+		errs = append(errs, "Prefix antrian hanya boleh huruf dan angka")
+	}
+
+	return errs
+}
+
+func (r *CreateKunjunganRequest) Validate() []string {
+	var errs []string
+
+	nik := strings.TrimSpace(r.NIK)
+	if nik == "" {
+		errs = append(errs, "NIK tidak boleh kosong")
+	} else if len(nik) != 16 {
+		errs = append(errs, "NIK harus tepat 16 angka")
+	} else if !isNumericStr(nik) {
+		errs = append(errs, "NIK harus berisi angka saja")
+	}
+
+	if r.IDPoli <= 0 {
+		errs = append(errs, "Poli harus dipilih")
+	}
+
+	if r.Prioritas != "" && !IsPrioritasValid(r.Prioritas) {
+		errs = append(errs, "Prioritas harus reguler, lansia, ibu_hamil, disabilitas, atau darurat")
+	}
+
+	return errs
+}
@@ -0,0 +1,31 @@
+package model
+
+// ─── Login Percobaan ─────────────────────────────────────────────────────────
+// Setiap percobaan login, berhasil atau gagal, dicatat beserta IP dan user
+// agent. Penghitung gagal disimpan di tabel login_kunci supaya berlaku di
+// semua replika.
+
+// Jenis kunci login
+const (
+	KunciUsername = "username"
+	KunciIP       = "ip"
+)
+
+type LoginPercobaan struct {
+	IDPercobaan int64  `json:"id_percobaan"`
+	Username    string `json:"username"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	Berhasil    bool   `json:"berhasil"`
+	Keterangan  string `json:"keterangan"` // misal password_salah, terkunci
+	CreatedAt   string `json:"created_at"` // YYYY-MM-DD HH:MM:SS
+}
+
+// LoginKunci adalah penghitung gagal yang masih menunda atau mengunci login
+type LoginKunci struct {
+	Jenis          string  `json:"jenis"` // username / ip
+	Nilai          string  `json:"nilai"`
+	Gagal          int     `json:"gagal"`
+	TundaSampai    *string `json:"tunda_sampai"`
+	TerkunciSampai *string `json:"terkunci_sampai"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import "strings"
+
+// ─── Loket Model ─────────────────────────────────────────────────────────────
+// Loket pendaftaran tempat petugas memanggil antrian. Petugas memilih loket
+// saat login; panggilan dari petugas tersebut diarahkan ke lokasinya.
+
+type Loket struct {
+	IDLoket   int     `json:"id_loket"`
+	NamaLoket string  `json:"nama_loket"` // diumumkan apa adanya, misal "Loket 2"
+	Aktif     bool    `json:"aktif"`
+	IDPetugas *int    `json:"id_petugas"` // petugas yang sedang bertugas
+	Petugas   *string `json:"petugas"`
+	MulaiAt   *string `json:"mulai_at"` // YYYY-MM-DD HH:MM:SS
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type LoketRequest struct {
+	NamaLoket string `json:"nama_loket"`
+	Aktif     *bool  `json:"aktif"` // hanya dipakai saat update
+}
+
+// PilihLoketRequest dipakai petugas untuk pindah loket; id_loket kosong
+// berarti meninggalkan loket
+type PilihLoketRequest struct {
+	IDLoket *int `json:"id_loket"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+// LaporanLoket adalah throughput satu loket dalam periode laporan
+type LaporanLoket struct {
+	IDLoket          int      `json:"id_loket"`
+	NamaLoket        string   `json:"nama_loket"`
+	Dipanggil        int      `json:"dipanggil"`
+	Dilayani         int      `json:"dilayani"`
+	TidakHadir       int      `json:"tidak_hadir"`
+	RataLayananMenit *float64 `json:"rata_layanan_menit"`
+	JamBertugas      float64  `json:"jam_bertugas"`     // total durasi sesi petugas
+	DilayaniPerJam   *float64 `json:"dilayani_per_jam"` // kosong jika tidak ada sesi
+}
+
+type ReportLoket struct {
+	TanggalDari   string         `json:"tanggal_dari"`
+	TanggalSampai string         `json:"tanggal_sampai"`
+	PerLoket      []LaporanLoket `json:"per_loket"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *LoketRequest) Validate() []string {
+	var errs []string
+
+	nama := strings.TrimSpace(r.NamaLoket)
+	if nama == "" {
+		errs = append(errs, "Nama loket tidak boleh kosong")
+	} else if len(nama) > 50 {
+		errs = append(errs, "Nama loket maksimal 50 karakter")
+	}
+
+	return errs
+}
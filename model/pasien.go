@@ -40,12 +40,13 @@ type UpdatePasienRequest struct {
 // ─── Response DTO ───────────────────────────────────────────────────────────
 
 type PasienResponse struct {
-	NIK          string `json:"nik"`
-	NamaPasien   string `json:"nama_pasien"`
-	TanggalLahir string `json:"tanggal_lahir"`
-	Umur         int    `json:"umur"`
-	JenisKelamin string `json:"jenis_kelamin"`
-	Alamat       string `json:"alamat"`
+	NIK              string `json:"nik"`
+	NamaPasien       string `json:"nama_pasien"`
+	TanggalLahir     string `json:"tanggal_lahir"`
+	Umur             int    `json:"umur"`
+	JenisKelamin     string `json:"jenis_kelamin"`
+	Alamat           string `json:"alamat"`
+	JumlahTidakHadir *int   `json:"jumlah_tidak_hadir,omitempty"` // hanya diisi pada detail pasien
 }
 
 // ─── Validation ──────────────────────────────────────────────────────────────
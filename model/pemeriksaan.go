@@ -5,14 +5,6 @@ import (
 	"time"
 )
 
-// ─── Poli Model ──────────────────────────────────────────────────────────────
-
-type Poli struct {
-	IDPoli     int    `json:"id_poli"`
-	NamaPoli   string `json:"nama_poli"`
-	NamaDokter string `json:"nama_dokter"`
-}
-
 // ─── Pemeriksaan Model ───────────────────────────────────────────────────────
 
 type Pemeriksaan struct {
@@ -23,29 +15,50 @@ type Pemeriksaan struct {
 	Keluhan            string    `json:"keluhan"`
 	IDPoli             int       `json:"id_poli"`
 	NamaPoli           string    `json:"nama_poli"`             // dari JOIN poli
-	NamaDokter         string    `json:"nama_dokter"`           // dari JOIN poli
-	MetodePembayaran   string    `json:"metode_pembayaran"`     // Umum / BPJS
+	IDDokter           *int      `json:"id_dokter"`             // dokter pemeriksa
+	NamaDokter         string    `json:"nama_dokter"`           // dari JOIN dokter / poli
+	MetodePembayaran   string    `json:"metode_pembayaran"`     // kode penjamin: Umum / BPJS / Jamkesda / ...
+	NomorPenjamin      *string   `json:"nomor_penjamin"`        // no. kartu / polis / surat jaminan
 	NominalPembayaran  float64   `json:"nominal_pembayaran"`
+	KodeDiagnosa       *string   `json:"kode_diagnosa"`         // ICD-10, dikirim ke BPJS
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 }
 
 // ─── Request DTO ─────────────────────────────────────────────────────────────
 
+// Nominal pembayaran tidak diisi manual; dihitung dari tagihan dan aturan harga.
+// CekEligibilitas dan KirimBPJS hanya berlaku untuk penjamin BPJS; bila kosong
+// mengikuti BPJS_CEK_ELIGIBILITAS / BPJS_KIRIM_KUNJUNGAN.
+// TanggalPemeriksaan kosong berarti tanggal tiket antrian/kunjungan, atau hari
+// ini; tanggal lampau dipakai untuk input susulan.
 type CreatePemeriksaanRequest struct {
-	NIKPasien         string  `json:"nik_pasien"`
-	Keluhan           string  `json:"keluhan"`
-	IDPoli            int     `json:"id_poli"`
-	MetodePembayaran  string  `json:"metode_pembayaran"`
-	NominalPembayaran float64 `json:"nominal_pembayaran"`
+	NIKPasien          string `json:"nik_pasien"`
+	TanggalPemeriksaan string `json:"tanggal_pemeriksaan"`
+	IDAntrian          *int   `json:"id_antrian"`
+	IDKunjungan        *int   `json:"id_kunjungan"`
+	Keluhan            string `json:"keluhan"`
+	IDPoli             int    `json:"id_poli"`
+	IDDokter           *int   `json:"id_dokter"`
+	MetodePembayaran   string `json:"metode_pembayaran"`
+	NomorPenjamin      string `json:"nomor_penjamin"`
+	KodeDiagnosa       string `json:"kode_diagnosa"`
+	CekEligibilitas    *bool  `json:"cek_eligibilitas"`
+	KirimBPJS          *bool  `json:"kirim_bpjs"`
 }
 
+// TanggalPemeriksaan, IDAntrian dan IDKunjungan kosong berarti tidak berubah
 type UpdatePemeriksaanRequest struct {
-	NIKPasien         string  `json:"nik_pasien"`
-	Keluhan           string  `json:"keluhan"`
-	IDPoli            int     `json:"id_poli"`
-	MetodePembayaran  string  `json:"metode_pembayaran"`
-	NominalPembayaran float64 `json:"nominal_pembayaran"`
+	NIKPasien          string `json:"nik_pasien"`
+	TanggalPemeriksaan string `json:"tanggal_pemeriksaan"`
+	IDAntrian          *int   `json:"id_antrian"`
+	IDKunjungan        *int   `json:"id_kunjungan"`
+	Keluhan            string `json:"keluhan"`
+	IDPoli             int    `json:"id_poli"`
+	IDDokter           *int   `json:"id_dokter"`
+	MetodePembayaran   string `json:"metode_pembayaran"`
+	NomorPenjamin      string `json:"nomor_penjamin"`
+	KodeDiagnosa       string `json:"kode_diagnosa"`
 }
 
 // ─── Response DTO ───────────────────────────────────────────────────────────
@@ -55,11 +68,20 @@ type PemeriksaanResponse struct {
 	NIKPasien          string  `json:"nik_pasien"`
 	NamaPasien         string  `json:"nama_pasien"`
 	TanggalPemeriksaan string  `json:"tanggal_pemeriksaan"`
+	IDAntrian          *int    `json:"id_antrian"`   // tiket antrian asal
+	KodeAntrian        *string `json:"kode_antrian"` // misal A-012
+	IDKunjungan        *int    `json:"id_kunjungan"`
 	Keluhan            string  `json:"keluhan"`
+	IDPoli             int     `json:"id_poli"`
 	NamaPoli           string  `json:"nama_poli"`
+	IDDokter           *int    `json:"id_dokter"`
 	NamaDokter         string  `json:"nama_dokter"`
 	MetodePembayaran   string  `json:"metode_pembayaran"`
+	NamaPenjamin       string  `json:"nama_penjamin"`
+	NomorPenjamin      *string `json:"nomor_penjamin"`
 	NominalPembayaran  float64 `json:"nominal_pembayaran"`
+	KodeDiagnosa       *string `json:"kode_diagnosa"`
+	StatusBPJS         *string `json:"status_bpjs"` // null = tidak dibridging
 }
 
 // ─── Report Response ─────────────────────────────────────────────────────────
@@ -105,23 +127,34 @@ func (r *CreatePemeriksaanRequest) Validate() []string {
 		errs = append(errs, "Keluhan minimal 3 karakter")
 	}
 
+	if msg := validasiTanggalPemeriksaan(r.TanggalPemeriksaan); msg != "" {
+		errs = append(errs, msg)
+	}
+	if r.IDAntrian != nil && *r.IDAntrian <= 0 {
+		errs = append(errs, "Antrian tidak valid")
+	}
+	if r.IDKunjungan != nil && *r.IDKunjungan <= 0 {
+		errs = append(errs, "Kunjungan tidak valid")
+	}
+
 	if r.IDPoli <= 0 {
 		errs = append(errs, "Poli harus dipilih")
 	}
 
-	mp := strings.TrimSpace(r.MetodePembayaran)
-	if mp != "Umum" && mp != "BPJS" {
-		errs = append(errs, "Metode Pembayaran harus 'Umum' atau 'BPJS'")
+	if r.IDDokter != nil && *r.IDDokter <= 0 {
+		errs = append(errs, "Dokter tidak valid")
 	}
 
-	// Jika BPJS, nominal harus 0
-	if mp == "BPJS" && r.NominalPembayaran != 0 {
-		errs = append(errs, "Nominal Pembayaran harus Rp 0 untuk BPJS")
+	// Penjamin dan format nomor penjamin dicek terhadap tabel penjamin di handler
+	mp := strings.TrimSpace(r.MetodePembayaran)
+	if mp == "" {
+		errs = append(errs, "Metode Pembayaran harus dipilih")
+	} else if len(mp) > 20 {
+		errs = append(errs, "Metode Pembayaran tidak valid")
 	}
 
-	// Jika Umum, nominal harus >= 0
-	if mp == "Umum" && r.NominalPembayaran < 0 {
-		errs = append(errs, "Nominal Pembayaran tidak boleh negatif")
+	if msg := ValidasiKodeDiagnosa(r.KodeDiagnosa); msg != "" {
+		errs = append(errs, msg)
 	}
 
 	return errs
@@ -145,22 +178,51 @@ func (r *UpdatePemeriksaanRequest) Validate() []string {
 		errs = append(errs, "Keluhan minimal 3 karakter")
 	}
 
+	if msg := validasiTanggalPemeriksaan(r.TanggalPemeriksaan); msg != "" {
+		errs = append(errs, msg)
+	}
+	if r.IDAntrian != nil && *r.IDAntrian <= 0 {
+		errs = append(errs, "Antrian tidak valid")
+	}
+	if r.IDKunjungan != nil && *r.IDKunjungan <= 0 {
+		errs = append(errs, "Kunjungan tidak valid")
+	}
+
 	if r.IDPoli <= 0 {
 		errs = append(errs, "Poli harus dipilih")
 	}
 
-	mp := strings.TrimSpace(r.MetodePembayaran)
-	if mp != "Umum" && mp != "BPJS" {
-		errs = append(errs, "Metode Pembayaran harus 'Umum' atau 'BPJS'")
+	if r.IDDokter != nil && *r.IDDokter <= 0 {
+		errs = append(errs, "Dokter tidak valid")
 	}
 
-	if mp == "BPJS" && r.NominalPembayaran != 0 {
-		errs = append(errs, "Nominal Pembayaran harus Rp 0 untuk BPJS")
+	mp := strings.TrimSpace(r.MetodePembayaran)
+	if mp == "" {
+		errs = append(errs, "Metode Pembayaran harus dipilih")
+	} else if len(mp) > 20 {
+		errs = append(errs, "Metode Pembayaran tidak valid")
 	}
 
-	if mp == "Umum" && r.NominalPembayaran < 0 {
-		errs = append(errs, "Nominal Pembayaran tidak boleh negatif")
+	if msg := ValidasiKodeDiagnosa(r.KodeDiagnosa); msg != "" {
+		errs = append(errs, msg)
 	}
 
 	return errs
 }
+
+// validasiTanggalPemeriksaan menerima tanggal kosong atau YYYY-MM-DD yang
+// tidak di masa depan
+func validasiTanggalPemeriksaan(tanggal string) string {
+	tanggal = strings.TrimSpace(tanggal)
+	if tanggal == "" {
+		return ""
+	}
+	tg, err := time.Parse("2006-01-02", tanggal)
+	if err != nil {
+		return "Format Tanggal Pemeriksaan harus YYYY-MM-DD"
+	}
+	if tg.Format("2006-01-02") > time.Now().Format("2006-01-02") {
+		return "Tanggal Pemeriksaan tidak boleh di masa depan"
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ─── Penjamin (Payer) Model ──────────────────────────────────────────────────
+// Kode penjamin disimpan di pemeriksaan.metode_pembayaran (Umum, BPJS, ...)
+
+var JenisPenjamin = []string{"umum", "bpjs", "jamkesda", "asuransi", "perusahaan"}
+
+type Penjamin struct {
+	KodePenjamin string `json:"kode_penjamin"`
+	NamaPenjamin string `json:"nama_penjamin"`
+	Jenis        string `json:"jenis"`
+	WajibNomor   bool   `json:"wajib_nomor"`   // nomor kartu/polis/surat jaminan wajib diisi
+	PanjangNomor *int   `json:"panjang_nomor"` // jumlah digit nomor, kosong = bebas
+	Aktif        bool   `json:"aktif"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type PenjaminRequest struct {
+	KodePenjamin string `json:"kode_penjamin"`
+	NamaPenjamin string `json:"nama_penjamin"`
+	Jenis        string `json:"jenis"`
+	WajibNomor   bool   `json:"wajib_nomor"`
+	PanjangNomor *int   `json:"panjang_nomor"`
+	Aktif        *bool  `json:"aktif"`
+}
+
+// ─── Laporan Pendapatan per Penjamin ────────────────────────────────────────
+
+type PendapatanPenjamin struct {
+	KodePenjamin      string  `json:"kode_penjamin"`
+	NamaPenjamin      string  `json:"nama_penjamin"`
+	JumlahPemeriksaan int     `json:"jumlah_pemeriksaan"`
+	JumlahTagihan     int     `json:"jumlah_tagihan"`
+	TotalTagihan      float64 `json:"total_tagihan"`
+	Ditanggung        float64 `json:"ditanggung"` // klaim ke penjamin
+	Diskon            float64 `json:"diskon"`
+	DibayarPasien     float64 `json:"dibayar_pasien"` // hanya tagihan lunas
+	BelumLunas        float64 `json:"belum_lunas"`
+}
+
+type ReportPendapatan struct {
+	TanggalDari   string               `json:"tanggal_dari"`
+	TanggalSampai string               `json:"tanggal_sampai"`
+	TotalTagihan  float64              `json:"total_tagihan"`
+	Ditanggung    float64              `json:"ditanggung"`
+	DibayarPasien float64              `json:"dibayar_pasien"`
+	PerPenjamin   []PendapatanPenjamin `json:"per_penjamin"`
+}
+
+// ─── Validasi Nomor Penjamin ─────────────────────────────────────────────────
+
+// ValidasiNomor cek nomor penjamin pasien sesuai aturan penjamin,
+// mengembalikan pesan error atau string kosong
+func (p *Penjamin) ValidasiNomor(nomor string) string {
+	nomor = strings.TrimSpace(nomor)
+	if nomor == "" {
+		if p.WajibNomor {
+			return "Nomor penjamin wajib diisi untuk " + p.NamaPenjamin
+		}
+		return ""
+	}
+	if len(nomor) > 30 {
+		return "Nomor penjamin maksimal 30 karakter"
+	}
+	if p.PanjangNomor != nil && (len(nomor) != *p.PanjangNomor || !isNumericStr(nomor)) {
+		return fmt.Sprintf("Nomor %s harus %d angka", p.NamaPenjamin, *p.PanjangNomor)
+	}
+	return ""
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *PenjaminRequest) Validate() []string {
+	var errs []string
+
+	kode := strings.TrimSpace(r.KodePenjamin)
+	if kode == "" {
+		errs = append(errs, "Kode Penjamin tidak boleh kosong")
+	} else if len(kode) > 20 || strings.ContainsAny(kode, " /") {
+		errs = append(errs, "Kode Penjamin maksimal 20 karakter tanpa spasi atau '/'")
+	}
+
+	nama := strings.TrimSpace(r.NamaPenjamin)
+	if nama == "" {
+		errs = append(errs, "Nama Penjamin tidak boleh kosong")
+	} else if len(nama) > 100 {
+		errs = append(errs, "Nama Penjamin maksimal 100 karakter")
+	}
+
+	if !isJenisPenjamin(strings.TrimSpace(r.Jenis)) {
+		errs = append(errs, "Jenis harus salah satu dari: "+strings.Join(JenisPenjamin, ", "))
+	}
+
+	if r.PanjangNomor != nil && (*r.PanjangNomor < 1 || *r.PanjangNomor > 30) {
+		errs = append(errs, "Panjang nomor harus antara 1-30")
+	}
+
+	return errs
+}
+
+func isJenisPenjamin(j string) bool {
+	for _, v := range JenisPenjamin {
+		if j == v {
+			return true
+		}
+	}
+	return false
+}
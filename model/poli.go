@@ -0,0 +1,151 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ─── Poli Model ──────────────────────────────────────────────────────────────
+
+type Poli struct {
+	IDPoli        int    `json:"id_poli"`
+	NamaPoli      string `json:"nama_poli"`
+	NamaDokter    string `json:"nama_dokter"` // dokter default poli
+	Aktif         bool   `json:"aktif"`
+	Urutan        int    `json:"urutan"`
+	JamBuka       string `json:"jam_buka"`  // HH:MM
+	JamTutup      string `json:"jam_tutup"` // HH:MM
+	KuotaHarian   int    `json:"kuota_harian"`
+	PrefixAntrian string `json:"prefix_antrian"` // contoh: A -> A-001
+	KodePoliBPJS  string `json:"kode_poli_bpjs"` // kode poli di PCare, misal 001
+	DurasiSlot    int    `json:"durasi_slot"`    // menit per slot booking online
+	KuotaSlot     int    `json:"kuota_slot"`     // pasien per dokter per slot
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type PoliRequest struct {
+	NamaPoli      string `json:"nama_poli"`
+	NamaDokter    string `json:"nama_dokter"`
+	JamBuka       string `json:"jam_buka"`
+	JamTutup      string `json:"jam_tutup"`
+	KuotaHarian   int    `json:"kuota_harian"`
+	PrefixAntrian string `json:"prefix_antrian"`
+	KodePoliBPJS  string `json:"kode_poli_bpjs"`
+	DurasiSlot    int    `json:"durasi_slot"` // 0 = tidak diubah
+	KuotaSlot     int    `json:"kuota_slot"`  // 0 = tidak diubah
+}
+
+type UpdatePoliStatusRequest struct {
+	Aktif *bool `json:"aktif"`
+}
+
+type ReorderPoliRequest struct {
+	Urutan []int `json:"urutan"` // daftar id_poli sesuai urutan tampil
+}
+
+// ─── Kode Antrian ────────────────────────────────────────────────────────────
+
+// FormatKodeAntrian menggabungkan prefix poli dengan nomor antrian, misal A-012
+func FormatKodeAntrian(prefix string, nomor int) string {
+	if prefix == "" {
+		return fmt.Sprintf("%03d", nomor)
+	}
+	return fmt.Sprintf("%s-%03d", prefix, nomor)
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *PoliRequest) Validate() []string {
+	var errs []string
+
+	nama := strings.TrimSpace(r.NamaPoli)
+	if nama == "" {
+		errs = append(errs, "Nama Poli tidak boleh kosong")
+	} else if len(nama) < 3 || len(nama) > 50 {
+		errs = append(errs, "Nama Poli harus antara 3-50 karakter")
+	}
+
+	dokter := strings.TrimSpace(r.NamaDokter)
+	if dokter == "" {
+		errs = append(errs, "Nama Dokter tidak boleh kosong")
+	} else if len(dokter) > 100 {
+		errs = append(errs, "Nama Dokter maksimal 100 karakter")
+	}
+
+	buka, err1 := time.Parse("15:04", strings.TrimSpace(r.JamBuka))
+	if err1 != nil {
+		errs = append(errs, "Format Jam Buka harus HH:MM")
+	}
+	tutup, err2 := time.Parse("15:04", strings.TrimSpace(r.JamTutup))
+	if err2 != nil {
+		errs = append(errs, "Format Jam Tutup harus HH:MM")
+	}
+	if err1 == nil && err2 == nil && !tutup.After(buka) {
+		errs = append(errs, "Jam Tutup harus setelah Jam Buka")
+	}
+
+	if r.KuotaHarian < 1 || r.KuotaHarian > 999 {
+		errs = append(errs, "Kuota Harian harus antara 1-999")
+	}
+
+	prefix := strings.TrimSpace(r.PrefixAntrian)
+	if prefix == "" {
+		errs = append(errs, "Prefix Antrian tidak boleh kosong")
+	} else if len(prefix) > 3 || !isUpperAlpha(prefix) {
+		errs = append(errs, "Prefix Antrian harus 1-3 huruf kapital")
+	}
+
+	if len(strings.TrimSpace(r.KodePoliBPJS)) > 5 {
+		errs = append(errs, "Kode Poli BPJS maksimal 5 karakter")
+	}
+
+	if r.DurasiSlot != 0 && (r.DurasiSlot < 5 || r.DurasiSlot > 240) {
+		errs = append(errs, "Durasi Slot harus antara 5-240 menit")
+	}
+	if r.KuotaSlot != 0 && (r.KuotaSlot < 1 || r.KuotaSlot > 50) {
+		errs = append(errs, "Kuota Slot harus antara 1-50")
+	}
+
+	return errs
+}
+
+func (r *UpdatePoliStatusRequest) Validate() []string {
+	var errs []string
+	if r.Aktif == nil {
+		errs = append(errs, "Status aktif harus diisi")
+	}
+	return errs
+}
+
+func (r *ReorderPoliRequest) Validate() []string {
+	var errs []string
+	if len(r.Urutan) == 0 {
+		errs = append(errs, "Urutan poli tidak boleh kosong")
+	}
+
+	seen := map[int]bool{}
+	for _, id := range r.Urutan {
+		if id <= 0 {
+			errs = append(errs, "ID Poli pada urutan tidak valid")
+			break
+		}
+		if seen[id] {
+			errs = append(errs, "ID Poli pada urutan tidak boleh duplikat")
+			break
+		}
+		seen[id] = true
+	}
+	return errs
+}
+
+// isUpperAlpha cek string hanya huruf kapital A-Z
+func isUpperAlpha(s string) bool {
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -69,3 +69,31 @@ func PaginatedSuccessResponse(c *fiber.Ctx, data interface{}, totalData, current
 		},
 	})
 }
+
+// ─── Cursor Pagination ──────────────────────────────────────────────────────
+
+type CursorMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Limit      int    `json:"limit"`
+}
+
+type CursorPaginatedResponse struct {
+	Status     bool        `json:"status"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Pagination CursorMeta  `json:"pagination"`
+}
+
+func CursorSuccessResponse(c *fiber.Ctx, data interface{}, nextCursor string, limit int) error {
+	return c.Status(200).JSON(CursorPaginatedResponse{
+		Status:  true,
+		Message: "Berhasil",
+		Data:    data,
+		Pagination: CursorMeta{
+			NextCursor: nextCursor,
+			HasMore:    nextCursor != "",
+			Limit:      limit,
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package model
+
+// ─── Ringkasan Antrian Harian ────────────────────────────────────────────────
+// Dibuat oleh job penutupan antrian setelah poli tutup
+
+type RingkasanAntrian struct {
+	IDRingkasan      int      `json:"id_ringkasan"`
+	Tanggal          string   `json:"tanggal"`
+	IDPoli           *int     `json:"id_poli"`
+	NamaPoli         string   `json:"nama_poli"`
+	Total            int      `json:"total"`
+	Dilayani         int      `json:"dilayani"`
+	TidakHadir       int      `json:"tidak_hadir"`        // dipanggil tapi tidak datang
+	Kedaluwarsa      int      `json:"kedaluwarsa"`        // belum sempat dipanggil
+	Prioritas        int      `json:"prioritas"`          // antrian non-reguler
+	RataTungguMenit  *float64 `json:"rata_tunggu_menit"`  // ambil nomor -> dipanggil
+	RataLayananMenit *float64 `json:"rata_layanan_menit"` // dipanggil -> selesai
+	DitutupAt        string   `json:"ditutup_at"`
+}
+
+type ReportAntrian struct {
+	TanggalDari   string             `json:"tanggal_dari"`
+	TanggalSampai string             `json:"tanggal_sampai"`
+	Total         int                `json:"total"`
+	Dilayani      int                `json:"dilayani"`
+	TidakHadir    int                `json:"tidak_hadir"`
+	Kedaluwarsa   int                `json:"kedaluwarsa"`
+	PerHari       []RingkasanAntrian `json:"per_hari"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ─── Riwayat (Timeline Kunjungan Pasien) ────────────────────────────────────
+
+type RiwayatItem struct {
+	Tipe      string                 `json:"tipe"` // antrian / pemeriksaan / rujukan / kunjungan_tahap
+	ID        int                    `json:"id"`
+	Waktu     time.Time              `json:"waktu"`
+	Tanggal   string                 `json:"tanggal"` // YYYY-MM-DD
+	IDPoli    *int                   `json:"id_poli,omitempty"`
+	NamaPoli  string                 `json:"nama_poli,omitempty"`
+	Ringkasan string                 `json:"ringkasan"`
+	Detail    map[string]interface{} `json:"detail"`
+}
+
+// ─── Cursor ──────────────────────────────────────────────────────────────────
+// Cursor menunjuk item terakhir pada halaman sebelumnya: tanggal|waktu|tipe|id
+
+type RiwayatCursor struct {
+	Tanggal string // YYYY-MM-DD
+	Waktu   string // format timestamp PostgreSQL, presisi mikrodetik
+	Tipe    string
+	ID      int
+}
+
+const riwayatCursorLayout = "2006-01-02 15:04:05.999999"
+
+func NewRiwayatCursor(item RiwayatItem) RiwayatCursor {
+	return RiwayatCursor{
+		Tanggal: item.Tanggal,
+		Waktu:   item.Waktu.Format(riwayatCursorLayout),
+		Tipe:    item.Tipe,
+		ID:      item.ID,
+	}
+}
+
+func (c RiwayatCursor) Encode() string {
+	raw := c.Tanggal + "|" + c.Waktu + "|" + c.Tipe + "|" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeRiwayatCursor(s string) (RiwayatCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return RiwayatCursor{}, fmt.Errorf("cursor tidak valid")
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 4 {
+		return RiwayatCursor{}, fmt.Errorf("cursor tidak valid")
+	}
+	if _, err := time.Parse("2006-01-02", parts[0]); err != nil {
+		return RiwayatCursor{}, fmt.Errorf("cursor tidak valid")
+	}
+	if _, err := time.Parse(riwayatCursorLayout, parts[1]); err != nil {
+		return RiwayatCursor{}, fmt.Errorf("cursor tidak valid")
+	}
+	id, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return RiwayatCursor{}, fmt.Errorf("cursor tidak valid")
+	}
+
+	return RiwayatCursor{Tanggal: parts[0], Waktu: parts[1], Tipe: parts[2], ID: id}, nil
+}
@@ -0,0 +1,169 @@
+package model
+
+import "strings"
+
+// ─── Role & Izin Model ───────────────────────────────────────────────────────
+// Akses endpoint ditentukan oleh izin, bukan nama role. Role dan daftar
+// izinnya disimpan di database dan bisa diubah admin; role admin selalu
+// memegang semua izin.
+
+const RoleAdmin = "admin"
+
+// Izin akses endpoint. Izin *.read untuk melihat, *.write untuk mengubah.
+const (
+	IzinPasienRead       = "pasien.read"
+	IzinPasienWrite      = "pasien.write"
+	IzinAntrianRead      = "antrian.read"
+	IzinAntrianWrite     = "antrian.write"
+	IzinKunjunganRead    = "kunjungan.read"
+	IzinKunjunganWrite   = "kunjungan.write"
+	IzinBookingRead      = "booking.read"
+	IzinBookingWrite     = "booking.write"
+	IzinPemeriksaanRead  = "pemeriksaan.read"
+	IzinPemeriksaanWrite = "pemeriksaan.write"
+	IzinRujukanRead      = "rujukan.read"
+	IzinRujukanWrite     = "rujukan.write"
+	IzinIntegrasiRead    = "integrasi.read"
+	IzinIntegrasiWrite   = "integrasi.write"
+	IzinMasterRead       = "master.read"
+	IzinMasterWrite      = "master.write"
+	IzinTarifRead        = "tarif.read"
+	IzinTarifWrite       = "tarif.write"
+	IzinKasirRead        = "kasir.read"
+	IzinKasirWrite       = "kasir.write"
+	IzinLaporanRead      = "laporan.read"
+	IzinLaporanExport    = "laporan.export"
+	IzinKioskKelola      = "kiosk.kelola"
+	IzinAuditRead        = "audit.read"
+	IzinUserKelola       = "user.kelola"
+)
+
+type Izin struct {
+	Kode       string `json:"kode"`
+	Keterangan string `json:"keterangan"`
+}
+
+// DaftarIzin adalah katalog semua izin yang dikenal aplikasi
+var DaftarIzin = []Izin{
+	{IzinPasienRead, "Melihat data dan riwayat pasien"},
+	{IzinPasienWrite, "Menambah, mengubah dan menghapus pasien"},
+	{IzinAntrianRead, "Melihat antrian dan dashboard"},
+	{IzinAntrianWrite, "Membuat, memanggil dan mengubah antrian"},
+	{IzinKunjunganRead, "Melihat kunjungan bertahap"},
+	{IzinKunjunganWrite, "Membuat, memanggil dan melanjutkan kunjungan"},
+	{IzinBookingRead, "Melihat booking online"},
+	{IzinBookingWrite, "Check-in booking online"},
+	{IzinPemeriksaanRead, "Melihat pemeriksaan dan tanda vital"},
+	{IzinPemeriksaanWrite, "Mencatat pemeriksaan dan tanda vital"},
+	{IzinRujukanRead, "Melihat rujukan"},
+	{IzinRujukanWrite, "Membuat dan mengubah rujukan"},
+	{IzinIntegrasiRead, "Melihat status bridging BPJS dan SATUSEHAT"},
+	{IzinIntegrasiWrite, "Mengirim ulang data ke BPJS dan SATUSEHAT"},
+	{IzinMasterRead, "Melihat poli, dokter, loket, tahap layanan dan hari libur"},
+	{IzinMasterWrite, "Mengubah poli, dokter, loket, tahap layanan dan hari libur"},
+	{IzinTarifRead, "Melihat tarif, penjamin dan aturan harga"},
+	{IzinTarifWrite, "Mengubah tarif, penjamin dan aturan harga"},
+	{IzinKasirRead, "Melihat tagihan dan tutup kas"},
+	{IzinKasirWrite, "Membuat tagihan dan menerima pembayaran"},
+	{IzinLaporanRead, "Melihat laporan"},
+	{IzinLaporanExport, "Mengunduh laporan dan mencetak surat rujukan"},
+	{IzinKioskKelola, "Mengelola perangkat kiosk"},
+	{IzinAuditRead, "Melihat audit log"},
+	{IzinUserKelola, "Mengelola user dan role"},
+}
+
+// IsIzinValid cek kode izin ada di katalog
+func IsIzinValid(kode string) bool {
+	for _, i := range DaftarIzin {
+		if i.Kode == kode {
+			return true
+		}
+	}
+	return false
+}
+
+// SemuaIzin mengembalikan kode semua izin, dipakai untuk role admin
+func SemuaIzin() []string {
+	kode := make([]string, len(DaftarIzin))
+	for i, izin := range DaftarIzin {
+		kode[i] = izin.Kode
+	}
+	return kode
+}
+
+type Role struct {
+	KodeRole   string   `json:"kode_role"`
+	NamaRole   string   `json:"nama_role"`
+	Keterangan string   `json:"keterangan"`
+	Sistem     bool     `json:"sistem"`      // role bawaan, tidak bisa dihapus
+	BatasiPoli bool     `json:"batasi_poli"` // data dibatasi ke poli tugas user
+	Wajib2FA   bool     `json:"wajib_2fa"`   // user harus memakai 2FA untuk login
+	Izin       []string `json:"izin"`
+	JumlahUser int      `json:"jumlah_user"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+// RoleRequest dipakai untuk membuat dan mengubah role; kode_role hanya
+// dipakai saat membuat
+type RoleRequest struct {
+	KodeRole   string   `json:"kode_role"`
+	NamaRole   string   `json:"nama_role"`
+	Keterangan string   `json:"keterangan"`
+	BatasiPoli bool     `json:"batasi_poli"`
+	Wajib2FA   bool     `json:"wajib_2fa"`
+	Izin       []string `json:"izin"`
+}
+
+type UbahRoleUserRequest struct {
+	Role string `json:"role"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *RoleRequest) Validate(buat bool) []string {
+	var errs []string
+
+	if buat {
+		kode := strings.TrimSpace(r.KodeRole)
+		if kode == "" {
+			errs = append(errs, "Kode role tidak boleh kosong")
+		} else if len(kode) > 20 {
+			errs = append(errs, "Kode role maksimal 20 karakter")
+		} else {
+			for _, c := range kode {
+				if !((c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '_') {
+					errs = append(errs, "Kode role hanya boleh huruf kecil, angka dan garis bawah")
+					break
+				}
+			}
+		}
+	}
+
+	nama := strings.TrimSpace(r.NamaRole)
+	if nama == "" {
+		errs = append(errs, "Nama role tidak boleh kosong")
+	} else if len(nama) > 50 {
+		errs = append(errs, "Nama role maksimal 50 karakter")
+	}
+
+	if len(strings.TrimSpace(r.Keterangan)) > 255 {
+		errs = append(errs, "Keterangan maksimal 255 karakter")
+	}
+
+	for _, izin := range r.Izin {
+		if !IsIzinValid(izin) {
+			errs = append(errs, "Izin '"+izin+"' tidak dikenal")
+		}
+	}
+
+	return errs
+}
+
+func (r *UbahRoleUserRequest) Validate() []string {
+	var errs []string
+	if strings.TrimSpace(r.Role) == "" {
+		errs = append(errs, "Role tidak boleh kosong")
+	}
+	return errs
+}
@@ -0,0 +1,144 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ─── Rujukan Model ───────────────────────────────────────────────────────────
+
+type Rujukan struct {
+	IDRujukan      int       `json:"id_rujukan"`
+	NomorRujukan   string    `json:"nomor_rujukan"` // RJK/YYYYMMDD/0001
+	IDPemeriksaan  int       `json:"id_pemeriksaan"`
+	JenisRujukan   string    `json:"jenis_rujukan"` // internal / eksternal
+	FaskesTujuan   string    `json:"faskes_tujuan"`
+	Spesialis      string    `json:"spesialis"`
+	Diagnosa       string    `json:"diagnosa"`
+	Alasan         string    `json:"alasan"`
+	TanggalRujukan string    `json:"tanggal_rujukan"` // YYYY-MM-DD
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type CreateRujukanRequest struct {
+	IDPemeriksaan int    `json:"id_pemeriksaan"`
+	JenisRujukan  string `json:"jenis_rujukan"`
+	FaskesTujuan  string `json:"faskes_tujuan"`
+	Spesialis     string `json:"spesialis"`
+	Diagnosa      string `json:"diagnosa"`
+	Alasan        string `json:"alasan"`
+}
+
+type UpdateRujukanRequest struct {
+	JenisRujukan string `json:"jenis_rujukan"`
+	FaskesTujuan string `json:"faskes_tujuan"`
+	Spesialis    string `json:"spesialis"`
+	Diagnosa     string `json:"diagnosa"`
+	Alasan       string `json:"alasan"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+type RujukanResponse struct {
+	IDRujukan      int    `json:"id_rujukan"`
+	NomorRujukan   string `json:"nomor_rujukan"`
+	IDPemeriksaan  int    `json:"id_pemeriksaan"`
+	NIKPasien      string `json:"nik_pasien"`
+	NamaPasien     string `json:"nama_pasien"`
+	IDPoli         int    `json:"id_poli"`
+	NamaPoli       string `json:"nama_poli"`
+	NamaDokter     string `json:"nama_dokter"`
+	JenisRujukan   string `json:"jenis_rujukan"`
+	FaskesTujuan   string `json:"faskes_tujuan"`
+	Spesialis      string `json:"spesialis"`
+	Diagnosa       string `json:"diagnosa"`
+	Alasan         string `json:"alasan"`
+	TanggalRujukan string `json:"tanggal_rujukan"`
+}
+
+// ─── Report Response ─────────────────────────────────────────────────────────
+
+type RujukanStatItem struct {
+	Label  string `json:"label"`
+	Jumlah int    `json:"jumlah"`
+}
+
+type ReportRujukan struct {
+	TotalRujukan   int               `json:"total_rujukan"`
+	TotalInternal  int               `json:"total_internal"`
+	TotalEksternal int               `json:"total_eksternal"`
+	PerFaskes      []RujukanStatItem `json:"per_faskes"`
+	PerSpesialis   []RujukanStatItem `json:"per_spesialis"`
+	PerPoliAsal    []RujukanStatItem `json:"per_poli_asal"`
+}
+
+// ─── Nomor Rujukan ───────────────────────────────────────────────────────────
+
+// FormatNomorRujukan menghasilkan nomor rujukan berurutan per hari
+func FormatNomorRujukan(tanggal time.Time, urutan int) string {
+	return fmt.Sprintf("RJK/%s/%04d", tanggal.Format("20060102"), urutan)
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *CreateRujukanRequest) Validate() []string {
+	var errs []string
+
+	if r.IDPemeriksaan <= 0 {
+		errs = append(errs, "Pemeriksaan harus dipilih")
+	}
+
+	errs = append(errs, validateRujukanFields(r.JenisRujukan, r.FaskesTujuan, r.Spesialis, r.Diagnosa, r.Alasan)...)
+	return errs
+}
+
+func (r *UpdateRujukanRequest) Validate() []string {
+	return validateRujukanFields(r.JenisRujukan, r.FaskesTujuan, r.Spesialis, r.Diagnosa, r.Alasan)
+}
+
+func validateRujukanFields(jenis, faskes, spesialis, diagnosa, alasan string) []string {
+	var errs []string
+
+	jenis = strings.TrimSpace(jenis)
+	if jenis != "internal" && jenis != "eksternal" {
+		errs = append(errs, "Jenis Rujukan harus 'internal' atau 'eksternal'")
+	}
+
+	faskes = strings.TrimSpace(faskes)
+	if faskes == "" {
+		errs = append(errs, "Faskes Tujuan tidak boleh kosong")
+	} else if len(faskes) > 150 {
+		errs = append(errs, "Faskes Tujuan maksimal 150 karakter")
+	}
+
+	spesialis = strings.TrimSpace(spesialis)
+	if spesialis == "" {
+		errs = append(errs, "Spesialis tidak boleh kosong")
+	} else if len(spesialis) > 100 {
+		errs = append(errs, "Spesialis maksimal 100 karakter")
+	}
+
+	diagnosa = strings.TrimSpace(diagnosa)
+	if diagnosa == "" {
+		errs = append(errs, "Diagnosa tidak boleh kosong")
+	} else if len(diagnosa) < 3 {
+		errs = append(errs, "Diagnosa minimal 3 karakter")
+	} else if len(diagnosa) > 1000 {
+		errs = append(errs, "Diagnosa maksimal 1000 karakter")
+	}
+
+	alasan = strings.TrimSpace(alasan)
+	if alasan == "" {
+		errs = append(errs, "Alasan Rujukan tidak boleh kosong")
+	} else if len(alasan) < 3 {
+		errs = append(errs, "Alasan Rujukan minimal 3 karakter")
+	} else if len(alasan) > 1000 {
+		errs = append(errs, "Alasan Rujukan maksimal 1000 karakter")
+	}
+
+	return errs
+}
@@ -0,0 +1,19 @@
+package model
+
+// ─── Sinkronisasi SATUSEHAT ──────────────────────────────────────────────────
+// Satu baris per resource FHIR yang dikirim; worker mengirim ulang baris gagal
+// dengan jeda yang makin panjang
+
+var JenisResourceSatusehat = []string{"Patient", "Encounter", "Condition", "Observation"}
+
+type SatusehatSinkron struct {
+	IDSinkron       int     `json:"id_sinkron"`
+	JenisResource   string  `json:"jenis_resource"`
+	IDLokal         string  `json:"id_lokal"`     // NIK / id_pemeriksaan / id_pemeriksaan:jenis
+	IDSatusehat     *string `json:"id_satusehat"` // id resource di SATUSEHAT
+	Status          string  `json:"status"`       // menunggu / terkirim / gagal
+	PesanError      string  `json:"pesan_error"`
+	Percobaan       int     `json:"percobaan"`
+	CobaLagiAt      *string `json:"coba_lagi_at"` // YYYY-MM-DD HH:MM:SS
+	TerakhirDikirim *string `json:"terakhir_dikirim"`
+}
@@ -0,0 +1,198 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ─── Tagihan (Invoice) Model ────────────────────────────────────────────────
+
+type Tagihan struct {
+	IDTagihan     int       `json:"id_tagihan"`
+	NomorTagihan  string    `json:"nomor_tagihan"` // INV/YYYYMMDD/0001
+	IDPemeriksaan int       `json:"id_pemeriksaan"`
+	Status        string    `json:"status"` // belum_lunas / lunas / batal
+	Total         float64   `json:"total"`
+	Ditanggung    float64   `json:"ditanggung"` // bagian yang ditanggung penjamin
+	Diskon        float64   `json:"diskon"`     // potongan dari aturan harga
+	TotalBayar    float64   `json:"total_bayar"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type TagihanItem struct {
+	IDItem      int     `json:"id_item"`
+	IDTarif     int     `json:"id_tarif"`
+	NamaItem    string  `json:"nama_item"`
+	Kategori    string  `json:"kategori"`
+	HargaSatuan float64 `json:"harga_satuan"`
+	Jumlah      int     `json:"jumlah"`
+	Subtotal    float64 `json:"subtotal"`
+	Ditanggung  float64 `json:"ditanggung"`
+	Diskon      float64 `json:"diskon"`
+}
+
+type Pembayaran struct {
+	IDPembayaran  int       `json:"id_pembayaran"`
+	Metode        string    `json:"metode"` // tunai / qris
+	JumlahDibayar float64   `json:"jumlah_dibayar"`
+	Kembalian     float64   `json:"kembalian"`
+	ReferensiQRIS string    `json:"referensi_qris,omitempty"`
+	IDKasir       int       `json:"id_kasir"`
+	NamaKasir     string    `json:"nama_kasir"`
+	DibayarAt     time.Time `json:"dibayar_at"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type CreateTagihanRequest struct {
+	IDPemeriksaan int                  `json:"id_pemeriksaan"`
+	Items         []TagihanItemRequest `json:"items"`
+}
+
+type TagihanItemRequest struct {
+	IDTarif int `json:"id_tarif"`
+	Jumlah  int `json:"jumlah"`
+}
+
+type BayarTagihanRequest struct {
+	Metode        string  `json:"metode"`
+	JumlahDibayar float64 `json:"jumlah_dibayar"`
+	ReferensiQRIS string  `json:"referensi_qris"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+type TagihanResponse struct {
+	IDTagihan          int           `json:"id_tagihan"`
+	NomorTagihan       string        `json:"nomor_tagihan"`
+	IDPemeriksaan      int           `json:"id_pemeriksaan"`
+	NIKPasien          string        `json:"nik_pasien"`
+	NamaPasien         string        `json:"nama_pasien"`
+	NamaPoli           string        `json:"nama_poli"`
+	MetodePembayaran   string        `json:"metode_pembayaran"`
+	NamaPenjamin       string        `json:"nama_penjamin"`
+	TanggalPemeriksaan string        `json:"tanggal_pemeriksaan"`
+	Status             string        `json:"status"`
+	Total              float64       `json:"total"`
+	Ditanggung         float64       `json:"ditanggung"`
+	Diskon             float64       `json:"diskon"`
+	TotalBayar         float64       `json:"total_bayar"`
+	Items              []TagihanItem `json:"items,omitempty"`
+	Pembayaran         *Pembayaran   `json:"pembayaran,omitempty"`
+}
+
+// ─── Laporan Tutup Kas Harian ───────────────────────────────────────────────
+
+type TutupKasMetode struct {
+	Metode          string  `json:"metode"`
+	JumlahTransaksi int     `json:"jumlah_transaksi"`
+	Total           float64 `json:"total"`
+}
+
+type TutupKasKasir struct {
+	IDKasir         int     `json:"id_kasir"`
+	NamaKasir       string  `json:"nama_kasir"`
+	JumlahTransaksi int     `json:"jumlah_transaksi"`
+	TotalTunai      float64 `json:"total_tunai"`
+	TotalQRIS       float64 `json:"total_qris"`
+}
+
+type TutupKasReport struct {
+	Tanggal           string           `json:"tanggal"`
+	JumlahTransaksi   int              `json:"jumlah_transaksi"`
+	TotalPenerimaan   float64          `json:"total_penerimaan"`
+	TotalDitanggung   float64          `json:"total_ditanggung"`
+	TotalDiskon       float64          `json:"total_diskon"`
+	TagihanBelumLunas int              `json:"tagihan_belum_lunas"`
+	PerMetode         []TutupKasMetode `json:"per_metode"`
+	PerKasir          []TutupKasKasir  `json:"per_kasir"`
+}
+
+// ─── Nomor Tagihan & Nominal ─────────────────────────────────────────────────
+
+func FormatNomorTagihan(tanggal time.Time, urutan int) string {
+	return fmt.Sprintf("INV/%s/%04d", tanggal.Format("20060102"), urutan)
+}
+
+// HitungKembalian mengembalikan uang kembalian pembayaran tunai.
+// Nilai dibulatkan ke rupiah agar tidak muncul sisa pecahan dari float.
+func HitungKembalian(dibayar, tagihan float64) float64 {
+	k := dibayar - tagihan
+	if k < 0 {
+		return 0
+	}
+	return float64(int64(k*100+0.5)) / 100
+}
+
+// FormatRupiah memformat nominal ke "Rp 15.000" (tanpa desimal)
+func FormatRupiah(n float64) string {
+	neg := n < 0
+	if neg {
+		n = -n
+	}
+	s := fmt.Sprintf("%d", int64(n+0.5))
+
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(c)
+	}
+
+	if neg {
+		return "-Rp " + b.String()
+	}
+	return "Rp " + b.String()
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *CreateTagihanRequest) Validate() []string {
+	var errs []string
+
+	if r.IDPemeriksaan <= 0 {
+		errs = append(errs, "Pemeriksaan harus dipilih")
+	}
+
+	for _, it := range r.Items {
+		errs = append(errs, it.Validate()...)
+	}
+
+	return errs
+}
+
+func (r *TagihanItemRequest) Validate() []string {
+	var errs []string
+	if r.IDTarif <= 0 {
+		errs = append(errs, "Tarif harus dipilih")
+	}
+	if r.Jumlah < 1 || r.Jumlah > 1000 {
+		errs = append(errs, "Jumlah item harus antara 1-1000")
+	}
+	return errs
+}
+
+func (r *BayarTagihanRequest) Validate() []string {
+	var errs []string
+
+	metode := strings.TrimSpace(r.Metode)
+	if metode != "tunai" && metode != "qris" {
+		errs = append(errs, "Metode pembayaran harus 'tunai' atau 'qris'")
+	}
+
+	if r.JumlahDibayar < 0 {
+		errs = append(errs, "Jumlah dibayar tidak boleh negatif")
+	}
+
+	ref := strings.TrimSpace(r.ReferensiQRIS)
+	if metode == "qris" && ref == "" {
+		errs = append(errs, "Referensi QRIS wajib diisi untuk pembayaran QRIS")
+	} else if len(ref) > 64 {
+		errs = append(errs, "Referensi QRIS maksimal 64 karakter")
+	}
+
+	return errs
+}
@@ -0,0 +1,68 @@
+package model
+
+// ─── Tanda Vital Model ───────────────────────────────────────────────────────
+// Satu set pengukuran per pemeriksaan; kolom kosong berarti tidak diukur
+
+type TandaVital struct {
+	IDPemeriksaan int      `json:"id_pemeriksaan"`
+	Sistole       *int     `json:"sistole"`      // mmHg
+	Diastole      *int     `json:"diastole"`     // mmHg
+	Nadi          *int     `json:"nadi"`         // kali/menit
+	Pernapasan    *int     `json:"pernapasan"`   // kali/menit
+	Suhu          *float64 `json:"suhu"`         // °C
+	BeratBadan    *float64 `json:"berat_badan"`  // kg
+	TinggiBadan   *float64 `json:"tinggi_badan"` // cm
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type TandaVitalRequest struct {
+	Sistole     *int     `json:"sistole"`
+	Diastole    *int     `json:"diastole"`
+	Nadi        *int     `json:"nadi"`
+	Pernapasan  *int     `json:"pernapasan"`
+	Suhu        *float64 `json:"suhu"`
+	BeratBadan  *float64 `json:"berat_badan"`
+	TinggiBadan *float64 `json:"tinggi_badan"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *TandaVitalRequest) Validate() []string {
+	var errs []string
+
+	if r.Sistole == nil && r.Diastole == nil && r.Nadi == nil && r.Pernapasan == nil &&
+		r.Suhu == nil && r.BeratBadan == nil && r.TinggiBadan == nil {
+		errs = append(errs, "Minimal satu tanda vital harus diisi")
+	}
+
+	if (r.Sistole == nil) != (r.Diastole == nil) {
+		errs = append(errs, "Sistole dan Diastole harus diisi bersamaan")
+	}
+	if r.Sistole != nil && (*r.Sistole < 40 || *r.Sistole > 300) {
+		errs = append(errs, "Sistole harus antara 40-300 mmHg")
+	}
+	if r.Diastole != nil && (*r.Diastole < 20 || *r.Diastole > 200) {
+		errs = append(errs, "Diastole harus antara 20-200 mmHg")
+	}
+	if r.Sistole != nil && r.Diastole != nil && *r.Diastole >= *r.Sistole {
+		errs = append(errs, "Diastole harus lebih kecil dari Sistole")
+	}
+	if r.Nadi != nil && (*r.Nadi < 20 || *r.Nadi > 250) {
+		errs = append(errs, "Nadi harus antara 20-250 kali/menit")
+	}
+	if r.Pernapasan != nil && (*r.Pernapasan < 5 || *r.Pernapasan > 80) {
+		errs = append(errs, "Pernapasan harus antara 5-80 kali/menit")
+	}
+	if r.Suhu != nil && (*r.Suhu < 30 || *r.Suhu > 45) {
+		errs = append(errs, "Suhu harus antara 30-45 °C")
+	}
+	if r.BeratBadan != nil && (*r.BeratBadan <= 0 || *r.BeratBadan > 500) {
+		errs = append(errs, "Berat Badan tidak valid")
+	}
+	if r.TinggiBadan != nil && (*r.TinggiBadan <= 0 || *r.TinggiBadan > 300) {
+		errs = append(errs, "Tinggi Badan tidak valid")
+	}
+
+	return errs
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ─── Tarif Model ─────────────────────────────────────────────────────────────
+
+var KategoriTarif = []string{"konsultasi", "tindakan", "obat", "lab"}
+
+type Tarif struct {
+	IDTarif     int       `json:"id_tarif"`
+	KodeTarif   string    `json:"kode_tarif"`
+	NamaLayanan string    `json:"nama_layanan"`
+	Kategori    string    `json:"kategori"` // konsultasi / tindakan / obat / lab
+	Harga       float64   `json:"harga"`
+	Aktif       bool      `json:"aktif"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ─── Request DTO ─────────────────────────────────────────────────────────────
+
+type TarifRequest struct {
+	KodeTarif   string  `json:"kode_tarif"`
+	NamaLayanan string  `json:"nama_layanan"`
+	Kategori    string  `json:"kategori"`
+	Harga       float64 `json:"harga"`
+	Aktif       *bool   `json:"aktif"`
+}
+
+// ─── Response DTO ───────────────────────────────────────────────────────────
+
+type TarifResponse struct {
+	IDTarif     int     `json:"id_tarif"`
+	KodeTarif   string  `json:"kode_tarif"`
+	NamaLayanan string  `json:"nama_layanan"`
+	Kategori    string  `json:"kategori"`
+	Harga       float64 `json:"harga"`
+	Aktif       bool    `json:"aktif"`
+}
+
+// ─── Validation ──────────────────────────────────────────────────────────────
+
+func (r *TarifRequest) Validate() []string {
+	var errs []string
+
+	kode := strings.TrimSpace(r.KodeTarif)
+	if kode == "" {
+		errs = append(errs, "Kode Tarif tidak boleh kosong")
+	} else if len(kode) > 20 {
+		errs = append(errs, "Kode Tarif maksimal 20 karakter")
+	}
+
+	nama := strings.TrimSpace(r.NamaLayanan)
+	if nama == "" {
+		errs = append(errs, "Nama Layanan tidak boleh kosong")
+	} else if len(nama) < 3 || len(nama) > 100 {
+		errs = append(errs, "Nama Layanan harus antara 3-100 karakter")
+	}
+
+	if !isKategoriTarif(strings.TrimSpace(r.Kategori)) {
+		errs = append(errs, "Kategori harus salah satu dari: "+strings.Join(KategoriTarif, ", "))
+	}
+
+	if r.Harga < 0 {
+		errs = append(errs, "Harga tidak boleh negatif")
+	}
+
+	return errs
+}
+
+func isKategoriTarif(k string) bool {
+	for _, v := range KategoriTarif {
+		if k == v {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,6 @@
 package model
 
 import (
-	"os"
 	"strings"
 	"time"
 
@@ -32,20 +31,29 @@ type RegisterRequest struct {
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	IDLoket  *int   `json:"id_loket"` // opsional, loket tempat petugas bertugas
 }
 
 // ─── Response DTO ───────────────────────────────────────────────────────────
 
 type LoginResponse struct {
-	Token string       `json:"token"`
-	User  UserResponse `json:"user"`
+	Token         string       `json:"token"`
+	User          UserResponse `json:"user"`
+	KodePemulihan []string     `json:"kode_pemulihan,omitempty"` // jika 2FA baru didaftarkan saat login
 }
 
 type UserResponse struct {
-	ID       int    `json:"id"`
-	Nama     string `json:"nama"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	ID                 int      `json:"id"`
+	Nama               string   `json:"nama"`
+	Username           string   `json:"username"`
+	Role               string   `json:"role"`
+	IDLoket            *int     `json:"id_loket,omitempty"` // loket yang sedang dipegang
+	NamaLoket          *string  `json:"nama_loket,omitempty"`
+	Izin               []string `json:"izin"`                           // izin efektif dari role
+	IDPoli             []int    `json:"id_poli,omitempty"`              // poli tugas, untuk role batasi_poli
+	AksesDaruratSampai *string  `json:"akses_darurat_sampai,omitempty"` // akses darurat yang sedang aktif
+	TerkunciSampai     *string  `json:"terkunci_sampai,omitempty"`      // login dikunci karena terlalu banyak gagal
+	Aktif2FA           bool     `json:"2fa_aktif"`
 }
 
 // ─── Validation ──────────────────────────────────────────────────────────────
@@ -77,9 +85,11 @@ func (r *RegisterRequest) Validate() []string {
 		errs = append(errs, "Password minimal 6 karakter")
 	}
 
-	role := strings.TrimSpace(r.Role)
-	if role != "" && role != "admin" && role != "kepala_puskesmas" {
-		errs = append(errs, "Role harus 'admin' atau 'kepala_puskesmas'")
+	// Keberadaan role dicek ke tabel role oleh handler
+	if strings.TrimSpace(r.Role) == "" {
+		errs = append(errs, "Role tidak boleh kosong")
+	} else if len(strings.TrimSpace(r.Role)) > 20 {
+		errs = append(errs, "Role maksimal 20 karakter")
 	}
 
 	return errs
@@ -108,17 +118,6 @@ func ComparePassword(hashed, plain string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain))
 }
 
-// ─── Simple Token (Base64-encoded JSON) ──────────────────────────────────────
-// Untuk production gunakan JWT library yang proper. Ini simplified token.
-
-func GetJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "sikupas_default_secret_key_2024"
-	}
-	return secret
-}
-
 // isAlphanumeric cek string hanya huruf dan angka
 func isAlphanumeric(s string) bool {
 	for _, c := range s {
@@ -0,0 +1,155 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ─── Minimal PDF Writer ──────────────────────────────────────────────────────
+// Cukup untuk surat dan tiket sederhana: teks Helvetica dan garis, tanpa
+// dependency tambahan. Karakter di luar ASCII diganti '?'.
+
+const (
+	A4Width  = 595.0
+	A4Height = 842.0
+)
+
+type Document struct {
+	width  float64
+	height float64
+	pages  []*bytes.Buffer
+}
+
+// New membuat dokumen dengan ukuran halaman dalam point (1/72 inch)
+func New(width, height float64) *Document {
+	d := &Document{width: width, height: height}
+	d.AddPage()
+	return d
+}
+
+// AddPage menambah halaman baru; operasi berikutnya ditulis ke halaman ini
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) current() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// Text menulis satu baris teks. Koordinat y dihitung dari atas halaman.
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current(), "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		font, size, x, d.height-y, escape(s))
+}
+
+// TextCenter menulis teks rata tengah berdasarkan perkiraan lebar Helvetica
+func (d *Document) TextCenter(y, size float64, bold bool, s string) {
+	x := (d.width - TextWidth(s, size)) / 2
+	if x < 0 {
+		x = 0
+	}
+	d.Text(x, y, size, bold, s)
+}
+
+// Line menggambar garis lurus
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.current(), "%.2f %.2f m %.2f %.2f l S\n",
+		x1, d.height-y1, x2, d.height-y2)
+}
+
+// Rect menggambar kotak terisi hitam (dipakai untuk QR / barcode)
+func (d *Document) Rect(x, y, w, h float64) {
+	fmt.Fprintf(d.current(), "%.2f %.2f %.2f %.2f re f\n",
+		x, d.height-y-h, w, h)
+}
+
+// Bytes menghasilkan file PDF lengkap
+func (d *Document) Bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	// 1: catalog, 2: pages, 3-4: font, lalu pasangan page + content
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			d.width, d.height, 6+i*2))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// TextWidth memperkirakan lebar teks Helvetica (rata-rata 0.5 em per karakter)
+func TextWidth(s string, size float64) float64 {
+	return float64(len(s)) * size * 0.5
+}
+
+// Wrap memecah teks panjang menjadi beberapa baris sesuai lebar maksimum
+func Wrap(s string, size, maxWidth float64) []string {
+	maxChars := int(maxWidth / (size * 0.5))
+	if maxChars < 1 {
+		maxChars = 1
+	}
+
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line == "" {
+				line = word
+			} else if len(line)+1+len(word) <= maxChars {
+				line += " " + word
+			} else {
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
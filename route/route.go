@@ -1,8 +1,11 @@
 package route
 
 import (
+	"time"
+
 	"sikupas/backend/handler"
 	"sikupas/backend/middleware"
+	"sikupas/backend/model"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,8 +16,35 @@ func SetupRoutes(app *fiber.App) {
 	// ─── Auth Routes (public) ──────────────────────────────────────────
 	auth := app.Group("/api/auth")
 	{
-		auth.Post("/register", handler.Register)
+		auth.Post("/setup", handler.SetupAdmin)
 		auth.Post("/login", handler.Login)
+		auth.Get("/loket", handler.GetLoketLogin)
+		auth.Post("/2fa/daftar", middleware.TantanganRequired(), handler.Daftar2FA)
+		auth.Post("/2fa/verifikasi", middleware.TantanganRequired(), handler.Verifikasi2FA)
+	}
+
+	// Public key JWT untuk sistem lain (RFC 7517)
+	app.Get("/.well-known/jwks.json", handler.GetJWKS)
+
+	// ─── Booking Online & Layar Antrian (public, dibatasi per IP) ──────
+	public := app.Group("/api/public", middleware.RateLimit(60, time.Minute))
+	{
+		bookingLimit := middleware.RateLimit(10, 10*time.Minute)
+		public.Get("/booking/slot", handler.GetSlotBooking)                           // Get /api/public/booking/slot
+		public.Post("/booking", bookingLimit, handler.CreateBooking)                  // Post /api/public/booking
+		public.Post("/booking/:kode/cek", bookingLimit, handler.CekBookingPublik)     // Post /api/public/booking/:kode/cek
+		public.Post("/booking/:kode/batal", bookingLimit, handler.BatalBookingPublik) // Post /api/public/booking/:kode/batal
+		public.Get("/display", handler.GetDisplayAntrian)                             // Get /api/public/display
+		public.Get("/display/stream", handler.StreamDisplayAntrian)                   // Get /api/public/display/stream
+		public.Get("/display/klip", handler.GetKlipPengumuman)                        // Get /api/public/display/klip
+	}
+
+	// ─── Kiosk Lobi (API key perangkat) ────────────────────────────────
+	kiosk := app.Group("/api/kiosk", middleware.KioskRequired(model.IzinKioskBuatAntrian))
+	{
+		kiosk.Get("/poli", handler.GetPoliKiosk)                      // Get /api/kiosk/poli
+		kiosk.Post("/antrian", handler.CreateAntrianKiosk)            // Post /api/kiosk/antrian
+		kiosk.Get("/antrian/:id/tiket", handler.GetTiketAntrianKiosk) // Get /api/kiosk/antrian/:id/tiket
 	}
 
 	// ─── Protected Routes ──────────────────────────────────────────────
@@ -22,49 +52,266 @@ func SetupRoutes(app *fiber.App) {
 
 	// Endpoint untuk mendapatkan info user login
 	api.Get("/me", handler.GetCurrentUser)
+	api.Put("/me/loket", handler.PilihLoket)
+	api.Post("/me/akses-darurat", handler.MulaiAksesDarurat)
+	api.Delete("/me/akses-darurat", handler.AkhiriAksesDarurat)
+	api.Post("/me/2fa/daftar", handler.Daftar2FA)
+	api.Post("/me/2fa/aktifkan", handler.Aktifkan2FA)
+	api.Delete("/me/2fa", handler.Nonaktifkan2FA)
+	api.Post("/me/2fa/kode-pemulihan", handler.BuatUlangKodePemulihan)
+
+	// ─── Pasien ────────────────────────────────────────────────────────
+	pasien := api.Group("/pasien", middleware.PermissionRequired(model.IzinPasienRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinPasienWrite)
+		pasien.Get("/", handler.GetAllPasien)                 // Get /api/pasien
+		pasien.Get("/:nik", handler.GetPasienByNIK)           // Get /api/pasien/:nik
+		pasien.Get("/:nik/riwayat", handler.GetRiwayatPasien) // Get /api/pasien/:nik/riwayat
+		pasien.Post("/", ubah, handler.CreatePasien)          // Post /api/pasien
+		pasien.Put("/:nik", ubah, handler.UpdatePasien)       // Put /api/pasien/:nik
+		pasien.Delete("/:nik", ubah, handler.DeletePasien)    // Delete /api/pasien/:nik
+	}
+
+	// ─── Antrian ───────────────────────────────────────────────────────
+	antrian := api.Group("/antrian", middleware.PermissionRequired(model.IzinAntrianRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinAntrianWrite)
+		antrian.Get("/", handler.GetAllAntrian)                             // Get /api/antrian
+		antrian.Get("/dashboard", handler.GetDashboardSummary)              // Get /api/antrian/dashboard
+		antrian.Get("/boxes", handler.GetAntrianBoxes)                      // Get /api/antrian/boxes
+		antrian.Get("/:id", handler.GetAntrianByID)                         // Get /api/antrian/:id
+		antrian.Get("/:id/tiket", handler.GetTiketAntrian)                  // Get /api/antrian/:id/tiket
+		antrian.Post("/", ubah, handler.CreateAntrian)                      // Post /api/antrian
+		antrian.Post("/panggil", ubah, handler.PanggilAntrianBerikutnya)    // Post /api/antrian/panggil
+		antrian.Post("/:id/panggil", ubah, handler.PanggilAntrian)          // Post /api/antrian/:id/panggil
+		antrian.Put("/:id", ubah, handler.UpdateAntrianStatus)              // Put /api/antrian/:id
+		antrian.Put("/:id/prioritas", ubah, handler.UpdatePrioritasAntrian) // Put /api/antrian/:id/prioritas
+		antrian.Delete("/:id", ubah, handler.DeleteAntrian)                 // Delete /api/antrian/:id
+	}
+
+	// ─── Kunjungan Bertahap ────────────────────────────────────────────
+	kunjungan := api.Group("/kunjungan", middleware.PermissionRequired(model.IzinKunjunganRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinKunjunganWrite)
+		kunjungan.Get("/", handler.GetAllKunjungan)                      // Get /api/kunjungan
+		kunjungan.Get("/:id", handler.GetKunjunganByID)                  // Get /api/kunjungan/:id
+		kunjungan.Post("/", ubah, handler.CreateKunjungan)               // Post /api/kunjungan
+		kunjungan.Post("/panggil", ubah, handler.PanggilTahapBerikutnya) // Post /api/kunjungan/panggil
+		kunjungan.Post("/:id/lanjut", ubah, handler.LanjutKunjungan)     // Post /api/kunjungan/:id/lanjut
+		kunjungan.Post("/:id/batal", ubah, handler.BatalKunjungan)       // Post /api/kunjungan/:id/batal
+	}
+	tahapLayanan := api.Group("/tahap-layanan", middleware.PermissionRequired(model.IzinMasterRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinMasterWrite)
+		tahapLayanan.Get("/", handler.GetAllTahapLayanan)          // Get /api/tahap-layanan
+		tahapLayanan.Post("/", ubah, handler.CreateTahapLayanan)   // Post /api/tahap-layanan
+		tahapLayanan.Put("/:id", ubah, handler.UpdateTahapLayanan) // Put /api/tahap-layanan/:id
+	}
 
-	// ─── Pasien CRUD (Admin only) ──────────────────────────────────────
-	pasien := api.Group("/pasien", middleware.RoleRequired("admin"))
+	// ─── Booking Online ────────────────────────────────────────────────
+	booking := api.Group("/booking", middleware.PermissionRequired(model.IzinBookingRead))
 	{
-		pasien.Get("/", handler.GetAllPasien)        // Get /api/pasien
-		pasien.Get("/:nik", handler.GetPasienByNIK)  // Get /api/pasien/:nik
-		pasien.Post("/", handler.CreatePasien)       // Post /api/pasien
-		pasien.Put("/:nik", handler.UpdatePasien)    // Put /api/pasien/:nik
-		pasien.Delete("/:nik", handler.DeletePasien) // Delete /api/pasien/:nik
+		ubah := middleware.PermissionRequired(model.IzinBookingWrite)
+		booking.Get("/", handler.GetAllBooking)                      // Get /api/booking
+		booking.Get("/:kode", handler.GetBookingByKode)              // Get /api/booking/:kode
+		booking.Post("/:kode/checkin", ubah, handler.CheckinBooking) // Post /api/booking/:kode/checkin
+	}
+
+	// ─── Perangkat Kiosk & Audit Log ───────────────────────────────────
+	perangkatKiosk := api.Group("/perangkat-kiosk", middleware.PermissionRequired(model.IzinKioskKelola))
+	{
+		perangkatKiosk.Get("/", handler.GetAllKiosk)                 // Get /api/perangkat-kiosk
+		perangkatKiosk.Post("/", handler.CreateKiosk)                // Post /api/perangkat-kiosk
+		perangkatKiosk.Put("/:id", handler.UpdateKiosk)              // Put /api/perangkat-kiosk/:id
+		perangkatKiosk.Post("/:id/reset-key", handler.ResetKeyKiosk) // Post /api/perangkat-kiosk/:id/reset-key
+	}
+	api.Get("/audit-log", middleware.PermissionRequired(model.IzinAuditRead), handler.GetAllAuditLog)             // Get /api/audit-log
+	api.Get("/akses-darurat", middleware.PermissionRequired(model.IzinAuditRead), handler.GetAllAksesDarurat)     // Get /api/akses-darurat
+	api.Get("/login-percobaan", middleware.PermissionRequired(model.IzinAuditRead), handler.GetAllLoginPercobaan) // Get /api/login-percobaan
+
+	// ─── User & Role ───────────────────────────────────────────────────
+	users := api.Group("/users", middleware.PermissionRequired(model.IzinUserKelola))
+	{
+		users.Get("/", handler.GetAllUser)                   // Get /api/users
+		users.Post("/", handler.Register)                    // Post /api/users
+		users.Put("/:id/role", handler.UpdateRoleUser)       // Put /api/users/:id/role
+		users.Put("/:id/poli", handler.UpdatePoliUser)       // Put /api/users/:id/poli
+		users.Post("/:id/buka-kunci", handler.BukaKunciUser) // Post /api/users/:id/buka-kunci
+		users.Post("/:id/reset-2fa", handler.Reset2FAUser)   // Post /api/users/:id/reset-2fa
+	}
+	loginKunci := api.Group("/login-kunci", middleware.PermissionRequired(model.IzinUserKelola))
+	{
+		loginKunci.Get("/", handler.GetAllLoginKunci)                // Get /api/login-kunci
+		loginKunci.Delete("/:jenis/:nilai", handler.HapusKunciLogin) // Delete /api/login-kunci/:jenis/:nilai
+	}
+	role := api.Group("/role", middleware.PermissionRequired(model.IzinUserKelola))
+	{
+		role.Get("/", handler.GetAllRole)         // Get /api/role
+		role.Get("/izin", handler.GetAllIzin)     // Get /api/role/izin
+		role.Post("/", handler.CreateRole)        // Post /api/role
+		role.Put("/:kode", handler.UpdateRole)    // Put /api/role/:kode
+		role.Delete("/:kode", handler.DeleteRole) // Delete /api/role/:kode
+	}
+
+	// ─── Poli ──────────────────────────────────────────────────────────
+	poli := api.Group("/poli", middleware.PermissionRequired(model.IzinMasterRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinMasterWrite)
+		poli.Get("/", handler.GetAllPoli)                                                         // Get /api/poli
+		poli.Put("/urutan", ubah, handler.ReorderPoli)                                            // Put /api/poli/urutan
+		poli.Get("/:id", handler.GetPoliByID)                                                     // Get /api/poli/:id
+		poli.Post("/", ubah, handler.CreatePoli)                                                  // Post /api/poli
+		poli.Put("/:id", ubah, handler.UpdatePoli)                                                // Put /api/poli/:id
+		poli.Put("/:id/status", ubah, handler.UpdatePoliStatus)                                   // Put /api/poli/:id/status
+		poli.Get("/:id/kapasitas", handler.GetKapasitasPoli)                                      // Get /api/poli/:id/kapasitas
+		poli.Put("/:id/kapasitas/mingguan", ubah, handler.UpdateKapasitasMingguan)                // Put /api/poli/:id/kapasitas/mingguan
+		poli.Post("/:id/kapasitas/tanggal", ubah, handler.SetKapasitasTanggal)                    // Post /api/poli/:id/kapasitas/tanggal
+		poli.Delete("/:id/kapasitas/tanggal/:id_kapasitas", ubah, handler.DeleteKapasitasTanggal) // Delete /api/poli/:id/kapasitas/tanggal/:id_kapasitas
+	}
+
+	// ─── Loket Pendaftaran ─────────────────────────────────────────────
+	loket := api.Group("/loket", middleware.PermissionRequired(model.IzinMasterRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinMasterWrite)
+		loket.Get("/", handler.GetAllLoket)          // Get /api/loket
+		loket.Post("/", ubah, handler.CreateLoket)   // Post /api/loket
+		loket.Put("/:id", ubah, handler.UpdateLoket) // Put /api/loket/:id
+	}
+
+	// ─── Hari Libur ────────────────────────────────────────────────────
+	hariLibur := api.Group("/hari-libur", middleware.PermissionRequired(model.IzinMasterRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinMasterWrite)
+		hariLibur.Get("/", handler.GetAllHariLibur)             // Get /api/hari-libur
+		hariLibur.Post("/", ubah, handler.CreateHariLibur)      // Post /api/hari-libur
+		hariLibur.Delete("/:id", ubah, handler.DeleteHariLibur) // Delete /api/hari-libur/:id
+	}
+
+	// ─── Dokter & Jadwal Praktik ───────────────────────────────────────
+	dokter := api.Group("/dokter", middleware.PermissionRequired(model.IzinMasterRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinMasterWrite)
+		dokter.Get("/", handler.GetAllDokter)                                     // Get /api/dokter
+		dokter.Get("/bertugas", handler.GetDokterBertugas)                        // Get /api/dokter/bertugas
+		dokter.Get("/:id", handler.GetDokterByID)                                 // Get /api/dokter/:id
+		dokter.Post("/", ubah, handler.CreateDokter)                              // Post /api/dokter
+		dokter.Put("/:id", ubah, handler.UpdateDokter)                            // Put /api/dokter/:id
+		dokter.Delete("/:id", ubah, handler.DeleteDokter)                         // Delete /api/dokter/:id
+		dokter.Post("/:id/jadwal", ubah, handler.CreateJadwalDokter)              // Post /api/dokter/:id/jadwal
+		dokter.Delete("/:id/jadwal/:id_jadwal", ubah, handler.DeleteJadwalDokter) // Delete /api/dokter/:id/jadwal/:id_jadwal
+		dokter.Post("/:id/cuti", ubah, handler.CreateCutiDokter)                  // Post /api/dokter/:id/cuti
+		dokter.Delete("/:id/cuti/:id_cuti", ubah, handler.DeleteCutiDokter)       // Delete /api/dokter/:id/cuti/:id_cuti
+	}
+
+	// ─── Pemeriksaan ───────────────────────────────────────────────────
+	pemeriksaan := api.Group("/pemeriksaan", middleware.PermissionRequired(model.IzinPemeriksaanRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinPemeriksaanWrite)
+		pemeriksaan.Get("/", handler.GetAllPemeriksaan)             // Get /api/pemeriksaan
+		pemeriksaan.Get("/:id", handler.GetPemeriksaanByID)         // Get /api/pemeriksaan/:id
+		pemeriksaan.Post("/", ubah, handler.CreatePemeriksaan)      // Post /api/pemeriksaan
+		pemeriksaan.Put("/:id", ubah, handler.UpdatePemeriksaan)    // Put /api/pemeriksaan/:id
+		pemeriksaan.Delete("/:id", ubah, handler.DeletePemeriksaan) // Delete /api/pemeriksaan/:id
+
+		lihatBPJS := middleware.PermissionRequired(model.IzinIntegrasiRead)
+		kirimBPJS := middleware.PermissionRequired(model.IzinIntegrasiWrite)
+		pemeriksaan.Get("/:id/bpjs", lihatBPJS, handler.GetSinkronBPJS)            // Get /api/pemeriksaan/:id/bpjs
+		pemeriksaan.Post("/:id/bpjs/kirim", kirimBPJS, handler.KirimKunjunganBPJS) // Post /api/pemeriksaan/:id/bpjs/kirim
+		pemeriksaan.Get("/:id/tanda-vital", handler.GetTandaVital)                 // Get /api/pemeriksaan/:id/tanda-vital
+		pemeriksaan.Put("/:id/tanda-vital", ubah, handler.SimpanTandaVital)        // Put /api/pemeriksaan/:id/tanda-vital
+	}
+
+	// ─── Bridging BPJS ─────────────────────────────────────────────────
+	bpjs := api.Group("/bpjs", middleware.PermissionRequired(model.IzinIntegrasiRead))
+	{
+		bpjs.Get("/peserta/:no_kartu", handler.CekPesertaBPJS) // Get /api/bpjs/peserta/:no_kartu
+		bpjs.Get("/sinkron", handler.GetAllSinkronBPJS)        // Get /api/bpjs/sinkron
+	}
+
+	// ─── Sinkronisasi SATUSEHAT ────────────────────────────────────────
+	satusehat := api.Group("/satusehat", middleware.PermissionRequired(model.IzinIntegrasiRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinIntegrasiWrite)
+		satusehat.Get("/sinkron", handler.GetAllSinkronSatusehat)                                 // Get /api/satusehat/sinkron
+		satusehat.Post("/sinkron/kirim-ulang-gagal", ubah, handler.KirimUlangSemuaGagalSatusehat) // Post /api/satusehat/sinkron/kirim-ulang-gagal
+		satusehat.Get("/sinkron/:id", handler.GetSinkronSatusehatByID)                            // Get /api/satusehat/sinkron/:id
+		satusehat.Post("/sinkron/:id/kirim-ulang", ubah, handler.KirimUlangSatusehat)             // Post /api/satusehat/sinkron/:id/kirim-ulang
+	}
+
+	// ─── Rujukan ───────────────────────────────────────────────────────
+	rujukan := api.Group("/rujukan", middleware.PermissionRequired(model.IzinRujukanRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinRujukanWrite)
+		rujukan.Get("/", handler.GetAllRujukan)             // Get /api/rujukan
+		rujukan.Get("/:id", handler.GetRujukanByID)         // Get /api/rujukan/:id
+		rujukan.Post("/", ubah, handler.CreateRujukan)      // Post /api/rujukan
+		rujukan.Put("/:id", ubah, handler.UpdateRujukan)    // Put /api/rujukan/:id
+		rujukan.Delete("/:id", ubah, handler.DeleteRujukan) // Delete /api/rujukan/:id
+
+		ekspor := middleware.PermissionRequired(model.IzinLaporanExport)
+		rujukan.Get("/:id/pdf", ekspor, handler.GetRujukanPDF) // Get /api/rujukan/:id/pdf
+	}
+
+	// ─── Tarif ─────────────────────────────────────────────────────────
+	tarif := api.Group("/tarif", middleware.PermissionRequired(model.IzinTarifRead))
+	{
+		ubah := middleware.PermissionRequired(model.IzinTarifWrite)
+		tarif.Get("/", handler.GetAllTarif)          // Get /api/tarif
+		tarif.Get("/:id", handler.GetTarifByID)      // Get /api/tarif/:id
+		tarif.Post("/", ubah, handler.CreateTarif)   // Post /api/tarif
+		tarif.Put("/:id", ubah, handler.UpdateTarif) // Put /api/tarif/:id
+
+		tarif.Get("/:id/harga", handler.GetRiwayatHargaTarif)                // Get /api/tarif/:id/harga
+		tarif.Post("/:id/harga", ubah, handler.CreateHargaTarif)             // Post /api/tarif/:id/harga
+		tarif.Delete("/:id/harga/:id_harga", ubah, handler.DeleteHargaTarif) // Delete /api/tarif/:id/harga/:id_harga
 	}
 
-	// ─── Antrian CRUD (Admin only) ─────────────────────────────────────
-	antrian := api.Group("/antrian", middleware.RoleRequired("admin"))
+	// ─── Penjamin ──────────────────────────────────────────────────────
+	penjamin := api.Group("/penjamin", middleware.PermissionRequired(model.IzinTarifRead))
 	{
-		antrian.Get("/", handler.GetAllAntrian)                // Get /api/antrian
-		antrian.Get("/dashboard", handler.GetDashboardSummary) // Get /api/antrian/dashboard
-		antrian.Get("/boxes", handler.GetAntrianBoxes)         // Get /api/antrian/boxes
-		antrian.Get("/:id", handler.GetAntrianByID)            // Get /api/antrian/:id
-		antrian.Post("/", handler.CreateAntrian)               // Post /api/antrian
-		antrian.Put("/:id", handler.UpdateAntrianStatus)       // Put /api/antrian/:id
-		antrian.Delete("/:id", handler.DeleteAntrian)          // Delete /api/antrian/:id
+		ubah := middleware.PermissionRequired(model.IzinTarifWrite)
+		penjamin.Get("/", handler.GetAllPenjamin)            // Get /api/penjamin
+		penjamin.Get("/:kode", handler.GetPenjaminByKode)    // Get /api/penjamin/:kode
+		penjamin.Post("/", ubah, handler.CreatePenjamin)     // Post /api/penjamin
+		penjamin.Put("/:kode", ubah, handler.UpdatePenjamin) // Put /api/penjamin/:kode
 	}
 
-	// ─── Poli (Admin only) ─────────────────────────────────────────────
-	poli := api.Group("/poli", middleware.RoleRequired("admin"))
+	// ─── Aturan Harga per Penjamin ─────────────────────────────────────
+	aturanHarga := api.Group("/aturan-harga", middleware.PermissionRequired(model.IzinTarifRead))
 	{
-		poli.Get("/", handler.GetAllPoli) // Get /api/poli
+		ubah := middleware.PermissionRequired(model.IzinTarifWrite)
+		aturanHarga.Get("/", handler.GetAllAturanHarga)             // Get /api/aturan-harga
+		aturanHarga.Get("/:id", handler.GetAturanHargaByID)         // Get /api/aturan-harga/:id
+		aturanHarga.Post("/", ubah, handler.CreateAturanHarga)      // Post /api/aturan-harga
+		aturanHarga.Put("/:id", ubah, handler.UpdateAturanHarga)    // Put /api/aturan-harga/:id
+		aturanHarga.Delete("/:id", ubah, handler.DeleteAturanHarga) // Delete /api/aturan-harga/:id
 	}
 
-	// ─── Pemeriksaan CRUD (Admin only) ─────────────────────────────────
-	pemeriksaan := api.Group("/pemeriksaan", middleware.RoleRequired("admin"))
+	// ─── Kasir ─────────────────────────────────────────────────────────
+	kasir := api.Group("/kasir", middleware.PermissionRequired(model.IzinKasirRead))
 	{
-		pemeriksaan.Get("/", handler.GetAllPemeriksaan)       // Get /api/pemeriksaan
-		pemeriksaan.Get("/:id", handler.GetPemeriksaanByID)   // Get /api/pemeriksaan/:id
-		pemeriksaan.Post("/", handler.CreatePemeriksaan)      // Post /api/pemeriksaan
-		pemeriksaan.Put("/:id", handler.UpdatePemeriksaan)    // Put /api/pemeriksaan/:id
-		pemeriksaan.Delete("/:id", handler.DeletePemeriksaan) // Delete /api/pemeriksaan/:id
+		ubah := middleware.PermissionRequired(model.IzinKasirWrite)
+		kasir.Get("/tagihan", handler.GetAllTagihan)                                // Get /api/kasir/tagihan
+		kasir.Get("/tagihan/:id", handler.GetTagihanByID)                           // Get /api/kasir/tagihan/:id
+		kasir.Get("/tagihan/:id/kwitansi", handler.GetKwitansi)                     // Get /api/kasir/tagihan/:id/kwitansi
+		kasir.Post("/tagihan", ubah, handler.CreateTagihan)                         // Post /api/kasir/tagihan
+		kasir.Post("/tagihan/:id/item", ubah, handler.AddTagihanItem)               // Post /api/kasir/tagihan/:id/item
+		kasir.Delete("/tagihan/:id/item/:id_item", ubah, handler.DeleteTagihanItem) // Delete /api/kasir/tagihan/:id/item/:id_item
+		kasir.Post("/tagihan/:id/bayar", ubah, handler.BayarTagihan)                // Post /api/kasir/tagihan/:id/bayar
+		kasir.Post("/tagihan/:id/batal", ubah, handler.BatalTagihan)                // Post /api/kasir/tagihan/:id/batal
+		kasir.Get("/tutup-kas", handler.GetTutupKas)                                // Get /api/kasir/tutup-kas
 	}
 
-	// ─── Laporan (Admin + Kepala Puskesmas) ────────────────────────────
-	laporan := api.Group("/laporan", middleware.RoleRequired("admin", "kepala_puskesmas"))
+	// ─── Laporan ───────────────────────────────────────────────────────
+	laporan := api.Group("/laporan", middleware.PermissionRequired(model.IzinLaporanRead, model.IzinLaporanExport))
 	{
 		laporan.Get("/pasien", handler.GetReportPasien)           // Get /api/laporan/pasien
 		laporan.Get("/pemeriksaan", handler.GetReportPemeriksaan) // Get /api/laporan/pemeriksaan
+		laporan.Get("/rujukan", handler.GetReportRujukan)         // Get /api/laporan/rujukan
+		laporan.Get("/tutup-kas", handler.GetTutupKas)            // Get /api/laporan/tutup-kas
+		laporan.Get("/pendapatan", handler.GetReportPendapatan)   // Get /api/laporan/pendapatan
+		laporan.Get("/antrian", handler.GetReportAntrian)         // Get /api/laporan/antrian
+		laporan.Get("/loket", handler.GetReportLoket)             // Get /api/laporan/loket
+		laporan.Get("/kunjungan", handler.GetReportKunjungan)     // Get /api/laporan/kunjungan
 	}
 }
@@ -0,0 +1,181 @@
+package satusehat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ─── SATUSEHAT FHIR Client ───────────────────────────────────────────────────
+// Token diambil dengan OAuth2 client credentials lalu disimpan sampai hampir
+// kedaluwarsa. Respons 401 memicu pengambilan token ulang satu kali.
+
+type Client struct {
+	AuthURL      string // contoh: https://api-satusehat-stg.dto.kemkes.go.id/oauth2/v1
+	BaseURL      string // contoh: https://api-satusehat-stg.dto.kemkes.go.id/fhir-r4/v1
+	ClientID     string
+	ClientSecret string
+	OrgID        string
+	HTTP         *http.Client
+
+	mu          sync.Mutex
+	token       string
+	kedaluwarsa time.Time
+}
+
+func NewClient(authURL, baseURL, clientID, clientSecret, orgID string) *Client {
+	return &Client{
+		AuthURL:      strings.TrimRight(authURL, "/"),
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		OrgID:        orgID,
+		HTTP:         &http.Client{Timeout: 20 * time.Second},
+	}
+}
+
+// Error dari SATUSEHAT (HTTP bukan 2xx), Pesan diambil dari OperationOutcome
+type Error struct {
+	StatusCode int
+	Pesan      string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("SATUSEHAT HTTP %d: %s", e.StatusCode, e.Pesan)
+}
+
+func (c *Client) accessToken(ctx context.Context, paksa bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !paksa && c.token != "" && time.Now().Before(c.kedaluwarsa) {
+		return c.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		c.AuthURL+"/accesstoken?grant_type=client_credentials", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gagal menghubungi server otorisasi SATUSEHAT: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", &Error{StatusCode: resp.StatusCode, Pesan: "gagal mengambil access token"}
+	}
+
+	// expires_in dikirim sebagai string oleh SATUSEHAT, angka oleh server lain
+	var r struct {
+		AccessToken string      `json:"access_token"`
+		ExpiresIn   json.Number `json:"expires_in"`
+	}
+	if err := json.Unmarshal(raw, &r); err != nil || r.AccessToken == "" {
+		return "", fmt.Errorf("respons token SATUSEHAT tidak valid")
+	}
+	detik, _ := strconv.Atoi(r.ExpiresIn.String())
+	if detik <= 0 {
+		detik = 3600
+	}
+
+	c.token = r.AccessToken
+	// Diperbarui satu menit sebelum benar-benar kedaluwarsa
+	c.kedaluwarsa = time.Now().Add(time.Duration(detik)*time.Second - time.Minute)
+	return c.token, nil
+}
+
+// Kirim membuat resource baru (POST) atau memperbarui resource yang sudah
+// punya id (PUT), mengembalikan id resource di SATUSEHAT
+func (c *Client) Kirim(ctx context.Context, r Resource) (string, error) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+
+	method, path := http.MethodPost, "/"+r.ResourceType
+	if r.ID != "" {
+		method, path = http.MethodPut, "/"+r.ResourceType+"/"+r.ID
+	}
+
+	for percobaan := 0; ; percobaan++ {
+		token, err := c.accessToken(ctx, percobaan > 0)
+		if err != nil {
+			return "", err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/fhir+json")
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("gagal menghubungi SATUSEHAT: %w", err)
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusUnauthorized && percobaan == 0 {
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return "", &Error{StatusCode: resp.StatusCode, Pesan: pesanOutcome(raw)}
+		}
+
+		var hasil struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &hasil); err != nil || hasil.ID == "" {
+			return "", fmt.Errorf("respons SATUSEHAT tanpa id resource")
+		}
+		return hasil.ID, nil
+	}
+}
+
+// pesanOutcome mengambil pesan dari OperationOutcome, atau isi mentah respons
+func pesanOutcome(raw []byte) string {
+	var oo struct {
+		Issue []struct {
+			Diagnostics string `json:"diagnostics"`
+			Details     struct {
+				Text string `json:"text"`
+			} `json:"details"`
+		} `json:"issue"`
+	}
+	if json.Unmarshal(raw, &oo) == nil {
+		var pesan []string
+		for _, is := range oo.Issue {
+			if is.Details.Text != "" {
+				pesan = append(pesan, is.Details.Text)
+			} else if is.Diagnostics != "" {
+				pesan = append(pesan, is.Diagnostics)
+			}
+		}
+		if len(pesan) > 0 {
+			return strings.Join(pesan, "; ")
+		}
+	}
+	s := strings.TrimSpace(string(raw))
+	if len(s) > 200 {
+		s = s[:200]
+	}
+	return s
+}
@@ -0,0 +1,145 @@
+package satusehat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// klienTiruan menjalankan MockServer dan mengembalikan client yang mengarah
+// ke sana beserta penghitung request token
+func klienTiruan(t *testing.T) (*Client, *MockServer, *int32) {
+	t.Helper()
+	mock := NewMockServer("klien", "rahasia")
+
+	var jumlahToken int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/accesstoken") {
+			atomic.AddInt32(&jumlahToken, 1)
+		}
+		mock.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient(srv.URL+"/oauth2/v1", srv.URL+"/fhir-r4/v1", "klien", "rahasia", "org-1")
+	return c, mock, &jumlahToken
+}
+
+func contohPasien() Resource {
+	return PatientDari(Pasien{
+		NIK: "3273010101900001", Nama: "Budi Santoso", TanggalLahir: "1990-01-01",
+		JenisKelamin: "Laki-Laki", Alamat: "Jl. Merdeka 1",
+	})
+}
+
+func TestTokenDisimpan(t *testing.T) {
+	c, mock, jumlahToken := klienTiruan(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.Kirim(ctx, contohPasien()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(jumlahToken); n != 1 {
+		t.Errorf("token diambil %d kali, want 1", n)
+	}
+	if n := mock.Jumlah("Patient"); n != 3 {
+		t.Errorf("Patient tersimpan %d, want 3", n)
+	}
+}
+
+// Token yang ditolak server (401) diambil ulang satu kali lalu request diulang
+func TestTokenDiambilUlangSetelah401(t *testing.T) {
+	c, mock, jumlahToken := klienTiruan(t)
+	ctx := context.Background()
+
+	if _, err := c.Kirim(ctx, contohPasien()); err != nil {
+		t.Fatal(err)
+	}
+	mock.CabutToken()
+
+	id, err := c.Kirim(ctx, contohPasien())
+	if err != nil {
+		t.Fatalf("kirim setelah token dicabut: %v", err)
+	}
+	if id == "" {
+		t.Error("id resource kosong")
+	}
+	if n := atomic.LoadInt32(jumlahToken); n != 2 {
+		t.Errorf("token diambil %d kali, want 2", n)
+	}
+	if n := mock.Jumlah("Patient"); n != 2 {
+		t.Errorf("Patient tersimpan %d, want 2", n)
+	}
+}
+
+func TestCredentialSalah(t *testing.T) {
+	c, mock, _ := klienTiruan(t)
+	c.ClientSecret = "salah"
+
+	_, err := c.Kirim(context.Background(), contohPasien())
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want *Error 401", err)
+	}
+	if n := mock.Jumlah("Patient"); n != 0 {
+		t.Errorf("Patient tersimpan %d, want 0", n)
+	}
+}
+
+func TestKirimPutPakaiID(t *testing.T) {
+	c, mock, _ := klienTiruan(t)
+	ctx := context.Background()
+
+	id, err := c.Kirim(ctx, contohPasien())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := contohPasien()
+	res.ID = id
+	idUlang, err := c.Kirim(ctx, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idUlang != id {
+		t.Errorf("PUT mengembalikan id %q, want %q", idUlang, id)
+	}
+	if n := mock.Jumlah("Patient"); n != 1 {
+		t.Errorf("Patient tersimpan %d, want 1", n)
+	}
+}
+
+// Pesan OperationOutcome dari server diteruskan di Error.Pesan
+func TestRujukanDanOperationOutcome(t *testing.T) {
+	c, _, _ := klienTiruan(t)
+	ctx := context.Background()
+
+	_, err := c.Kirim(ctx, EncounterDari(Kunjungan{IDPemeriksaan: 1, Tanggal: "2026-10-19", IDPasienSS: "tidak-ada"}, c.OrgID))
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusBadRequest ||
+		e.Pesan != "Subject harus merujuk Patient yang terdaftar" {
+		t.Fatalf("err = %v", err)
+	}
+
+	idPasien, err := c.Kirim(ctx, contohPasien())
+	if err != nil {
+		t.Fatal(err)
+	}
+	idKunjungan, err := c.Kirim(ctx, EncounterDari(Kunjungan{IDPemeriksaan: 1, Tanggal: "2026-10-19", IDPasienSS: idPasien}, c.OrgID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Kirim(ctx, ConditionDari("J06.9", "2026-10-19", idPasien, idKunjungan)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.Kirim(ctx, ConditionDari("X00", "2026-10-19", idPasien, idKunjungan))
+	if !errors.As(err, &e) || e.Pesan != "Kode ICD-10 X00 tidak valid" {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package satusehat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ─── Resource FHIR R4 ────────────────────────────────────────────────────────
+// Hanya field yang dipakai SATUSEHAT untuk pelayanan rawat jalan puskesmas.
+
+const (
+	SystemNIK   = "https://fhir.kemkes.go.id/id/nik"
+	SystemICD10 = "http://hl7.org/fhir/sid/icd-10"
+	SystemLOINC = "http://loinc.org"
+	SystemUCUM  = "http://unitsofmeasure.org"
+)
+
+type Resource struct {
+	ResourceType string `json:"resourceType"`
+	ID           string `json:"id,omitempty"`
+
+	// Patient
+	Identifier []Identifier `json:"identifier,omitempty"`
+	Active     *bool        `json:"active,omitempty"`
+	Name       []HumanName  `json:"name,omitempty"`
+	Gender     string       `json:"gender,omitempty"`
+	BirthDate  string       `json:"birthDate,omitempty"`
+	Address    []Address    `json:"address,omitempty"`
+
+	// Encounter / Condition / Observation
+	Status          string            `json:"status,omitempty"`
+	Class           *Coding           `json:"class,omitempty"`
+	Category        []CodeableConcept `json:"category,omitempty"`
+	Code            *CodeableConcept  `json:"code,omitempty"`
+	ClinicalStatus  *CodeableConcept  `json:"clinicalStatus,omitempty"`
+	Subject         *Reference        `json:"subject,omitempty"`
+	Encounter       *Reference        `json:"encounter,omitempty"`
+	Participant     []Participant     `json:"participant,omitempty"`
+	Period          *Period           `json:"period,omitempty"`
+	Location        []LocationRef     `json:"location,omitempty"`
+	ReasonCode      []CodeableConcept `json:"reasonCode,omitempty"`
+	ServiceProvider *Reference        `json:"serviceProvider,omitempty"`
+	Performer       []Reference       `json:"performer,omitempty"`
+	Effective       string            `json:"effectiveDateTime,omitempty"`
+	ValueQuantity   *Quantity         `json:"valueQuantity,omitempty"`
+	RecordedDate    string            `json:"recordedDate,omitempty"`
+}
+
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system"`
+	Value  string `json:"value"`
+}
+
+type HumanName struct {
+	Use  string `json:"use,omitempty"`
+	Text string `json:"text"`
+}
+
+type Address struct {
+	Use  string `json:"use,omitempty"`
+	Text string `json:"text"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Participant struct {
+	Individual Reference `json:"individual"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type LocationRef struct {
+	Location Reference `json:"location"`
+}
+
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+	System string  `json:"system"`
+	Code   string  `json:"code"`
+}
+
+// ─── Data Lokal ──────────────────────────────────────────────────────────────
+
+type Pasien struct {
+	NIK          string
+	Nama         string
+	TanggalLahir string // YYYY-MM-DD
+	JenisKelamin string // Laki-Laki / Perempuan
+	Alamat       string
+}
+
+type Kunjungan struct {
+	IDPemeriksaan int
+	Tanggal       string // YYYY-MM-DD
+	Keluhan       string
+	NamaPoli      string
+	NamaDokter    string
+	IDPasienSS    string // id Patient di SATUSEHAT
+}
+
+// TandaVital satu hasil pengukuran, Jenis sesuai kunci di JenisTandaVital
+type TandaVital struct {
+	Jenis       string
+	Nilai       float64
+	Tanggal     string // YYYY-MM-DD
+	IDPasienSS  string
+	IDKunjungan string // id Encounter di SATUSEHAT
+}
+
+type jenisVital struct {
+	Kode    string // LOINC
+	Display string
+	Unit    string
+	UCUM    string
+}
+
+// JenisTandaVital memetakan kolom tanda_vital ke kode LOINC dan satuan UCUM
+var JenisTandaVital = map[string]jenisVital{
+	"sistole":      {"8480-6", "Systolic blood pressure", "mm[Hg]", "mm[Hg]"},
+	"diastole":     {"8462-4", "Diastolic blood pressure", "mm[Hg]", "mm[Hg]"},
+	"nadi":         {"8867-4", "Heart rate", "beats/minute", "/min"},
+	"pernapasan":   {"9279-1", "Respiratory rate", "breaths/minute", "/min"},
+	"suhu":         {"8310-5", "Body temperature", "C", "Cel"},
+	"berat_badan":  {"29463-7", "Body weight", "kg", "kg"},
+	"tinggi_badan": {"8302-2", "Body height", "cm", "cm"},
+}
+
+// ─── Mapper ──────────────────────────────────────────────────────────────────
+
+func PatientDari(p Pasien) Resource {
+	gender := "unknown"
+	switch p.JenisKelamin {
+	case "Laki-Laki":
+		gender = "male"
+	case "Perempuan":
+		gender = "female"
+	}
+	aktif := true
+
+	r := Resource{
+		ResourceType: "Patient",
+		Identifier:   []Identifier{{Use: "official", System: SystemNIK, Value: p.NIK}},
+		Active:       &aktif,
+		Name:         []HumanName{{Use: "official", Text: p.Nama}},
+		Gender:       gender,
+		BirthDate:    p.TanggalLahir,
+	}
+	if alamat := strings.TrimSpace(p.Alamat); alamat != "" {
+		r.Address = []Address{{Use: "home", Text: alamat}}
+	}
+	return r
+}
+
+// EncounterDari memetakan pemeriksaan rawat jalan (class AMB) yang sudah selesai
+func EncounterDari(k Kunjungan, orgID string) Resource {
+	r := Resource{
+		ResourceType: "Encounter",
+		Identifier: []Identifier{{
+			System: "http://sys-ids.kemkes.go.id/encounter/" + orgID,
+			Value:  fmt.Sprint(k.IDPemeriksaan),
+		}},
+		Status:          "finished",
+		Class:           &Coding{System: "http://terminology.hl7.org/CodeSystem/v3-ActCode", Code: "AMB", Display: "ambulatory"},
+		Subject:         &Reference{Reference: "Patient/" + k.IDPasienSS},
+		Period:          &Period{Start: k.Tanggal, End: k.Tanggal},
+		Location:        []LocationRef{{Location: Reference{Display: k.NamaPoli}}},
+		ServiceProvider: &Reference{Reference: "Organization/" + orgID},
+	}
+	if k.NamaDokter != "" {
+		r.Participant = []Participant{{Individual: Reference{Display: k.NamaDokter}}}
+	}
+	if k.Keluhan != "" {
+		r.ReasonCode = []CodeableConcept{{Text: k.Keluhan}}
+	}
+	return r
+}
+
+// ConditionDari memetakan kode diagnosa ICD-10 pada pemeriksaan
+func ConditionDari(kodeDiagnosa, tanggal, idPasienSS, idKunjunganSS string) Resource {
+	return Resource{
+		ResourceType: "Condition",
+		ClinicalStatus: &CodeableConcept{Coding: []Coding{{
+			System: "http://terminology.hl7.org/CodeSystem/condition-clinical", Code: "active", Display: "Active",
+		}}},
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System: "http://terminology.hl7.org/CodeSystem/condition-category", Code: "encounter-diagnosis", Display: "Encounter Diagnosis",
+		}}}},
+		Code:         &CodeableConcept{Coding: []Coding{{System: SystemICD10, Code: kodeDiagnosa}}},
+		Subject:      &Reference{Reference: "Patient/" + idPasienSS},
+		Encounter:    &Reference{Reference: "Encounter/" + idKunjunganSS},
+		RecordedDate: tanggal,
+	}
+}
+
+// ObservationDari memetakan satu tanda vital; error bila jenis tidak dikenal
+func ObservationDari(v TandaVital) (Resource, error) {
+	j, ok := JenisTandaVital[v.Jenis]
+	if !ok {
+		return Resource{}, fmt.Errorf("jenis tanda vital '%s' tidak dikenal", v.Jenis)
+	}
+	return Resource{
+		ResourceType: "Observation",
+		Status:       "final",
+		Category: []CodeableConcept{{Coding: []Coding{{
+			System: "http://terminology.hl7.org/CodeSystem/observation-category", Code: "vital-signs", Display: "Vital Signs",
+		}}}},
+		Code:          &CodeableConcept{Coding: []Coding{{System: SystemLOINC, Code: j.Kode, Display: j.Display}}},
+		Subject:       &Reference{Reference: "Patient/" + v.IDPasienSS},
+		Encounter:     &Reference{Reference: "Encounter/" + v.IDKunjungan},
+		Effective:     v.Tanggal,
+		ValueQuantity: &Quantity{Value: v.Nilai, Unit: j.Unit, System: SystemUCUM, Code: j.UCUM},
+	}, nil
+}
@@ -0,0 +1,205 @@
+package satusehat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ─── Server Tiruan SATUSEHAT ─────────────────────────────────────────────────
+// Meniru /oauth2/v1/accesstoken dan /fhir-r4/v1/{ResourceType}[/{id}] dengan
+// penyimpanan di memori. Validasi dibuat seperlunya supaya alur gagal bisa
+// dicoba:
+//   - Patient wajib punya identifier NIK
+//   - Encounter, Condition, Observation wajib merujuk Patient yang ada
+//   - Condition dengan kode ICD-10 "X00" selalu ditolak
+
+type MockServer struct {
+	ClientID     string
+	ClientSecret string
+
+	mu       sync.Mutex
+	tokens   map[string]bool
+	resource map[string]map[string]json.RawMessage // resourceType -> id -> isi
+}
+
+func NewMockServer(clientID, clientSecret string) *MockServer {
+	return &MockServer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		tokens:       map[string]bool{},
+		resource:     map[string]map[string]json.RawMessage{},
+	}
+}
+
+// Jumlah mengembalikan banyaknya resource yang tersimpan untuk satu jenis
+func (m *MockServer) Jumlah(resourceType string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.resource[resourceType])
+}
+
+// CabutToken membatalkan semua access token yang pernah diterbitkan, meniru
+// token yang kedaluwarsa lebih cepat di sisi server
+func (m *MockServer) CabutToken() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens = map[string]bool{}
+}
+
+func (m *MockServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/oauth2/v1/accesstoken") && r.Method == http.MethodPost:
+		m.handleToken(w, r)
+	case strings.Contains(r.URL.Path, "/fhir-r4/v1/"):
+		m.handleFHIR(w, r)
+	default:
+		writeOutcome(w, 404, "not-found", "Endpoint tidak ditemukan")
+	}
+}
+
+func (m *MockServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("grant_type") != "client_credentials" ||
+		r.FormValue("client_id") != m.ClientID || r.FormValue("client_secret") != m.ClientSecret {
+		writeOutcome(w, 401, "security", "Client ID atau secret tidak valid")
+		return
+	}
+
+	token := acakHex(16)
+	m.mu.Lock()
+	m.tokens[token] = true
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"access_token": token,
+		"token_type":   "BearerToken",
+		"expires_in":   "3599",
+	})
+}
+
+func (m *MockServer) handleFHIR(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	m.mu.Lock()
+	valid := m.tokens[token]
+	m.mu.Unlock()
+	if !valid {
+		writeOutcome(w, 401, "security", "Access token tidak valid")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path[strings.Index(r.URL.Path, "/fhir-r4/v1/")+len("/fhir-r4/v1/"):], "/"), "/")
+	resourceType := parts[0]
+
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 2:
+		m.mu.Lock()
+		isi, ok := m.resource[resourceType][parts[1]]
+		m.mu.Unlock()
+		if !ok {
+			writeOutcome(w, 404, "not-found", resourceType+"/"+parts[1]+" tidak ditemukan")
+			return
+		}
+		w.Header().Set("Content-Type", "application/fhir+json")
+		w.Write(isi)
+	case r.Method == http.MethodPost && len(parts) == 1:
+		m.simpan(w, r, resourceType, acakHex(8))
+	case r.Method == http.MethodPut && len(parts) == 2:
+		m.mu.Lock()
+		_, ok := m.resource[resourceType][parts[1]]
+		m.mu.Unlock()
+		if !ok {
+			writeOutcome(w, 404, "not-found", resourceType+"/"+parts[1]+" tidak ditemukan")
+			return
+		}
+		m.simpan(w, r, resourceType, parts[1])
+	default:
+		writeOutcome(w, 405, "not-supported", "Operasi tidak didukung")
+	}
+}
+
+func (m *MockServer) simpan(w http.ResponseWriter, r *http.Request, resourceType, id string) {
+	var res Resource
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil || res.ResourceType != resourceType {
+		writeOutcome(w, 400, "structure", "Isi resource tidak valid")
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if msg := m.validasi(res); msg != "" {
+		writeOutcome(w, 400, "business-rule", msg)
+		return
+	}
+
+	res.ID = id
+	isi, _ := json.Marshal(res)
+	if m.resource[resourceType] == nil {
+		m.resource[resourceType] = map[string]json.RawMessage{}
+	}
+	m.resource[resourceType][id] = isi
+
+	w.Header().Set("Content-Type", "application/fhir+json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(isi)
+}
+
+// validasi dipanggil dengan mu terkunci
+func (m *MockServer) validasi(res Resource) string {
+	if res.ResourceType == "Patient" {
+		for _, id := range res.Identifier {
+			if id.System == SystemNIK && len(id.Value) == 16 {
+				return ""
+			}
+		}
+		return "Identifier NIK wajib diisi"
+	}
+
+	if res.Subject == nil || !m.ada(res.Subject.Reference) {
+		return "Subject harus merujuk Patient yang terdaftar"
+	}
+	if res.Encounter != nil && !m.ada(res.Encounter.Reference) {
+		return "Encounter yang dirujuk tidak ditemukan"
+	}
+	if res.ResourceType == "Condition" && res.Code != nil {
+		for _, c := range res.Code.Coding {
+			if c.Code == "X00" {
+				return fmt.Sprintf("Kode ICD-10 %s tidak valid", c.Code)
+			}
+		}
+	}
+	return ""
+}
+
+func (m *MockServer) ada(ref string) bool {
+	resourceType, id, ok := strings.Cut(ref, "/")
+	if !ok {
+		return false
+	}
+	_, ada := m.resource[resourceType][id]
+	return ada
+}
+
+func writeOutcome(w http.ResponseWriter, status int, code, pesan string) {
+	w.Header().Set("Content-Type", "application/fhir+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"resourceType": "OperationOutcome",
+		"issue": []map[string]interface{}{{
+			"severity": "error",
+			"code":     code,
+			"details":  map[string]string{"text": pesan},
+		}},
+	})
+}
+
+func acakHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
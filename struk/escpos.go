@@ -0,0 +1,90 @@
+package struk
+
+import (
+	"bytes"
+	"strings"
+)
+
+// ─── Renderer ESC/POS ────────────────────────────────────────────────────────
+// Perintah yang dipakai didukung printer thermal umum (Epson TM, Xprinter,
+// dll): ESC @, ESC a, ESC E, ESC d, GS !, GS ( k untuk QR, dan GS V untuk potong.
+
+const (
+	esc = 0x1B
+	gs  = 0x1D
+	lf  = 0x0A
+)
+
+// ESCPOS menghasilkan byte stream siap kirim ke printer
+func (s *Struk) ESCPOS() []byte {
+	var b bytes.Buffer
+
+	b.Write([]byte{esc, '@'}) // reset printer
+
+	rataAktif, skalaAktif, tebalAktif := Rata(-1), 0, false
+	atur := func(rata Rata, skala int, tebal bool) {
+		if rata != rataAktif {
+			b.Write([]byte{esc, 'a', byte(rata)})
+			rataAktif = rata
+		}
+		if skala != skalaAktif {
+			b.Write([]byte{gs, '!', byte((skala-1)<<4 | (skala - 1))})
+			skalaAktif = skala
+		}
+		if tebal != tebalAktif {
+			t := byte(0)
+			if tebal {
+				t = 1
+			}
+			b.Write([]byte{esc, 'E', t})
+			tebalAktif = tebal
+		}
+	}
+
+	for _, l := range s.baris {
+		switch l.jenis {
+		case barisTeks:
+			atur(l.rata, l.skala, l.tebal)
+			b.WriteString(l.teks)
+			if l.kanan != "" {
+				b.WriteString(strings.Repeat(" ", s.Kertas.Kolom()-len(l.teks)-len(l.kanan)))
+				b.WriteString(l.kanan)
+			}
+			b.WriteByte(lf)
+		case barisGaris:
+			atur(Kiri, 1, false)
+			b.Write(bytes.Repeat([]byte{'-'}, s.Kertas.Kolom()))
+			b.WriteByte(lf)
+		case barisKosong:
+			b.WriteByte(lf)
+		case barisQR:
+			atur(Tengah, 1, false)
+			tulisQR(&b, l.teks, s.Kertas)
+		}
+	}
+
+	atur(Kiri, 1, false)
+	b.Write([]byte{esc, 'd', 4}) // feed agar baris terakhir melewati pisau
+	b.Write([]byte{gs, 'V', 1})  // potong sebagian
+	return b.Bytes()
+}
+
+// tulisQR memakai perintah QR bawaan printer (GS ( k, model 2, level M)
+func tulisQR(b *bytes.Buffer, data string, k Kertas) {
+	modul := byte(6)
+	if k == Kertas58 {
+		modul = 5
+	}
+	fn := func(isi ...byte) {
+		n := len(isi)
+		b.Write([]byte{gs, '(', 'k', byte(n), byte(n >> 8)})
+		b.Write(isi)
+	}
+
+	fn('1', 'A', '2', 0)                          // model 2
+	fn('1', 'C', modul)                           // ukuran modul dalam dot
+	fn('1', 'E', '1')                             // error correction M
+	fn(append([]byte{'1', 'P', '0'}, data...)...) // simpan data
+	fn('1', 'Q', '0')                             // cetak
+	b.WriteByte(lf)
+}
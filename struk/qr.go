@@ -0,0 +1,389 @@
+package struk
+
+import "fmt"
+
+// ─── QR Code Encoder ─────────────────────────────────────────────────────────
+// Encoder QR minimal untuk PDF dan PNG (printer ESC/POS membuat QR sendiri):
+// mode byte, error correction level M, versi 1–6 (maksimal 106 byte). Cukup
+// untuk kode booking / nomor antrian tanpa dependency tambahan.
+
+const qrMaksVersi = 6
+
+// ec codeword per blok dan jumlah blok untuk level M, indeks = versi
+var (
+	qrECPerBlok  = [qrMaksVersi + 1]int{0, 10, 16, 26, 18, 24, 16}
+	qrJumlahBlok = [qrMaksVersi + 1]int{0, 1, 1, 1, 2, 2, 4}
+)
+
+// QR adalah matriks modul; true = hitam
+type QR struct {
+	Ukuran int
+	modul  [][]bool
+	fungsi [][]bool // modul pola tetap yang tidak boleh ditimpa data / mask
+}
+
+// Hitam mengembalikan warna modul pada kolom x, baris y
+func (q *QR) Hitam(x, y int) bool {
+	return q.modul[y][x]
+}
+
+// EncodeQR membuat QR dari data byte dengan versi sekecil mungkin
+func EncodeQR(data string) (*QR, error) {
+	versi := 0
+	for v := 1; v <= qrMaksVersi; v++ {
+		if 4+8+len(data)*8 <= qrDataCodeword(v)*8 {
+			versi = v
+			break
+		}
+	}
+	if versi == 0 {
+		return nil, fmt.Errorf("data QR terlalu panjang (%d byte)", len(data))
+	}
+
+	// Bit stream: mode byte, panjang 8 bit, isi, terminator, padding
+	var bits []bool
+	tulis := func(val, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (val>>i)&1 == 1)
+		}
+	}
+	tulis(0x4, 4)
+	tulis(len(data), 8)
+	for i := 0; i < len(data); i++ {
+		tulis(int(data[i]), 8)
+	}
+	kapasitas := qrDataCodeword(versi) * 8
+	for i := 0; i < 4 && len(bits) < kapasitas; i++ {
+		bits = append(bits, false)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	for pad := 0xEC; len(bits) < kapasitas; pad ^= 0xEC ^ 0x11 {
+		tulis(pad, 8)
+	}
+
+	codeword := make([]byte, len(bits)/8)
+	for i, b := range bits {
+		if b {
+			codeword[i>>3] |= 1 << (7 - uint(i&7))
+		}
+	}
+
+	q := &QR{Ukuran: versi*4 + 17}
+	q.modul = make([][]bool, q.Ukuran)
+	q.fungsi = make([][]bool, q.Ukuran)
+	for i := range q.modul {
+		q.modul[i] = make([]bool, q.Ukuran)
+		q.fungsi[i] = make([]bool, q.Ukuran)
+	}
+
+	q.gambarPolaTetap(versi)
+	q.gambarCodeword(qrTambahEC(codeword, versi))
+
+	// Pilih mask dengan penalti terkecil
+	mask, penaltiMin := 0, -1
+	for m := 0; m < 8; m++ {
+		q.terapkanMask(m)
+		q.gambarFormat(m)
+		if p := q.penalti(); penaltiMin < 0 || p < penaltiMin {
+			mask, penaltiMin = m, p
+		}
+		q.terapkanMask(m) // XOR dua kali = kembali semula
+	}
+	q.terapkanMask(mask)
+	q.gambarFormat(mask)
+
+	return q, nil
+}
+
+// qrRawCodeword menghitung jumlah codeword (data + ec) yang muat di versi v
+func qrRawCodeword(v int) int {
+	n := (16*v+128)*v + 64
+	if v >= 2 {
+		align := v/7 + 2
+		n -= (25*align-10)*align - 55
+	}
+	return n / 8
+}
+
+func qrDataCodeword(v int) int {
+	return qrRawCodeword(v) - qrECPerBlok[v]*qrJumlahBlok[v]
+}
+
+// qrTambahEC membagi data ke blok, menghitung Reed-Solomon per blok lalu
+// menyisipkan (interleave) codeword antar blok
+func qrTambahEC(data []byte, v int) []byte {
+	jumlahBlok := qrJumlahBlok[v]
+	ecLen := qrECPerBlok[v]
+	raw := qrRawCodeword(v)
+	blokPendek := jumlahBlok - raw%jumlahBlok
+	panjangPendek := raw / jumlahBlok
+
+	pembagi := rsPembagi(ecLen)
+	var blok [][]byte
+	k := 0
+	for i := 0; i < jumlahBlok; i++ {
+		n := panjangPendek - ecLen
+		if i >= blokPendek {
+			n++
+		}
+		dat := append([]byte{}, data[k:k+n]...)
+		k += n
+		ec := rsSisa(dat, pembagi)
+		if i < blokPendek {
+			dat = append(dat, 0) // tempat kosong agar semua blok sama panjang
+		}
+		blok = append(blok, append(dat, ec...))
+	}
+
+	var hasil []byte
+	for i := range blok[0] {
+		for j, b := range blok {
+			// Lewati tempat kosong pada blok pendek
+			if i != panjangPendek-ecLen || j >= blokPendek {
+				hasil = append(hasil, b[i])
+			}
+		}
+	}
+	return hasil
+}
+
+func rsPembagi(derajat int) []byte {
+	hasil := make([]byte, derajat)
+	hasil[derajat-1] = 1
+	akar := byte(1)
+	for i := 0; i < derajat; i++ {
+		for j := 0; j < derajat; j++ {
+			hasil[j] = gfKali(hasil[j], akar)
+			if j+1 < derajat {
+				hasil[j] ^= hasil[j+1]
+			}
+		}
+		akar = gfKali(akar, 0x02)
+	}
+	return hasil
+}
+
+func rsSisa(data, pembagi []byte) []byte {
+	hasil := make([]byte, len(pembagi))
+	for _, b := range data {
+		faktor := b ^ hasil[0]
+		copy(hasil, hasil[1:])
+		hasil[len(hasil)-1] = 0
+		for i := range hasil {
+			hasil[i] ^= gfKali(pembagi[i], faktor)
+		}
+	}
+	return hasil
+}
+
+// gfKali mengalikan dua elemen GF(2^8) dengan polinom 0x11D
+func gfKali(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+func (q *QR) set(x, y int, hitam bool) {
+	q.modul[y][x] = hitam
+	q.fungsi[y][x] = true
+}
+
+func (q *QR) gambarPolaTetap(v int) {
+	n := q.Ukuran
+
+	// Timing pattern
+	for i := 0; i < n; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	// Finder pattern + separator di tiga sudut
+	for _, p := range [][2]int{{3, 3}, {n - 4, 3}, {3, n - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x < 0 || x >= n || y < 0 || y >= n {
+					continue
+				}
+				d := maxInt(absInt(dx), absInt(dy))
+				q.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+
+	// Versi 2–6 hanya punya satu alignment pattern di pojok kanan bawah
+	if v >= 2 {
+		c := n - 7
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				q.set(c+dx, c+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+			}
+		}
+	}
+
+	// Tandai area format info sebagai fungsi; isinya ditulis ulang nanti
+	q.gambarFormat(0)
+}
+
+func (q *QR) gambarCodeword(data []byte) {
+	n := q.Ukuran
+	i := 0
+	for kanan := n - 1; kanan >= 1; kanan -= 2 {
+		if kanan == 6 {
+			kanan = 5
+		}
+		for vert := 0; vert < n; vert++ {
+			for j := 0; j < 2; j++ {
+				x := kanan - j
+				y := vert
+				if (kanan+1)&2 == 0 {
+					y = n - 1 - vert
+				}
+				if !q.fungsi[y][x] && i < len(data)*8 {
+					q.modul[y][x] = (data[i>>3]>>(7-uint(i&7)))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *QR) terapkanMask(mask int) {
+	for y := 0; y < q.Ukuran; y++ {
+		for x := 0; x < q.Ukuran; x++ {
+			if q.fungsi[y][x] {
+				continue
+			}
+			var balik bool
+			switch mask {
+			case 0:
+				balik = (x+y)%2 == 0
+			case 1:
+				balik = y%2 == 0
+			case 2:
+				balik = x%3 == 0
+			case 3:
+				balik = (x+y)%3 == 0
+			case 4:
+				balik = (x/3+y/2)%2 == 0
+			case 5:
+				balik = x*y%2+x*y%3 == 0
+			case 6:
+				balik = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				balik = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if balik {
+				q.modul[y][x] = !q.modul[y][x]
+			}
+		}
+	}
+}
+
+// gambarFormat menulis 15 bit format info (level M = 00) di dua salinan
+func (q *QR) gambarFormat(mask int) {
+	data := mask // bit level M = 00
+	sisa := data
+	for i := 0; i < 10; i++ {
+		sisa = (sisa << 1) ^ ((sisa >> 9) * 0x537)
+	}
+	bits := (data<<10 | sisa) ^ 0x5412
+	bit := func(i int) bool { return (bits>>uint(i))&1 == 1 }
+
+	n := q.Ukuran
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(n-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, n-15+i, bit(i))
+	}
+	q.set(8, n-8, true) // dark module
+}
+
+// penalti menghitung skor mask sesuai empat aturan standar QR
+func (q *QR) penalti() int {
+	n := q.Ukuran
+	hasil := 0
+
+	baris := func(ambil func(i, j int) bool) {
+		for i := 0; i < n; i++ {
+			run := 1
+			for j := 1; j <= n; j++ {
+				if j < n && ambil(i, j) == ambil(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					hasil += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Pola mirip finder 1011101 dengan 4 modul terang di salah satu sisi
+			for j := 0; j+11 <= n; j++ {
+				p := [11]bool{}
+				for k := range p {
+					p[k] = ambil(i, j+k)
+				}
+				inti := p[4] && !p[5] && p[6] && p[7] && p[8] && !p[9] && p[10]
+				if inti && !p[0] && !p[1] && !p[2] && !p[3] {
+					hasil += 40
+				}
+				inti = p[0] && !p[1] && p[2] && p[3] && p[4] && !p[5] && p[6]
+				if inti && !p[7] && !p[8] && !p[9] && !p[10] {
+					hasil += 40
+				}
+			}
+		}
+	}
+	baris(func(i, j int) bool { return q.modul[i][j] })
+	baris(func(i, j int) bool { return q.modul[j][i] })
+
+	hitam := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.modul[y][x] {
+				hitam++
+			}
+			if x+1 < n && y+1 < n {
+				c := q.modul[y][x]
+				if c == q.modul[y][x+1] && c == q.modul[y+1][x] && c == q.modul[y+1][x+1] {
+					hasil += 3
+				}
+			}
+		}
+	}
+
+	persen := hitam * 100 / (n * n)
+	hasil += absInt(persen-50) / 5 * 10
+	return hasil
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,224 @@
+package struk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Contoh "HELLO WORLD" versi 1-M dari tutorial QR thonky.com: codeword data
+// dan codeword Reed-Solomon yang diharapkan
+func TestQRReedSolomon(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	wantEC := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := qrTambahEC(data, 1)
+	if !bytes.Equal(got[:len(data)], data) {
+		t.Errorf("codeword data berubah: %v", got[:len(data)])
+	}
+	if !bytes.Equal(got[len(data):], wantEC) {
+		t.Errorf("EC = %v, want %v", got[len(data):], wantEC)
+	}
+}
+
+// Batas kapasitas mode byte level M per versi (ISO/IEC 18004 tabel 7)
+func TestQRVersi(t *testing.T) {
+	cases := []struct {
+		panjang int
+		ukuran  int
+	}{
+		{1, 21}, {14, 21},
+		{15, 25}, {26, 25},
+		{27, 29}, {42, 29},
+		{43, 33}, {62, 33},
+		{63, 37}, {84, 37},
+		{85, 41}, {106, 41},
+	}
+	for _, c := range cases {
+		q, err := EncodeQR(strings.Repeat("A", c.panjang))
+		if err != nil {
+			t.Errorf("%d byte: %v", c.panjang, err)
+			continue
+		}
+		if q.Ukuran != c.ukuran {
+			t.Errorf("%d byte: ukuran %d, want %d", c.panjang, q.Ukuran, c.ukuran)
+		}
+	}
+	if _, err := EncodeQR(strings.Repeat("A", 107)); err == nil {
+		t.Error("107 byte seharusnya ditolak")
+	}
+}
+
+// Format info level M untuk mask 0–7 (ISO/IEC 18004 lampiran C)
+var formatM = [8]string{
+	"101010000010010",
+	"101000100100101",
+	"101111001111100",
+	"101101101001011",
+	"100010111111001",
+	"100000011001110",
+	"100111110010111",
+	"100101010100000",
+}
+
+// bacaFormat membaca dua salinan format info, bit 14 lebih dulu
+func bacaFormat(q *QR) (string, string) {
+	n := q.Ukuran
+	var a, b [15]bool
+	for i := 0; i <= 5; i++ {
+		a[i] = q.Hitam(8, i)
+	}
+	a[6] = q.Hitam(8, 7)
+	a[7] = q.Hitam(8, 8)
+	a[8] = q.Hitam(7, 8)
+	for i := 9; i < 15; i++ {
+		a[i] = q.Hitam(14-i, 8)
+	}
+	for i := 0; i < 8; i++ {
+		b[i] = q.Hitam(n-1-i, 8)
+	}
+	for i := 8; i < 15; i++ {
+		b[i] = q.Hitam(8, n-15+i)
+	}
+
+	str := func(bits [15]bool) string {
+		var s strings.Builder
+		for i := 14; i >= 0; i-- {
+			if bits[i] {
+				s.WriteByte('1')
+			} else {
+				s.WriteByte('0')
+			}
+		}
+		return s.String()
+	}
+	return str(a), str(b)
+}
+
+func maskDari(t *testing.T, q *QR) int {
+	t.Helper()
+	a, b := bacaFormat(q)
+	if a != b {
+		t.Fatalf("dua salinan format info berbeda: %s / %s", a, b)
+	}
+	for m, f := range formatM {
+		if f == a {
+			return m
+		}
+	}
+	t.Fatalf("format info %s bukan level M", a)
+	return -1
+}
+
+// bacaData membuka mask lalu membaca ulang codeword dengan urutan zig-zag
+// dan mengembalikan isi segmen mode byte
+func bacaData(t *testing.T, q *QR, mask int) string {
+	t.Helper()
+	salinan := &QR{Ukuran: q.Ukuran, fungsi: q.fungsi}
+	for _, r := range q.modul {
+		salinan.modul = append(salinan.modul, append([]bool{}, r...))
+	}
+	salinan.terapkanMask(mask)
+
+	n := q.Ukuran
+	var bits []bool
+	naik := true
+	for kanan := n - 1; kanan >= 1; kanan -= 2 {
+		if kanan == 6 {
+			kanan = 5
+		}
+		for i := 0; i < n; i++ {
+			y := i
+			if naik {
+				y = n - 1 - i
+			}
+			for _, x := range []int{kanan, kanan - 1} {
+				if !q.fungsi[y][x] {
+					bits = append(bits, salinan.modul[y][x])
+				}
+			}
+		}
+		naik = !naik
+	}
+
+	ambil := func(pos, n int) int {
+		v := 0
+		for i := 0; i < n; i++ {
+			v <<= 1
+			if bits[pos+i] {
+				v |= 1
+			}
+		}
+		return v
+	}
+
+	// Semua contoh di sini muat dalam satu blok, jadi codeword data tidak
+	// di-interleave
+	if mode := ambil(0, 4); mode != 0x4 {
+		t.Fatalf("mode %04b, want 0100 (byte)", mode)
+	}
+	panjang := ambil(4, 8)
+	out := make([]byte, panjang)
+	for i := range out {
+		out[i] = byte(ambil(12+8*i, 8))
+	}
+	return string(out)
+}
+
+func TestQRFormatDanIsi(t *testing.T) {
+	// Mask yang terpilih dikunci supaya perubahan perhitungan penalti
+	// terdeteksi
+	cases := []struct {
+		data   string
+		ukuran int
+		mask   int
+	}{
+		{"BK7Q2M9X", 21, 2},
+		{"ANTRIAN-1", 21, 2},
+		{"ANTRIAN-123456", 21, 1},
+		{"https://sikupas.id/b/BK7Q2M9X", 29, 2},
+	}
+	for _, c := range cases {
+		q, err := EncodeQR(c.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.Ukuran != c.ukuran {
+			t.Errorf("%q: ukuran %d, want %d", c.data, q.Ukuran, c.ukuran)
+		}
+		mask := maskDari(t, q)
+		if mask != c.mask {
+			t.Errorf("%q: mask %d, want %d", c.data, mask, c.mask)
+		}
+		if got := bacaData(t, q, mask); got != c.data {
+			t.Errorf("isi QR %q, want %q", got, c.data)
+		}
+		if !q.Hitam(8, q.Ukuran-8) {
+			t.Errorf("%q: dark module tidak ada", c.data)
+		}
+	}
+}
+
+// Mask terpilih harus yang penaltinya paling kecil
+func TestQRMaskPenaltiTerkecil(t *testing.T) {
+	q, err := EncodeQR("BK7Q2M9X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	terpilih := maskDari(t, q)
+
+	// Kembalikan ke data tanpa mask lalu hitung penalti semua mask
+	q.terapkanMask(terpilih)
+	penalti := make([]int, 8)
+	for m := 0; m < 8; m++ {
+		q.terapkanMask(m)
+		q.gambarFormat(m)
+		penalti[m] = q.penalti()
+		q.terapkanMask(m)
+	}
+	for m, p := range penalti {
+		if p < penalti[terpilih] {
+			t.Errorf("mask %d penalti %d lebih kecil dari mask terpilih %d (%d)", m, p, terpilih, penalti[terpilih])
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package struk
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"sikupas/backend/pdf"
+)
+
+// ─── Renderer PDF ────────────────────────────────────────────────────────────
+
+// PDF menghasilkan satu halaman selebar kertas thermal dengan tinggi sesuai isi
+func (s *Struk) PDF() []byte {
+	lebar := float64(s.Kertas) / 25.4 * 72 // mm -> point
+	const margin = 10.0
+	ukuran := (lebar - 2*margin) / (float64(s.Kertas.Kolom()) * 0.5)
+	modulQR := 90.0 / 29 // QR versi 3 kira-kira 90 pt
+
+	tinggiBaris := func(l baris) float64 {
+		switch l.jenis {
+		case barisTeks:
+			return ukuran * 1.3 * float64(l.skala)
+		case barisQR:
+			if q, err := EncodeQR(l.teks); err == nil {
+				return float64(q.Ukuran+2)*modulQR + ukuran
+			}
+			return ukuran * 1.3
+		default:
+			return ukuran * 1.3
+		}
+	}
+
+	tinggi := 2 * margin
+	for _, l := range s.baris {
+		tinggi += tinggiBaris(l)
+	}
+
+	doc := pdf.New(lebar, tinggi)
+	y := margin
+	for _, l := range s.baris {
+		h := tinggiBaris(l)
+		switch l.jenis {
+		case barisTeks:
+			size := ukuran * float64(l.skala)
+			baseline := y + size
+			switch {
+			case l.kanan != "":
+				doc.Text(margin, baseline, size, l.tebal, l.teks)
+				doc.Text(lebar-margin-pdf.TextWidth(l.kanan, size), baseline, size, l.tebal, l.kanan)
+			case l.rata == Tengah:
+				doc.TextCenter(baseline, size, l.tebal, l.teks)
+			case l.rata == Kanan:
+				doc.Text(lebar-margin-pdf.TextWidth(l.teks, size), baseline, size, l.tebal, l.teks)
+			default:
+				doc.Text(margin, baseline, size, l.tebal, l.teks)
+			}
+		case barisGaris:
+			doc.Line(margin, y+h/2, lebar-margin, y+h/2)
+		case barisQR:
+			q, err := EncodeQR(l.teks)
+			if err != nil {
+				doc.TextCenter(y+ukuran, ukuran, false, l.teks)
+				break
+			}
+			x0 := (lebar - float64(q.Ukuran)*modulQR) / 2
+			y0 := y + modulQR
+			for qy := 0; qy < q.Ukuran; qy++ {
+				for qx := 0; qx < q.Ukuran; qx++ {
+					if q.Hitam(qx, qy) {
+						doc.Rect(x0+float64(qx)*modulQR, y0+float64(qy)*modulQR, modulQR, modulQR)
+					}
+				}
+			}
+		}
+		y += h
+	}
+
+	return doc.Bytes()
+}
+
+// ─── Renderer PNG ────────────────────────────────────────────────────────────
+// Pratinjau hitam-putih dengan font bitmap 7x13; satu kolom karakter = 7 px
+
+// PNG menghasilkan gambar pratinjau struk
+func (s *Struk) PNG() []byte {
+	face := basicfont.Face7x13
+	const margin = 8
+	const charW, charH = 7, 16 // tinggi baris sedikit lebih besar dari glyph
+	lebar := s.Kertas.Kolom()*charW + 2*margin
+	modulQR := 4
+	if s.Kertas == Kertas58 {
+		modulQR = 3
+	}
+
+	var qrs = map[int]*QR{}
+	tinggi := 2 * margin
+	for i, l := range s.baris {
+		switch l.jenis {
+		case barisTeks:
+			tinggi += charH * l.skala
+		case barisQR:
+			if q, err := EncodeQR(l.teks); err == nil {
+				qrs[i] = q
+				tinggi += (q.Ukuran + 8) * modulQR
+			} else {
+				tinggi += charH
+			}
+		default:
+			tinggi += charH
+		}
+	}
+
+	img := image.NewGray(image.Rect(0, 0, lebar, tinggi))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// tulis menggambar teks skala 1 ke kanvas kecil lalu memperbesarnya
+	tulis := func(x, y int, teks string, skala int, tebal bool) {
+		kecil := image.NewGray(image.Rect(0, 0, len(teks)*charW+1, charH))
+		draw.Draw(kecil, kecil.Bounds(), image.White, image.Point{}, draw.Src)
+		d := &font.Drawer{Dst: kecil, Src: image.Black, Face: face}
+		for _, dx := range []int{0, 1} {
+			if dx == 1 && !tebal {
+				break
+			}
+			d.Dot = fixed.P(dx, face.Ascent+2)
+			d.DrawString(teks)
+		}
+		b := kecil.Bounds()
+		for py := 0; py < b.Dy()*skala; py++ {
+			for px := 0; px < b.Dx()*skala; px++ {
+				if kecil.GrayAt(px/skala, py/skala).Y < 128 {
+					img.SetGray(x+px, y+py, color.Gray{})
+				}
+			}
+		}
+	}
+
+	y := margin
+	for i, l := range s.baris {
+		switch l.jenis {
+		case barisTeks:
+			w := len(l.teks) * charW * l.skala
+			x := margin
+			switch {
+			case l.kanan != "":
+				tulis(lebar-margin-len(l.kanan)*charW, y, l.kanan, 1, l.tebal)
+			case l.rata == Tengah:
+				x = (lebar - w) / 2
+			case l.rata == Kanan:
+				x = lebar - margin - w
+			}
+			tulis(x, y, l.teks, l.skala, l.tebal)
+			y += charH * l.skala
+		case barisGaris:
+			for x := margin; x < lebar-margin; x++ {
+				if x%4 < 3 {
+					img.SetGray(x, y+charH/2, color.Gray{})
+				}
+			}
+			y += charH
+		case barisKosong:
+			y += charH
+		case barisQR:
+			q := qrs[i]
+			if q == nil {
+				tulis(margin, y, l.teks, 1, false)
+				y += charH
+				break
+			}
+			x0 := (lebar - q.Ukuran*modulQR) / 2
+			y0 := y + 4*modulQR
+			for qy := 0; qy < q.Ukuran; qy++ {
+				for qx := 0; qx < q.Ukuran; qx++ {
+					if !q.Hitam(qx, qy) {
+						continue
+					}
+					for py := 0; py < modulQR; py++ {
+						for px := 0; px < modulQR; px++ {
+							img.SetGray(x0+qx*modulQR+px, y0+qy*modulQR+py, color.Gray{})
+						}
+					}
+				}
+			}
+			y += (q.Ukuran + 8) * modulQR
+		}
+	}
+
+	var out bytes.Buffer
+	png.Encode(&out, img)
+	return out.Bytes()
+}
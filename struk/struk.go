@@ -0,0 +1,155 @@
+package struk
+
+import (
+	"strings"
+)
+
+// ─── Layout Struk Thermal ────────────────────────────────────────────────────
+// Struk disusun sekali sebagai daftar baris, lalu dirender ke ESC/POS, PDF
+// atau PNG. Semua renderer deterministik (tidak membaca jam / env) sehingga
+// hasil untuk input yang sama selalu identik byte per byte.
+
+// Kertas adalah lebar kertas printer thermal dalam milimeter
+type Kertas int
+
+const (
+	Kertas58 Kertas = 58
+	Kertas80 Kertas = 80
+)
+
+// Kolom mengembalikan jumlah karakter font A (12x24 dot) per baris
+func (k Kertas) Kolom() int {
+	if k == Kertas58 {
+		return 32
+	}
+	return 48
+}
+
+// Dot mengembalikan lebar area cetak dalam dot (203 dpi)
+func (k Kertas) Dot() int {
+	if k == Kertas58 {
+		return 384
+	}
+	return 576
+}
+
+type Rata int
+
+const (
+	Kiri Rata = iota
+	Tengah
+	Kanan
+)
+
+type jenisBaris int
+
+const (
+	barisTeks jenisBaris = iota
+	barisGaris
+	barisQR
+	barisKosong
+)
+
+type baris struct {
+	jenis jenisBaris
+	teks  string
+	kanan string // nilai rata kanan pada baris Kolom
+	rata  Rata
+	skala int // 1–8, lebar dan tinggi karakter
+	tebal bool
+}
+
+type Struk struct {
+	Kertas Kertas
+	baris  []baris
+}
+
+func New(k Kertas) *Struk {
+	if k != Kertas58 {
+		k = Kertas80
+	}
+	return &Struk{Kertas: k}
+}
+
+// Teks menambah teks; teks yang lebih panjang dari lebar kertas dipecah per kata
+func (s *Struk) Teks(rata Rata, skala int, tebal bool, teks string) {
+	if skala < 1 {
+		skala = 1
+	}
+	if skala > 8 {
+		skala = 8
+	}
+	for _, l := range bungkus(ascii(teks), s.Kertas.Kolom()/skala) {
+		s.baris = append(s.baris, baris{jenis: barisTeks, teks: l, rata: rata, skala: skala, tebal: tebal})
+	}
+}
+
+// Kolom menambah satu baris label di kiri dan nilai rata kanan
+func (s *Struk) Kolom(label, nilai string, tebal bool) {
+	label, nilai = ascii(label), ascii(nilai)
+	lebar := s.Kertas.Kolom()
+	if len(label)+1+len(nilai) > lebar {
+		s.Teks(Kiri, 1, tebal, label)
+		s.Teks(Kanan, 1, tebal, nilai)
+		return
+	}
+	s.baris = append(s.baris, baris{jenis: barisTeks, teks: label, kanan: nilai, skala: 1, tebal: tebal})
+}
+
+// Garis menambah garis pemisah
+func (s *Struk) Garis() {
+	s.baris = append(s.baris, baris{jenis: barisGaris})
+}
+
+// Kosong menambah satu baris kosong
+func (s *Struk) Kosong() {
+	s.baris = append(s.baris, baris{jenis: barisKosong})
+}
+
+// QR menambah QR code rata tengah
+func (s *Struk) QR(data string) {
+	s.baris = append(s.baris, baris{jenis: barisQR, teks: data})
+}
+
+// bungkus memecah teks per kata agar tiap baris maksimal n karakter
+func bungkus(s string, n int) []string {
+	if n < 1 {
+		n = 1
+	}
+	var hasil []string
+	line := ""
+	for _, w := range strings.Fields(s) {
+		for len(w) > n {
+			if line != "" {
+				hasil = append(hasil, line)
+				line = ""
+			}
+			hasil = append(hasil, w[:n])
+			w = w[n:]
+		}
+		switch {
+		case line == "":
+			line = w
+		case len(line)+1+len(w) <= n:
+			line += " " + w
+		default:
+			hasil = append(hasil, line)
+			line = w
+		}
+	}
+	return append(hasil, line)
+}
+
+// ascii mengganti karakter di luar ASCII cetak dengan '?' karena code page
+// printer thermal berbeda-beda
+func ascii(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 32 || r > 126 {
+			b.WriteByte('?')
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package struk
+
+import "testing"
+
+// strukPanjang berisi teks dan kolom yang lebih panjang dari lebar kertas
+// serta karakter non-ASCII; golden layout sebenarnya ada di handler
+func strukPanjang(k Kertas) *Struk {
+	s := New(k)
+	s.Teks(Tengah, 1, false, "Jl. Raya Sukasari No. 12, Kecamatan Sukasari, Kota Bandung")
+	s.Teks(Tengah, 4, true, "U-007")
+	s.Teks(Tengah, 2, true, "Poli Kesehatan Ibu dan Anak")
+	s.Teks(Kiri, 1, false, "Pemeriksaan gigi dan konsultasi dokter gigi spesialis")
+	s.Teks(Kiri, 1, false, "Obat – Amoxicillin 500mg")
+	s.Kolom("  10 x Rp 1.500", "Rp 15.000", false)
+	s.Kolom("Harus Dibayar", "Rp 60.000", true)
+	s.Kolom("Ref QRIS", "QRIS-REF-0000000000123456789", false)
+	return s
+}
+
+func TestLebarBarisESCPOS(t *testing.T) {
+	for _, k := range []Kertas{Kertas58, Kertas80} {
+		s := strukPanjang(k)
+		for _, l := range s.baris {
+			if l.jenis != barisTeks {
+				continue
+			}
+			lebar := len(l.teks) * l.skala
+			if l.kanan != "" {
+				lebar = len(l.teks) + 1 + len(l.kanan)
+			}
+			if lebar > k.Kolom() {
+				t.Errorf("kertas %d: baris %q lebih lebar dari %d kolom", k, l.teks, k.Kolom())
+			}
+		}
+	}
+}
+
+func TestBungkus(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want []string
+	}{
+		{"satu dua tiga", 7, []string{"satu", "dua", "tiga"}},
+		{"satu dua tiga", 8, []string{"satu dua", "tiga"}},
+		{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"", 5, []string{""}},
+	}
+	for _, c := range cases {
+		got := bungkus(c.in, c.n)
+		if len(got) != len(c.want) {
+			t.Errorf("bungkus(%q, %d) = %q, want %q", c.in, c.n, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("bungkus(%q, %d) = %q, want %q", c.in, c.n, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestASCII(t *testing.T) {
+	if got := ascii("Obat – Ñ\tx"); got != "Obat ? ??x" {
+		t.Errorf("ascii = %q", got)
+	}
+}
@@ -0,0 +1,157 @@
+package suara
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"sikupas/backend/model"
+)
+
+// ─── Pengumuman Panggilan Antrian ────────────────────────────────────────────
+// Pengumuman disusun sebagai teks dan daftar ID klip rekaman. Setiap kata
+// yang diucapkan punya satu klip (misal "dua", "belas", "huruf_a", "umum")
+// sehingga pemutar di PC display cukup menyambung file audio secara offline
+// tanpa layanan TTS.
+
+// Klip tetap yang mengapit setiap pengumuman
+const (
+	KlipBel          = "bel"
+	KlipNomorAntrian = "nomor_antrian"
+	KlipSilakanKe    = "silakan_ke"
+)
+
+var satuan = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan"}
+
+// Terbilang mengeja bilangan bulat dalam bahasa Indonesia, misal 112 ->
+// "seratus dua belas"
+func Terbilang(n int) string {
+	return strings.Join(terbilang(n), " ")
+}
+
+func terbilang(n int) []string {
+	sisa := func(n int) []string {
+		if n == 0 {
+			return nil
+		}
+		return terbilang(n)
+	}
+
+	switch {
+	case n < 0:
+		return append([]string{"minus"}, terbilang(-n)...)
+	case n == 0:
+		return []string{"nol"}
+	case n < 10:
+		return []string{satuan[n]}
+	case n == 10:
+		return []string{"sepuluh"}
+	case n == 11:
+		return []string{"sebelas"}
+	case n < 20:
+		return []string{satuan[n-10], "belas"}
+	case n < 100:
+		return append([]string{satuan[n/10], "puluh"}, sisa(n%10)...)
+	case n < 200:
+		return append([]string{"seratus"}, sisa(n-100)...)
+	case n < 1000:
+		return append([]string{satuan[n/100], "ratus"}, sisa(n%100)...)
+	case n < 2000:
+		return append([]string{"seribu"}, sisa(n-1000)...)
+	case n < 1000000:
+		return append(append(terbilang(n/1000), "ribu"), sisa(n%1000)...)
+	case n < 1000000000:
+		return append(append(terbilang(n/1000000), "juta"), sisa(n%1000000)...)
+	default:
+		return append(append(terbilang(n/1000000000), "miliar"), sisa(n%1000000000)...)
+	}
+}
+
+// Panggilan menyusun pengumuman "Nomor antrian A dua belas, silakan ke Poli
+// Umum". prefix adalah huruf kode antrian (boleh kosong), tujuan adalah poli
+// atau loket yang dituju (boleh kosong).
+func Panggilan(prefix string, nomor int, tujuan string) model.Pengumuman {
+	p := model.Pengumuman{Klip: []string{KlipBel, KlipNomorAntrian}}
+
+	var nomorTeks []string
+	for _, r := range strings.ToUpper(prefix) {
+		if r >= 'A' && r <= 'Z' {
+			nomorTeks = append(nomorTeks, string(r))
+			p.Klip = append(p.Klip, "huruf_"+strings.ToLower(string(r)))
+		}
+	}
+	for _, kata := range terbilang(nomor) {
+		nomorTeks = append(nomorTeks, kata)
+		p.Klip = append(p.Klip, kata)
+	}
+	p.Nomor = strings.Join(nomorTeks, " ")
+	p.Teks = "Nomor antrian " + p.Nomor
+
+	if tujuanTeks, tujuanKlip := ucapkan(tujuan); len(tujuanKlip) > 0 {
+		p.Tujuan = tujuanTeks
+		p.Teks += ", silakan ke " + tujuanTeks
+		p.Klip = append(p.Klip, KlipSilakanKe)
+		p.Klip = append(p.Klip, tujuanKlip...)
+	}
+
+	return p
+}
+
+// ucapkan memecah nama tujuan per kata; angka dieja, kata lain menjadi klip
+// huruf kecil tanpa tanda baca ("Poli KIA" -> poli, kia)
+func ucapkan(s string) (string, []string) {
+	var teks, klip []string
+	for _, kata := range strings.Fields(s) {
+		if n, err := strconv.Atoi(kata); err == nil {
+			eja := terbilang(n)
+			teks = append(teks, eja...)
+			klip = append(klip, eja...)
+			continue
+		}
+
+		id := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return unicode.ToLower(r)
+			}
+			return -1
+		}, kata)
+		if id == "" {
+			continue
+		}
+		teks = append(teks, kata)
+		klip = append(klip, id)
+	}
+	return strings.Join(teks, " "), klip
+}
+
+// KlipDibutuhkan mengembalikan semua ID klip yang harus direkam agar setiap
+// panggilan untuk prefix dan tujuan tersebut bisa diputar, urut abjad
+func KlipDibutuhkan(prefix, tujuan []string) []string {
+	ada := map[string]bool{KlipBel: true, KlipNomorAntrian: true, KlipSilakanKe: true}
+	for _, k := range satuan[1:] {
+		ada[k] = true
+	}
+	for _, k := range []string{"nol", "sepuluh", "sebelas", "belas", "puluh", "seratus", "ratus", "seribu", "ribu"} {
+		ada[k] = true
+	}
+
+	for _, p := range prefix {
+		for _, k := range Panggilan(p, 0, "").Klip {
+			ada[k] = true
+		}
+	}
+	for _, t := range tujuan {
+		_, klip := ucapkan(t)
+		for _, k := range klip {
+			ada[k] = true
+		}
+	}
+
+	hasil := make([]string, 0, len(ada))
+	for k := range ada {
+		hasil = append(hasil, k)
+	}
+	sort.Strings(hasil)
+	return hasil
+}
@@ -0,0 +1,80 @@
+package suara
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTerbilang(t *testing.T) {
+	cases := []struct {
+		n    int
+		want string
+	}{
+		{0, "nol"},
+		{10, "sepuluh"},
+		{11, "sebelas"},
+		{12, "dua belas"},
+		{19, "sembilan belas"},
+		{20, "dua puluh"},
+		{21, "dua puluh satu"},
+		{100, "seratus"},
+		{101, "seratus satu"},
+		{111, "seratus sebelas"},
+		{1000, "seribu"},
+		{1001, "seribu satu"},
+		{2012, "dua ribu dua belas"},
+	}
+	for _, c := range cases {
+		if got := Terbilang(c.n); got != c.want {
+			t.Errorf("Terbilang(%d) = %q, want %q", c.n, got, c.want)
+		}
+	}
+}
+
+func TestPanggilan(t *testing.T) {
+	cases := []struct {
+		prefix string
+		nomor  int
+		tujuan string
+		teks   string
+		klip   []string
+	}{
+		{"A", 12, "Poli Umum", "Nomor antrian A dua belas, silakan ke Poli Umum",
+			[]string{KlipBel, KlipNomorAntrian, "huruf_a", "dua", "belas", KlipSilakanKe, "poli", "umum"}},
+		{"", 7, "", "Nomor antrian tujuh",
+			[]string{KlipBel, KlipNomorAntrian, "tujuh"}},
+		{"r", 101, "Loket 2", "Nomor antrian R seratus satu, silakan ke Loket dua",
+			[]string{KlipBel, KlipNomorAntrian, "huruf_r", "seratus", "satu", KlipSilakanKe, "loket", "dua"}},
+		{"U-", 20, "Poli K.I.A.", "Nomor antrian U dua puluh, silakan ke Poli K.I.A.",
+			[]string{KlipBel, KlipNomorAntrian, "huruf_u", "dua", "puluh", KlipSilakanKe, "poli", "kia"}},
+	}
+	for _, c := range cases {
+		p := Panggilan(c.prefix, c.nomor, c.tujuan)
+		if p.Teks != c.teks {
+			t.Errorf("Panggilan(%q, %d, %q).Teks = %q, want %q", c.prefix, c.nomor, c.tujuan, p.Teks, c.teks)
+		}
+		if !reflect.DeepEqual(p.Klip, c.klip) {
+			t.Errorf("Panggilan(%q, %d, %q).Klip = %q, want %q", c.prefix, c.nomor, c.tujuan, p.Klip, c.klip)
+		}
+	}
+}
+
+// Setiap klip yang dipakai panggilan harus ada di daftar rekaman
+func TestKlipDibutuhkan(t *testing.T) {
+	ada := map[string]bool{}
+	for _, k := range KlipDibutuhkan([]string{"A", "R"}, []string{"Poli Umum", "Loket 2"}) {
+		ada[k] = true
+	}
+	for _, n := range []int{0, 9, 11, 19, 20, 99, 100, 112, 999, 1000, 2012} {
+		for _, k := range Panggilan("A", n, "Poli Umum").Klip {
+			if !ada[k] {
+				t.Errorf("klip %q untuk nomor %d tidak ada di KlipDibutuhkan", k, n)
+			}
+		}
+	}
+	for _, k := range Panggilan("R", 1, "Loket 2").Klip {
+		if !ada[k] {
+			t.Errorf("klip %q tidak ada di KlipDibutuhkan", k)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret SHA1 dari RFC 6238 lampiran B: ASCII "12345678901234567890"
+const secretRFC = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Vektor uji RFC 6238 lampiran B (SHA1). RFC memakai 8 digit; kode 6 digit
+// adalah 6 digit terakhirnya.
+var vektorRFC = []struct {
+	waktu int64
+	kode8 string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func TestKodeRFC6238(t *testing.T) {
+	for _, v := range vektorRFC {
+		got, err := Kode(secretRFC, Step(time.Unix(v.waktu, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := v.kode8[2:]; got != want {
+			t.Errorf("T=%d: kode %s, want %s", v.waktu, got, want)
+		}
+	}
+}
+
+func TestKodeSecretHurufKecil(t *testing.T) {
+	got, err := Kode(strings.ToLower(secretRFC), 1)
+	if err != nil || got != "287082" {
+		t.Errorf("kode = %q, %v", got, err)
+	}
+	if _, err := Kode("bukan-base32!", 1); err == nil {
+		t.Error("secret tidak valid seharusnya ditolak")
+	}
+}
+
+func TestVerifikasi(t *testing.T) {
+	const waktu = 1111111111
+	sekarang := time.Unix(waktu, 0)
+	step := Step(sekarang)
+
+	for d := int64(-1); d <= 1; d++ {
+		kode, _ := Kode(secretRFC, step+d)
+		got, ok := Verifikasi(secretRFC, kode, sekarang)
+		if !ok || got != step+d {
+			t.Errorf("selisih %d step: (%d, %v), want (%d, true)", d, got, ok, step+d)
+		}
+	}
+
+	// Di luar toleransi satu periode
+	for _, d := range []int64{-2, 2} {
+		kode, _ := Kode(secretRFC, step+d)
+		if _, ok := Verifikasi(secretRFC, kode, sekarang); ok {
+			t.Errorf("kode selisih %d step seharusnya ditolak", d)
+		}
+	}
+
+	kode, _ := Kode(secretRFC, step)
+	lain := kode[:5] + string('0'+(kode[5]-'0'+1)%10)
+	salah := []string{
+		"",
+		lain,
+		kode[:5],
+		kode + "0",
+		"abcdef",
+		vektorRFC[2].kode8, // 8 digit
+	}
+	for _, k := range salah {
+		if _, ok := Verifikasi(secretRFC, k, sekarang); ok {
+			t.Errorf("kode %q seharusnya ditolak", k)
+		}
+	}
+
+	// Spasi dari aplikasi authenticator (misal "050 471") diabaikan
+	if _, ok := Verifikasi(secretRFC, " "+kode[:3]+" "+kode[3:]+" ", sekarang); !ok {
+		t.Error("kode dengan spasi seharusnya diterima")
+	}
+}
+
+func TestBuatSecret(t *testing.T) {
+	a, b := BuatSecret(), BuatSecret()
+	if len(a) != 32 || a == b {
+		t.Errorf("secret %q / %q", a, b)
+	}
+	if _, err := Kode(a, 0); err != nil {
+		t.Errorf("secret baru tidak bisa dipakai: %v", err)
+	}
+}